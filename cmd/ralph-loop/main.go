@@ -2,10 +2,16 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -19,16 +25,48 @@ var (
 func main() {
 	// 定義子命令
 	runCmd := flag.NewFlagSet("run", flag.ExitOnError)
-	runPrompt := runCmd.String("prompt", "", "初始提示 (必填)")
-	runMaxLoops := runCmd.Int("max-loops", 10, "最大迴圈次數")
-	runTimeout := runCmd.Duration("timeout", 5*time.Minute, "總執行逾時")
-	runCLITimeout := runCmd.Duration("cli-timeout", 3*time.Minute, "單次 Copilot CLI 執行逾時（預設 3 分鐘）")
-	runWorkDir := runCmd.String("workdir", ".", "工作目錄")
-	runSilent := runCmd.Bool("silent", false, "靜默模式")
-	runNoSDK := runCmd.Bool("no-sdk", false, "強制使用 CLI 執行器，跳過 SDK（除錯用）")
+	var opts runOptions
+	runPrompt := runCmd.String("prompt", "", "初始提示；\"-\" 表示從標準輸入讀取（與 -prompt-file 擇一必填）")
+	runPromptFile := runCmd.String("prompt-file", "", "從檔案讀取初始提示，適合多行的長指令（與 -prompt 擇一必填）")
+	runCmd.IntVar(&opts.maxLoops, "max-loops", 10, "最大迴圈次數")
+	runCmd.DurationVar(&opts.timeout, "timeout", 5*time.Minute, "總執行逾時")
+	runCmd.DurationVar(&opts.cliTimeout, "cli-timeout", 3*time.Minute, "單次 Copilot CLI 執行逾時（預設 3 分鐘）")
+	runCmd.StringVar(&opts.workDir, "workdir", ".", "工作目錄")
+	runCmd.BoolVar(&opts.silent, "silent", false, "靜默模式")
+	runCmd.BoolVar(&opts.noSDK, "no-sdk", false, "強制使用 CLI 執行器，跳過 SDK（除錯用）")
+	runCmd.StringVar(&opts.transcriptPath, "transcript", "", "執行結束後將 Markdown 執行紀錄寫入此路徑")
+	runCmd.BoolVar(&opts.perRunDir, "per-run-dir", false, "每次執行將歷史存到獨立的子目錄，避免覆蓋先前執行")
+	runCmd.StringVar(&opts.runID, "run-id", "", "指定執行子目錄名稱（隱含 -per-run-dir）")
+	runCmd.BoolVar(&opts.explain, "explain", false, "每個迴圈結束後顯示繼續或停止的判定依據（-silent 時不顯示）")
+	runCmd.StringVar(&opts.model, "model", "", "AI 模型名稱（優先於 .ralphrc）")
+	runCmd.StringVar(&opts.verifyCommand, "verify", "", "判定完成後執行的驗證命令，退出碼為 0 才結束（優先於 .ralphrc）")
+	runCmd.StringVar(&opts.verifyPattern, "verify-pattern", "", "驗證命令的 stdout 必須符合此正規表示式（優先於 .ralphrc）")
+	runCmd.BoolVar(&opts.adaptiveRetry, "adaptive-retry", false, "依先前的重試結果略過從未恢復過的錯誤（統計跨執行保存）")
+	runSkipChecks := runCmd.Bool("skip-checks", false, "略過啟動時的依賴檢查")
+	runRefreshChecks := runCmd.Bool("refresh-checks", false, "忽略快取，重新執行依賴檢查")
+	runCopilotMin := runCmd.String("copilot-min", ghcopilot.DefaultMinCopilotVersion, "支援的最低 copilot CLI 版本（含），空字串表示不限")
+	runCopilotMax := runCmd.String("copilot-max", "", "支援的 copilot CLI 版本上限（不含），空字串表示不限")
+	runStrictVersion := runCmd.Bool("strict-copilot-version", false, "copilot CLI 版本超出支援範圍時拒絕執行（預設只警告）")
+	runFocus := runCmd.String("focus", "", "任務必須修改的檔案（逗號分隔）；連續多個迴圈沒有修改時提醒模型，最後打開熔斷器")
+	runCmd.BoolVar(&opts.force, "force", false, "即使相同的 prompt 最近失敗過也照常執行")
+	runNonRetryable := runCmd.String("non-retryable", "", "CLI 錯誤包含這些字串時不重試（逗號分隔，不區分大小寫，例如 \"quota,auth failed\"）")
+	runCmd.DurationVar(&opts.failedPromptTTL, "failed-prompt-ttl", ghcopilot.DefaultFailedPromptTTL, "以錯誤結束的 prompt 在此期間內再次執行需要 -force，0 表示停用")
+	runCmd.IntVar(&opts.breakerThreshold, "breaker-threshold", 0, "連續幾個迴圈無進展時打開熔斷器，0 表示使用 .ralphrc 或預設值")
+	runCmd.IntVar(&opts.sameErrorThreshold, "same-error-threshold", 0, "連續幾個迴圈出現相同錯誤時打開熔斷器，0 表示使用 .ralphrc 或預設值")
+	runCmd.IntVar(&opts.maxChangedFiles, "max-changed-files", 0, "本次執行最多可修改的不同檔案數（git 儲存庫），超過時中止，0 表示不限制")
+	runCmd.StringVar(&opts.argStyle, "cli-arg-style", "", "copilot CLI 參數風格: current、legacy（前一代 CLI）或 auto（依版本選擇），空值為 current")
+	runCmd.StringVar(&opts.budgetReport, "budget-report", "text", "結束時輸出各項上限的使用率: text、json 或 none")
+	runFormat := runCmd.String("format", "text", "輸出格式: text 或 jsonl（每個迴圈結束時輸出一行 JSON，最後一行為 type=summary 的摘要；其他訊息改寫到 stderr）")
+	runCmd.BoolVar(&opts.observe, "observe", false, "觀察模式：禁止修改檔案，只預覽代理會做什麼（git 儲存庫中有檔案被修改時中止）")
+	runCmd.StringVar(&opts.progressFile, "progress-file", "", "每個迴圈後將進度（JSON）寫入此路徑，供 CI 等外部工具輪詢")
+	runCmd.BoolVar(&opts.dryRun, "dry-run", false, "只顯示第一個迴圈會執行的 copilot 命令列與工作目錄，不實際執行")
+	runCmd.BoolVar(&opts.emitSummary, "emit-summary", true, "結束時輸出 ---RALPH_SUMMARY--- 區塊供腳本擷取（-silent 時預設不輸出）")
 
 	statusCmd := flag.NewFlagSet("status", flag.ExitOnError)
 	statusWorkDir := statusCmd.String("workdir", ".", "工作目錄")
+	statusVerbose := statusCmd.Bool("verbose", false, "顯示熔斷器的計數與最近的打開記錄")
+	statusFormat := statusCmd.String("format", "text", "輸出格式: text 或 json")
+	statusCheckSDK := statusCmd.Bool("check-sdk", false, "顯示 SDK 執行器的狀態與會話指標")
 
 	resetCmd := flag.NewFlagSet("reset", flag.ExitOnError)
 	resetWorkDir := resetCmd.String("workdir", ".", "工作目錄")
@@ -36,6 +74,28 @@ func main() {
 	watchCmd := flag.NewFlagSet("watch", flag.ExitOnError)
 	watchWorkDir := watchCmd.String("workdir", ".", "工作目錄")
 	watchInterval := watchCmd.Duration("interval", 5*time.Second, "檢查間隔")
+	watchTailLines := watchCmd.Int("tail-lines", 10, "在狀態下方顯示日誌檔（SaveDir/ralph-loop.log）的最後幾行，0 表示不顯示")
+
+	retryStatsCmd := flag.NewFlagSet("retry-stats", flag.ExitOnError)
+	retryStatsReset := retryStatsCmd.Bool("reset", false, "清除已學到的重試統計")
+
+	compareCmd := flag.NewFlagSet("compare", flag.ExitOnError)
+	compareA := compareCmd.String("a", "", "執行 A 匯出的歷史 JSON (必填)")
+	compareB := compareCmd.String("b", "", "執行 B 匯出的歷史 JSON (必填)")
+	compareFormat := compareCmd.String("format", "text", "輸出格式: text 或 json")
+
+	artifactsCmd := flag.NewFlagSet("artifacts", flag.ExitOnError)
+	artifactsLoop := artifactsCmd.Int("loop", 0, "只顯示此迴圈（從 1 起算）保存的產出檔案，0 表示全部")
+	artifactsExtract := artifactsCmd.String("extract", "", "將 -loop 指定迴圈的產出檔案複製到此目錄")
+	artifactsDir := artifactsCmd.String("save-dir", ghcopilot.DefaultClientConfig().SaveDir, "儲存目錄（找不到產出目錄時改找最新的執行子目錄）")
+
+	capabilitiesCmd := flag.NewFlagSet("capabilities", flag.ExitOnError)
+	capabilitiesFormat := capabilitiesCmd.String("format", "text", "輸出格式: text 或 json")
+
+	configCmd := flag.NewFlagSet("config", flag.ExitOnError)
+	configAction := configCmd.String("action", "diff", "show: 顯示所有設定; diff: 只顯示與預設值不同的設定")
+	configWorkDir := configCmd.String("workdir", ".", "工作目錄（載入其中的 .ralphrc）")
+	configFormat := configCmd.String("format", "text", "輸出格式: text 或 json")
 
 	// 檢查參數
 	if len(os.Args) < 2 {
@@ -47,17 +107,39 @@ func main() {
 	case "run":
 		// #nosec G104 -- FlagSet 使用 ExitOnError，Parse 失敗會自動 os.Exit(2)
 		runCmd.Parse(os.Args[2:])
-		if *runPrompt == "" {
-			fmt.Println("錯誤: -prompt 為必填參數")
+		prompt, err := ghcopilot.ReadPrompt(*runPrompt, *runPromptFile, os.Stdin)
+		if err != nil {
+			fmt.Printf("錯誤: %v\n", err)
 			runCmd.Usage()
 			os.Exit(1)
 		}
-		cmdRun(*runPrompt, *runMaxLoops, *runTimeout, *runCLITimeout, *runWorkDir, *runSilent, *runNoSDK)
+		opts.out = os.Stdout
+		switch *runFormat {
+		case "text":
+		case "jsonl":
+			// stdout 只保留 JSON 行，其餘輸出（含日誌與 copilot 的串流）改寫到 stderr
+			opts.jsonl = ghcopilot.NewJSONLWriter(os.Stdout)
+			opts.out = os.Stderr
+		default:
+			fmt.Printf("未知的輸出格式: %s（可用: text, jsonl）\n", *runFormat)
+			os.Exit(1)
+		}
+		versionRange := ghcopilot.CopilotVersionRange{Min: *runCopilotMin, Max: *runCopilotMax}
+		if err := preflight(opts.out, *runSkipChecks || opts.dryRun, *runRefreshChecks, versionRange, *runStrictVersion); err != nil {
+			fmt.Fprintln(opts.out, err)
+			os.Exit(1)
+		}
+		// 靜默模式下只有明確指定 -emit-summary 才輸出摘要
+		opts.emitSummary = opts.emitSummary && (!opts.silent || flagSet(runCmd, "emit-summary"))
+		opts.perRunDir = opts.perRunDir || opts.runID != ""
+		opts.focusFiles = splitList(*runFocus)
+		opts.nonRetryable = splitList(*runNonRetryable)
+		cmdRun(prompt, &opts)
 
 	case "status":
 		// #nosec G104 -- FlagSet 使用 ExitOnError，Parse 失敗會自動 os.Exit(2)
 		statusCmd.Parse(os.Args[2:])
-		cmdStatus(*statusWorkDir)
+		cmdStatus(*statusWorkDir, *statusVerbose, *statusFormat, *statusCheckSDK)
 
 	case "reset":
 		// #nosec G104 -- FlagSet 使用 ExitOnError，Parse 失敗會自動 os.Exit(2)
@@ -67,7 +149,42 @@ func main() {
 	case "watch":
 		// #nosec G104 -- FlagSet 使用 ExitOnError，Parse 失敗會自動 os.Exit(2)
 		watchCmd.Parse(os.Args[2:])
-		cmdWatch(*watchWorkDir, *watchInterval)
+		cmdWatch(*watchWorkDir, *watchInterval, *watchTailLines)
+
+	case "retry-stats":
+		// #nosec G104 -- FlagSet 使用 ExitOnError，Parse 失敗會自動 os.Exit(2)
+		retryStatsCmd.Parse(os.Args[2:])
+		cmdRetryStats(*retryStatsReset)
+
+	case "compare":
+		// #nosec G104 -- FlagSet 使用 ExitOnError，Parse 失敗會自動 os.Exit(2)
+		compareCmd.Parse(os.Args[2:])
+		if *compareA == "" || *compareB == "" {
+			fmt.Println("錯誤: -a 與 -b 為必填參數")
+			compareCmd.Usage()
+			os.Exit(1)
+		}
+		cmdCompare(*compareA, *compareB, *compareFormat)
+
+	case "artifacts":
+		// #nosec G104 -- FlagSet 使用 ExitOnError，Parse 失敗會自動 os.Exit(2)
+		artifactsCmd.Parse(os.Args[2:])
+		if *artifactsExtract != "" && *artifactsLoop <= 0 {
+			fmt.Println("錯誤: -extract 需要指定 -loop")
+			artifactsCmd.Usage()
+			os.Exit(1)
+		}
+		cmdArtifacts(*artifactsDir, *artifactsLoop, *artifactsExtract)
+
+	case "capabilities":
+		// #nosec G104 -- FlagSet 使用 ExitOnError，Parse 失敗會自動 os.Exit(2)
+		capabilitiesCmd.Parse(os.Args[2:])
+		cmdCapabilities(*capabilitiesFormat)
+
+	case "config":
+		// #nosec G104 -- FlagSet 使用 ExitOnError，Parse 失敗會自動 os.Exit(2)
+		configCmd.Parse(os.Args[2:])
+		cmdConfig(*configAction, *configWorkDir, *configFormat)
 
 	case "version":
 		fmt.Printf("Ralph Loop v%s\n", Version)
@@ -93,6 +210,11 @@ func printUsage() {
   status    查看當前狀態
   reset     重置熔斷器
   watch     監控模式 (持續顯示狀態)
+  retry-stats  查看 -adaptive-retry 學到的錯誤重試統計
+  compare   比較兩次執行匯出的歷史 (A/B 測試 prompt 或設定)
+  artifacts 列出或取出各迴圈保存的產出檔案 (.ralphrc 的 artifact_globs)
+  capabilities 顯示建置版本、功能、已知模型與執行環境 (回報問題時附上)
+  config    顯示套用 .ralphrc 後的設定，或只列出與預設值不同的欄位
   version   顯示版本資訊
   help      顯示此幫助訊息
 
@@ -100,11 +222,74 @@ func printUsage() {
   # 啟動自動迴圈
   ralph-loop run -prompt "修正所有編譯錯誤" -max-loops 20
 
+  # 執行並匯出 Markdown 執行紀錄
+  ralph-loop run -prompt "修正所有編譯錯誤" -transcript transcript.md
+
+  # 顯示每個迴圈的判定依據
+  ralph-loop run -prompt "修正所有編譯錯誤" -explain
+
+  # 測試全部通過才結束
+  ralph-loop run -prompt "修正所有測試" -verify "npm test" -verify-pattern "All tests passed"
+
+  # 腳本中連續執行時，依賴檢查結果會快取 10 分鐘；-refresh-checks 強制重新檢查
+  ralph-loop run -prompt "修正所有編譯錯誤" -refresh-checks
+
+  # 在輸出結尾附加摘要區塊（---RALPH_SUMMARY--- ... ---END_SUMMARY---）
+  ralph-loop run -prompt "修正所有編譯錯誤" -silent -emit-summary
+
+  # 限定任務必須修改 main.go
+  ralph-loop run -prompt "修正 main.go 的 panic" -focus main.go
+
+  # 觀察模式：只分析與規劃，不修改任何檔案
+  ralph-loop run -prompt "重構 parser 模組" -observe
+
+  # CI 中逐迴圈輸出 JSON 行（最後一行為 {"type":"summary",...}）
+  ralph-loop run -prompt "修正所有編譯錯誤" -format jsonl 2>/dev/null
+
+  # 從檔案或標準輸入讀取多行的長指令
+  ralph-loop run -prompt-file task.md
+  cat task.md | ralph-loop run -prompt -
+
+  # 檢查會傳給 copilot 的參數（不實際執行）
+  ralph-loop run -prompt "修正所有編譯錯誤" -model gpt-5 -dry-run
+
+  # 長時間執行時暫停與恢復（目前的迴圈跑完後暫停）
+  kill -USR1 <pid>   # 暫停
+  kill -USR2 <pid>   # 恢復
+
+  # 連續 2 個迴圈無進展就停止（覆蓋 .ralphrc 的 completion.circuit_breaker_threshold）
+  ralph-loop run -prompt "修正所有編譯錯誤" -breaker-threshold 2
+
+  # 配額用盡時不重試，讓迴圈直接失敗
+  ralph-loop run -prompt "修正所有編譯錯誤" -non-retryable "quota,rate limit"
+
+  # 每次執行使用獨立的歷史目錄
+  ralph-loop run -prompt "修正所有編譯錯誤" -run-id nightly-01
+
   # 查看狀態
   ralph-loop status
 
+  # 查看熔斷器的計數與最近的打開記錄（JSON）
+  ralph-loop status -verbose -format json
+
+  # 查看 SDK 執行器與會話指標
+  ralph-loop status -check-sdk
+
+  # 比較兩次執行
+  ralph-loop compare -a runA.json -b runB.json -format json
+
+  # 取出第 3 個迴圈的產出檔案
+  ralph-loop artifacts -loop 3 -extract ./loop3
+
+  # 查看 .ralphrc 改了哪些預設設定
+  ralph-loop config -action diff
+
+  # 查看哪些錯誤重試無效
+  ralph-loop retry-stats
+
   # 監控模式
   ralph-loop watch -interval 3s
+  ralph-loop watch -interval 2s -tail-lines 20
 
   # 重置熔斷器
   ralph-loop reset
@@ -113,94 +298,300 @@ func printUsage() {
 `, Version)
 }
 
-func cmdRun(prompt string, maxLoops int, timeout time.Duration, cliTimeout time.Duration, workDir string, silent bool, noSDK bool) {
-	fmt.Println("========================================")
-	fmt.Println("  Ralph Loop - 自動程式碼迭代系統")
-	fmt.Println("========================================")
-	fmt.Printf("提示: %s\n", prompt)
-	fmt.Printf("最大迴圈: %d\n", maxLoops)
-	fmt.Printf("逾時: %v\n", timeout)
-	fmt.Printf("工作目錄: %s\n", workDir)
-	fmt.Println("----------------------------------------")
+// flagSet 檢查旗標是否在命令列上明確指定
+func flagSet(fs *flag.FlagSet, name string) bool {
+	found := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return found
+}
 
-	// 建立配置
+// splitList 將逗號分隔的參數切成清單（略過空白項目）
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// preflight 啟動前檢查 Copilot CLI 與其版本，成功的結果在 TTL 內快取
+func preflight(out io.Writer, skip, refresh bool, versionRange ghcopilot.CopilotVersionRange, strictVersion bool) error {
+	if skip || os.Getenv("COPILOT_MOCK_MODE") == "true" {
+		return nil
+	}
+	// 支援範圍改變時快取失效，重新檢查版本
+	cacheKey := fmt.Sprintf("%s copilot %s strict=%v", Version, versionRange, strictVersion)
+	cache := ghcopilot.NewDependencyCache("", ghcopilot.DefaultDependencyCacheTTL, cacheKey)
+	cached, err := cache.Preflight(func() error {
+		checker := ghcopilot.NewDependencyChecker()
+		checker.SetCopilotVersionRange(versionRange, strictVersion)
+		err := checker.CheckRequired()
+		for _, w := range checker.GetWarnings() {
+			fmt.Fprintf(out, "⚠️ %s\n", w)
+		}
+		return err
+	}, refresh)
+	if cached {
+		fmt.Fprintf(out, "依賴檢查: 使用快取結果（%s）\n", cache.Path())
+	}
+	return err
+}
+
+// runOptions run 子命令的選項（由命令列參數填入）
+type runOptions struct {
+	maxLoops        int
+	timeout         time.Duration
+	cliTimeout      time.Duration
+	workDir         string
+	silent          bool
+	noSDK           bool
+	transcriptPath  string
+	perRunDir       bool
+	runID           string
+	explain         bool
+	model           string
+	verifyCommand   string
+	verifyPattern   string
+	adaptiveRetry   bool
+	emitSummary     bool
+	focusFiles      []string
+	force           bool
+	failedPromptTTL time.Duration
+	progressFile    string
+	maxChangedFiles int
+	observe         bool
+	budgetReport    string
+	argStyle        string
+	jsonl           *ghcopilot.JSONLWriter // -format jsonl 時逐迴圈輸出 JSON 行（text 時為 nil）
+	out             io.Writer              // 人類可讀的輸出（jsonl 時為 stderr，讓 stdout 只有 JSON 行）
+	dryRun          bool
+	nonRetryable    []string
+
+	// 熔斷器閾值，0 表示使用 .ralphrc 或預設值
+	breakerThreshold   int
+	sameErrorThreshold int
+}
+
+// buildRunConfig 依 預設值 < .ralphrc < 命令列參數 的順序建立 run 的設定（.ralphrc 載入失敗時只警告）
+func buildRunConfig(opts *runOptions) (*ghcopilot.ClientConfig, error) {
 	config := ghcopilot.DefaultClientConfig()
-	config.WorkDir = workDir
-	config.Silent = silent
-	config.CLITimeout = cliTimeout
-	config.CLIMaxRetries = 3
-	config.CircuitBreakerThreshold = 3
-	config.SameErrorThreshold = 5
+	projectConfig, err := ghcopilot.LoadProjectConfig(opts.workDir)
+	if err != nil {
+		fmt.Fprintf(opts.out, "⚠️ 專案設定載入失敗: %v\n", err)
+	} else if projectConfig != nil {
+		projectConfig.Apply(config)
+		fmt.Fprintf(opts.out, "專案設定: %s\n", projectConfig.Path)
+	}
+	if opts.model != "" {
+		config.Model = opts.model
+	}
+	if opts.verifyCommand != "" {
+		config.VerifyCommand = opts.verifyCommand
+	}
+	if opts.verifyPattern != "" {
+		config.VerifyExpectPattern = opts.verifyPattern
+	}
+	config.WorkDir = opts.workDir
+	config.Silent = opts.silent
+	config.CLITimeout = opts.cliTimeout
+	config.PerRunSaveDir = opts.perRunDir
+	config.RunID = opts.runID
+	config.ExplainDecisions = opts.explain
+	config.AdaptiveRetryClassification = opts.adaptiveRetry
+	if len(opts.focusFiles) > 0 {
+		config.FocusFiles = opts.focusFiles
+	}
+	if len(opts.nonRetryable) > 0 {
+		config.NonRetryableErrors = opts.nonRetryable
+		if err := ghcopilot.ValidateRetryErrorLists(config.RetryableErrors, config.NonRetryableErrors); err != nil {
+			return nil, fmt.Errorf("-non-retryable 設定錯誤: %w", err)
+		}
+	}
+	config.FailedPromptTTL = opts.failedPromptTTL
+	config.ForceRun = opts.force
+	config.ProgressFile = opts.progressFile
+	config.MaxChangedFiles = opts.maxChangedFiles
+	config.ObservationMode = opts.observe
+	config.CLIArgStyle = opts.argStyle
+	if opts.breakerThreshold > 0 {
+		config.CircuitBreakerThreshold = opts.breakerThreshold
+	}
+	if opts.sameErrorThreshold > 0 {
+		config.SameErrorThreshold = opts.sameErrorThreshold
+	}
+	if opts.jsonl != nil {
+		config.OnLoopComplete = opts.jsonl.OnLoopComplete
+	}
 
-	if noSDK {
+	if opts.noSDK {
 		config.EnableSDK = false
 		config.PreferSDK = false
 	}
+	return config, nil
+}
+
+func cmdRun(prompt string, opts *runOptions) {
+	fmt.Fprintln(opts.out, "========================================")
+	fmt.Fprintln(opts.out, "  Ralph Loop - 自動程式碼迭代系統")
+	fmt.Fprintln(opts.out, "========================================")
+	fmt.Fprintf(opts.out, "提示: %s\n", prompt)
+	fmt.Fprintf(opts.out, "最大迴圈: %d\n", opts.maxLoops)
+	fmt.Fprintf(opts.out, "逾時: %v\n", opts.timeout)
+	fmt.Fprintf(opts.out, "工作目錄: %s\n", opts.workDir)
+	fmt.Fprintln(opts.out, "----------------------------------------")
+
+	config, err := buildRunConfig(opts)
+	if err != nil {
+		fmt.Fprintf(opts.out, "❌ %v\n", err)
+		os.Exit(1)
+	}
 
 	// 傳遞靜默模式給環境變數（供 infoLog 使用）
-	if silent {
+	if opts.silent {
 		// #nosec G104 -- Setenv 失敗不影響核心邏輯，僅影響日誌顯示
 		os.Setenv("RALPH_SILENT", "1")
 	}
 
+	// 預覽模式：只顯示命令列，不建立持久化目錄也不執行
+	if opts.dryRun {
+		config.EnablePersistence = false
+		client := ghcopilot.NewRalphLoopClientWithConfig(config)
+		defer client.Close()
+		line, err := client.DryRun(context.Background(), prompt)
+		if err != nil {
+			fmt.Fprintf(opts.out, "❌ 無法產生命令列: %v\n", err)
+			os.Exit(1)
+		}
+		if config.EnableSDK && config.PreferSDK {
+			fmt.Fprintln(opts.out, "（優先使用 SDK，只有 SDK 無法使用時才會執行以下命令）")
+		}
+		fmt.Fprintln(opts.out, line)
+		return
+	}
+
+	// 日誌同時寫入 SaveDir/ralph-loop.log，供 watch -tail-lines 顯示
+	ghcopilot.SetLogOutput(opts.out)
+	defer ghcopilot.SetLogOutput(nil)
+	if logFile, err := ghcopilot.OpenLogFile(config.SaveDir); err != nil {
+		fmt.Fprintf(opts.out, "⚠️ %v\n", err)
+	} else {
+		defer logFile.Close()
+		ghcopilot.SetLogOutput(io.MultiWriter(opts.out, logFile))
+		log.SetOutput(io.MultiWriter(os.Stderr, logFile))
+		defer log.SetOutput(os.Stderr)
+	}
+
 	// 建立客戶端
 	client := ghcopilot.NewRalphLoopClientWithConfig(config)
 	defer client.Close()
+	if id := client.RunID(); id != "" {
+		fmt.Fprintf(opts.out, "執行 ID: %s\n", id)
+	}
 
 	// 建立 context 與取消機制
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
 	defer cancel()
 
-	// 處理中斷信號
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		fmt.Println("\n收到中斷信號，正在停止...")
-		cancel()
-	}()
+	// 處理中斷信號：先寫入迴圈歷史再停止，避免容器在寬限期後強制結束而遺失資料
+	stopSignals := client.HandleTermination(cancel, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	// SIGUSR1 暫停、SIGUSR2 恢復（目前的迴圈跑完後才暫停）
+	stopPause := client.HandlePauseSignals()
+	defer stopPause()
 
-	fmt.Println("開始執行迴圈...")
-	fmt.Println()
+	fmt.Fprintln(opts.out, "開始執行迴圈...")
+	fmt.Fprintln(opts.out)
 
 	// 執行迴圈（顯示進度）
-	fmt.Println("⏳ 正在初始化 Copilot CLI...")
-	results, err := client.ExecuteUntilCompletion(ctx, prompt, maxLoops)
+	fmt.Fprintln(opts.out, "⏳ 正在初始化 Copilot CLI...")
+	runStart := time.Now()
+	results, err := client.ExecuteUntilCompletion(ctx, prompt, opts.maxLoops)
+	runDuration := time.Since(runStart)
 
 	// 顯示結果摘要
-	fmt.Println()
-	fmt.Println("========================================")
-	fmt.Println("  執行結果摘要")
-	fmt.Println("========================================")
-	fmt.Printf("總迴圈數: %d\n", len(results))
+	fmt.Fprintln(opts.out)
+	fmt.Fprintln(opts.out, "========================================")
+	fmt.Fprintln(opts.out, "  執行結果摘要")
+	fmt.Fprintln(opts.out, "========================================")
+	fmt.Fprintf(opts.out, "總迴圈數: %d\n", len(results))
 
 	if err != nil {
-		fmt.Printf("結束原因: %v\n", err)
+		fmt.Fprintf(opts.out, "結束原因: %v\n", err)
+		if errors.Is(err, ghcopilot.ErrPromptKnownBad) {
+			fmt.Fprintln(opts.out, "⚠️ 相同的 prompt 最近已經失敗過，確認要重試請加上 -force")
+		}
+		category := ghcopilot.ClassifyRunError(err, results)
+		fmt.Fprintf(opts.out, "錯誤類別: %s\n", category)
+		fmt.Fprintf(opts.out, "建議處理: %s\n", category.Remediation())
 	} else {
-		fmt.Println("結束原因: 任務完成")
+		fmt.Fprintln(opts.out, "結束原因: 任務完成")
 	}
 
 	// 顯示狀態
 	status := client.GetStatus()
-	fmt.Printf("熔斷器狀態: %s\n", status.CircuitBreakerState)
+	fmt.Fprintf(opts.out, "熔斷器狀態: %s\n", status.CircuitBreakerState)
+	if status.BreakerAutoResets > 0 {
+		fmt.Fprintf(opts.out, "熔斷器自動重置: %d 次\n", status.BreakerAutoResets)
+	}
+	if status.RecoveryAttempts > 0 {
+		fmt.Fprintf(opts.out, "恢復嘗試: %d 次\n", status.RecoveryAttempts)
+	}
+
+	// 各項上限的使用率：找出造成結束的限制或剩餘空間
+	report := client.BuildBudgetReport(ghcopilot.BudgetLimits{MaxLoops: opts.maxLoops, Timeout: opts.timeout}, len(results), runDuration)
+	switch opts.budgetReport {
+	case "text":
+		fmt.Fprint(opts.out, report.Text())
+	case "json":
+		if data, err := report.JSON(); err == nil {
+			fmt.Fprintln(opts.out, data)
+		}
+	}
 
 	// 顯示每個迴圈的簡要
 	if len(results) > 0 {
-		fmt.Println()
-		fmt.Println("迴圈歷史:")
+		fmt.Fprintln(opts.out)
+		fmt.Fprintln(opts.out, "迴圈歷史:")
 		for i, r := range results {
 			continueStr := "否"
 			if r.ShouldContinue {
 				continueStr = "是"
 			}
-			fmt.Printf("  [%d] 繼續=%s, 原因=%s\n", i+1, continueStr, r.ExitReason)
+			fmt.Fprintf(opts.out, "  [%d] 繼續=%s, 原因=%s\n", i+1, continueStr, r.ExitReason)
+			for _, w := range r.Warnings {
+				fmt.Fprintf(opts.out, "      ⚠️ %s\n", w)
+			}
 		}
 	}
 
-	fmt.Println("========================================")
+	// 匯出執行紀錄
+	if opts.transcriptPath != "" {
+		if err := client.ExportTranscript(opts.transcriptPath); err != nil {
+			fmt.Fprintf(opts.out, "⚠️ 執行紀錄匯出失敗: %v\n", err)
+		} else {
+			fmt.Fprintf(opts.out, "執行紀錄: %s\n", opts.transcriptPath)
+		}
+	}
+
+	fmt.Fprintln(opts.out, "========================================")
+
+	// 可供腳本擷取的摘要區塊（放在最後，方便以 tail 取得）；jsonl 時改為最後一行的 summary 事件
+	if opts.jsonl != nil {
+		if err := opts.jsonl.WriteSummary(client.BuildRunSummary(err, runDuration)); err != nil {
+			fmt.Fprintf(opts.out, "⚠️ JSONL 輸出失敗: %v\n", err)
+		}
+	} else if opts.emitSummary {
+		fmt.Fprint(opts.out, client.BuildRunSummary(err, runDuration).Block())
+	}
 }
 
-func cmdStatus(workDir string) {
+func cmdStatus(workDir string, verbose bool, format string, checkSDK bool) {
 	config := ghcopilot.DefaultClientConfig()
 	config.WorkDir = workDir
 
@@ -211,6 +602,33 @@ func cmdStatus(workDir string) {
 	_ = client.LoadHistoryFromDisk()
 
 	status := client.GetStatus()
+	var breakerStats *ghcopilot.CircuitBreakerStats
+	if verbose {
+		breakerStats = client.GetCircuitBreakerStats()
+	}
+	var sdkMetrics *ghcopilot.SDKSessionMetrics
+	if checkSDK {
+		sdkMetrics = client.GetSDKSessionMetrics()
+	}
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(struct {
+			Status         *ghcopilot.ClientStatus        `json:"status"`
+			CircuitBreaker *ghcopilot.CircuitBreakerStats `json:"circuit_breaker,omitempty"`
+			SDKSessions    *ghcopilot.SDKSessionMetrics   `json:"sdk_sessions,omitempty"`
+		}{status, breakerStats, sdkMetrics}, "", "  ")
+		if err != nil {
+			fmt.Printf("編碼失敗: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	case "text":
+	default:
+		fmt.Printf("未知的輸出格式: %s（可用: text, json）\n", format)
+		os.Exit(1)
+	}
 
 	fmt.Println("========================================")
 	fmt.Println("  Ralph Loop 狀態")
@@ -220,6 +638,49 @@ func cmdStatus(workDir string) {
 	fmt.Printf("熔斷器狀態: %s\n", status.CircuitBreakerState)
 	fmt.Printf("熔斷器打開: %v\n", status.CircuitBreakerOpen)
 	fmt.Printf("已執行迴圈數: %d\n", status.LoopsExecuted)
+	printCopilotVersion()
+	for _, w := range status.Warnings {
+		fmt.Printf("⚠️ %s\n", w)
+	}
+
+	if breakerStats != nil {
+		fmt.Println()
+		fmt.Println("熔斷器統計:")
+		for _, line := range strings.Split(strings.TrimSuffix(breakerStats.Text(), "\n"), "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	if checkSDK {
+		fmt.Println()
+		fmt.Println("SDK 會話:")
+		if sdkStatus := client.GetSDKStatus(); sdkStatus == nil {
+			fmt.Println("  SDK 執行器不可用")
+		} else {
+			fmt.Printf("  已初始化: %v, 執行中: %v\n", sdkStatus.Initialized, sdkStatus.Running)
+			if sdkStatus.LastError != nil {
+				fmt.Printf("  最後錯誤: %v\n", sdkStatus.LastError)
+			}
+		}
+		if sdkMetrics != nil {
+			fmt.Printf("  建立總數: %d, 現存: %d\n", sdkMetrics.TotalCreated, sdkMetrics.Active)
+			fmt.Printf("  平均存活時間: %v, 最舊會話: %v\n", sdkMetrics.AverageLifetime.Round(time.Second), sdkMetrics.OldestActiveAge.Round(time.Second))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("執行模式:")
+	for _, m := range status.ExecutionModes {
+		mark := "❌"
+		if m.Available {
+			mark = "✅"
+		}
+		selected := ""
+		if m.Selected {
+			selected = " (使用中)"
+		}
+		fmt.Printf("  %s %s%s: %s\n", mark, m.Mode, selected, m.Reason)
+	}
 
 	if status.Summary != nil {
 		fmt.Println()
@@ -231,6 +692,195 @@ func cmdStatus(workDir string) {
 	fmt.Println("========================================")
 }
 
+// printCopilotVersion 顯示偵測到的 copilot CLI 版本與是否在預設支援範圍內
+func printCopilotVersion() {
+	supported := ghcopilot.DefaultCopilotVersionRange()
+	version, err := ghcopilot.NewDependencyChecker().DetectCopilotVersion()
+	if err != nil {
+		fmt.Printf("Copilot CLI 版本: 未知 (%v)\n", err)
+		return
+	}
+	ok, err := supported.Contains(version)
+	switch {
+	case err != nil:
+		fmt.Printf("Copilot CLI 版本: %s (無法比較: %v)\n", version, err)
+	case ok:
+		fmt.Printf("Copilot CLI 版本: %s (支援範圍 %s)\n", version, supported)
+	default:
+		fmt.Printf("Copilot CLI 版本: %s ⚠️ 不在支援範圍 %s 內\n", version, supported)
+	}
+}
+
+func cmdRetryStats(reset bool) {
+	path := filepath.Join(ghcopilot.DefaultClientConfig().SaveDir, ghcopilot.RetryStatsFile)
+
+	if reset {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("清除失敗: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✅ 已清除重試統計")
+		return
+	}
+
+	classifier, err := ghcopilot.LoadRetryClassifier(path)
+	if err != nil {
+		fmt.Printf("載入失敗: %v\n", err)
+		os.Exit(1)
+	}
+	stats := classifier.Stats()
+
+	fmt.Println("========================================")
+	fmt.Println("  重試統計")
+	fmt.Println("========================================")
+	if len(stats) == 0 {
+		fmt.Println("尚無統計（以 run -adaptive-retry 執行後累積）")
+		return
+	}
+	threshold := classifier.FutileThreshold()
+	for _, s := range stats {
+		mark := "🔁"
+		if s.Demoted(threshold) {
+			mark = "⛔"
+		}
+		fmt.Printf("%s %s\n", mark, s.Signature)
+		fmt.Printf("   恢復: %d  未恢復: %d  略過: %d  最後出現: %s\n",
+			s.Recovered, s.Futile, s.Skipped, s.LastSeen.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("\n⛔ 表示從未恢復且未恢復次數達 %d 次，之後不再重試\n", threshold)
+}
+
+func cmdCompare(pathA, pathB, format string) {
+	a, err := ghcopilot.LoadRunHistory(pathA)
+	if err != nil {
+		fmt.Printf("載入 A 失敗: %v\n", err)
+		os.Exit(1)
+	}
+	b, err := ghcopilot.LoadRunHistory(pathB)
+	if err != nil {
+		fmt.Printf("載入 B 失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	comparison := ghcopilot.CompareRuns(a, b)
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(comparison, "", "  ")
+		if err != nil {
+			fmt.Printf("編碼失敗: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	case "text":
+		fmt.Printf("A: %s\nB: %s\n\n", pathA, pathB)
+		fmt.Print(comparison.Text())
+	default:
+		fmt.Printf("未知的輸出格式: %s（可用: text, json）\n", format)
+		os.Exit(1)
+	}
+}
+
+func cmdCapabilities(format string) {
+	caps := ghcopilot.DescribeCapabilities(Version)
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(caps, "", "  ")
+		if err != nil {
+			fmt.Printf("編碼失敗: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	case "text":
+		fmt.Print(caps.Text())
+	default:
+		fmt.Printf("未知的輸出格式: %s（可用: text, json）\n", format)
+		os.Exit(1)
+	}
+}
+
+func cmdConfig(action string, workDir string, format string) {
+	config := ghcopilot.DefaultClientConfig()
+	config.WorkDir = workDir
+	projectConfig, err := ghcopilot.LoadProjectConfig(workDir)
+	if err != nil {
+		fmt.Printf("專案設定載入失敗: %v\n", err)
+		os.Exit(1)
+	}
+	projectConfig.Apply(config)
+
+	var fields []ghcopilot.ConfigField
+	switch action {
+	case "show":
+		fields = ghcopilot.ClientConfigFields(config)
+	case "diff":
+		// WorkDir 由 -workdir 指定，不算自訂設定
+		config.WorkDir = ghcopilot.DefaultClientConfig().WorkDir
+		fields = ghcopilot.DiffClientConfig(config)
+	default:
+		fmt.Printf("未知的動作: %s（可用: show, diff）\n", action)
+		os.Exit(1)
+	}
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(fields, "", "  ")
+		if err != nil {
+			fmt.Printf("編碼失敗: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	case "text":
+		if projectConfig != nil {
+			fmt.Printf("專案設定: %s\n", projectConfig.Path)
+		}
+		if len(fields) == 0 {
+			fmt.Println("所有設定皆為預設值")
+			return
+		}
+		fmt.Print(ghcopilot.ConfigFieldsText(fields, action == "diff"))
+	default:
+		fmt.Printf("未知的輸出格式: %s（可用: text, json）\n", format)
+		os.Exit(1)
+	}
+}
+
+func cmdArtifacts(saveDir string, loop int, extractDir string) {
+	root, err := ghcopilot.LatestArtifactDir(saveDir)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if extractDir != "" {
+		artifacts, err := ghcopilot.ExtractArtifacts(root, loop, extractDir)
+		if err != nil {
+			fmt.Printf("取出失敗: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ 已將迴圈 %d 的 %d 個產出檔案複製到 %s\n", loop, len(artifacts), extractDir)
+		return
+	}
+
+	artifacts, err := ghcopilot.ListArtifacts(root, loop)
+	if err != nil {
+		fmt.Printf("載入失敗: %v\n", err)
+		os.Exit(1)
+	}
+	if len(artifacts) == 0 {
+		fmt.Printf("%s 中沒有保存的產出檔案\n", root)
+		return
+	}
+	fmt.Printf("產出目錄: %s\n", root)
+	current := 0
+	for _, a := range artifacts {
+		if a.Loop != current {
+			current = a.Loop
+			fmt.Printf("\n迴圈 %d:\n", current)
+		}
+		fmt.Printf("  %s (%d bytes)\n", a.Path, a.Size)
+	}
+}
+
 func cmdReset(workDir string) {
 	config := ghcopilot.DefaultClientConfig()
 	config.WorkDir = workDir
@@ -247,13 +897,23 @@ func cmdReset(workDir string) {
 	fmt.Println("熔斷器已重置")
 }
 
-func cmdWatch(workDir string, interval time.Duration) {
-	config := ghcopilot.DefaultClientConfig()
-	config.WorkDir = workDir
+func cmdWatch(workDir string, interval time.Duration, tailLines int) {
+	// 與 run 使用相同的設定來源，日誌檔與歷史的位置才會一致
+	config, err := buildRunConfig(&runOptions{workDir: workDir, out: io.Discard})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 	client := ghcopilot.NewRalphLoopClientWithConfig(config)
 	defer client.Close()
 
+	// 追蹤日誌檔的最後幾行（截斷或輪替後改讀新檔案）
+	var tailer *ghcopilot.LogTailer
+	if tailLines > 0 {
+		tailer = ghcopilot.NewLogTailer(ghcopilot.LogFilePath(config.SaveDir), tailLines)
+	}
+
 	fmt.Println("========================================")
 	fmt.Println("  Ralph Loop 監控模式")
 	fmt.Println("========================================")
@@ -297,6 +957,20 @@ func cmdWatch(workDir string, interval time.Duration) {
 					fmt.Printf("  %s: %v\n", k, v)
 				}
 			}
+			if tailer != nil {
+				fmt.Println("----------------------------------------")
+				fmt.Printf("最近日誌 (%s):\n", tailer.Path())
+				lines, err := tailer.Poll()
+				switch {
+				case err != nil:
+					fmt.Printf("  ⚠️ %v\n", err)
+				case len(lines) == 0:
+					fmt.Println("  (尚無日誌)")
+				}
+				for _, line := range lines {
+					fmt.Printf("  %s\n", line)
+				}
+			}
 			fmt.Println("----------------------------------------")
 			fmt.Println("按 Ctrl+C 停止監控")
 		}
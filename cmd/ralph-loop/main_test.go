@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cy540/ralph-loop/internal/ghcopilot"
+)
+
+// writeRalphrc 在 dir 建立 .ralphrc 與 .git（讓搜尋停在 dir）
+func writeRalphrc(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".ralphrc"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBuildRunConfigAppliesRalphrcThresholds 測試 .ralphrc 的熔斷器閾值不會被 run 的設定覆蓋
+func TestBuildRunConfigAppliesRalphrcThresholds(t *testing.T) {
+	dir := t.TempDir()
+	writeRalphrc(t, dir, `{"model": "gpt-5", "completion": {"circuit_breaker_threshold": 1, "same_error_threshold": 2}}`)
+
+	config, err := buildRunConfig(&runOptions{workDir: dir, out: io.Discard})
+	if err != nil {
+		t.Fatalf("建立設定失敗: %v", err)
+	}
+	if config.CircuitBreakerThreshold != 1 {
+		t.Errorf("CircuitBreakerThreshold 應為 .ralphrc 的 1，實際 %d", config.CircuitBreakerThreshold)
+	}
+	if config.SameErrorThreshold != 2 {
+		t.Errorf("SameErrorThreshold 應為 .ralphrc 的 2，實際 %d", config.SameErrorThreshold)
+	}
+
+	// 命令列參數優先於 .ralphrc
+	config, err = buildRunConfig(&runOptions{workDir: dir, out: io.Discard, model: "claude-sonnet-4.5"})
+	if err != nil {
+		t.Fatalf("建立設定失敗: %v", err)
+	}
+	if config.Model != "claude-sonnet-4.5" {
+		t.Errorf("-model 應優先於 .ralphrc，實際 %s", config.Model)
+	}
+}
+
+// TestBuildRunConfigDefaults 測試 .ralphrc 未設定閾值時使用預設值
+func TestBuildRunConfigDefaults(t *testing.T) {
+	dir := t.TempDir()
+	writeRalphrc(t, dir, `{}`)
+
+	config, err := buildRunConfig(&runOptions{workDir: dir, out: io.Discard})
+	if err != nil {
+		t.Fatalf("建立設定失敗: %v", err)
+	}
+	if config.CircuitBreakerThreshold != 3 || config.SameErrorThreshold != 5 || config.CLIMaxRetries != 3 {
+		t.Errorf("應使用預設值，實際 breaker=%d sameError=%d retries=%d",
+			config.CircuitBreakerThreshold, config.SameErrorThreshold, config.CLIMaxRetries)
+	}
+}
+
+// TestBuildRunConfigThresholdFlags 測試閾值參數優先於 .ralphrc，並傳入客戶端的熔斷器
+func TestBuildRunConfigThresholdFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeRalphrc(t, dir, `{"completion": {"circuit_breaker_threshold": 4, "same_error_threshold": 6}}`)
+
+	config, err := buildRunConfig(&runOptions{workDir: dir, out: io.Discard, breakerThreshold: 1})
+	if err != nil {
+		t.Fatalf("建立設定失敗: %v", err)
+	}
+	config.EnablePersistence = false
+	client := ghcopilot.NewRalphLoopClientWithConfig(config)
+	defer client.Close()
+
+	stats := client.GetCircuitBreakerStats()
+	if stats.NoProgressThreshold != 1 {
+		t.Errorf("-breaker-threshold 應優先於 .ralphrc，實際 %d", stats.NoProgressThreshold)
+	}
+	if stats.SameErrorThreshold != 6 {
+		t.Errorf("未指定 -same-error-threshold 時應使用 .ralphrc 的 6，實際 %d", stats.SameErrorThreshold)
+	}
+}
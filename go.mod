@@ -5,4 +5,5 @@ go 1.24.5
 require (
 	github.com/github/copilot-sdk/go v0.1.26-preview.0.0.20260223150653-f0909a78ce6c // indirect
 	github.com/google/jsonschema-go v0.4.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
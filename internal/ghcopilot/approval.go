@@ -0,0 +1,206 @@
+package ghcopilot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ApprovalStatus 代表外部審核的狀態
+type ApprovalStatus string
+
+const (
+	// ApprovalApproved 審核通過，允許迴圈結束
+	ApprovalApproved ApprovalStatus = "approved"
+	// ApprovalRejected 審核拒絕，迴圈將繼續
+	ApprovalRejected ApprovalStatus = "rejected"
+	// ApprovalPending 等待審核中
+	ApprovalPending ApprovalStatus = "pending"
+)
+
+// ApprovalDecision 記錄外部審核的結果
+type ApprovalDecision struct {
+	Approved  bool      `json:"approved"`   // 是否核准
+	Reason    string    `json:"reason"`     // 審核者提供的原因
+	TimedOut  bool      `json:"timed_out"`  // 是否因逾時而預設拒絕
+	DecidedAt time.Time `json:"decided_at"` // 決定時間
+}
+
+// approvalRequest 是送往審核端點的內容
+type approvalRequest struct {
+	LoopID          string `json:"loop_id"`
+	LoopIndex       int    `json:"loop_index"`
+	ExitReason      string `json:"exit_reason"`
+	CompletionScore int    `json:"completion_score"`
+	Output          string `json:"output"`
+}
+
+// approvalResponse 是審核端點的回應格式
+//
+//	{"status": "approved" | "rejected" | "pending", "reason": "...", "poll_url": "..."}
+type approvalResponse struct {
+	Status  ApprovalStatus `json:"status"`
+	Reason  string         `json:"reason"`
+	PollURL string         `json:"poll_url"`
+}
+
+// ExternalApprover 在迴圈判定完成時向外部 URL 請求人工審核
+//
+// 流程：先 POST 迴圈結果到設定的 URL；若回應為 pending，
+// 則以 GET 輪詢 poll_url（未提供時使用原 URL 加上 loop_id 參數），
+// 直到取得 approved/rejected 或逾時。逾時一律視為拒絕。
+type ExternalApprover struct {
+	url          string
+	timeout      time.Duration
+	pollInterval time.Duration
+	maskOutput   bool // 送出前遮蔽輸出與結束原因中的密碼與 token
+	httpClient   *http.Client
+}
+
+// NewExternalApprover 建立新的外部審核器
+func NewExternalApprover(approvalURL string, timeout, pollInterval time.Duration) *ExternalApprover {
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	return &ExternalApprover{
+		url:          approvalURL,
+		timeout:      timeout,
+		pollInterval: pollInterval,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetMaskOutput 設定送出審核請求前是否遮蔽輸出中的密碼與 token（對應 MaskSensitiveOutput）
+func (a *ExternalApprover) SetMaskOutput(mask bool) {
+	a.maskOutput = mask
+}
+
+// RequestApproval 送出審核請求並等待結果
+//
+// 只有網路或協定錯誤會回傳 error；逾時會回傳 TimedOut=true 的拒絕決定。
+func (a *ExternalApprover) RequestApproval(ctx context.Context, execCtx *ExecutionContext) (*ApprovalDecision, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	payload := approvalRequest{
+		LoopID:          execCtx.LoopID,
+		LoopIndex:       execCtx.LoopIndex,
+		ExitReason:      execCtx.ExitReason,
+		CompletionScore: execCtx.CompletionScore,
+		Output:          execCtx.CLIOutput,
+	}
+	if a.maskOutput {
+		payload.ExitReason = MaskSecrets(payload.ExitReason)
+		payload.Output = MaskSecrets(payload.Output)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("無法序列化審核請求: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(waitCtx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("無法建立審核請求: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.do(req)
+	if err != nil {
+		if waitCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return a.timedOut(), nil
+		}
+		return nil, err
+	}
+
+	pollURL := resp.PollURL
+	if pollURL == "" {
+		pollURL = a.defaultPollURL(execCtx.LoopID)
+	}
+
+	for resp.Status == ApprovalPending {
+		select {
+		case <-time.After(a.pollInterval):
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return a.timedOut(), nil
+		}
+
+		req, err := http.NewRequestWithContext(waitCtx, http.MethodGet, pollURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("無法建立輪詢請求: %w", err)
+		}
+		resp, err = a.do(req)
+		if err != nil {
+			if waitCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+				return a.timedOut(), nil
+			}
+			return nil, err
+		}
+	}
+
+	switch resp.Status {
+	case ApprovalApproved:
+		return &ApprovalDecision{Approved: true, Reason: resp.Reason, DecidedAt: time.Now()}, nil
+	case ApprovalRejected:
+		return &ApprovalDecision{Approved: false, Reason: resp.Reason, DecidedAt: time.Now()}, nil
+	default:
+		return nil, fmt.Errorf("未知的審核狀態: %q", resp.Status)
+	}
+}
+
+// do 送出請求並解析審核回應
+func (a *ExternalApprover) do(req *http.Request) (*approvalResponse, error) {
+	httpResp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("審核請求失敗: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取審核回應: %w", err)
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return nil, fmt.Errorf("審核端點回應 HTTP %d: %s", httpResp.StatusCode, truncateString(strings.TrimSpace(string(data)), 200))
+	}
+
+	var resp approvalResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("無法解析審核回應: %w", err)
+	}
+	resp.Status = ApprovalStatus(strings.ToLower(strings.TrimSpace(string(resp.Status))))
+	return &resp, nil
+}
+
+// defaultPollURL 在原 URL 上附加 loop_id 參數作為輪詢位址
+func (a *ExternalApprover) defaultPollURL(loopID string) string {
+	u, err := url.Parse(a.url)
+	if err != nil {
+		return a.url
+	}
+	q := u.Query()
+	q.Set("loop_id", loopID)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// timedOut 傳回逾時的預設拒絕決定
+func (a *ExternalApprover) timedOut() *ApprovalDecision {
+	return &ApprovalDecision{
+		Approved:  false,
+		Reason:    fmt.Sprintf("審核逾時 (%v)，預設拒絕", a.timeout),
+		TimedOut:  true,
+		DecidedAt: time.Now(),
+	}
+}
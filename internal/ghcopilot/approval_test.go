@@ -0,0 +1,156 @@
+package ghcopilot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newApprovalServer 建立回應固定序列狀態的測試審核端點
+func newApprovalServer(t *testing.T, statuses ...string) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		if n >= len(statuses) {
+			n = len(statuses) - 1
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"status": statuses[n],
+			"reason": "審核者回覆 " + statuses[n],
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// TestExternalApproverApproved 測試立即核准
+func TestExternalApproverApproved(t *testing.T) {
+	server, calls := newApprovalServer(t, "approved")
+	approver := NewExternalApprover(server.URL, time.Second, 10*time.Millisecond)
+
+	decision, err := approver.RequestApproval(context.Background(), &ExecutionContext{LoopID: "loop-1"})
+	if err != nil {
+		t.Fatalf("RequestApproval 不應失敗: %v", err)
+	}
+	if !decision.Approved {
+		t.Error("應為核准")
+	}
+	if atomic.LoadInt32(calls) != 1 {
+		t.Errorf("應只呼叫一次，但為 %d", atomic.LoadInt32(calls))
+	}
+}
+
+// TestExternalApproverPolling 測試 pending 後輪詢取得結果
+func TestExternalApproverPolling(t *testing.T) {
+	server, calls := newApprovalServer(t, "pending", "pending", "approved")
+	approver := NewExternalApprover(server.URL, time.Second, 10*time.Millisecond)
+
+	decision, err := approver.RequestApproval(context.Background(), &ExecutionContext{LoopID: "loop-1"})
+	if err != nil {
+		t.Fatalf("RequestApproval 不應失敗: %v", err)
+	}
+	if !decision.Approved {
+		t.Error("輪詢後應為核准")
+	}
+	if atomic.LoadInt32(calls) != 3 {
+		t.Errorf("應呼叫 3 次，但為 %d", atomic.LoadInt32(calls))
+	}
+}
+
+// TestExternalApproverRejected 測試拒絕
+func TestExternalApproverRejected(t *testing.T) {
+	server, _ := newApprovalServer(t, "rejected")
+	approver := NewExternalApprover(server.URL, time.Second, 10*time.Millisecond)
+
+	decision, err := approver.RequestApproval(context.Background(), &ExecutionContext{LoopID: "loop-1"})
+	if err != nil {
+		t.Fatalf("RequestApproval 不應失敗: %v", err)
+	}
+	if decision.Approved {
+		t.Error("應為拒絕")
+	}
+	if decision.Reason != "審核者回覆 rejected" {
+		t.Errorf("原因不正確: %s", decision.Reason)
+	}
+}
+
+// TestExternalApproverTimeout 測試逾時預設拒絕
+func TestExternalApproverTimeout(t *testing.T) {
+	server, _ := newApprovalServer(t, "pending")
+	approver := NewExternalApprover(server.URL, 50*time.Millisecond, 10*time.Millisecond)
+
+	decision, err := approver.RequestApproval(context.Background(), &ExecutionContext{LoopID: "loop-1"})
+	if err != nil {
+		t.Fatalf("逾時不應回傳錯誤: %v", err)
+	}
+	if decision.Approved {
+		t.Error("逾時應視為拒絕")
+	}
+	if !decision.TimedOut {
+		t.Error("TimedOut 應為 true")
+	}
+}
+
+// TestExecuteLoopExternalApprovalRejected 測試審核拒絕時迴圈繼續
+func TestExecuteLoopExternalApprovalRejected(t *testing.T) {
+	server, _ := newApprovalServer(t, "rejected")
+
+	config := DefaultClientConfig()
+	config.ExternalApprovalURL = server.URL
+	config.ExternalApprovalTimeout = time.Second
+	config.ExternalApprovalPollInterval = 10 * time.Millisecond
+
+	client := newScriptedClient(config, doneOutput)
+
+	result, err := client.ExecuteLoop(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("ExecuteLoop 不應失敗: %v", err)
+	}
+	if !result.ShouldContinue {
+		t.Error("審核拒絕後應繼續迴圈")
+	}
+	if result.Approval == nil || result.Approval.Approved {
+		t.Error("應記錄拒絕的審核結果")
+	}
+
+	history := client.GetHistory()
+	if len(history) != 1 || history[0].ApprovalDecision == nil {
+		t.Error("執行上下文應記錄審核結果")
+	}
+}
+
+// TestExitDetectorConfirmExitMasksPayload 測試審核步驟在 MaskSensitiveOutput 時遮蔽送出的輸出，並記錄拒絕
+func TestExitDetectorConfirmExitMasksPayload(t *testing.T) {
+	var body approvalRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "rejected", "reason": "需要修改"})
+	}))
+	t.Cleanup(server.Close)
+
+	detector := NewExitDetector(t.TempDir())
+	if decision, err := detector.ConfirmExit(context.Background(), &ExecutionContext{}); decision != nil || err != nil {
+		t.Fatalf("未設定審核時不需要確認: %v %v", decision, err)
+	}
+
+	approver := NewExternalApprover(server.URL, time.Second, 10*time.Millisecond)
+	approver.SetMaskOutput(true)
+	detector.SetApprover(approver)
+
+	execCtx := &ExecutionContext{LoopID: "loop-1", CLIOutput: "部署完成 password=hunter2", ExitReason: "token: abc123"}
+	decision, err := detector.ConfirmExit(context.Background(), execCtx)
+	if err != nil || decision == nil || decision.Approved {
+		t.Fatalf("應傳回拒絕的決定: %+v %v", decision, err)
+	}
+	if body.Output != "部署完成 password=****" || body.ExitReason != "token: ****" {
+		t.Errorf("送出的內容應已遮蔽: %+v", body)
+	}
+	if got := detector.GetExitConditions()[ApprovalRejectedCondition]; got != 1 {
+		t.Errorf("應記錄 1 次審核拒絕，實際 %d", got)
+	}
+}
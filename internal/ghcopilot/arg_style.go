@@ -0,0 +1,147 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ArgStyle 描述某個版本範圍的 copilot CLI 使用的參數名稱；空字串表示該版本不支援此參數（略過）
+type ArgStyle struct {
+	Name       string `json:"name"`
+	MinVersion string `json:"min_version"` // 適用的最低 copilot 版本（含）
+
+	Prompt               string `json:"prompt"`
+	Model                string `json:"model"`
+	Silent               string `json:"silent"`
+	Yolo                 string `json:"yolo"` // 全部開放時使用的單一旗標，不支援時改用個別的 allow-all 旗標
+	AllowAllTools        string `json:"allow_all_tools"`
+	AllowAllPaths        string `json:"allow_all_paths"`
+	AllowAllURLs         string `json:"allow_all_urls"`
+	NoAskUser            string `json:"no_ask_user"`
+	NoCustomInstructions string `json:"no_custom_instructions"`
+	DisableParallel      string `json:"disable_parallel"`
+	AllowTool            string `json:"allow_tool"`
+	DenyTool             string `json:"deny_tool"`
+	AddDir               string `json:"add_dir"`
+	Resume               string `json:"resume"`
+	Continue             string `json:"continue"`
+	Share                string `json:"share"`
+}
+
+// 內建的參數風格名稱
+const (
+	ArgStyleCurrentName = "current"
+	ArgStyleLegacyName  = "legacy"
+	// ArgStyleAuto 依偵測到的 copilot 版本選擇參數風格
+	ArgStyleAuto = "auto"
+)
+
+// ArgStyleCurrent 目前支援的 copilot CLI（DefaultMinCopilotVersion 起）的參數
+func ArgStyleCurrent() ArgStyle {
+	return ArgStyle{
+		Name:                 ArgStyleCurrentName,
+		MinVersion:           DefaultMinCopilotVersion,
+		Prompt:               "-p",
+		Model:                "--model",
+		Silent:               "-s",
+		Yolo:                 "--yolo",
+		AllowAllTools:        "--allow-all-tools",
+		AllowAllPaths:        "--allow-all-paths",
+		AllowAllURLs:         "--allow-all-urls",
+		NoAskUser:            "--no-ask-user",
+		NoCustomInstructions: "--no-custom-instructions",
+		DisableParallel:      "--disable-parallel-tools-execution",
+		AllowTool:            "--allow-tool",
+		DenyTool:             "--deny-tool",
+		AddDir:               "--add-dir",
+		Resume:               "--resume",
+		Continue:             "--continue",
+		Share:                "--share",
+	}
+}
+
+// ArgStyleLegacy 前一代 copilot CLI 的參數：沒有 --yolo、-s、--allow-all-urls、
+// --no-ask-user、--no-custom-instructions 與 --share
+func ArgStyleLegacy() ArgStyle {
+	return ArgStyle{
+		Name:            ArgStyleLegacyName,
+		MinVersion:      "0.0.300",
+		Prompt:          "-p",
+		Model:           "--model",
+		AllowAllTools:   "--allow-all-tools",
+		AllowAllPaths:   "--allow-all-paths",
+		DisableParallel: "--disable-parallel-tools-execution",
+		AllowTool:       "--allow-tool",
+		DenyTool:        "--deny-tool",
+		AddDir:          "--add-dir",
+		Resume:          "--resume",
+		Continue:        "--continue",
+	}
+}
+
+// ArgStyles 傳回所有內建的參數風格（依 MinVersion 由新到舊）
+func ArgStyles() []ArgStyle {
+	return []ArgStyle{ArgStyleCurrent(), ArgStyleLegacy()}
+}
+
+// ArgStyleByName 依名稱取得內建的參數風格
+func ArgStyleByName(name string) (ArgStyle, error) {
+	for _, style := range ArgStyles() {
+		if style.Name == name {
+			return style, nil
+		}
+	}
+	return ArgStyle{}, fmt.Errorf("未知的 CLI 參數風格 %q", name)
+}
+
+// ArgStyleForVersion 選擇適用於指定 copilot 版本的參數風格（MinVersion 不超過該版本中最新的一個）；
+// 版本無法解析或比所有風格都舊時使用最舊的風格
+func ArgStyleForVersion(version string) ArgStyle {
+	styles := ArgStyles()
+	sort.SliceStable(styles, func(i, j int) bool {
+		c, _ := compareVersions(styles[i].MinVersion, styles[j].MinVersion)
+		return c > 0
+	})
+	for _, style := range styles {
+		if c, err := compareVersions(version, style.MinVersion); err == nil && c >= 0 {
+			return style
+		}
+	}
+	return styles[len(styles)-1]
+}
+
+// resolveArgStyle 依 ClientConfig.CLIArgStyle 決定參數風格：空值為 current，auto 時偵測 copilot 版本
+func resolveArgStyle(name string) (ArgStyle, error) {
+	switch name {
+	case "":
+		return ArgStyleCurrent(), nil
+	case ArgStyleAuto:
+		version, err := NewDependencyChecker().DetectCopilotVersion()
+		if err != nil {
+			return ArgStyleCurrent(), fmt.Errorf("無法偵測 copilot 版本: %w", err)
+		}
+		return ArgStyleForVersion(version), nil
+	default:
+		return ArgStyleByName(name)
+	}
+}
+
+// ensureArgStyle CLIArgStyle 為 auto 時，在第一次以 CLI 執行前偵測 copilot 版本並套用對應的參數風格；
+// 偵測失敗時維持 current 並回傳錯誤（只回報一次）
+func (c *RalphLoopClient) ensureArgStyle() error {
+	if !c.argStylePending {
+		return nil
+	}
+	c.argStylePending = false
+	style, err := resolveArgStyle(ArgStyleAuto)
+	c.executor.SetArgStyle(style)
+	return err
+}
+
+// appendFlag 加入旗標；該風格不支援（旗標為空）時略過
+func appendFlag(args []string, flag string, values ...string) []string {
+	if flag == "" {
+		return args
+	}
+	return append(append(args, flag), values...)
+}
@@ -0,0 +1,132 @@
+package ghcopilot
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newArgStyleExecutor 建立使用相同選項、指定參數風格的執行器
+func newArgStyleExecutor(style ArgStyle) *CLIExecutor {
+	ce := NewCLIExecutor("/tmp")
+	ce.SetModel(ModelClaudeSonnet45)
+	ce.SetSilent(true)
+	ce.options.DeniedTools = []string{"shell(rm)"}
+	ce.options.SharePath = "session.md"
+	ce.SetArgStyle(style)
+	return ce
+}
+
+// TestArgStyleProfiles 測試相同選項在不同參數風格下產生的參數
+func TestArgStyleProfiles(t *testing.T) {
+	tests := []struct {
+		style ArgStyle
+		want  []string
+	}{
+		{ArgStyleCurrent(), []string{
+			"-p", "task", "--model", "claude-sonnet-4.5", "-s", "--yolo", "--no-custom-instructions",
+			"--deny-tool", "shell(rm)", "--share", "session.md",
+		}},
+		{ArgStyleLegacy(), []string{
+			"-p", "task", "--model", "claude-sonnet-4.5", "--allow-all-tools", "--allow-all-paths",
+			"--deny-tool", "shell(rm)",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style.Name, func(t *testing.T) {
+			got := newArgStyleExecutor(tt.style).buildArgs("task")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("參數錯誤\n實際: %q\n預期: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestArgStyleForVersion 測試依 copilot 版本選擇參數風格
+func TestArgStyleForVersion(t *testing.T) {
+	tests := map[string]string{
+		DefaultMinCopilotVersion: ArgStyleCurrentName,
+		"1.2.0":                  ArgStyleCurrentName,
+		"0.0.320":                ArgStyleLegacyName,
+		"0.0.100":                ArgStyleLegacyName,
+		"unknown":                ArgStyleLegacyName,
+	}
+	for version, want := range tests {
+		if got := ArgStyleForVersion(version).Name; got != want {
+			t.Errorf("版本 %s 應使用 %s，實際 %s", version, want, got)
+		}
+	}
+}
+
+// TestCLIArgStyleConfig 測試 ClientConfig.CLIArgStyle 選擇參數風格
+func TestCLIArgStyleConfig(t *testing.T) {
+	config := DefaultClientConfig()
+	config.CLIArgStyle = ArgStyleLegacyName
+	if got := NewRalphLoopClientWithConfig(config).executor.ArgStyle().Name; got != ArgStyleLegacyName {
+		t.Errorf("應使用 legacy 參數風格，實際 %s", got)
+	}
+
+	config.CLIArgStyle = "nonexistent"
+	if got := NewRalphLoopClientWithConfig(config).executor.ArgStyle().Name; got != ArgStyleCurrentName {
+		t.Errorf("未知的參數風格應改用 current，實際 %s", got)
+	}
+	if _, err := ArgStyleByName("nonexistent"); err == nil {
+		t.Error("未知的參數風格應回傳錯誤")
+	}
+}
+
+// TestCLIArgStyleAutoIsLazy 測試 auto 不在建構時執行 copilot --version，而是在第一次以 CLI 執行時才偵測
+func TestCLIArgStyleAutoIsLazy(t *testing.T) {
+	marker := installFakeCopilotVersion(t, "GitHub Copilot CLI 0.0.300")
+	t.Setenv("COPILOT_MOCK_MODE", "")
+
+	config := DefaultClientConfig()
+	config.CLIArgStyle = ArgStyleAuto
+	config.EnableSDK = false
+	config.WorkDir = t.TempDir()
+	client := newScriptedClient(config, "完成")
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("建構時不應執行 copilot --version")
+	}
+	if got := client.executor.ArgStyle().Name; got != ArgStyleCurrentName {
+		t.Errorf("偵測前應使用 current，實際 %s", got)
+	}
+
+	if _, err := client.ExecuteLoop(context.Background(), "test"); err != nil {
+		t.Fatalf("ExecuteLoop 失敗: %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Error("第一次以 CLI 執行時應偵測 copilot 版本")
+	}
+	if got := client.executor.ArgStyle().Name; got != ArgStyleLegacyName {
+		t.Errorf("copilot 0.0.300 應使用 legacy 參數風格，實際 %s", got)
+	}
+}
+
+// TestDryRunResolvesAutoArgStyle 測試 auto 時預覽的命令列使用偵測到的版本對應的參數風格
+func TestDryRunResolvesAutoArgStyle(t *testing.T) {
+	marker := installFakeCopilotVersion(t, "GitHub Copilot CLI 0.0.300")
+	t.Setenv("COPILOT_MOCK_MODE", "")
+
+	config := DefaultClientConfig()
+	config.CLIArgStyle = ArgStyleAuto
+	config.EnableSDK = false
+	config.WorkDir = t.TempDir()
+	client := newScriptedClient(config, "完成")
+
+	line, err := client.DryRun(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("DryRun 失敗: %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Error("DryRun 應偵測 copilot 版本")
+	}
+	if strings.Contains(line, "--yolo") || strings.Contains(line, "--no-ask-user") {
+		t.Errorf("legacy 版本的命令列不應包含 current 的旗標: %s", line)
+	}
+	if !strings.Contains(line, "--allow-all-tools") {
+		t.Errorf("legacy 版本的命令列應使用個別的 allow-all 旗標: %s", line)
+	}
+}
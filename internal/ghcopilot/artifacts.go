@@ -0,0 +1,312 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArtifactsDirName 迴圈產出檔案在儲存目錄中的子目錄名稱
+const ArtifactsDirName = "artifacts"
+
+// DefaultMaxArtifactBytes 產出檔案的預設總容量上限（100 MB）
+const DefaultMaxArtifactBytes int64 = 100 << 20
+
+// artifactLoopPrefix 每個迴圈子目錄的名稱前綴（loop-001、loop-002...）
+const artifactLoopPrefix = "loop-"
+
+// artifactStamp 檔案的大小與修改時間，用來判斷檔案是否新增或變更
+type artifactStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// Artifact 某個迴圈保存的產出檔案
+type Artifact struct {
+	Loop int    `json:"loop"` // 迴圈序號（從 1 起算）
+	Path string `json:"path"` // 相對於工作目錄的路徑（以 / 分隔）
+	Size int64  `json:"size"`
+	File string `json:"file"` // 保存的副本路徑
+}
+
+// matchArtifactGlob 檢查相對路徑是否符合任一 glob（比對完整相對路徑或檔名）
+func matchArtifactGlob(rel string, globs []string) bool {
+	for _, g := range globs {
+		if ok, _ := filepath.Match(g, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(g, filepath.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotArtifacts 記錄工作目錄中所有符合 glob 的檔案狀態（略過 .git 與 Ralph Loop 的狀態目錄）
+func snapshotArtifacts(dir string, globs []string) (map[string]artifactStamp, error) {
+	if dir == "" {
+		dir = "."
+	}
+	stamps := make(map[string]artifactStamp)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && fingerprintSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || fingerprintSkipFiles[d.Name()] {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if !matchArtifactGlob(rel, globs) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // 檔案在走訪途中被刪除
+		}
+		stamps[rel] = artifactStamp{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return stamps, err
+}
+
+// artifactRoot 本次執行的產出檔案目錄（PerRunSaveDir 時位於執行子目錄下）
+func (c *RalphLoopClient) artifactRoot() string {
+	return filepath.Join(c.config.SaveDir, c.runID, ArtifactsDirName)
+}
+
+// snapshotArtifactBaseline 記錄第一個迴圈前的檔案狀態，執行前就存在且未變更的檔案不會被保存
+func (c *RalphLoopClient) snapshotArtifactBaseline() {
+	if len(c.config.ArtifactGlobs) == 0 || c.artifactStamps != nil {
+		return
+	}
+	stamps, err := snapshotArtifacts(c.config.WorkDir, c.config.ArtifactGlobs)
+	if err != nil {
+		debugLog("無法記錄產出檔案基準: %v", err)
+		return
+	}
+	c.artifactStamps = stamps
+}
+
+// collectArtifacts 將本迴圈新增或變更、且符合 ArtifactGlobs 的檔案複製到迴圈的產出目錄
+//
+// 複製後依 MaxArtifactBytes 刪除最舊的迴圈目錄。失敗只記錄為警告，不影響迴圈決策。
+func (c *RalphLoopClient) collectArtifacts(execCtx *ExecutionContext) {
+	if len(c.config.ArtifactGlobs) == 0 || c.artifactStamps == nil {
+		return
+	}
+
+	current, err := snapshotArtifacts(c.config.WorkDir, c.config.ArtifactGlobs)
+	if err != nil {
+		execCtx.AddWarning("無法掃描產出檔案: %v", err)
+		return
+	}
+
+	var changed []string
+	for rel, stamp := range current {
+		if prev, ok := c.artifactStamps[rel]; !ok || prev.size != stamp.size || !prev.modTime.Equal(stamp.modTime) {
+			changed = append(changed, rel)
+		}
+	}
+	c.artifactStamps = current
+	if len(changed) == 0 {
+		return
+	}
+	sort.Strings(changed)
+
+	root := c.artifactRoot()
+	loopDir := artifactLoopDir(root, execCtx.LoopIndex+1)
+	workDir := c.config.WorkDir
+	if workDir == "" {
+		workDir = "."
+	}
+	for _, rel := range changed {
+		src := filepath.Join(workDir, filepath.FromSlash(rel))
+		if err := copyArtifact(src, filepath.Join(loopDir, filepath.FromSlash(rel))); err != nil {
+			execCtx.AddWarning("無法保存產出檔案 %s: %v", rel, err)
+			continue
+		}
+		execCtx.Artifacts = append(execCtx.Artifacts, rel)
+	}
+	if len(execCtx.Artifacts) > 0 {
+		infoLog("📦 已保存 %d 個產出檔案到 %s", len(execCtx.Artifacts), loopDir)
+	}
+
+	limit := c.config.MaxArtifactBytes
+	if limit <= 0 {
+		limit = DefaultMaxArtifactBytes
+	}
+	pruned, err := PruneArtifacts(root, limit)
+	if err != nil {
+		execCtx.AddWarning("清理產出檔案失敗: %v", err)
+	}
+	if len(pruned) > 0 {
+		infoLog("🗑️ 產出檔案超過 %d bytes，已刪除迴圈 %v 的副本", limit, pruned)
+	}
+}
+
+// copyArtifact 複製單一檔案，必要時建立目錄
+func copyArtifact(src, dst string) error {
+	in, err := os.Open(src) // #nosec G304 -- 路徑來自工作目錄走訪
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) // #nosec G304 -- 路徑由產出目錄組成
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// artifactLoopDirs 傳回產出目錄下的迴圈子目錄（依迴圈序號排序）
+func artifactLoopDirs(root string) ([]int, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var loops []int
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), artifactLoopPrefix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), artifactLoopPrefix))
+		if err != nil {
+			continue
+		}
+		loops = append(loops, n)
+	}
+	sort.Ints(loops)
+	return loops, nil
+}
+
+// artifactLoopDir 迴圈子目錄路徑
+func artifactLoopDir(root string, loop int) string {
+	return filepath.Join(root, fmt.Sprintf("%s%03d", artifactLoopPrefix, loop))
+}
+
+// PruneArtifacts 從最舊的迴圈開始刪除，直到產出檔案總大小不超過 maxBytes；傳回被刪除的迴圈序號
+func PruneArtifacts(root string, maxBytes int64) ([]int, error) {
+	all, err := ListArtifacts(root, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[int]int64)
+	var total int64
+	for _, a := range all {
+		sizes[a.Loop] += a.Size
+		total += a.Size
+	}
+
+	loops, err := artifactLoopDirs(root)
+	if err != nil {
+		return nil, err
+	}
+	var pruned []int
+	for _, loop := range loops {
+		if total <= maxBytes {
+			break
+		}
+		if err := os.RemoveAll(artifactLoopDir(root, loop)); err != nil {
+			return pruned, fmt.Errorf("無法刪除迴圈 %d 的產出檔案: %w", loop, err)
+		}
+		total -= sizes[loop]
+		pruned = append(pruned, loop)
+	}
+	return pruned, nil
+}
+
+// ListArtifacts 列出保存的產出檔案；loop 大於 0 時只列出該迴圈
+func ListArtifacts(root string, loop int) ([]Artifact, error) {
+	loops, err := artifactLoopDirs(root)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取產出目錄 %s: %w", root, err)
+	}
+
+	var artifacts []Artifact
+	for _, n := range loops {
+		if loop > 0 && n != loop {
+			continue
+		}
+		dir := artifactLoopDir(root, n)
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			artifacts = append(artifacts, Artifact{Loop: n, Path: filepath.ToSlash(rel), Size: info.Size(), File: path})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("無法讀取迴圈 %d 的產出檔案: %w", n, err)
+		}
+	}
+	return artifacts, nil
+}
+
+// ExtractArtifacts 將某個迴圈保存的產出檔案依原本的相對路徑複製到 dest
+func ExtractArtifacts(root string, loop int, dest string) ([]Artifact, error) {
+	if loop <= 0 {
+		return nil, fmt.Errorf("必須指定迴圈序號")
+	}
+	artifacts, err := ListArtifacts(root, loop)
+	if err != nil {
+		return nil, err
+	}
+	if len(artifacts) == 0 {
+		return nil, fmt.Errorf("迴圈 %d 沒有保存的產出檔案", loop)
+	}
+	for _, a := range artifacts {
+		if err := copyArtifact(a.File, filepath.Join(dest, filepath.FromSlash(a.Path))); err != nil {
+			return nil, fmt.Errorf("無法取出 %s: %w", a.Path, err)
+		}
+	}
+	return artifacts, nil
+}
+
+// LatestArtifactDir 傳回 saveDir 中的產出目錄；saveDir 本身沒有時改找最新的執行子目錄
+func LatestArtifactDir(saveDir string) (string, error) {
+	root := filepath.Join(saveDir, ArtifactsDirName)
+	if _, err := os.Stat(root); err == nil {
+		return root, nil
+	}
+	runDir, err := LatestRunDir(saveDir)
+	if err == nil {
+		root = filepath.Join(runDir, ArtifactsDirName)
+		if _, err := os.Stat(root); err == nil {
+			return root, nil
+		}
+	}
+	return "", fmt.Errorf("找不到產出檔案目錄（以 ArtifactGlobs 執行後產生）: %s", saveDir)
+}
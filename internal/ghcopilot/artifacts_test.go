@@ -0,0 +1,125 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFilesStep 每次呼叫將 files(call) 寫入 dir（鍵為相對路徑）、尚未完成的 step
+func writeFilesStep(t *testing.T, dir string, files func(call int) map[string]string) func(int, string) (string, error) {
+	return func(call int, prompt string) (string, error) {
+		for name, content := range files(call) {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return pendingOutput(fmt.Sprintf("第 %d 次寫入檔案", call)), nil
+	}
+}
+
+// TestArtifactsCapturedPerLoop 測試每個迴圈只保存新增或變更且符合 glob 的檔案
+func TestArtifactsCapturedPerLoop(t *testing.T) {
+	workDir := t.TempDir()
+	config := DefaultClientConfig()
+	config.WorkDir = workDir
+	config.SaveDir = t.TempDir()
+	config.ArtifactGlobs = []string{"reports/*.md"}
+	client, _ := newStepClient(config, writeFilesStep(t, workDir, func(loop int) map[string]string {
+		files := map[string]string{"notes.txt": "不保存"}
+		if loop <= 2 {
+			files["reports/summary.md"] = strings.Repeat("v", loop*10)
+		}
+		return files
+	}))
+	if err := os.MkdirAll(filepath.Join(workDir, "reports"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "reports", "old.md"), []byte("執行前就存在"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []*LoopResult
+	for i := 0; i < 3; i++ {
+		result, err := client.ExecuteLoop(context.Background(), "產生報告")
+		if err != nil {
+			t.Fatalf("ExecuteLoop 不應失敗: %v", err)
+		}
+		results = append(results, result)
+	}
+
+	for i, want := range [][]string{{"reports/summary.md"}, {"reports/summary.md"}, nil} {
+		if strings.Join(results[i].Artifacts, ",") != strings.Join(want, ",") {
+			t.Errorf("迴圈 %d 應保存 %v，實際 %v", i+1, want, results[i].Artifacts)
+		}
+	}
+
+	root := client.artifactRoot()
+	for loop, size := range map[int]int64{1: 10, 2: 20} {
+		artifacts, err := ListArtifacts(root, loop)
+		if err != nil {
+			t.Fatalf("ListArtifacts 失敗: %v", err)
+		}
+		if len(artifacts) != 1 || artifacts[0].Path != "reports/summary.md" || artifacts[0].Size != size {
+			t.Errorf("迴圈 %d 應保存 %d bytes 的 summary.md: %+v", loop, size, artifacts)
+		}
+	}
+	if artifacts, _ := ListArtifacts(root, 3); len(artifacts) != 0 {
+		t.Errorf("沒有變更的迴圈不應保存檔案: %+v", artifacts)
+	}
+	if history := client.contextManager.GetLoopHistory(); len(history[0].Artifacts) != 1 {
+		t.Errorf("迴圈記錄應包含產出檔案: %+v", history[0].Artifacts)
+	}
+
+	dest := t.TempDir()
+	if _, err := ExtractArtifacts(root, 1, dest); err != nil {
+		t.Fatalf("ExtractArtifacts 失敗: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dest, "reports", "summary.md")) // #nosec G304 -- test temp dir
+	if err != nil || string(data) != strings.Repeat("v", 10) {
+		t.Errorf("取出的檔案應為迴圈 1 的版本: %q %v", data, err)
+	}
+}
+
+// TestArtifactsPrunedAtCap 測試總大小超過上限時刪除最舊的迴圈
+func TestArtifactsPrunedAtCap(t *testing.T) {
+	workDir := t.TempDir()
+	config := DefaultClientConfig()
+	config.WorkDir = workDir
+	config.SaveDir = t.TempDir()
+	config.ArtifactGlobs = []string{"*.json"}
+	config.MaxArtifactBytes = 250
+	client, _ := newStepClient(config, writeFilesStep(t, workDir, func(loop int) map[string]string {
+		return map[string]string{"out.json": strings.Repeat(string(rune('a'+loop)), 100)}
+	}))
+
+	for i := 0; i < 4; i++ {
+		if _, err := client.ExecuteLoop(context.Background(), "產生資料"); err != nil {
+			t.Fatalf("ExecuteLoop 不應失敗: %v", err)
+		}
+	}
+
+	artifacts, err := ListArtifacts(client.artifactRoot(), 0)
+	if err != nil {
+		t.Fatalf("ListArtifacts 失敗: %v", err)
+	}
+	var loops []int
+	var total int64
+	for _, a := range artifacts {
+		loops = append(loops, a.Loop)
+		total += a.Size
+	}
+	if len(loops) != 2 || loops[0] != 3 || loops[1] != 4 {
+		t.Errorf("超過 250 bytes 時應只保留最新的迴圈 3、4，實際 %v", loops)
+	}
+	if total > 250 {
+		t.Errorf("總大小不應超過上限，實際 %d", total)
+	}
+}
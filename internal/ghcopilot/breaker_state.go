@@ -0,0 +1,139 @@
+package ghcopilot
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// breakerStateFile 熔斷器狀態存檔的檔名（不含副檔名，每次覆寫）
+const breakerStateFile = "circuit_breaker"
+
+// CircuitBreakerSnapshot 熔斷器的狀態與計數（導出欄位以支援 Gob）
+//
+// 門檻值不在其中：重新啟動後仍以設定檔的門檻為準。
+type CircuitBreakerSnapshot struct {
+	State           CircuitBreakerState `json:"state"`
+	NoProgressLoops int                 `json:"no_progress_loops"`
+	SameErrorLoops  int                 `json:"same_error_loops"`
+	TotalErrors     int                 `json:"total_errors"`
+	SuccessCount    int                 `json:"success_count"`
+	LastErrors      []string            `json:"last_errors,omitempty"`
+	LastError       string              `json:"last_error,omitempty"`
+	OpenReason      string              `json:"open_reason,omitempty"`
+	Trips           []BreakerTrip       `json:"trips,omitempty"`
+	LastStateChange time.Time           `json:"last_state_change"`
+	SavedAt         time.Time           `json:"saved_at"`
+}
+
+// Snapshot 傳回熔斷器目前的狀態與計數
+func (cb *CircuitBreaker) Snapshot() CircuitBreakerSnapshot {
+	return CircuitBreakerSnapshot{
+		State:           cb.state,
+		NoProgressLoops: cb.noProgressLoops,
+		SameErrorLoops:  cb.sameErrorLoops,
+		TotalErrors:     cb.totalErrors,
+		SuccessCount:    cb.successCount,
+		LastErrors:      append([]string(nil), cb.lastErrors...),
+		LastError:       cb.lastError,
+		OpenReason:      cb.openReason,
+		Trips:           append([]BreakerTrip(nil), cb.trips...),
+		LastStateChange: cb.lastStateChange,
+		SavedAt:         time.Now(),
+	}
+}
+
+// Restore 以快照還原熔斷器的狀態與計數（門檻值不變）
+func (cb *CircuitBreaker) Restore(s CircuitBreakerSnapshot) {
+	if s.State == "" {
+		s.State = StateClosed
+	}
+	cb.state = s.State
+	cb.noProgressLoops = s.NoProgressLoops
+	cb.sameErrorLoops = s.SameErrorLoops
+	cb.totalErrors = s.TotalErrors
+	cb.successCount = s.SuccessCount
+	cb.lastErrors = append([]string{}, s.LastErrors...)
+	cb.lastError = s.LastError
+	cb.openReason = s.OpenReason
+	cb.trips = append([]BreakerTrip(nil), s.Trips...)
+	cb.lastStateChange = s.LastStateChange
+	if cb.lastStateChange.IsZero() {
+		cb.lastStateChange = time.Now()
+	}
+}
+
+// SaveBreakerState 將熔斷器的狀態與計數存到儲存目錄（依 useGob 使用 Gob 或 JSON）
+func (pm *PersistenceManager) SaveBreakerState(cb *CircuitBreaker) error {
+	if cb == nil {
+		return fmt.Errorf("熔斷器不能為 nil")
+	}
+	snapshot := cb.Snapshot()
+	filename := filepath.Join(pm.storageDir, breakerStateFile+pm.getExtension())
+
+	// #nosec G304 -- filename 由 filepath.Join 從 storageDir 構建，範圍受限
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("無法建立檔案: %w", err)
+	}
+	defer file.Close()
+
+	if pm.useGob {
+		return gob.NewEncoder(file).Encode(&snapshot)
+	}
+	data, err := json.MarshalIndent(&snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("JSON 編碼失敗: %w", err)
+	}
+	_, err = file.Write(data)
+	return err
+}
+
+// LoadBreakerState 從儲存目錄還原熔斷器，傳回是否找到存檔
+//
+// 優先讀取目前格式的存檔，找不到時改讀另一種格式（切換 UseGobFormat 後仍能還原）。
+func (pm *PersistenceManager) LoadBreakerState(cb *CircuitBreaker) (bool, error) {
+	if cb == nil {
+		return false, fmt.Errorf("熔斷器不能為 nil")
+	}
+	exts := []string{".json", ".gob"}
+	if pm.useGob {
+		exts = []string{".gob", ".json"}
+	}
+
+	for _, ext := range exts {
+		filename := filepath.Join(pm.storageDir, breakerStateFile+ext)
+		data, err := os.ReadFile(filename) // #nosec G304 -- filename 由 filepath.Join 從 storageDir 構建，範圍受限
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("無法讀取熔斷器狀態: %w", err)
+		}
+
+		var snapshot CircuitBreakerSnapshot
+		if ext == ".gob" {
+			if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snapshot); err != nil {
+				return false, fmt.Errorf("Gob 解碼失敗: %w", err)
+			}
+		} else if err := json.Unmarshal(data, &snapshot); err != nil {
+			return false, fmt.Errorf("JSON 解碼失敗: %w", err)
+		}
+		cb.Restore(snapshot)
+		return true, nil
+	}
+	return false, nil
+}
+
+// saveBreakerState 自動持久化時一併保存預設的熔斷器（標籤熔斷器不保存）
+func (c *RalphLoopClient) saveBreakerState() error {
+	if c.persistence == nil {
+		return nil
+	}
+	return c.persistence.SaveBreakerState(c.defaultBreaker())
+}
@@ -0,0 +1,83 @@
+package ghcopilot
+
+import (
+	"testing"
+)
+
+// TestBreakerStateSurvivesRestart 測試打開的熔斷器在重新建立客戶端並載入歷史後仍為打開
+func TestBreakerStateSurvivesRestart(t *testing.T) {
+	for _, useGob := range []bool{false, true} {
+		saveDir := t.TempDir()
+		newClient := func() *RalphLoopClient {
+			config := DefaultClientConfig()
+			config.SaveDir = saveDir
+			config.UseGobFormat = useGob
+			return NewRalphLoopClientWithConfig(config)
+		}
+
+		first := newClient()
+		first.contextManager.StartLoop(0, "修正測試")
+		first.contextManager.FinishLoop()
+		for i := 0; i < 3; i++ {
+			first.breaker.RecordNoProgress()
+		}
+		if !first.breaker.IsOpen() {
+			t.Fatal("連續 3 次無進展後熔斷器應打開")
+		}
+		if err := first.SaveHistoryToDisk(); err != nil {
+			t.Fatalf("保存歷史失敗 (gob=%v): %v", useGob, err)
+		}
+		first.Close()
+
+		second := newClient()
+		if !second.breaker.IsClosed() {
+			t.Fatal("新的客戶端在載入前應為關閉")
+		}
+		if err := second.LoadHistoryFromDisk(); err != nil {
+			t.Fatalf("載入歷史失敗 (gob=%v): %v", useGob, err)
+		}
+		if !second.breaker.IsOpen() {
+			t.Errorf("載入後熔斷器應維持打開 (gob=%v)", useGob)
+		}
+		stats := second.GetCircuitBreakerStats()
+		if stats.NoProgressLoops != 3 || len(stats.Trips) != 1 || second.breaker.OpenReason() == "" {
+			t.Errorf("應還原計數與打開記錄 (gob=%v): %+v", useGob, stats)
+		}
+		second.Close()
+	}
+}
+
+// TestLoadBreakerStateMissing 測試沒有存檔時不變更熔斷器，並可讀取另一種格式的存檔
+func TestLoadBreakerStateMissing(t *testing.T) {
+	dir := t.TempDir()
+	jsonPM, err := NewPersistenceManager(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cb := NewCircuitBreaker(dir)
+	if found, err := jsonPM.LoadBreakerState(cb); found || err != nil || !cb.IsClosed() {
+		t.Fatalf("沒有存檔時應維持原狀: found=%v err=%v state=%s", found, err, cb.GetState())
+	}
+
+	cb.RecordSameError("build failed")
+	if err := jsonPM.SaveBreakerState(cb); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := jsonPM.ListSavedContexts(); len(files) != 0 {
+		t.Errorf("熔斷器狀態不應列為上下文存檔: %v", files)
+	}
+
+	gobPM, err := NewPersistenceManager(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewCircuitBreaker(dir)
+	found, err := gobPM.LoadBreakerState(restored)
+	if !found || err != nil {
+		t.Fatalf("Gob 設定下應能讀取 JSON 存檔: found=%v err=%v", found, err)
+	}
+	if restored.Stats().SameErrorLoops != 1 || restored.Stats().LastError != "build failed" {
+		t.Errorf("應還原錯誤計數: %+v", restored.Stats())
+	}
+}
@@ -0,0 +1,98 @@
+package ghcopilot
+
+import (
+	"context"
+	"sort"
+)
+
+// breakerTagKey context 中熔斷器標籤的鍵
+type breakerTagKey struct{}
+
+// WithBreakerTag 傳回帶有熔斷器標籤的 context
+//
+// 啟用 PerTagCircuitBreakers 時，以此 context 呼叫 ExecuteLoop 或 ExecuteUntilCompletion
+// 會使用該標籤專屬的熔斷器；沒有標籤時使用預設的熔斷器。
+func WithBreakerTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, breakerTagKey{}, tag)
+}
+
+// BreakerTagFromContext 取得 context 中的熔斷器標籤（沒有時為空字串）
+func BreakerTagFromContext(ctx context.Context) string {
+	tag, _ := ctx.Value(breakerTagKey{}).(string)
+	return tag
+}
+
+// TaskBreakerTag 任務使用的熔斷器標籤：第一個標籤視為任務類別，沒有標籤時為空字串
+func TaskBreakerTag(task *Task) string {
+	if task == nil || len(task.Tags) == 0 {
+		return ""
+	}
+	return task.Tags[0]
+}
+
+// ExecuteTask 以任務的 prompt 執行迴圈直到完成，並使用任務類別（TaskBreakerTag）的熔斷器
+func (c *RalphLoopClient) ExecuteTask(ctx context.Context, task *Task, maxLoops int) ([]*LoopResult, error) {
+	return c.ExecuteUntilCompletion(WithBreakerTag(ctx, TaskBreakerTag(task)), task.Prompt, maxLoops)
+}
+
+// selectBreaker 依 context 的標籤切換目前使用的熔斷器（呼叫端需持有執行鎖）
+//
+// 未啟用 PerTagCircuitBreakers 時一律使用預設的熔斷器。
+func (c *RalphLoopClient) selectBreaker(ctx context.Context) {
+	if c.tagBreakers == nil {
+		c.tagBreakers = map[string]*CircuitBreaker{"": c.breaker}
+	}
+	tag := ""
+	if c.config != nil && c.config.PerTagCircuitBreakers {
+		tag = BreakerTagFromContext(ctx)
+	}
+	breaker, ok := c.tagBreakers[tag]
+	if !ok {
+		breaker = c.newLoopBreaker()
+		c.tagBreakers[tag] = breaker
+	}
+	c.breaker = breaker
+}
+
+// defaultBreaker 沒有標籤時使用的熔斷器
+func (c *RalphLoopClient) defaultBreaker() *CircuitBreaker {
+	if breaker, ok := c.tagBreakers[""]; ok {
+		return breaker
+	}
+	return c.breaker
+}
+
+// TagBreakerStates 傳回各標籤熔斷器的狀態（不含預設的熔斷器，沒有時為 nil）
+func (c *RalphLoopClient) TagBreakerStates() map[string]CircuitBreakerState {
+	var states map[string]CircuitBreakerState
+	for tag, breaker := range c.tagBreakers {
+		if tag == "" {
+			continue
+		}
+		if states == nil {
+			states = make(map[string]CircuitBreakerState)
+		}
+		states[tag] = breaker.GetState()
+	}
+	return states
+}
+
+// OpenBreakerTags 傳回熔斷器已打開的標籤（排序後）
+func (c *RalphLoopClient) OpenBreakerTags() []string {
+	var tags []string
+	for tag, state := range c.TagBreakerStates() {
+		if state == StateOpen {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// resetAllBreakers 重置預設與所有標籤的熔斷器
+func (c *RalphLoopClient) resetAllBreakers() {
+	c.breaker.Reset()
+	for _, breaker := range c.tagBreakers {
+		breaker.Reset()
+	}
+}
@@ -0,0 +1,76 @@
+package ghcopilot
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// stuckStep 含「卡住」的 prompt 永遠沒有進展、其他 prompt 立即完成的 step
+func stuckStep(call int, prompt string) (string, error) {
+	if strings.Contains(prompt, "卡住") {
+		return pendingOutput("仍在處理"), nil
+	}
+	return doneOutput, nil
+}
+
+// TestPerTagBreakerIsolation 測試一個標籤的熔斷器打開時不阻擋其他標籤的執行
+func TestPerTagBreakerIsolation(t *testing.T) {
+	config := DefaultClientConfig()
+	config.PerTagCircuitBreakers = true
+	client, _ := newStepClient(config, stuckStep)
+
+	_, err := client.ExecuteTask(context.Background(), NewTask("1", "卡住的遷移").WithTags("migration"), 10)
+	if err == nil || !strings.Contains(err.Error(), "circuit breaker opened") {
+		t.Fatalf("migration 應因熔斷器中止: %v", err)
+	}
+
+	results, err := client.ExecuteTask(context.Background(), NewTask("2", "修正文件").WithTags("docs"), 5)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("docs 不應受 migration 的熔斷器影響: %d 個迴圈, %v", len(results), err)
+	}
+	if _, err := client.ExecuteLoop(context.Background(), "修正文件"); err != nil {
+		t.Errorf("沒有標籤的執行不應受影響: %v", err)
+	}
+
+	// 同一個標籤仍然被阻擋
+	if _, err := client.ExecuteLoop(WithBreakerTag(context.Background(), "migration"), "修正文件"); err == nil {
+		t.Error("migration 的熔斷器打開後應拒絕執行")
+	}
+
+	status := client.GetStatus()
+	if status.CircuitBreakerOpen || status.CircuitBreakerState != StateClosed {
+		t.Errorf("預設的熔斷器應保持關閉: %s", status.CircuitBreakerState)
+	}
+	if status.TagBreakers["migration"] != StateOpen || status.TagBreakers["docs"] != StateClosed {
+		t.Errorf("各標籤的狀態不正確: %v", status.TagBreakers)
+	}
+	if !slices.Equal(status.OpenBreakerTags, []string{"migration"}) {
+		t.Errorf("打開的標籤應為 [migration]，實際 %v", status.OpenBreakerTags)
+	}
+
+	if err := client.ResetCircuitBreaker(); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.GetStatus().OpenBreakerTags) != 0 {
+		t.Error("重置後所有標籤的熔斷器都應關閉")
+	}
+}
+
+// TestPerTagBreakersDisabled 測試未啟用時所有標籤共用同一個熔斷器
+func TestPerTagBreakersDisabled(t *testing.T) {
+	config := DefaultClientConfig()
+	config.PerTagCircuitBreakers = false
+	client, _ := newStepClient(config, stuckStep)
+
+	if _, err := client.ExecuteTask(context.Background(), NewTask("1", "卡住的遷移").WithTags("migration"), 10); err == nil {
+		t.Fatal("migration 應因熔斷器中止")
+	}
+	if _, err := client.ExecuteTask(context.Background(), NewTask("2", "修正文件").WithTags("docs"), 5); err == nil {
+		t.Error("未啟用 PerTagCircuitBreakers 時應共用已打開的熔斷器")
+	}
+	if client.GetStatus().TagBreakers != nil {
+		t.Error("未啟用時不應有標籤熔斷器")
+	}
+}
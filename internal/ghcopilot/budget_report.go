@@ -0,0 +1,127 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 預算報告中的上限名稱
+const (
+	BudgetLoops        = "loops"
+	BudgetWallClock    = "wall_clock"
+	BudgetChangedFiles = "changed_files"
+)
+
+// BudgetLimits 由呼叫端決定的執行上限（ExecuteUntilCompletion 的 maxLoops 與 context 逾時），0 表示未設定
+type BudgetLimits struct {
+	MaxLoops int
+	Timeout  time.Duration
+}
+
+// BudgetUsage 單一上限的使用情形
+type BudgetUsage struct {
+	Name      string  `json:"name"`
+	Used      float64 `json:"used"`
+	Limit     float64 `json:"limit"`
+	Unit      string  `json:"unit"`      // loops、seconds、files
+	Percent   float64 `json:"percent"`   // Used / Limit * 100
+	Exhausted bool    `json:"exhausted"` // 是否已達到上限（造成執行結束的限制）
+}
+
+// BudgetReport 執行結束時各項上限的使用率，只包含有設定的上限
+type BudgetReport struct {
+	Bounds []BudgetUsage `json:"bounds"`
+}
+
+// BuildBudgetReport 依本次執行的迴圈數、經過時間與設定的上限建立預算報告
+func (c *RalphLoopClient) BuildBudgetReport(limits BudgetLimits, loops int, elapsed time.Duration) *BudgetReport {
+	r := &BudgetReport{Bounds: []BudgetUsage{}}
+	if limits.MaxLoops > 0 {
+		r.add(BudgetLoops, float64(loops), float64(limits.MaxLoops), "loops", loops >= limits.MaxLoops)
+	}
+	if limits.Timeout > 0 {
+		r.add(BudgetWallClock, elapsed.Seconds(), limits.Timeout.Seconds(), "seconds", elapsed >= limits.Timeout)
+	}
+	if c.config.MaxChangedFiles > 0 {
+		changed := len(c.changedFiles)
+		r.add(BudgetChangedFiles, float64(changed), float64(c.config.MaxChangedFiles), "files", changed > c.config.MaxChangedFiles)
+	}
+	return r
+}
+
+// add 加入一項上限的使用情形
+func (r *BudgetReport) add(name string, used, limit float64, unit string, exhausted bool) {
+	r.Bounds = append(r.Bounds, BudgetUsage{
+		Name:      name,
+		Used:      used,
+		Limit:     limit,
+		Unit:      unit,
+		Percent:   used / limit * 100,
+		Exhausted: exhausted,
+	})
+}
+
+// Binding 傳回使用率最高的上限（已達到上限者優先）；沒有任何上限時為 nil
+func (r *BudgetReport) Binding() *BudgetUsage {
+	var binding *BudgetUsage
+	for i := range r.Bounds {
+		b := &r.Bounds[i]
+		if binding == nil || (b.Exhausted && !binding.Exhausted) ||
+			(b.Exhausted == binding.Exhausted && b.Percent > binding.Percent) {
+			binding = b
+		}
+	}
+	return binding
+}
+
+// budgetLabels 上限名稱的顯示文字
+var budgetLabels = map[string]string{
+	BudgetLoops:        "迴圈數",
+	BudgetWallClock:    "執行時間",
+	BudgetChangedFiles: "修改的檔案",
+}
+
+// Text 以人類可讀的表格輸出報告
+func (r *BudgetReport) Text() string {
+	var sb strings.Builder
+	sb.WriteString("預算使用:\n")
+	if len(r.Bounds) == 0 {
+		sb.WriteString("  （沒有設定任何上限）\n")
+		return sb.String()
+	}
+	binding := r.Binding()
+	for i, b := range r.Bounds {
+		label := budgetLabels[b.Name]
+		if label == "" {
+			label = b.Name
+		}
+		fmt.Fprintf(&sb, "  %-8s %s / %s (%.0f%%)", label, formatBudgetValue(b.Used, b.Unit), formatBudgetValue(b.Limit, b.Unit), b.Percent)
+		switch {
+		case b.Exhausted:
+			sb.WriteString(" ⛔ 已達上限")
+		case binding == &r.Bounds[i]:
+			sb.WriteString(" ← 最接近上限")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// JSON 以 JSON 輸出報告
+func (r *BudgetReport) JSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// formatBudgetValue 依單位格式化數值（秒數以時間長度顯示）
+func formatBudgetValue(v float64, unit string) string {
+	if unit == "seconds" {
+		return (time.Duration(v * float64(time.Second))).Round(time.Second).String()
+	}
+	return fmt.Sprintf("%.0f", v)
+}
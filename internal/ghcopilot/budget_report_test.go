@@ -0,0 +1,77 @@
+package ghcopilot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBudgetReportOnlyConfiguredBounds 測試只列出有設定的上限
+func TestBudgetReportOnlyConfiguredBounds(t *testing.T) {
+	client := newScriptedClient(DefaultClientConfig(), "")
+
+	report := client.BuildBudgetReport(BudgetLimits{MaxLoops: 10}, 3, time.Minute)
+	if len(report.Bounds) != 1 || report.Bounds[0].Name != BudgetLoops {
+		t.Fatalf("只應包含迴圈數上限: %+v", report.Bounds)
+	}
+	b := report.Bounds[0]
+	if b.Used != 3 || b.Limit != 10 || b.Percent != 30 || b.Exhausted {
+		t.Errorf("迴圈數使用率錯誤: %+v", b)
+	}
+
+	if empty := client.BuildBudgetReport(BudgetLimits{}, 3, time.Minute); len(empty.Bounds) != 0 || empty.Binding() != nil {
+		t.Errorf("沒有設定上限時報告應為空: %+v", empty.Bounds)
+	}
+}
+
+// TestBudgetReportReflectsRun 測試報告反映實際執行的迴圈數、時間與修改的檔案數
+func TestBudgetReportReflectsRun(t *testing.T) {
+	dir := initGitRepo(t)
+	config := DefaultClientConfig()
+	config.WorkDir = dir
+	config.MaxChangedFiles = 10
+	client, _ := newStepClient(config, addFilesStep(t, dir, 2))
+	results, err := client.ExecuteUntilCompletion(context.Background(), "新增功能", 3)
+	if err == nil {
+		t.Fatal("未完成時應回傳達到迴圈上限的錯誤")
+	}
+
+	report := client.BuildBudgetReport(BudgetLimits{MaxLoops: 3, Timeout: 10 * time.Minute}, len(results), 2*time.Minute)
+	got := map[string]BudgetUsage{}
+	for _, b := range report.Bounds {
+		got[b.Name] = b
+	}
+	if len(got) != 3 {
+		t.Fatalf("應包含三項上限: %+v", report.Bounds)
+	}
+	if b := got[BudgetLoops]; b.Used != 3 || !b.Exhausted {
+		t.Errorf("迴圈數應已用盡: %+v", b)
+	}
+	if b := got[BudgetWallClock]; b.Used != 120 || b.Percent != 20 || b.Exhausted {
+		t.Errorf("執行時間使用率錯誤: %+v", b)
+	}
+	if b := got[BudgetChangedFiles]; b.Used != 6 || b.Limit != 10 || b.Exhausted {
+		t.Errorf("修改的檔案數錯誤: %+v", b)
+	}
+	if binding := report.Binding(); binding == nil || binding.Name != BudgetLoops {
+		t.Errorf("迴圈數應為造成結束的限制: %+v", binding)
+	}
+
+	text := report.Text()
+	for _, want := range []string{"迴圈數", "3 / 3", "已達上限", "2m0s / 10m0s", "6 / 10"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("文字報告應包含 %q:\n%s", want, text)
+		}
+	}
+
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded BudgetReport
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil || len(decoded.Bounds) != 3 {
+		t.Errorf("JSON 報告應可解析: %v\n%s", err, data)
+	}
+}
@@ -0,0 +1,114 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// CapabilityFeature 一項功能及其在預設設定下是否啟用
+type CapabilityFeature struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// Capabilities 描述目前建置的版本、功能與執行環境，用於回報問題與腳本偵測功能
+type Capabilities struct {
+	Version        string              `json:"version"`
+	Revision       string              `json:"revision,omitempty"` // 建置時的 VCS revision（有時）
+	GoVersion      string              `json:"go_version"`
+	OS             string              `json:"os"`
+	Arch           string              `json:"arch"`
+	CGO            bool                `json:"cgo"`
+	DefaultModel   string              `json:"default_model"`
+	Models         []string            `json:"models"`
+	ExecutionModes []string            `json:"execution_modes"`
+	Features       []CapabilityFeature `json:"features"`
+	HistoryFormats []string            `json:"history_formats"` // 歷史持久化格式
+	ConfigFiles    []string            `json:"config_files"`    // 專案設定檔名稱（依優先順序）
+	OutputFormats  []string            `json:"output_formats"`  // capabilities / compare 的輸出格式
+	SecretDetector []string            `json:"secret_detectors"`
+}
+
+// DescribeCapabilities 從預設設定與建置資訊產生功能描述；version 為執行檔的版本
+func DescribeCapabilities(version string) *Capabilities {
+	config := DefaultClientConfig()
+
+	caps := &Capabilities{
+		Version:        version,
+		GoVersion:      runtime.Version(),
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		DefaultModel:   config.Model,
+		HistoryFormats: []string{"json", "gob"},
+		ConfigFiles:    append([]string(nil), projectConfigNames...),
+		OutputFormats:  []string{"text", "json"},
+		SecretDetector: SecretDetectorNames(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				caps.Revision = s.Value
+			case "CGO_ENABLED":
+				caps.CGO = s.Value == "1"
+			}
+		}
+	}
+	for _, m := range KnownModels() {
+		caps.Models = append(caps.Models, string(m))
+	}
+	for _, m := range []ExecutionMode{ModeCLI, ModeSDK, ModeAuto, ModeHybrid} {
+		caps.ExecutionModes = append(caps.ExecutionModes, m.String())
+	}
+
+	historyFormat := "json"
+	if config.UseGobFormat {
+		historyFormat = "gob"
+	}
+	caps.Features = []CapabilityFeature{
+		{Name: "sdk", Enabled: config.EnableSDK, Detail: fmt.Sprintf("prefer_sdk=%v", config.PreferSDK)},
+		{Name: "persistence", Enabled: config.EnablePersistence, Detail: fmt.Sprintf("format=%s, save_dir=%s", historyFormat, config.SaveDir)},
+		{Name: "serialize_persistence", Enabled: config.SerializePersistence},
+		{Name: "concurrency", Enabled: true, Detail: fmt.Sprintf("policy=%s", config.ConcurrencyPolicy)},
+		{Name: "dependency_cache", Enabled: DefaultDependencyCacheTTL > 0, Detail: fmt.Sprintf("ttl=%s", DefaultDependencyCacheTTL)},
+		{Name: "failed_prompt_cache", Enabled: config.FailedPromptTTL > 0, Detail: fmt.Sprintf("ttl=%s", config.FailedPromptTTL)},
+		{Name: "secret_leak_abort", Enabled: config.AbortOnSecretLeak},
+		{Name: "final_loop_instruction", Enabled: config.FinalLoopInstruction != ""},
+		{Name: "copilot_version_check", Enabled: true, Detail: DefaultCopilotVersionRange().String()},
+		{Name: "output_sampling", Enabled: !config.OutputSamplingPolicy.keepsAll(), Detail: string(config.OutputSamplingPolicy.Mode)},
+	}
+	return caps
+}
+
+// Text 以人類可讀的格式呈現
+func (c *Capabilities) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "版本: %s\n", c.Version)
+	if c.Revision != "" {
+		fmt.Fprintf(&sb, "Revision: %s\n", c.Revision)
+	}
+	fmt.Fprintf(&sb, "Go: %s (%s/%s, cgo=%v)\n", c.GoVersion, c.OS, c.Arch, c.CGO)
+	fmt.Fprintf(&sb, "預設模型: %s\n", c.DefaultModel)
+	fmt.Fprintf(&sb, "已知模型: %s\n", strings.Join(c.Models, ", "))
+	fmt.Fprintf(&sb, "執行模式: %s\n", strings.Join(c.ExecutionModes, ", "))
+	fmt.Fprintf(&sb, "歷史格式: %s\n", strings.Join(c.HistoryFormats, ", "))
+	fmt.Fprintf(&sb, "設定檔: %s\n", strings.Join(c.ConfigFiles, ", "))
+	fmt.Fprintf(&sb, "輸出格式: %s\n", strings.Join(c.OutputFormats, ", "))
+	fmt.Fprintf(&sb, "敏感資料偵測器: %s\n", strings.Join(c.SecretDetector, ", "))
+	sb.WriteString("功能（預設設定）:\n")
+	for _, f := range c.Features {
+		mark := "❌"
+		if f.Enabled {
+			mark = "✅"
+		}
+		if f.Detail != "" {
+			fmt.Fprintf(&sb, "  %s %s: %s\n", mark, f.Name, f.Detail)
+		} else {
+			fmt.Fprintf(&sb, "  %s %s\n", mark, f.Name)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,41 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestDescribeCapabilitiesJSON 測試 JSON 輸出包含版本、模型清單與預設設定衍生的功能
+func TestDescribeCapabilitiesJSON(t *testing.T) {
+	data, err := json.Marshal(DescribeCapabilities("9.8.7"))
+	if err != nil {
+		t.Fatalf("編碼失敗: %v", err)
+	}
+
+	var decoded Capabilities
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("解碼失敗: %v", err)
+	}
+	if decoded.Version != "9.8.7" {
+		t.Errorf("version 應為 9.8.7，實際 %q", decoded.Version)
+	}
+	if len(decoded.Models) != len(KnownModels()) || !slices.Contains(decoded.Models, string(ModelClaudeSonnet45)) {
+		t.Errorf("models 應包含所有已知模型: %v", decoded.Models)
+	}
+	if decoded.GoVersion != runtime.Version() || decoded.OS != runtime.GOOS {
+		t.Errorf("應回報目前的 Go 執行環境: %+v", decoded)
+	}
+
+	config := DefaultClientConfig()
+	for _, f := range decoded.Features {
+		if f.Name == "sdk" && f.Enabled != config.EnableSDK {
+			t.Errorf("sdk 功能應反映 DefaultClientConfig().EnableSDK=%v", config.EnableSDK)
+		}
+	}
+	if !strings.Contains(DescribeCapabilities("9.8.7").Text(), "9.8.7") {
+		t.Error("文字輸出應包含版本")
+	}
+}
@@ -0,0 +1,91 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// ErrChangeBudgetExceeded 本次執行修改的檔案數超過 MaxChangedFiles
+var ErrChangeBudgetExceeded = errors.New("change budget exceeded")
+
+// changeBudgetHashes 以 git 取得目前變更的路徑與內容雜湊（刪除的檔案雜湊為空字串）
+func (c *RalphLoopClient) changeBudgetHashes(ctx context.Context) (map[string]string, error) {
+	dir := c.config.WorkDir
+	if dir == "" {
+		dir = "."
+	}
+	paths, err := gitChangedPaths(ctx, dir, c.config.SaveDir)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string, len(paths))
+	for _, p := range paths {
+		hashes[p] = focusFileHash(filepath.Join(dir, p))
+	}
+	return hashes, nil
+}
+
+// snapshotChangeBaseline 記錄第一個迴圈前已變更的檔案；非 git 儲存庫時停用 MaxChangedFiles
+func (c *RalphLoopClient) snapshotChangeBaseline(ctx context.Context) {
+	if c.config.MaxChangedFiles <= 0 || c.changeBaseline != nil || c.changeBudgetSkipped {
+		return
+	}
+	hashes, err := c.changeBudgetHashes(ctx)
+	if err != nil {
+		c.changeBudgetSkipped = true
+		infoLog("⚠️ 工作目錄不是 git 儲存庫，略過 MaxChangedFiles 檢查: %v", err)
+		return
+	}
+	c.changeBaseline = hashes
+	c.changedFiles = make(map[string]bool)
+}
+
+// resetChangeBudget 清除修改檔案的追蹤狀態（每次執行開始時呼叫）
+func (c *RalphLoopClient) resetChangeBudget() {
+	c.changeBaseline = nil
+	c.changedFiles = nil
+	c.changeBudgetSkipped = false
+}
+
+// checkChangeBudget 累計本次執行修改過的不同檔案，超過 MaxChangedFiles 時傳回 true
+//
+// 執行前就已變更的檔案只有內容再次改變才計入；改回原狀的檔案仍算修改過。
+func (c *RalphLoopClient) checkChangeBudget(ctx context.Context, execCtx *ExecutionContext) bool {
+	if c.changeBaseline == nil {
+		return false
+	}
+	current, err := c.changeBudgetHashes(ctx)
+	if err != nil {
+		execCtx.AddWarning("無法取得修改的檔案: %v", err)
+		return false
+	}
+	for p, h := range current {
+		if base, ok := c.changeBaseline[p]; !ok || base != h {
+			c.changedFiles[p] = true
+		}
+	}
+	execCtx.FilesChanged = len(c.changedFiles)
+	return len(c.changedFiles) > c.config.MaxChangedFiles
+}
+
+// changedFileList 傳回本次執行修改過的檔案（排序後）
+func (c *RalphLoopClient) changedFileList() []string {
+	files := make([]string, 0, len(c.changedFiles))
+	for p := range c.changedFiles {
+		files = append(files, p)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// changeBudgetReason 超過 MaxChangedFiles 時的結束原因
+func (c *RalphLoopClient) changeBudgetReason() string {
+	files := c.changedFileList()
+	if len(files) > 10 {
+		files = append(files[:10], "...")
+	}
+	return fmt.Sprintf("已修改 %d 個檔案，超過上限 %d，中止執行: %v", len(c.changedFiles), c.config.MaxChangedFiles, files)
+}
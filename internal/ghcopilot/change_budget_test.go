@@ -0,0 +1,117 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// addFilesStep 每次呼叫在 dir 新增 perLoop 個檔案、尚未完成的 step
+func addFilesStep(t *testing.T, dir string, perLoop int) func(int, string) (string, error) {
+	return func(call int, prompt string) (string, error) {
+		for i := 0; i < perLoop; i++ {
+			name := filepath.Join(dir, fmt.Sprintf("gen_%d_%d.go", call, i))
+			if err := os.WriteFile(name, []byte("package main\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return pendingOutput(fmt.Sprintf("迴圈 %d 新增檔案", call)), nil
+	}
+}
+
+// TestMaxChangedFilesAborts 測試修改的檔案數超過上限時中止，執行前已變更的檔案不計入
+func TestMaxChangedFilesAborts(t *testing.T) {
+	dir := initGitRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main // 執行前的修改\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultClientConfig()
+	config.WorkDir = dir
+	config.MaxChangedFiles = 3
+	client, _ := newStepClient(config, addFilesStep(t, dir, 2))
+	results, err := client.ExecuteUntilCompletion(context.Background(), "新增功能", 10)
+	if !errors.Is(err, ErrChangeBudgetExceeded) {
+		t.Fatalf("應回傳 ErrChangeBudgetExceeded，實際 %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("第 2 個迴圈後應中止（4 > 3），實際執行 %d 個迴圈", len(results))
+	}
+	if results[0].FilesChanged != 2 || results[0].ChangeBudgetExceeded {
+		t.Errorf("迴圈 1 應記錄 2 個修改的檔案且未超過上限: %+v", results[0])
+	}
+	last := results[1]
+	if last.FilesChanged != 4 || !last.ChangeBudgetExceeded || last.ShouldContinue {
+		t.Errorf("迴圈 2 應記錄 4 個修改的檔案並中止: %+v", last)
+	}
+	if status := client.GetStatus(); status.FilesChanged != 4 {
+		t.Errorf("狀態應回報 4 個修改的檔案，實際 %d", status.FilesChanged)
+	}
+}
+
+// TestMaxChangedFilesSkippedOutsideGit 測試工作目錄不是 git 儲存庫時不檢查
+func TestMaxChangedFilesSkippedOutsideGit(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultClientConfig()
+	config.WorkDir = dir
+	config.MaxChangedFiles = 1
+	client, _ := newStepClient(config, addFilesStep(t, dir, 3))
+	results, err := client.ExecuteUntilCompletion(context.Background(), "新增功能", 2)
+	if errors.Is(err, ErrChangeBudgetExceeded) {
+		t.Fatalf("非 git 儲存庫不應檢查修改的檔案數: %v", err)
+	}
+	if len(results) != 2 || results[1].FilesChanged != 0 {
+		t.Errorf("應執行到迴圈上限且不記錄修改的檔案數: %+v", results)
+	}
+}
+
+// TestChangeBudgetHashesInSubdir 測試工作目錄為子目錄時，執行前已修改的追蹤檔案再次改變會被計入
+func TestChangeBudgetHashesInSubdir(t *testing.T) {
+	_, sub := initGitRepoWithSubdir(t)
+	tracked := filepath.Join(sub, "a.go")
+	if err := os.WriteFile(tracked, []byte("package sub // 執行前的修改\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultClientConfig()
+	config.WorkDir = sub
+	config.MaxChangedFiles = 5
+	client := newScriptedClient(config, "")
+	client.snapshotChangeBaseline(context.Background())
+	if client.changeBaseline == nil {
+		t.Fatal("子目錄工作目錄應記錄基準")
+	}
+
+	if err := os.WriteFile(tracked, []byte("package sub // 迴圈中的修改\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	execCtx := NewExecutionContext(1, "測試")
+	client.checkChangeBudget(context.Background(), execCtx)
+	if execCtx.FilesChanged != 1 {
+		t.Errorf("再次修改的 a.go 應計入，實際 %v", client.changedFileList())
+	}
+}
+
+// TestChangeBudgetWithoutCommits 測試尚無提交的儲存庫仍檢查修改的檔案數
+func TestChangeBudgetWithoutCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("需要 git")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init 失敗: %v %s", err, out)
+	}
+
+	config := DefaultClientConfig()
+	config.WorkDir = dir
+	config.MaxChangedFiles = 1
+	client, _ := newStepClient(config, addFilesStep(t, dir, 2))
+	_, err := client.ExecuteUntilCompletion(context.Background(), "新增功能", 3)
+	if !errors.Is(err, ErrChangeBudgetExceeded) {
+		t.Fatalf("尚無提交的儲存庫也應檢查修改的檔案數，實際 %v", err)
+	}
+}
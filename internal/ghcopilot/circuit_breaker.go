@@ -21,36 +21,81 @@ const (
 	StateOpen CircuitBreakerState = "OPEN"
 )
 
+// maxBreakerTrips 保留的熔斷器打開記錄數
+const maxBreakerTrips = 10
+
+// BreakerTrip 熔斷器的一次打開記錄
+type BreakerTrip struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+}
+
+// CircuitBreakerStats 熔斷器的計數與打開記錄，用於了解熔斷器為何接近打開
+type CircuitBreakerStats struct {
+	State               CircuitBreakerState `json:"state"`
+	NoProgressLoops     int                 `json:"no_progress_loops"`
+	NoProgressThreshold int                 `json:"no_progress_threshold"`
+	SameErrorLoops      int                 `json:"same_error_loops"`
+	SameErrorThreshold  int                 `json:"same_error_threshold"`
+	TotalErrors         int                 `json:"total_errors"`
+	LastError           string              `json:"last_error,omitempty"` // 最近一次增加相同錯誤計數的錯誤
+	Trips               []BreakerTrip       `json:"trips,omitempty"`      // 最近的打開記錄（最多 10 筆，由舊到新）
+}
+
 // CircuitBreaker 用於防止失控迴圈
 type CircuitBreaker struct {
-	state            CircuitBreakerState
-	noProgressLoops  int
-	sameErrorLoops   int
-	totalErrors      int
-	lastStateChange  time.Time
-	stateFile        string
-	failureThreshold int      // 無進展或相同錯誤達到此閾值時打開
-	successThreshold int      // 成功達到此次數時關閉
-	successCount     int      // 目前成功計數
-	lastErrors       []string // 最後 3 個錯誤
+	state               CircuitBreakerState
+	noProgressLoops     int
+	sameErrorLoops      int
+	totalErrors         int
+	lastStateChange     time.Time
+	stateFile           string
+	noProgressThreshold int      // 無進展迴圈達到此次數時打開
+	sameErrorThreshold  int      // 相同錯誤達到此次數時打開
+	successThreshold    int      // 成功達到此次數時關閉
+	successCount        int      // 目前成功計數
+	lastErrors          []string // 最後 3 個錯誤
+	openReason          string   // 最近一次打開的原因
+	lastError           string   // 最近一次記錄的錯誤（未正規化）
+	trips               []BreakerTrip
 }
 
 // NewCircuitBreaker 建立新的熔斷器
 func NewCircuitBreaker(workDir string) *CircuitBreaker {
 	return &CircuitBreaker{
-		state:            StateClosed,
-		noProgressLoops:  0,
-		sameErrorLoops:   0,
-		totalErrors:      0,
-		lastStateChange:  time.Now(),
-		stateFile:        filepath.Join(workDir, ".circuit_breaker_state"),
-		failureThreshold: 3, // 3 次無進展或相同錯誤
-		successThreshold: 1, // 1 次成功即可關閉
-		successCount:     0,
-		lastErrors:       []string{},
+		state:               StateClosed,
+		noProgressLoops:     0,
+		sameErrorLoops:      0,
+		totalErrors:         0,
+		lastStateChange:     time.Now(),
+		stateFile:           filepath.Join(workDir, ".circuit_breaker_state"),
+		noProgressThreshold: 3, // 3 次無進展
+		sameErrorThreshold:  5, // 5 次相同錯誤
+		successThreshold:    1, // 1 次成功即可關閉
+		successCount:        0,
+		lastErrors:          []string{},
+	}
+}
+
+// SetNoProgressThreshold 設定無進展迴圈達到幾次時打開，小於 1 時不變更
+func (cb *CircuitBreaker) SetNoProgressThreshold(n int) {
+	if n > 0 {
+		cb.noProgressThreshold = n
+	}
+}
+
+// SetSameErrorThreshold 設定相同錯誤達到幾次時打開，小於 1 時不變更
+func (cb *CircuitBreaker) SetSameErrorThreshold(n int) {
+	if n > 0 {
+		cb.sameErrorThreshold = n
 	}
 }
 
+// Thresholds 取得無進展與相同錯誤的閾值
+func (cb *CircuitBreaker) Thresholds() (noProgress, sameError int) {
+	return cb.noProgressThreshold, cb.sameErrorThreshold
+}
+
 // GetState 取得目前狀態
 func (cb *CircuitBreaker) GetState() CircuitBreakerState {
 	return cb.state
@@ -99,14 +144,20 @@ func (cb *CircuitBreaker) RecordNoProgress() {
 	cb.noProgressLoops++
 	cb.successCount = 0 // 重置成功計數
 
-	if cb.noProgressLoops >= cb.failureThreshold {
-		cb.openCircuit("無進展迴圈已達 3 次")
+	if cb.state == StateHalfOpen {
+		cb.openCircuit("半開狀態試探失敗（無進展）")
+		return
+	}
+
+	if cb.noProgressLoops >= cb.noProgressThreshold {
+		cb.openCircuit(fmt.Sprintf("無進展迴圈已達 %d 次", cb.noProgressThreshold))
 	}
 }
 
 // RecordSameError 記錄相同錯誤
 func (cb *CircuitBreaker) RecordSameError(errorMsg string) {
 	normalized := normalizeErrorMsg(errorMsg)
+	cb.lastError = errorMsg
 
 	// 檢查是否與最後一個錯誤相同
 	if len(cb.lastErrors) > 0 && cb.lastErrors[len(cb.lastErrors)-1] == normalized {
@@ -124,8 +175,13 @@ func (cb *CircuitBreaker) RecordSameError(errorMsg string) {
 	cb.totalErrors++
 	cb.successCount = 0 // 重置成功計數
 
-	if cb.sameErrorLoops >= 5 {
-		cb.openCircuit("相同錯誤已出現 5 次")
+	if cb.state == StateHalfOpen {
+		cb.openCircuit("半開狀態試探失敗（錯誤）")
+		return
+	}
+
+	if cb.sameErrorLoops >= cb.sameErrorThreshold {
+		cb.openCircuit(fmt.Sprintf("相同錯誤已出現 %d 次", cb.sameErrorThreshold))
 	}
 }
 
@@ -133,10 +189,15 @@ func (cb *CircuitBreaker) RecordSameError(errorMsg string) {
 func (cb *CircuitBreaker) openCircuit(reason string) {
 	if cb.state != StateOpen {
 		cb.state = StateOpen
+		cb.openReason = reason
 		cb.lastStateChange = time.Now()
-		fmt.Printf("⚠️ 熔斷器打開: %s\n", reason)
+		cb.trips = append(cb.trips, BreakerTrip{Time: cb.lastStateChange, Reason: reason})
+		if len(cb.trips) > maxBreakerTrips {
+			cb.trips = cb.trips[len(cb.trips)-maxBreakerTrips:]
+		}
+		fmt.Fprintf(logWriter(), "⚠️ 熔斷器打開: %s\n", reason)
 		if err := cb.SaveState(); err != nil {
-			fmt.Printf("⚠️ 儲存熔斷器狀態失敗: %v\n", err)
+			fmt.Fprintf(logWriter(), "⚠️ 儲存熔斷器狀態失敗: %v\n", err)
 		}
 	}
 }
@@ -150,10 +211,69 @@ func (cb *CircuitBreaker) Reset() {
 	cb.lastStateChange = time.Now()
 	cb.totalErrors = 0
 	cb.lastErrors = []string{}
+	cb.lastError = ""
+	cb.openReason = ""
+	if err := cb.SaveState(); err != nil {
+		fmt.Fprintf(logWriter(), "⚠️ 儲存熔斷器狀態失敗: %v\n", err)
+	}
+	fmt.Fprintln(logWriter(), "✅ 熔斷器已重置")
+}
+
+// OpenReason 取得最近一次打開熔斷器的原因（重置後為空）
+func (cb *CircuitBreaker) OpenReason() string {
+	return cb.openReason
+}
+
+// HalfOpen 將開啟的熔斷器轉為半開狀態，允許試探性執行
+//
+// 半開狀態下一次成功即關閉熔斷器，任何失敗則立即重新打開。
+func (cb *CircuitBreaker) HalfOpen() {
+	if cb.state != StateOpen {
+		return
+	}
+	cb.state = StateHalfOpen
+	cb.noProgressLoops = 0
+	cb.sameErrorLoops = 0
+	cb.successCount = 0
+	cb.lastStateChange = time.Now()
 	if err := cb.SaveState(); err != nil {
-		fmt.Printf("⚠️ 儲存熔斷器狀態失敗: %v\n", err)
+		fmt.Fprintf(logWriter(), "⚠️ 儲存熔斷器狀態失敗: %v\n", err)
+	}
+}
+
+// Stats 取得目前的計數、閾值與最近的打開記錄（重置不會清除打開記錄）
+func (cb *CircuitBreaker) Stats() CircuitBreakerStats {
+	return CircuitBreakerStats{
+		State:               cb.state,
+		NoProgressLoops:     cb.noProgressLoops,
+		NoProgressThreshold: cb.noProgressThreshold,
+		SameErrorLoops:      cb.sameErrorLoops,
+		SameErrorThreshold:  cb.sameErrorThreshold,
+		TotalErrors:         cb.totalErrors,
+		LastError:           cb.lastError,
+		Trips:               append([]BreakerTrip(nil), cb.trips...),
 	}
-	fmt.Println("✅ 熔斷器已重置")
+}
+
+// Text 以文字呈現熔斷器統計
+func (s *CircuitBreakerStats) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "狀態: %s\n", s.State)
+	fmt.Fprintf(&sb, "無進展迴圈: %d/%d\n", s.NoProgressLoops, s.NoProgressThreshold)
+	fmt.Fprintf(&sb, "相同錯誤: %d/%d\n", s.SameErrorLoops, s.SameErrorThreshold)
+	fmt.Fprintf(&sb, "累計錯誤: %d\n", s.TotalErrors)
+	if s.LastError != "" {
+		fmt.Fprintf(&sb, "最近的錯誤: %s\n", truncateRunes(s.LastError, 200))
+	}
+	if len(s.Trips) == 0 {
+		sb.WriteString("打開記錄: 無\n")
+		return sb.String()
+	}
+	sb.WriteString("打開記錄:\n")
+	for _, trip := range s.Trips {
+		fmt.Fprintf(&sb, "  %s %s\n", trip.Time.Format(time.RFC3339), trip.Reason)
+	}
+	return sb.String()
 }
 
 // GetStats 取得統計資訊
@@ -176,6 +296,8 @@ func (cb *CircuitBreaker) SaveState() error {
 		"same_error_loops":  cb.sameErrorLoops,
 		"total_errors":      cb.totalErrors,
 		"last_errors":       cb.lastErrors,
+		"last_error":        cb.lastError,
+		"trips":             cb.trips,
 		"timestamp":         time.Now().Unix(),
 	}
 
@@ -220,6 +342,20 @@ func (cb *CircuitBreaker) LoadState() error {
 		cb.totalErrors = int(t)
 	}
 
+	if e, ok := state["last_error"].(string); ok {
+		cb.lastError = e
+	}
+
+	if raw, ok := state["trips"]; ok {
+		// 重新解碼為 BreakerTrip 以還原時間
+		if data, err := json.Marshal(raw); err == nil {
+			var trips []BreakerTrip
+			if json.Unmarshal(data, &trips) == nil {
+				cb.trips = trips
+			}
+		}
+	}
+
 	if errs, ok := state["last_errors"].([]interface{}); ok {
 		cb.lastErrors = []string{}
 		for _, e := range errs {
@@ -1,6 +1,7 @@
 package ghcopilot
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -229,3 +230,117 @@ func TestLastErrors(t *testing.T) {
 		t.Errorf("最後一個錯誤應為 'error 4'，但為 '%s'", cb.lastErrors[len(cb.lastErrors)-1])
 	}
 }
+
+// TestHalfOpen 測試半開狀態的試探
+func TestHalfOpen(t *testing.T) {
+	cb := NewCircuitBreaker(t.TempDir())
+
+	// 非開啟狀態下不應改變
+	cb.HalfOpen()
+	if !cb.IsClosed() {
+		t.Error("CLOSED 狀態呼叫 HalfOpen 不應改變狀態")
+	}
+
+	for i := 0; i < 3; i++ {
+		cb.RecordNoProgress()
+	}
+	cb.HalfOpen()
+	if !cb.IsHalfOpen() {
+		t.Fatalf("應轉為 HALF_OPEN，但為 %s", cb.GetState())
+	}
+
+	// 半開狀態下任何失敗立即重新打開
+	cb.RecordSameError("boom")
+	if !cb.IsOpen() {
+		t.Errorf("半開狀態失敗應重新打開，但為 %s", cb.GetState())
+	}
+
+	// 半開狀態下成功則關閉
+	cb.HalfOpen()
+	cb.RecordSuccess()
+	if !cb.IsClosed() {
+		t.Errorf("半開狀態成功應關閉，但為 %s", cb.GetState())
+	}
+}
+
+// TestBreakerThresholdSetters 測試設定無進展與相同錯誤的閾值
+func TestBreakerThresholdSetters(t *testing.T) {
+	cb := NewCircuitBreaker(t.TempDir())
+	cb.SetNoProgressThreshold(2)
+	cb.SetSameErrorThreshold(0) // 小於 1 時不變更
+	if np, se := cb.Thresholds(); np != 2 || se != 5 {
+		t.Fatalf("閾值應為 2/5，實際 %d/%d", np, se)
+	}
+
+	cb.RecordNoProgress()
+	if cb.IsOpen() {
+		t.Fatal("1 次無進展不應打開")
+	}
+	cb.RecordNoProgress()
+	if !cb.IsOpen() || cb.OpenReason() != "無進展迴圈已達 2 次" {
+		t.Errorf("2 次無進展應打開: %s", cb.OpenReason())
+	}
+
+	cb = NewCircuitBreaker(t.TempDir())
+	cb.SetSameErrorThreshold(2)
+	cb.RecordSameError("boom")
+	cb.RecordSameError("boom")
+	if !cb.IsOpen() || cb.OpenReason() != "相同錯誤已出現 2 次" {
+		t.Errorf("2 次相同錯誤應打開: %s", cb.OpenReason())
+	}
+}
+
+// TestCircuitBreakerStats 測試計數、最近的錯誤與打開記錄
+func TestCircuitBreakerStats(t *testing.T) {
+	dir := t.TempDir()
+	cb := NewCircuitBreaker(dir)
+	cb.SetSameErrorThreshold(2)
+
+	cb.RecordNoProgress()
+	cb.RecordSameError("Build FAILED: main.go:10")
+	stats := cb.Stats()
+	if stats.NoProgressLoops != 1 || stats.NoProgressThreshold != 3 || stats.SameErrorLoops != 1 || stats.SameErrorThreshold != 2 {
+		t.Errorf("計數或閾值不正確: %+v", stats)
+	}
+	if stats.LastError != "Build FAILED: main.go:10" || len(stats.Trips) != 0 {
+		t.Errorf("應記錄原始錯誤且尚無打開記錄: %+v", stats)
+	}
+
+	cb.RecordSameError("Build FAILED: main.go:10")
+	cb.Reset()
+	for i := 0; i < 3; i++ {
+		cb.RecordNoProgress()
+	}
+	stats = cb.Stats()
+	if len(stats.Trips) != 2 || stats.Trips[0].Reason != "相同錯誤已出現 2 次" || stats.Trips[1].Reason != "無進展迴圈已達 3 次" {
+		t.Fatalf("重置後應保留打開記錄並依序新增: %+v", stats.Trips)
+	}
+	if stats.Trips[0].Time.IsZero() || stats.LastError != "" {
+		t.Errorf("打開記錄應有時間，重置應清除最近的錯誤: %+v", stats)
+	}
+	if text := stats.Text(); !strings.Contains(text, "無進展迴圈: 3/3") || !strings.Contains(text, "相同錯誤已出現 2 次") {
+		t.Errorf("文字輸出不完整:\n%s", text)
+	}
+
+	// 打開記錄會寫入狀態檔
+	loaded := NewCircuitBreaker(dir)
+	if err := loaded.LoadState(); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Stats().Trips; len(got) != 2 || !got[1].Time.Equal(stats.Trips[1].Time) {
+		t.Errorf("載入後應還原打開記錄: %+v", got)
+	}
+}
+
+// TestCircuitBreakerTripsCapped 測試只保留最近的打開記錄
+func TestCircuitBreakerTripsCapped(t *testing.T) {
+	cb := NewCircuitBreaker(t.TempDir())
+	cb.SetNoProgressThreshold(1)
+	for i := 0; i < maxBreakerTrips+3; i++ {
+		cb.Reset()
+		cb.RecordNoProgress()
+	}
+	if n := len(cb.Stats().Trips); n != maxBreakerTrips {
+		t.Errorf("應保留 %d 筆打開記錄，實際 %d", maxBreakerTrips, n)
+	}
+}
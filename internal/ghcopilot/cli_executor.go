@@ -8,7 +8,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -32,6 +35,18 @@ const (
 	ModelGemini3Pro     Model = "gemini-3-pro-preview"
 )
 
+// knownModels 已知可用的模型（依上方常數的順序）
+var knownModels = []Model{
+	ModelClaudeSonnet45, ModelClaudeHaiku45, ModelClaudeOpus45, ModelClaudeSonnet4,
+	ModelGPT52Codex, ModelGPT51CodexMax, ModelGPT51Codex, ModelGPT52, ModelGPT51, ModelGPT5,
+	ModelGPT51CodexMini, ModelGPT5Mini, ModelGPT41, ModelGemini3Pro,
+}
+
+// KnownModels 傳回已知可用的模型清單
+func KnownModels() []Model {
+	return slices.Clone(knownModels)
+}
+
 // ExecutionResult 代表 CLI 執行的結果
 type ExecutionResult struct {
 	Command       string        // 執行的指令
@@ -81,6 +96,22 @@ type CLIExecutor struct {
 	requestID        string
 	telemetryEnabled bool
 	options          ExecutorOptions
+	onProgress       func(done, total int) // 串流中解析到 TASKS_DONE 時的回呼
+	envAllowlist     []string              // 傳給子進程的環境變數白名單（空值表示全部傳遞）
+	envDenylist      []string              // 不傳給子進程的環境變數黑名單
+	classifier       *RetryClassifier      // 依錯誤特徵歷史決定是否重試（nil 表示一律重試）
+	retryableErrors  []string              // 只重試包含這些字串的錯誤（空值表示不限制）
+	nonRetryable     []string              // 包含這些字串的錯誤不重試
+	streamInterval   time.Duration         // 終端輸出的批次寫入間隔（0 表示不依時間批次）
+	streamBytes      int                   // 終端輸出累積多少位元組就寫出（0 表示不依大小批次）
+	failurePhrases   []string              // 串流中出現時提前結束（模型放棄任務）
+	argStyle         ArgStyle              // 參數名稱（依 copilot CLI 版本）
+
+	// 模擬模式（不執行 copilot），依序循環回傳 mockResponses
+	mockMode      bool
+	mockResponses []string
+	mockMu        sync.Mutex
+	mockNext      int
 }
 
 // NewCLIExecutor 建立新的 CLI 執行器
@@ -93,6 +124,7 @@ func NewCLIExecutor(workDir string) *CLIExecutor {
 		requestID:        generateRequestID(),
 		telemetryEnabled: true,
 		options:          DefaultOptions(),
+		argStyle:         ArgStyleCurrent(),
 	}
 }
 
@@ -106,6 +138,7 @@ func NewCLIExecutorWithOptions(workDir string, options ExecutorOptions) *CLIExec
 		requestID:        generateRequestID(),
 		telemetryEnabled: true,
 		options:          options,
+		argStyle:         ArgStyleCurrent(),
 	}
 }
 
@@ -114,6 +147,16 @@ func (ce *CLIExecutor) SetOptions(options ExecutorOptions) {
 	ce.options = options
 }
 
+// SetArgStyle 設定 CLI 參數風格（對應不同版本的 copilot CLI）
+func (ce *CLIExecutor) SetArgStyle(style ArgStyle) {
+	ce.argStyle = style
+}
+
+// ArgStyle 傳回目前使用的 CLI 參數風格
+func (ce *CLIExecutor) ArgStyle() ArgStyle {
+	return ce.argStyle
+}
+
 // SetModel 設定使用的 AI 模型
 func (ce *CLIExecutor) SetModel(model Model) {
 	ce.options.Model = model
@@ -139,62 +182,117 @@ func (ce *CLIExecutor) SetMaxRetries(retries int) {
 	ce.maxRetries = retries
 }
 
+// SetEnvFilter 設定傳給 copilot 子進程的環境變數過濾規則
+//
+// allowlist 非空時只傳遞列出的變數（內部的 REQUEST_ID 等一律保留）；
+// denylist 中的變數一律移除。名稱以 * 結尾時視為前綴比對，例如 "GH_*"。
+// 注意：白名單通常需要包含 PATH、HOME 與 GitHub 認證相關變數，copilot 才能正常運作。
+func (ce *CLIExecutor) SetEnvFilter(allowlist, denylist []string) {
+	ce.envAllowlist = allowlist
+	ce.envDenylist = denylist
+}
+
+// SetRetryClassifier 設定重試分類器，重試從未恢復過的錯誤特徵會直接放棄
+func (ce *CLIExecutor) SetRetryClassifier(classifier *RetryClassifier) {
+	ce.classifier = classifier
+}
+
+// SetRetryableErrors 設定可重試與不可重試的錯誤字串（不區分大小寫，比對 stderr 或錯誤訊息）
+//
+// 不可重試清單優先；可重試清單非空時，只重試符合其中一項的錯誤。
+func (ce *CLIExecutor) SetRetryableErrors(retryable, nonRetryable []string) {
+	ce.retryableErrors = retryable
+	ce.nonRetryable = nonRetryable
+}
+
+// SetStreamThrottle 設定終端串流輸出的節流方式，兩者皆為 0 時每個片段直接寫出
+//
+// 節流只影響終端顯示，擷取到的完整輸出不受影響。
+func (ce *CLIExecutor) SetStreamThrottle(interval time.Duration, maxBytes int) {
+	ce.streamInterval = interval
+	ce.streamBytes = maxBytes
+}
+
+// SetFailurePhrases 設定模型放棄任務的語句，串流中一出現就提前結束 CLI
+func (ce *CLIExecutor) SetFailurePhrases(phrases []string) {
+	ce.failurePhrases = phrases
+}
+
+// SetProgressCallback 設定串流進度回呼，CLI 輸出 TASKS_DONE: n/m 時即時呼叫
+func (ce *CLIExecutor) SetProgressCallback(fn func(done, total int)) {
+	ce.onProgress = fn
+}
+
 // buildArgs 根據選項構建 CLI 參數
+//
+// 參數名稱取自 argStyle；該版本 CLI 不支援的參數會被略過。
 func (ce *CLIExecutor) buildArgs(prompt string) []string {
-	args := []string{"-p", prompt}
+	style := ce.argStyle
+	args := appendFlag(nil, style.Prompt, prompt)
 
 	// 模型選擇
 	if ce.options.Model != "" {
-		args = append(args, "--model", string(ce.options.Model))
+		args = appendFlag(args, style.Model, string(ce.options.Model))
 	}
 
 	// 安靜模式
 	if ce.options.Silent {
-		args = append(args, "-s")
+		args = appendFlag(args, style.Silent)
 	}
 
-	// 權限控制：使用 --yolo 一次開放所有權限（等同 --allow-all-tools --allow-all-paths --allow-all-urls）
-	// 這是官方推薦的自動化腳本用法，比個別旗標更可靠
-	if ce.options.AllowAllTools || ce.options.AllowAllPaths || ce.options.AllowAllURLs {
-		args = append(args, "--yolo")
+	// 權限控制：全部開放時使用 --yolo（等同 --allow-all-tools --allow-all-paths --allow-all-urls）
+	// 這是官方推薦的自動化腳本用法，比個別旗標更可靠；只開放部分權限時（例如限定 AllowedTools）
+	// 或 CLI 不支援 --yolo 時才使用個別旗標
+	if ce.options.AllowAllTools && ce.options.AllowAllPaths && ce.options.AllowAllURLs && style.Yolo != "" {
+		args = appendFlag(args, style.Yolo)
+	} else {
+		if ce.options.AllowAllTools {
+			args = appendFlag(args, style.AllowAllTools)
+		}
+		if ce.options.AllowAllPaths {
+			args = appendFlag(args, style.AllowAllPaths)
+		}
+		if ce.options.AllowAllURLs {
+			args = appendFlag(args, style.AllowAllURLs)
+		}
 	}
 
 	// 自主模式
 	if ce.options.NoAskUser {
-		args = append(args, "--no-ask-user")
+		args = appendFlag(args, style.NoAskUser)
 	}
 
 	// 防止 Copilot 自動讀取 AGENTS.md / .claude/ 等指令檔，避免任務跑偏
-	args = append(args, "--no-custom-instructions")
+	args = appendFlag(args, style.NoCustomInstructions)
 
 	// 禁用平行執行
 	if ce.options.DisableParallel {
-		args = append(args, "--disable-parallel-tools-execution")
+		args = appendFlag(args, style.DisableParallel)
 	}
 
 	// 允許的工具
 	for _, tool := range ce.options.AllowedTools {
-		args = append(args, "--allow-tool", tool)
+		args = appendFlag(args, style.AllowTool, tool)
 	}
 
 	// 禁止的工具
 	for _, tool := range ce.options.DeniedTools {
-		args = append(args, "--deny-tool", tool)
+		args = appendFlag(args, style.DenyTool, tool)
 	}
 
 	// 允許的目錄
 	for _, dir := range ce.options.AllowedDirs {
-		args = append(args, "--add-dir", dir)
+		args = appendFlag(args, style.AddDir, dir)
 	}
 
 	// Session 相關
 	if ce.options.SessionID != "" {
-		args = append(args, "--resume", ce.options.SessionID)
+		args = appendFlag(args, style.Resume, ce.options.SessionID)
 	}
 
 	// 分享 session
 	if ce.options.SharePath != "" {
-		args = append(args, "--share", ce.options.SharePath)
+		args = appendFlag(args, style.Share, ce.options.SharePath)
 	}
 
 	return args
@@ -204,13 +302,54 @@ func (ce *CLIExecutor) buildArgs(prompt string) []string {
 func (ce *CLIExecutor) ExecutePrompt(ctx context.Context, prompt string) (*ExecutionResult, error) {
 	args := ce.buildArgs(prompt)
 
-	if os.Getenv("COPILOT_MOCK_MODE") == "true" {
+	if ce.isMockMode() {
 		return ce.mockExecute("prompt", args)
 	}
 
 	return ce.executeWithRetry(ctx, args)
 }
 
+// DryRun 傳回執行 prompt 時會使用的命令列，但不啟動 copilot
+//
+// 命令列以 "cd <工作目錄的絕對路徑> && copilot ..." 表示；過長的 prompt 與除錯日誌一樣只顯示開頭。
+func (ce *CLIExecutor) DryRun(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	dir := ce.workDir
+	if dir == "" {
+		dir = "."
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("無法解析工作目錄 %s: %w", dir, err)
+	}
+	return "cd " + quoteCommandArg(absDir) + " && " + ce.commandLine(ce.buildArgs(prompt)), nil
+}
+
+// maxLoggedPromptRunes 命令列預覽與除錯日誌中顯示的 prompt 字數上限
+const maxLoggedPromptRunes = 100
+
+// commandLine 將參數組成可閱讀的命令列，過長的 prompt 只顯示開頭
+func (ce *CLIExecutor) commandLine(args []string) string {
+	parts := []string{"copilot"}
+	for i, arg := range args {
+		if i > 0 && args[i-1] == ce.argStyle.Prompt {
+			arg = truncateRunes(arg, maxLoggedPromptRunes)
+		}
+		parts = append(parts, quoteCommandArg(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteCommandArg 為包含空白、引號或為空的參數加上引號
+func quoteCommandArg(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\n\r\"'`$\\") {
+		return strconv.Quote(arg)
+	}
+	return arg
+}
+
 // ExecutePromptWithOptions 使用自訂選項執行 prompt
 func (ce *CLIExecutor) ExecutePromptWithOptions(ctx context.Context, prompt string, opts ExecutorOptions) (*ExecutionResult, error) {
 	// 暫存原選項
@@ -225,7 +364,7 @@ func (ce *CLIExecutor) ExecutePromptWithOptions(ctx context.Context, prompt stri
 func (ce *CLIExecutor) SuggestShellCommand(ctx context.Context, description string) (*ExecutionResult, error) {
 	prompt := fmt.Sprintf("建議一個殼層指令來完成以下任務: %s\n\n請只回傳指令本身，不要額外解釋。", description)
 
-	if os.Getenv("COPILOT_MOCK_MODE") == "true" {
+	if ce.isMockMode() {
 		return ce.mockExecute("suggest", ce.buildArgs(prompt))
 	}
 
@@ -249,7 +388,7 @@ func (ce *CLIExecutor) ExplainShellError(ctx context.Context, errorOutput string
 
 	prompt := description.String()
 
-	if os.Getenv("COPILOT_MOCK_MODE") == "true" {
+	if ce.isMockMode() {
 		return ce.mockExecute("explain", ce.buildArgs(prompt))
 	}
 
@@ -267,7 +406,7 @@ func (ce *CLIExecutor) FixCode(ctx context.Context, errorMessage string, filePat
 
 請直接修復程式碼，不要詢問。`, errorMessage, filePath)
 
-	if os.Getenv("COPILOT_MOCK_MODE") == "true" {
+	if ce.isMockMode() {
 		return ce.mockExecute("fix", ce.buildArgs(prompt))
 	}
 
@@ -302,7 +441,7 @@ EXIT_SIGNAL: false
 TASKS_DONE: 0/1
 ---END_STATUS---`)
 
-	if os.Getenv("COPILOT_MOCK_MODE") == "true" {
+	if ce.isMockMode() {
 		return ce.mockExecute("analyze", ce.buildArgs(prompt.String()))
 	}
 
@@ -319,10 +458,10 @@ func truncateString(s string, maxLen int) string {
 
 // ResumeSession 恢復之前的 session
 func (ce *CLIExecutor) ResumeSession(ctx context.Context, sessionID string) (*ExecutionResult, error) {
-	args := []string{"--resume", sessionID}
+	args := appendFlag(nil, ce.argStyle.Resume, sessionID)
 
 	if ce.options.AllowAllTools {
-		args = append(args, "--allow-all-tools")
+		args = appendFlag(args, ce.argStyle.AllowAllTools)
 	}
 
 	return ce.execute(ctx, args)
@@ -330,10 +469,10 @@ func (ce *CLIExecutor) ResumeSession(ctx context.Context, sessionID string) (*Ex
 
 // ContinueLastSession 繼續最近的 session
 func (ce *CLIExecutor) ContinueLastSession(ctx context.Context) (*ExecutionResult, error) {
-	args := []string{"--continue"}
+	args := appendFlag(nil, ce.argStyle.Continue)
 
 	if ce.options.AllowAllTools {
-		args = append(args, "--allow-all-tools")
+		args = appendFlag(args, ce.argStyle.AllowAllTools)
 	}
 
 	return ce.execute(ctx, args)
@@ -343,6 +482,7 @@ func (ce *CLIExecutor) ContinueLastSession(ctx context.Context) (*ExecutionResul
 func (ce *CLIExecutor) executeWithRetry(ctx context.Context, args []string) (*ExecutionResult, error) {
 	var lastErr error
 	var result *ExecutionResult
+	examples := make(map[string]string) // 本次重試途中遇到的錯誤特徵，供重試分類器學習
 
 	debugLog("重試設定: 最大重試 %d 次, 延遲倍數 %v", ce.maxRetries, ce.retryDelay)
 
@@ -365,6 +505,7 @@ func (ce *CLIExecutor) executeWithRetry(ctx context.Context, args []string) (*Ex
 		if err == nil && result.Success {
 			if attempt > 0 {
 				infoLog("✅ 重試成功！")
+				ce.recordRetryOutcome(examples, true)
 			}
 			return result, nil
 		}
@@ -372,9 +513,34 @@ func (ce *CLIExecutor) executeWithRetry(ctx context.Context, args []string) (*Ex
 		lastErr = err
 		result.Error = err
 
+		message := retryFailureMessage(result, err)
+		signature := ErrorSignature(message)
+		examples[signature] = message
+
 		// 如果達到最大重試次數，返回結果
 		if attempt == ce.maxRetries {
 			infoLog("❌ 已達最大重試次數 (%d), 放棄執行", ce.maxRetries)
+			if attempt > 0 {
+				ce.recordRetryOutcome(examples, false)
+			}
+			return result, lastErr
+		}
+
+		// 設定為不可重試的錯誤：直接放棄
+		if !retryAllowedByLists(message, ce.retryableErrors, ce.nonRetryable) {
+			infoLog("⏭️ 錯誤「%s」設定為不可重試", truncateRunes(message, 100))
+			if attempt > 0 {
+				ce.recordRetryOutcome(examples, false)
+			}
+			return result, lastErr
+		}
+
+		// 此錯誤特徵先前的重試從未恢復：直接放棄
+		if ce.classifier != nil && !ce.classifier.ShouldRetry(signature) {
+			infoLog("⏭️ 錯誤「%s」先前重試皆未恢復，不再重試", signature)
+			if attempt > 0 {
+				ce.recordRetryOutcome(examples, false)
+			}
 			return result, lastErr
 		}
 
@@ -384,6 +550,13 @@ func (ce *CLIExecutor) executeWithRetry(ctx context.Context, args []string) (*Ex
 	return result, lastErr
 }
 
+// recordRetryOutcome 將一次重試的結果回報給重試分類器
+func (ce *CLIExecutor) recordRetryOutcome(examples map[string]string, recovered bool) {
+	if ce.classifier != nil {
+		ce.classifier.RecordOutcome(examples, recovered)
+	}
+}
+
 // execute 執行殼層指令並捕獲輸出
 func (ce *CLIExecutor) execute(ctx context.Context, args []string) (*ExecutionResult, error) {
 	start := time.Now()
@@ -414,11 +587,28 @@ func (ce *CLIExecutor) execute(ctx context.Context, args []string) (*ExecutionRe
 		)
 	}
 
-	cmd.Env = append(os.Environ(), envVars...)
+	cmd.Env = append(filterEnv(os.Environ(), ce.envAllowlist, ce.envDenylist), envVars...)
 
 	// 捕獲輸出並同時顯示到終端
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = io.MultiWriter(&stdout, os.Stdout) // 同時寫入 buffer 和終端
+	// 同時寫入 buffer 和終端；終端可依設定節流，buffer 一律保留完整輸出
+	terminal := NewThrottledWriter(logWriter(), ce.streamInterval, ce.streamBytes)
+	stdoutWriters := []io.Writer{&stdout, terminal}
+	var progress *ProgressWriter
+	if ce.onProgress != nil {
+		progress = NewProgressWriter(ce.onProgress)
+		stdoutWriters = append(stdoutWriters, progress)
+	}
+	var gaveUp *FailurePhraseDetector
+	if len(ce.failurePhrases) > 0 {
+		gaveUp = NewFailurePhraseDetector(ce.failurePhrases)
+		gaveUp.SetMatchCallback(func(phrase string) {
+			infoLog("🏳️ 偵測到放棄語句 %q，提前結束 Copilot CLI", phrase)
+			cancel()
+		})
+		stdoutWriters = append(stdoutWriters, gaveUp)
+	}
+	cmd.Stdout = io.MultiWriter(stdoutWriters...)
 	cmd.Stderr = io.MultiWriter(&stderr, newFilteredWriter(os.Stderr))
 	cmd.Stdin = nil // 明確設定沒有輸入，防止卡在等待輸入
 
@@ -431,16 +621,7 @@ func (ce *CLIExecutor) execute(ctx context.Context, args []string) (*ExecutionRe
 	debugLog("模型: %s", ce.options.Model)
 
 	// 顯示命令參數（隱藏過長的 prompt）
-	cmdStr := "copilot"
-	for i, arg := range args {
-		if i > 0 && args[i-1] == "-p" && len(arg) > 100 {
-			cmdStr += fmt.Sprintf(" %s \"%.100s...\"", args[i-1], arg)
-			i++ // 跳過下一個參數
-		} else if arg != "-p" && i > 0 && args[i-1] != "-p" {
-			cmdStr += " " + arg
-		}
-	}
-	debugLog("指令: %s", cmdStr)
+	debugLog("指令: %s", ce.commandLine(args))
 	debugLog("環境變數: %v", envVars)
 	debugLog("----------------------------------------")
 
@@ -468,6 +649,14 @@ func (ce *CLIExecutor) execute(ctx context.Context, args []string) (*ExecutionRe
 
 	err := cmd.Wait()
 	close(processDone) // 通知監控 goroutine 進程已結束，避免 goroutine 洩漏
+	_ = terminal.Flush() // 寫出節流緩衝中剩餘的輸出（終端寫入失敗不影響結果）
+	if progress != nil {
+		progress.Flush()
+	}
+	// 因放棄語句而提前結束：輸出已足以判定，不視為執行失敗（也不重試）
+	if gaveUp != nil && gaveUp.Matched() != "" {
+		err = nil
+	}
 
 	executionTime := time.Since(start)
 
@@ -520,10 +709,46 @@ func (ce *CLIExecutor) execute(ctx context.Context, args []string) (*ExecutionRe
 	return result, nil
 }
 
+// SetMockMode 設定模擬模式：啟用時不執行 copilot，依序循環回傳 responses
+//
+// responses 為空時使用內建的模擬回應。COPILOT_MOCK_MODE=true 時不論此設定一律為模擬模式。
+func (ce *CLIExecutor) SetMockMode(enabled bool, responses []string) {
+	ce.mockMu.Lock()
+	defer ce.mockMu.Unlock()
+	ce.mockMode = enabled
+	ce.mockResponses = append([]string(nil), responses...)
+	ce.mockNext = 0
+}
+
+// isMockMode 是否為模擬模式（SetMockMode 或 COPILOT_MOCK_MODE=true）
+func (ce *CLIExecutor) isMockMode() bool {
+	return ce.mockMode || os.Getenv("COPILOT_MOCK_MODE") == "true"
+}
+
+// nextMockResponse 傳回下一個設定的模擬回應，沒有設定時傳回 false
+func (ce *CLIExecutor) nextMockResponse() (string, bool) {
+	ce.mockMu.Lock()
+	defer ce.mockMu.Unlock()
+	if len(ce.mockResponses) == 0 {
+		return "", false
+	}
+	response := ce.mockResponses[ce.mockNext%len(ce.mockResponses)]
+	ce.mockNext++
+	return response, true
+}
+
 // mockExecute 用於測試的模擬執行
 func (ce *CLIExecutor) mockExecute(command string, args []string) (*ExecutionResult, error) {
-	// 根據參數產生模擬響應
-	mockResponse := ce.generateMockResponse(command, args)
+	// 優先使用設定的模擬回應，否則根據參數產生
+	mockResponse, ok := ce.nextMockResponse()
+	if !ok {
+		mockResponse = ce.generateMockResponse(command, args)
+	}
+	if ce.onProgress != nil {
+		progress := NewProgressWriter(ce.onProgress)
+		_, _ = progress.Write([]byte(mockResponse))
+		progress.Flush()
+	}
 
 	return &ExecutionResult{
 		Command:       fmt.Sprintf("copilot %s", strings.Join(args, " ")),
@@ -616,9 +841,7 @@ func generateRequestID() string {
 func debugLog(format string, args ...interface{}) {
 	if os.Getenv("RALPH_DEBUG") == "1" {
 		timestamp := time.Now().Format("15:04:05.000")
-		fmt.Printf("[DEBUG %s] ", timestamp)
-		fmt.Printf(format, args...)
-		fmt.Println()
+		fmt.Fprintf(logWriter(), "[DEBUG %s] %s\n", timestamp, fmt.Sprintf(format, args...))
 	}
 }
 
@@ -628,9 +851,29 @@ func infoLog(format string, args ...interface{}) {
 		return
 	}
 	timestamp := time.Now().Format("15:04:05.000")
-	fmt.Printf("[INFO %s] ", timestamp)
-	fmt.Printf(format, args...)
-	fmt.Println()
+	fmt.Fprintf(logWriter(), "[INFO %s] %s\n", timestamp, fmt.Sprintf(format, args...))
+}
+
+var (
+	logOutputMu sync.RWMutex
+	logOutput   io.Writer
+)
+
+// SetLogOutput 設定日誌、迴圈進度與 Copilot 串流輸出等人類可讀訊息的目的地，nil 表示標準輸出
+func SetLogOutput(w io.Writer) {
+	logOutputMu.Lock()
+	defer logOutputMu.Unlock()
+	logOutput = w
+}
+
+// logWriter 傳回目前的日誌輸出目的地
+func logWriter() io.Writer {
+	logOutputMu.RLock()
+	defer logOutputMu.RUnlock()
+	if logOutput == nil {
+		return os.Stdout
+	}
+	return logOutput
 }
 
 // GetWorkDir 取得工作目錄
@@ -652,6 +895,43 @@ func (ce *CLIExecutor) ValidateWorkDir() error {
 	return nil
 }
 
+// filterEnv 依白名單與黑名單過濾 KEY=VALUE 形式的環境變數
+func filterEnv(environ, allowlist, denylist []string) []string {
+	if len(allowlist) == 0 && len(denylist) == 0 {
+		return environ
+	}
+
+	filtered := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		name := kv
+		if idx := strings.Index(kv, "="); idx >= 0 {
+			name = kv[:idx]
+		}
+		if len(allowlist) > 0 && !matchEnvName(name, allowlist) {
+			continue
+		}
+		if matchEnvName(name, denylist) {
+			continue
+		}
+		filtered = append(filtered, kv)
+	}
+	return filtered
+}
+
+// matchEnvName 檢查變數名稱是否符合清單（支援結尾 * 的前綴比對）
+func matchEnvName(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
 // filteredWriter 過濾 Copilot CLI 已知噪音行後再寫入底層 writer
 type filteredWriter struct {
 	w    io.Writer
@@ -3,6 +3,8 @@ package ghcopilot
 import (
 	"context"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -259,6 +261,33 @@ func TestBuildArgs(t *testing.T) {
 	}
 }
 
+// TestDryRun 測試預覽命令列：包含工作目錄的絕對路徑，過長的 prompt 只顯示開頭
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	ce := NewCLIExecutor(dir)
+	ce.SetModel(ModelGPT5)
+
+	line, err := ce.DryRun(context.Background(), "修正 "+strings.Repeat("x", 200))
+	if err != nil {
+		t.Fatalf("DryRun 失敗: %v", err)
+	}
+	if !strings.HasPrefix(line, "cd "+dir+" && copilot -p ") {
+		t.Errorf("應以工作目錄與 copilot 開頭: %s", line)
+	}
+	if !strings.Contains(line, `"修正 `+strings.Repeat("x", 97)+`..."`) || strings.Contains(line, strings.Repeat("x", 98)) {
+		t.Errorf("過長的 prompt 應截斷為 100 字: %s", line)
+	}
+	if !strings.Contains(line, "--model gpt-5 -s --yolo --no-custom-instructions") {
+		t.Errorf("應包含模型與權限參數: %s", line)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ce.DryRun(ctx, "test"); err == nil {
+		t.Error("context 已取消時應回傳錯誤")
+	}
+}
+
 // TestExecutePromptMock 測試模擬執行 prompt
 func TestExecutePromptMock(t *testing.T) {
 	os.Setenv("COPILOT_MOCK_MODE", "true")
@@ -332,3 +361,104 @@ func containsFlag(args []string, flag string) bool {
 	}
 	return false
 }
+
+// TestFilterEnv 測試環境變數白名單與黑名單
+func TestFilterEnv(t *testing.T) {
+	environ := []string{"PATH=/bin", "HOME=/root", "GH_TOKEN=x", "GH_HOST=github.com", "AWS_SECRET=y"}
+
+	got := filterEnv(environ, nil, nil)
+	if len(got) != len(environ) {
+		t.Errorf("未設定規則應傳遞全部變數，但為 %v", got)
+	}
+
+	got = filterEnv(environ, []string{"PATH", "GH_*"}, []string{"GH_HOST"})
+	want := []string{"PATH=/bin", "GH_TOKEN=x"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("應為 %v，但為 %v", want, got)
+	}
+
+	got = filterEnv(environ, nil, []string{"AWS_*"})
+	if len(got) != 4 || strings.Contains(strings.Join(got, ","), "AWS_SECRET") {
+		t.Errorf("黑名單變數應被移除，但為 %v", got)
+	}
+}
+
+// TestCLIExecutorEnvAllowlist 測試只有白名單變數會傳給子進程
+func TestCLIExecutorEnvAllowlist(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("使用 shell 腳本模擬 copilot，Windows 上略過")
+	}
+
+	// 以印出環境變數的腳本取代 copilot
+	binDir := t.TempDir()
+	script := "#!/bin/sh\n/usr/bin/env\n"
+	if err := os.WriteFile(filepath.Join(binDir, "copilot"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("COPILOT_MOCK_MODE", "")
+	t.Setenv("RALPH_TEST_ALLOWED", "visible")
+	t.Setenv("RALPH_TEST_SECRET", "hidden")
+
+	ce := NewCLIExecutor(t.TempDir())
+	ce.SetMaxRetries(0)
+	ce.SetEnvFilter([]string{"RALPH_TEST_ALLOWED"}, nil)
+
+	result, err := ce.ExecutePrompt(context.Background(), "env")
+	if err != nil {
+		t.Fatalf("執行失敗: %v", err)
+	}
+	if !strings.Contains(result.Stdout, "RALPH_TEST_ALLOWED=visible") {
+		t.Error("白名單變數應傳給子進程")
+	}
+	if strings.Contains(result.Stdout, "RALPH_TEST_SECRET") {
+		t.Error("非白名單變數不應傳給子進程")
+	}
+	if !strings.Contains(result.Stdout, "REQUEST_ID=") {
+		t.Error("內部的 REQUEST_ID 應保留")
+	}
+}
+
+// TestNonRetryableErrorsFailFast 測試符合不可重試清單的錯誤不再重試，其他錯誤照常重試
+func TestNonRetryableErrorsFailFast(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake copilot is a shell script")
+	}
+	t.Setenv("COPILOT_MOCK_MODE", "")
+
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho x >> " + counter + "\necho \"Error: Quota exceeded for premium requests\" >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "copilot"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	calls := func() int {
+		data, _ := os.ReadFile(counter) // #nosec G304 -- test temp dir
+		return strings.Count(string(data), "x")
+	}
+
+	executor := NewCLIExecutor(dir)
+	executor.SetMaxRetries(2)
+	executor.retryDelay = time.Millisecond
+	executor.SetRetryableErrors(nil, []string{"auth failed", "quota"})
+	if res, _ := executor.ExecutePrompt(context.Background(), "fix"); res == nil || res.Success {
+		t.Fatal("fake copilot should fail")
+	}
+	if got := calls(); got != 1 {
+		t.Fatalf("不可重試的錯誤應只執行 1 次，實際 %d 次", got)
+	}
+
+	// 可重試清單不包含此錯誤時同樣不重試；清空清單後恢復預設的重試
+	executor.SetRetryableErrors([]string{"overloaded"}, nil)
+	_, _ = executor.ExecutePrompt(context.Background(), "fix")
+	if got := calls(); got != 2 {
+		t.Fatalf("不在可重試清單的錯誤應只執行 1 次，累計 %d 次", got)
+	}
+	executor.SetRetryableErrors(nil, nil)
+	_, _ = executor.ExecutePrompt(context.Background(), "fix")
+	if got := calls(); got != 5 {
+		t.Errorf("未設定清單時應完整重試（1 + 2 次），累計 %d 次", got)
+	}
+}
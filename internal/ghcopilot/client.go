@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -26,24 +28,114 @@ import (
 //		log.Fatal(err)
 //	}
 //	fmt.Println(result)
+//
+// 並行使用：ExecuteLoop、ExecuteUntilCompletion 與 ResetCircuitBreaker 可從多個 goroutine
+// 呼叫，同一時間只有一個會執行；其他呼叫依 ConcurrencyPolicy 排隊等待或回傳
+// ErrConcurrentExecution。ExecuteUntilCompletion 在整次執行期間持有鎖，其他呼叫
+// 不會插入到迴圈之間。GetStatus 等查詢方法與 Close 不加鎖，應在沒有執行中的呼叫時使用。
 type RalphLoopClient struct {
+	// 執行鎖：序列化會修改迴圈狀態（contextManager、breaker 等）的呼叫
+	execMu sync.Mutex
+
+	// 迴圈狀態鎖：executeLoop 修改執行上下文與持久化狀態時持有，等待 Copilot 回應時釋放，
+	// 讓 Flush 從其他 goroutine 寫入時不會讀到寫到一半的狀態
+	stateMu sync.Mutex
+
 	// 核心模組
-	executor       *CLIExecutor
-	parser         *OutputParser
-	analyzer       *ResponseAnalyzer
-	breaker        *CircuitBreaker
-	contextManager *ContextManager
-	persistence    *PersistenceManager
+	executor    *CLIExecutor
+	parser      *OutputParser
+	analyzer    *ResponseAnalyzer
+	breaker     *CircuitBreaker
+	tagBreakers map[string]*CircuitBreaker // PerTagCircuitBreakers 時各標籤的熔斷器（"" 為預設）
+
+	// SetCircuitBreakerThresholds 設定、尚未套用的閾值（無進展、相同錯誤）
+	thresholdMu       sync.Mutex
+	pendingThresholds *[2]int
+	contextManager    *ContextManager
+	persistence       *PersistenceManager
+
+	// Pause/Resume 的狀態（每個迴圈開始前檢查）
+	pauseMu   sync.Mutex
+	pauseCond *sync.Cond
+	paused    bool
 
 	// SDK 執行器（新增）
 	sdkExecutor *SDKExecutor
 
+	// 判定完成後、真正結束前的確認步驟（外部審核）
+	exitDetector *ExitDetector
+
+	// CLIArgStyle 為 auto 且尚未偵測版本；第一次以 CLI 執行時才執行 `copilot --version`
+	argStylePending bool
+
+	// 工作目錄無變更偵測
+	lastWorkdirFingerprint string
+	noChangeLoops          int
+
+	// 產出檔案的上次狀態（ArtifactGlobs 時，第一個迴圈前記錄基準）
+	artifactStamps map[string]artifactStamp
+
+	// FocusFiles 的上次內容雜湊與連續未修改的迴圈數
+	focusFileHashes map[string]string
+	focusMissLoops  int
+
+	// EnableProgressSummary 時跨迴圈累積的進度摘要
+	progress *progressSummary
+
+	// MaxIdenticalToolFailures：相同命令與失敗輸出的累計次數
+	toolFailures map[string]int
+
+	// PromptSafetyRules：本次執行的 prompt 命中的規則（附加在每個迴圈的結果）
+	promptSafetyHits []PromptSafetyHit
+
+	// MaxChangedFiles：執行前已變更的檔案雜湊、本次執行修改過的檔案、是否因非 git 儲存庫而略過
+	changeBaseline      map[string]string
+	changedFiles        map[string]bool
+	changeBudgetSkipped bool
+
+	// ObservationMode：執行前的檔案雜湊、是否因非 git 儲存庫而無法確認
+	observeBaseline map[string]string
+	observeSkipped  bool
+
+	// 目前的迴圈是否為 ExecuteUntilCompletion 的最後一個（附加 FinalLoopInstruction）
+	finalLoop bool
+
+	// 熔斷器自動重置次數
+	breakerAutoResets int
+
+	// 本次執行中的恢復（SDK 失敗降級 CLI）次數
+	recoveryAttempts int
+
+	// 本次執行中改用 EscalateModel 的迴圈編號（從 1 起算，0 表示未升級）
+	modelEscalatedAt int
+
+	// cliRunner 執行單次 CLI prompt（預設為 executor.ExecutePrompt，測試時可替換）
+	cliRunner func(ctx context.Context, prompt string) (*ExecutionResult, error)
+
 	// 配置
 	config *ClientConfig
 
+	// 本次執行的 ID（PerRunSaveDir 時為子目錄名稱）
+	runID string
+
+	// 迴圈自動持久化（預設為 persistence）與磁碟空間不足的處理狀態
+	backend persistenceBackend
+	writer  *persistenceWriter // SerializePersistence 時的背景寫入器
+	// 持久化寫入的重試執行器（PersistenceRetryPolicy，停用時為 nil）
+	persistRetry *RetryExecutor
+
+	// 重試分類器（AdaptiveRetryClassification 時）
+	retryClassifier     *RetryClassifier
+	persistenceDisabled bool
+	diskFullNotified    bool
+	persistenceAbortErr error
+
 	// 狀態
 	initialized bool
 	closed      bool
+
+	// 建立客戶端時降級處理的問題（例如持久化初始化失敗），由 GetStatus 回報
+	initWarnings []string
 }
 
 // ClientConfig 包含 Client 的配置選項
@@ -53,25 +145,249 @@ type ClientConfig struct {
 	CLIMaxRetries int           // 最大重試次數 (預設: 3)
 	WorkDir       string        // 工作目錄 (預設: 當前目錄)
 
+	// ConcurrencyPolicy 多個 goroutine 同時呼叫執行方法時排隊等待（serialize）
+	// 或立即回傳 ErrConcurrentExecution（reject），空值視為 serialize (預設: "serialize")
+	ConcurrencyPolicy ConcurrencyPolicy
+
+	// FailedPromptTTL 以錯誤結束的 prompt（正規化後加上模型）記錄在 SaveDir/failed_prompts.json，
+	// 有效期間內再次以相同 prompt 執行時 ExecuteUntilCompletion 回傳 ErrPromptKnownBad，0 表示停用 (預設: 0)
+	FailedPromptTTL time.Duration
+	ForceRun        bool // 忽略失敗 prompt 快取，照常執行（只記錄警告） (預設: false)
+
+	// AdaptiveRetryClassification 依錯誤特徵的歷史結果調整重試：重試從未恢復過的錯誤
+	// 不再重試；統計存於 SaveDir/retry_stats.json，跨執行累積 (預設: false)
+	AdaptiveRetryClassification bool
+
+	// CLI 失敗時依錯誤訊息（stderr，不區分大小寫的子字串）決定是否重試；不可重試清單優先，
+	// 可重試清單非空時只重試符合的錯誤 (預設: 皆為空，一律重試)
+	RetryableErrors    []string
+	NonRetryableErrors []string
+
+	// MockMode 不執行 copilot（也不使用 SDK），依序循環回傳 MockResponses，方便函式庫使用者
+	// 測試 ExecuteUntilCompletion 的流程；MockResponses 為空時使用內建的模擬回應 (預設: false)
+	MockMode      bool
+	MockResponses []string
+
+	// 串流輸出節流：合併 copilot 的終端輸出後再寫出，避免快速串流塞爆終端
+	StreamFlushInterval time.Duration // 批次寫出的間隔，0 表示不依時間批次 (預設: 0)
+	StreamFlushBytes    int           // 累積多少位元組就寫出，0 表示不依大小批次 (預設: 0，兩者皆 0 時不節流)
+
 	// 上下文配置
-	MaxHistorySize int    // 最大歷史記錄 (預設: 100)
-	SaveDir        string // 儲存目錄 (預設: ".ralph-loop/saves")
-	UseGobFormat   bool   // 是否使用 Gob 格式 (預設: false，使用 JSON)
+	MaxHistorySize     int    // 最大歷史記錄 (預設: 100)
+	MaxTokenBudget     int    // 歷史記錄的 token 上限（字元數 / 4 估算），超過時移出最舊的迴圈，0 表示不限制 (預設: 0)
+	SaveDir            string // 儲存目錄 (預設: ".ralph-loop/saves")
+	UseGobFormat       bool   // 是否使用 Gob 格式 (預設: false，使用 JSON)
+	PerRunSaveDir      bool   // 每次執行使用 SaveDir 下獨立的子目錄，避免互相覆蓋歷史 (預設: false)
+	RunID              string // 執行子目錄名稱，空值時以時間戳產生（僅 PerRunSaveDir 時使用）
+	ContextWindowLoops int    // 續行 prompt 附上最近 N 個迴圈的摘要，更早的壓縮成一行，0 表示不附加 (預設: 0)
+	SpillHistoryToDisk bool   // 超過 MaxHistorySize 的迴圈寫入 SaveDir 而不是捨棄，GetLoopByIndex 需要時再讀回，需啟用持久化 (預設: false)
+
+	// SerializePersistence 以單一背景寫入器依序執行迴圈中的持久化，
+	// 並合併佇列中重複的上下文快照，避免同時寫入互相干擾 (預設: true)
+	SerializePersistence bool
+
+	// FlushOnTerminate HandleTermination 收到終止信號（例如容器的 SIGTERM）時，先寫入迴圈歷史
+	// （含執行中的迴圈）與重試統計再停止執行，避免在寬限期後被強制結束而遺失資料 (預設: true)
+	FlushOnTerminate      bool
+	TerminateFlushTimeout time.Duration // 終止前寫入狀態的時限 (預設: 5s)
 
 	// 熔斷器配置
-	CircuitBreakerThreshold int // 無進展迴圈數 (預設: 3)
-	SameErrorThreshold      int // 相同錯誤數 (預設: 5)
+	CircuitBreakerThreshold int           // 無進展迴圈數 (預設: 3)
+	SameErrorThreshold      int           // 相同錯誤數 (預設: 5)
+	NoChangeLoopThreshold   int           // 工作目錄連續 N 個迴圈無變更即結束，0 表示停用 (預設: 0)
+	AutoResetBreakerAfter   time.Duration // 熔斷器打開後等待此時間自動轉為半開再試，0 表示直接中止 (預設: 0)
+	MaxBreakerAutoResets    int           // 單次執行中自動重置的上限 (預設: 3)
+
+	// PerTagCircuitBreakers 依標籤（WithBreakerTag、ExecuteTask 的任務類別）使用各自的熔斷器，
+	// 某一類任務反覆失敗時不會阻擋其他類別的執行 (預設: false)
+	PerTagCircuitBreakers bool
+
+	// 單次執行中恢復（SDK 失敗降級 CLI）的上限，超過後失敗直接交給熔斷器，0 表示不限制 (預設: 0)
+	MaxRecoveryAttemptsPerRun int
 
 	// AI 模型配置
-	Model  string // AI 模型名稱 (預設: "claude-sonnet-4.5")
-	Silent bool   // 是否靜默模式 (預設: false)
+	Model        string   // AI 模型名稱 (預設: "claude-sonnet-4.5")
+	Silent       bool     // 是否靜默模式 (預設: false)
+	AllowedTools []string // 只允許這些工具，設定後不再允許所有工具 (預設: nil，允許所有工具)
+	DeniedTools  []string // 禁止的工具 (預設: nil)
+
+	// CLIArgStyle copilot CLI 的參數風格：ArgStyleCurrentName、ArgStyleLegacyName（前一代 CLI），
+	// 或 ArgStyleAuto 依 `copilot --version` 選擇（第一次以 CLI 執行時才偵測）；空值使用 current (預設: "")
+	CLIArgStyle string
+
+	// MaskSensitiveOutput 在記錄的命令（RenderedCommand）與模型輸出中遮蔽密碼與 token (預設: true)
+	MaskSensitiveOutput bool
+	// AbortOnSecretLeak 模型輸出疑似包含真實的密碼或金鑰（私鑰、高熵 token 等）時立即中止並回傳
+	// ErrSecretLeak，而不只是遮蔽；日誌只記錄命中的偵測器名稱 (預設: false)
+	AbortOnSecretLeak bool
+	// SecretDetectors 啟用的偵測器（見 SecretDetectorNames），空值表示全部 (預設: nil)
+	SecretDetectors []string
+
+	// 模型升級：先用便宜的模型，已執行 EscalateAfterLoops 個迴圈仍未完成時
+	// 改用 EscalateModel 直到本次執行結束（僅影響 CLI 模式），0 或未設定模型表示停用 (預設: 0)
+	EscalateAfterLoops int
+	EscalateModel      Model
+
+	// ModelPromptPrefixes 依目前使用的模型加在 prompt 最前面的說明（例如 Codex 模型用簡短的指示、
+	// Claude 模型用較詳細的說明），模型升級後改用 EscalateModel 的前綴 (預設: nil)
+	ModelPromptPrefixes map[Model]string
 
 	// 其他
 	EnablePersistence bool // 是否啟用持久化 (預設: true)
 	EnableSDK         bool // 是否啟用 SDK 執行器 (預設: true)
 	PreferSDK         bool // 是否優先使用 SDK (預設: true)
+
+	// 安全配置
+	EnvAllowlist []string // 只把這些環境變數傳給 copilot（支援 "GH_*" 前綴），空值表示全部傳遞 (預設: nil)
+	EnvDenylist  []string // 不傳給 copilot 的環境變數（支援前綴），在白名單之後套用 (預設: nil)
+
+	// 外部審核配置（人工簽核流程）
+	ExternalApprovalURL          string        // 判定完成時送審的 URL，空字串表示停用 (預設: "")
+	ExternalApprovalTimeout      time.Duration // 等待審核的最長時間，逾時視為拒絕 (預設: 10m)
+	ExternalApprovalPollInterval time.Duration // 審核結果輪詢間隔 (預設: 5s)
+
+	// ExitCodeMap 自訂 Copilot CLI 退出碼的解讀方式
+	// 未列出的退出碼：0 視為 Success，其他視為 Failure (預設: nil)
+	ExitCodeMap map[int]ExitOutcome
+
+	// CompletionOverride 在內建判定（分析器、外部審核、無變更偵測）之後呼叫，
+	// 讓使用者依外部訊號（例如自己的測試結果）改寫迴圈決策。
+	// override 為 true 時以 completed 取代內建判定，reason 會成為新的結束原因；
+	// 因此它的優先順序高於所有內建訊號。使用者中斷（context 取消）不會呼叫此 hook。
+	CompletionOverride func(result *LoopResult) (override bool, completed bool, reason string)
+
+	// OnProgress 在單次 CLI 呼叫的串流中解析到 TASKS_DONE: n/m 時即時呼叫，
+	// 同一次呼叫內有多次更新時每次變化都會觸發（以最新者為準）
+	OnProgress func(done, total int)
+
+	// OnLoopComplete 在 ExecuteUntilCompletion 中每個迴圈結束後立即呼叫（例如以 JSONLWriter 串流輸出）
+	OnLoopComplete func(result *LoopResult)
+
+	// OnBreakerOpen 在 ExecuteUntilCompletion 中熔斷器打開時呼叫（每次打開一次），
+	// 讓使用者依原因執行自訂動作（例如通知、收集診斷資料）。
+	// 傳回的錯誤會附加在中止執行的錯誤訊息中；自動重置後繼續執行時只記錄警告。
+	OnBreakerOpen func(state CircuitBreakerState, reason string) error
+
+	// ExplainDecisions 每個迴圈結束後印出完成判定的詳細依據（Silent 時不印）(預設: false)
+	ExplainDecisions bool
+
+	// RequestConfidence 要求模型在狀態區塊回報 CONFIDENCE: n% 並記錄在迴圈結果 (預設: false)
+	RequestConfidence bool
+	// MinExitConfidence 自評信心低於此值時不結束、繼續驗證，0 表示不檢查（需啟用 RequestConfidence）(預設: 0)
+	MinExitConfidence int
+
+	// OnModelQuestion 模型在自主模式下仍在輸出結尾提出問題時的處理方式：
+	// QuestionAutoProceed 下一個迴圈要求依最佳判斷繼續、QuestionAnswer 以 QuestionAnswers 回答
+	// （找不到答案時同 QuestionAutoProceed）、QuestionAbort 中止執行；空值表示不偵測 (預設: "")
+	OnModelQuestion QuestionPolicy
+	// QuestionAnswers 問題關鍵字（不分大小寫）對應的回答，供 QuestionAnswer 使用 (預設: nil)
+	QuestionAnswers map[string]string
+
+	// FailurePhrases 模型表示放棄任務的語句（不分大小寫，例如 "I cannot complete this task"），
+	// 輸出中出現時立即結束執行並回傳 ErrModelGaveUp，不再消耗剩餘迴圈；
+	// CLI 模式下串流中一出現就提前結束進程 (預設: nil)
+	FailurePhrases []string
+
+	// PromptSafetyRules 送出前檢查使用者 prompt 的規則（例如 "force push"），依規則警告、確認、阻擋或改寫；
+	// 阻擋時回傳 ErrPromptBlocked，命中的規則記錄在迴圈結果。可從 DefaultPromptSafetyRules 開始 (預設: nil)
+	PromptSafetyRules []PromptSafetyRule
+	// OnPromptSafetyConfirm PromptSafetyConfirm 規則的確認回呼，傳回 true 才執行；未設定時視同阻擋 (預設: nil)
+	OnPromptSafetyConfirm func(hit PromptSafetyHit, prompt string) bool
+
+	// MaxCodeBlocksPerLoop 單一迴圈輸出的程式碼區塊超過此數時加上警告，
+	// 並在下一個迴圈要求模型一次只做一個變更，0 表示不檢查 (預設: 0)
+	MaxCodeBlocksPerLoop int
+
+	// PostLoopFormatters 每個迴圈後對變更檔案（git diff）執行的格式化命令樣板，
+	// 例如 "gofmt -w {files}"；不經過 shell，{files} 展開為檔案清單，沒有佔位符時附加在最後 (預設: nil)
+	PostLoopFormatters    []string
+	PostLoopFormatGlobs   []string      // 只格式化檔名符合這些 glob 的檔案，例如 "*.go"，空值表示全部 (預設: nil)
+	PostLoopFormatTimeout time.Duration // 單一格式化命令的逾時 (預設: 30s)
+
+	// 產出檔案保存：每個迴圈後將新增或變更、且符合 ArtifactGlobs 的檔案複製到
+	// SaveDir/artifacts/loop-NNN/，保留各迴圈的中間產出（以 artifacts 命令查看）
+	ArtifactGlobs    []string // 比對相對路徑或檔名的 glob，例如 "reports/*.md"，空值表示停用 (預設: nil)
+	MaxArtifactBytes int64    // 產出檔案總大小上限，超過時刪除最舊的迴圈 (預設: 100MB)
+
+	// FocusFiles 任務必須修改的檔案（相對於工作目錄）：連續 FocusRemindAfter 個迴圈沒有修改時
+	// 在 prompt 提醒模型，達 FocusBreakAfter 時打開熔斷器 (預設: nil，停用)
+	FocusFiles       []string
+	FocusRemindAfter int // 提醒前允許的連續未修改迴圈數 (預設: 2)
+	FocusBreakAfter  int // 打開熔斷器前允許的連續未修改迴圈數 (預設: 4)
+
+	// MaxChangedFiles 本次執行最多可修改的不同檔案數（以 git diff 與未追蹤檔案計算），
+	// 超過時中止並回傳 ErrChangeBudgetExceeded；工作目錄不是 git 儲存庫時略過，0 表示不限制 (預設: 0)
+	MaxChangedFiles int
+
+	// ObservationMode 觀察模式：禁止寫入檔案與 shell 工具、不使用 --yolo 與 SDK（SDK 會自動核准所有工具），
+	// 不執行 PostLoopFormatters，並在每個迴圈後以 git 確認沒有檔案被修改，否則回傳 ErrObservationViolation (預設: false)
+	ObservationMode bool
+
+	// UsagePatterns 從輸出解析實際模型與 token 用量的正規表示式，空欄位使用 DefaultUsagePatterns (預設: 空值)
+	UsagePatterns UsagePatterns
+
+	// FinalLoopInstruction ExecuteUntilCompletion 的最後一個迴圈附加的收尾提示，
+	// 讓達到迴圈上限的執行以總結結束而不是停在中途，空值表示停用 (預設: DefaultFinalLoopInstruction)
+	FinalLoopInstruction string
+
+	// ProgressFile ExecuteUntilCompletion 每個迴圈後以原子方式（暫存檔 + 改名）寫入的 JSON 進度檔，
+	// 內容為 ProgressStatus，供 CI 等外部工具輪詢，空值表示停用 (預設: "")
+	ProgressFile string
+
+	// MaxIdenticalToolFailures 輸出中同一個命令（"$ <命令>"）以相同方式失敗達此次數時中止，
+	// 並回傳 ErrRepeatedToolFailure，例如反覆執行同樣失敗的 go test；0 表示停用 (預設: 0)
+	MaxIdenticalToolFailures int
+
+	// EnableProgressSummary 以本地樣板從每個迴圈的輸出累積簡短的進度摘要，加在每個迴圈的 prompt 前面，
+	// 讓模型不需要完整歷史也能掌握目前進度 (預設: false)
+	EnableProgressSummary   bool
+	ProgressSummaryMaxRunes int // 進度摘要的字數上限 (預設: DefaultProgressSummaryMaxRunes)
+
+	// ParserProfiles 依執行後端（BackendCLI、BackendSDK）覆寫完成判定的解析設定，
+	// 未設定的後端使用 CLIParserProfile / SDKParserProfile (預設: nil)
+	ParserProfiles map[string]ParserProfile
+
+	// OutputSamplingPolicy 哪些迴圈的完整輸出會寫入磁碟；其餘迴圈只保存 metadata 與決策，
+	// 用於降低長時間執行的儲存量，設定無效時視為 KeepAll (預設: KeepAll)
+	OutputSamplingPolicy OutputSamplingPolicy
+
+	// VerifyCommand 判定完成後在工作目錄以 shell 執行的驗證命令，未通過時繼續迴圈 (預設: "")
+	VerifyCommand       string
+	VerifyExpectExit    int           // 驗證命令預期的退出碼 (預設: 0)
+	VerifyExpectPattern string        // stdout 必須符合的正規表示式，例如 "All tests passed"，空值表示不檢查 (預設: "")
+	VerifyTimeout       time.Duration // 驗證命令的逾時 (預設: 5m)
+
+	// HookTimeout 所有鉤子命令（VerifyCommand、PostLoopFormatters）逾時的上限，
+	// 個別設定的逾時較長時以此為準，0 表示不另外限制 (預設: 0)
+	HookTimeout time.Duration
+	// OnHookOutput 鉤子命令執行中逐行回報輸出（stream 為 "stdout" 或 "stderr"）(預設: nil)
+	OnHookOutput func(command, stream, line string)
+
+	// DiskFullPolicy 持久化遇到磁碟空間不足時的處理方式 (預設: DiskFullWarnOnce)
+	DiskFullPolicy DiskFullPolicy
+
+	// OnDiskFull 第一次偵測到磁碟空間不足時呼叫，告知採用的處理方式
+	OnDiskFull func(policy DiskFullPolicy, err error)
+
+	// PersistenceRetryPolicy 持久化寫入失敗時的重試策略，重試用盡後才記錄警告或依 DiskFullPolicy 處理；
+	// 磁碟空間不足不重試，nil 或 MaxAttempts <= 1 表示不重試 (預設: DefaultPersistenceRetryPolicy())
+	PersistenceRetryPolicy *RetryPolicy
 }
 
+// ExitOutcome 代表 CLI 退出碼對應的迴圈處理方式
+type ExitOutcome string
+
+const (
+	// ExitOutcomeSuccess 正常解析輸出並判斷是否完成
+	ExitOutcomeSuccess ExitOutcome = "success"
+	// ExitOutcomeFailure 無輸出時記錄錯誤並重試；有輸出時仍會解析
+	ExitOutcomeFailure ExitOutcome = "failure"
+	// ExitOutcomeRetry 不解析輸出、不計入熔斷器，直接進入下一個迴圈
+	ExitOutcomeRetry ExitOutcome = "retry"
+	// ExitOutcomeNeedsInput Copilot 需要使用者輸入，停止迴圈
+	ExitOutcomeNeedsInput ExitOutcome = "needs_input"
+)
+
 // NewRalphLoopClient 建立新的 Ralph Loop 客戶端
 func NewRalphLoopClient() *RalphLoopClient {
 	return NewRalphLoopClientWithConfig(DefaultClientConfig())
@@ -89,6 +405,8 @@ func NewRalphLoopClientWithConfig(config *ClientConfig) *RalphLoopClient {
 	client.executor = NewCLIExecutor(config.WorkDir)
 	client.executor.SetTimeout(config.CLITimeout)
 	client.executor.SetMaxRetries(config.CLIMaxRetries)
+	client.executor.SetRetryableErrors(config.RetryableErrors, config.NonRetryableErrors)
+	client.executor.SetMockMode(config.MockMode, config.MockResponses)
 	if config.Model != "" {
 		opts := DefaultOptions()
 		opts.Model = Model(config.Model)
@@ -96,25 +414,79 @@ func NewRalphLoopClientWithConfig(config *ClientConfig) *RalphLoopClient {
 		client.executor.options = opts
 	}
 	client.executor.SetSilent(config.Silent)
+	if len(config.AllowedTools) > 0 {
+		client.executor.options.AllowAllTools = false
+		client.executor.options.AllowedTools = config.AllowedTools
+	}
+	client.executor.options.DeniedTools = config.DeniedTools
+	if config.CLIArgStyle == ArgStyleAuto {
+		// 偵測版本需要執行外部程序，延到第一次以 CLI 執行時，偵測前先使用 current
+		client.argStylePending = true
+		client.executor.SetArgStyle(ArgStyleCurrent())
+	} else if style, err := resolveArgStyle(config.CLIArgStyle); err != nil {
+		client.initWarning("%v，使用 %s 參數風格", err, ArgStyleCurrentName)
+		client.executor.SetArgStyle(ArgStyleCurrent())
+	} else {
+		client.executor.SetArgStyle(style)
+	}
+	if config.ObservationMode {
+		client.executor.applyObservationMode()
+	}
+	client.executor.SetEnvFilter(config.EnvAllowlist, config.EnvDenylist)
+	client.executor.SetStreamThrottle(config.StreamFlushInterval, config.StreamFlushBytes)
+	client.executor.SetFailurePhrases(config.FailurePhrases)
+	if config.AdaptiveRetryClassification {
+		client.retryClassifier = client.loadRetryClassifier()
+		client.executor.SetRetryClassifier(client.retryClassifier)
+	}
+	if config.OnProgress != nil {
+		client.executor.SetProgressCallback(config.OnProgress)
+	}
+	client.cliRunner = client.executor.ExecutePrompt
 
 	client.parser = NewOutputParser("")
 
 	client.analyzer = NewResponseAnalyzer("")
 
-	client.breaker = NewCircuitBreaker("")
+	client.breaker = client.newLoopBreaker()
 
 	client.contextManager = NewContextManager()
 	client.contextManager.SetMaxHistorySize(config.MaxHistorySize)
+	client.contextManager.SetMaxTokenBudget(config.MaxTokenBudget)
+	client.persistRetry = newPersistenceRetryExecutor(config.PersistenceRetryPolicy)
 
 	if config.EnablePersistence {
-		pm, err := NewPersistenceManager(config.SaveDir, config.UseGobFormat)
+		saveDir := config.SaveDir
+		if config.PerRunSaveDir {
+			client.runID = config.RunID
+			if client.runID == "" {
+				client.runID = time.Now().Format("20060102_150405.000")
+			}
+			saveDir = filepath.Join(config.SaveDir, client.runID)
+		}
+		pm, err := NewPersistenceManager(saveDir, config.UseGobFormat)
 		if err != nil {
-			log.Printf("⚠️ 持久化管理器初始化失敗: %v (持久化功能將被禁用)", err)
+			client.initWarning("持久化管理器初始化失敗: %v (持久化功能將被禁用)", err)
 		} else {
 			client.persistence = pm
+			if err := config.OutputSamplingPolicy.Validate(); err != nil {
+				client.initWarning("%v，保存所有迴圈的輸出", err)
+			}
+			client.backend = newSamplingBackend(pm, config.OutputSamplingPolicy)
+			if config.SpillHistoryToDisk {
+				client.contextManager.SetSpillStore(pm)
+			}
+			if config.SerializePersistence {
+				client.writer = newPersistenceWriter(client.backend)
+				client.backend = client.writer
+			}
 		}
 	}
 
+	if config.SpillHistoryToDisk && client.persistence == nil {
+		client.initWarning("SpillHistoryToDisk 需要啟用持久化，超過 MaxHistorySize 的迴圈將被捨棄")
+	}
+
 	// 初始化 SDK 執行器
 	sdkConfig := &SDKConfig{
 		CLIPath:        "copilot",
@@ -129,25 +501,53 @@ func NewRalphLoopClientWithConfig(config *ClientConfig) *RalphLoopClient {
 	}
 	client.sdkExecutor = NewSDKExecutor(sdkConfig)
 
+	// 判定完成後的確認步驟（外部審核）
+	client.exitDetector = NewExitDetector(config.WorkDir)
+	if config.ExternalApprovalURL != "" {
+		approver := NewExternalApprover(config.ExternalApprovalURL, config.ExternalApprovalTimeout, config.ExternalApprovalPollInterval)
+		approver.SetMaskOutput(config.MaskSensitiveOutput)
+		client.exitDetector.SetApprover(approver)
+	}
+
 	client.initialized = true
 	return client
 }
 
+// initWarning 記錄建立客戶端時的非致命問題：寫入日誌並保留給 GetStatus
+func (c *RalphLoopClient) initWarning(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("⚠️ %s", msg)
+	c.initWarnings = append(c.initWarnings, msg)
+}
+
 // DefaultClientConfig 傳回預設的配置
 func DefaultClientConfig() *ClientConfig {
 	return &ClientConfig{
-		CLITimeout:              3 * time.Minute, // 預設 3 分鐘，應對複雜任務
-		CLIMaxRetries:           3,
-		MaxHistorySize:          100,
-		SaveDir:                 ".ralph-loop/saves",
-		UseGobFormat:            false,
-		CircuitBreakerThreshold: 3,
-		SameErrorThreshold:      5,
-		Model:                   "claude-sonnet-4.5",
-		Silent:                  false,
-		EnablePersistence:       true,
-		EnableSDK:               false, // SDK 需要 embeddedcli.Setup()，目前不支援
-		PreferSDK:               false, // 預設使用 CLI 路徑（穩定可用）
+		CLITimeout:                   3 * time.Minute, // 預設 3 分鐘，應對複雜任務
+		CLIMaxRetries:                3,
+		ConcurrencyPolicy:            ConcurrencySerialize,
+		FinalLoopInstruction:         DefaultFinalLoopInstruction,
+		MaxHistorySize:               100,
+		SaveDir:                      ".ralph-loop/saves",
+		UseGobFormat:                 false,
+		DiskFullPolicy:               DiskFullWarnOnce,
+		PersistenceRetryPolicy:       DefaultPersistenceRetryPolicy(),
+		PostLoopFormatTimeout:        defaultPostLoopFormatTimeout,
+		VerifyTimeout:                defaultVerifyTimeout,
+		CircuitBreakerThreshold:      3,
+		SameErrorThreshold:           5,
+		Model:                        "claude-sonnet-4.5",
+		MaskSensitiveOutput:          true,
+		Silent:                       false,
+		EnablePersistence:            true,
+		SerializePersistence:         true,
+		FlushOnTerminate:             true,
+		TerminateFlushTimeout:        DefaultTerminateFlushTimeout,
+		EnableSDK:                    false, // SDK 需要 embeddedcli.Setup()，目前不支援
+		PreferSDK:                    false, // 預設使用 CLI 路徑（穩定可用）
+		MaxBreakerAutoResets:         3,
+		ExternalApprovalTimeout:      10 * time.Minute,
+		ExternalApprovalPollInterval: 5 * time.Second,
 	}
 }
 
@@ -173,36 +573,129 @@ REASON: <完成原因>
 ---END_RALPH_STATUS---
 若尚未完成則輸出 EXIT_SIGNAL: false。`
 
+// confidenceSuffix 啟用 RequestConfidence 時附加在 ralphStatusSuffix 之後
+const confidenceSuffix = `
+並在狀態區塊中加入一行 CONFIDENCE: <0-100>%，表示你對這次結果正確無誤的信心。`
+
+// statusSuffix 傳回附加在 prompt 後面的狀態格式說明
+func (c *RalphLoopClient) statusSuffix() string {
+	if c.config.RequestConfidence {
+		return ralphStatusSuffix + confidenceSuffix
+	}
+	return ralphStatusSuffix
+}
+
 func (c *RalphLoopClient) ExecuteLoop(ctx context.Context, prompt string) (*LoopResult, error) {
+	if err := c.lockExecution(); err != nil {
+		return nil, err
+	}
+	defer c.unlockExecution()
+	c.selectBreaker(ctx)
+
+	prompt, hits, err := c.applyPromptSafety(prompt)
+	c.promptSafetyHits = hits
+	if err != nil {
+		return nil, err
+	}
+	return c.executeLoop(ctx, prompt)
+}
+
+// DryRun 傳回下一個迴圈以 CLI 執行時的命令列，但不執行
+//
+// prompt 會套用與 ExecuteLoop 相同的安全規則與附加說明；PreferSDK 時只有 SDK 失敗才會執行此命令。
+// CLIArgStyle 為 auto 時先偵測 copilot 版本，無法偵測時回傳錯誤。
+func (c *RalphLoopClient) DryRun(ctx context.Context, prompt string) (string, error) {
+	if err := c.lockExecution(); err != nil {
+		return "", err
+	}
+	defer c.unlockExecution()
+	if err := c.ensureArgStyle(); err != nil {
+		return "", err
+	}
+
+	prompt, _, err := c.applyPromptSafety(prompt)
+	if err != nil {
+		return "", err
+	}
+	prompt, _, _ = c.loopPrompt(prompt)
+	return c.executor.DryRun(ctx, prompt)
+}
+
+// loopPrompt 在使用者 prompt 後面附加格式要求等說明，再套用模型專屬前綴（傳回前綴與附加說明的字數）
+func (c *RalphLoopClient) loopPrompt(prompt string) (string, int, int) {
+	// 使用者 prompt 放前面（主要內容），格式要求放後面（附註）
+	suffix := c.focusPromptSuffix() + c.focusFileSuffix() + c.questionReplySuffix() + c.finalLoopSuffix() + c.statusSuffix()
+	prompt, prefixChars := c.applyModelPromptPrefix(prompt + suffix)
+	return prompt, prefixChars, len([]rune(suffix))
+}
+
+// executeLoop 執行單一迴圈（呼叫端需持有執行鎖）
+func (c *RalphLoopClient) executeLoop(ctx context.Context, prompt string) (*LoopResult, error) {
 	if !c.initialized {
 		return nil, fmt.Errorf("client not initialized")
 	}
 	if c.closed {
 		return nil, fmt.Errorf("client is closed")
 	}
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
 
-	// 使用者 prompt 放前面（主要內容），格式要求放後面（附註）
-	prompt = prompt + ralphStatusSuffix
+	prompt, prefixChars, suffixChars := c.loopPrompt(prompt)
 
 	// 檢查熔斷器
+	c.applyBreakerThresholds()
 	if c.breaker.IsOpen() {
 		return nil, fmt.Errorf("circuit breaker is open: %s", c.breaker.GetState())
 	}
 
+	// 持久化因磁碟空間不足而中止時不再開始新迴圈
+	if c.persistenceAbortErr != nil {
+		return nil, c.persistenceAbortErr
+	}
+
 	// 開始新迴圈
-	loopIndex := len(c.contextManager.GetLoopHistory())
+	loopIndex := c.contextManager.NextLoopIndex()
 	execCtx := c.contextManager.StartLoop(loopIndex, prompt)
+	execCtx.PromptSafetyHits = c.promptSafetyHits
+	for _, hit := range c.promptSafetyHits {
+		execCtx.AddWarning("prompt 命中安全規則 %q (%s): %q", hit.Pattern, hit.Action, hit.Match)
+	}
+	execCtx.Model = c.activeModel()
+	execCtx.PromptPrefixChars = prefixChars
+	execCtx.PromptSuffixChars = suffixChars
+	execCtx.WrapUp = c.finalLoopSuffix() != ""
+	if c.modelEscalatedAt > 0 {
+		execCtx.Metadata["model_escalated"] = true
+	}
+
+	// 記錄第一個迴圈前的工作目錄狀態，作為無變更偵測的基準
+	if c.config.NoChangeLoopThreshold > 0 && c.lastWorkdirFingerprint == "" {
+		if fp, err := workdirFingerprint(ctx, c.config.WorkDir, c.config.SaveDir); err == nil {
+			c.lastWorkdirFingerprint = fp
+		}
+	}
+	c.snapshotArtifactBaseline()
+	c.snapshotFocusBaseline()
+	c.snapshotChangeBaseline(ctx)
+	c.snapshotObservationBaseline(ctx)
 
 	defer func() {
 		// 完成迴圈
+		prunedBefore := c.contextManager.TokenPrunedLoops()
 		if err := c.contextManager.FinishLoop(); err != nil {
 			log.Printf("⚠️ 迴圈結束記錄失敗: %v", err)
 		}
+		if pruned := c.contextManager.TokenPrunedLoops() - prunedBefore; pruned > 0 {
+			infoLog("✂️ 歷史記錄超過 token 上限 %d，已移出最舊的 %d 個迴圈（目前約 %d tokens）",
+				c.config.MaxTokenBudget, pruned, c.contextManager.EstimatedTokens())
+		}
 
 		// 自動持久化整個 ContextManager（如果啟用）
-		if c.persistence != nil && c.config.EnablePersistence {
-			if err := c.persistence.SaveContextManager(c.contextManager); err != nil {
-				log.Printf("⚠️ 上下文持久化失敗 (迴圈 %d): %v", loopIndex, err)
+		if c.persistEnabled() {
+			if err := c.persistWithRetry(func() error { return c.backend.SaveContextManager(c.contextManager) }); err != nil {
+				_ = c.handlePersistError(fmt.Sprintf("上下文持久化 (迴圈 %d) ", loopIndex), err)
+			} else if err := c.persistWithRetry(c.saveBreakerState); err != nil {
+				_ = c.handlePersistError(fmt.Sprintf("熔斷器狀態持久化 (迴圈 %d) ", loopIndex), err)
 			}
 		}
 	}()
@@ -211,33 +704,61 @@ func (c *RalphLoopClient) ExecuteLoop(ctx context.Context, prompt string) (*Loop
 	var output string
 	var executionErr error
 	var usedSDK bool
+	var sdkFailure error // SDK 啟動或執行失敗的原因（用於記錄降級恢復）
 
 	// 如果配置優先使用 SDK，嘗試啟動並使用 SDK
-	if c.config.PreferSDK && c.config.EnableSDK && c.sdkExecutor != nil {
+	if c.config.PreferSDK && c.config.EnableSDK && c.sdkExecutor != nil && !c.config.ObservationMode && !c.config.MockMode {
 		// Lazy-start：第一次呼叫時才啟動 SDK 執行器
 		if !c.sdkExecutor.isHealthy() {
 			if startErr := c.sdkExecutor.Start(ctx); startErr != nil {
 				infoLog("⚠️ SDK 執行器啟動失敗，降級使用 CLI 模式: %v", startErr)
+				sdkFailure = startErr
 			}
 		}
 		if c.sdkExecutor.isHealthy() {
 			infoLog("📡 使用 SDK 模式執行")
-			output, executionErr = c.sdkExecutor.Complete(ctx, prompt)
+			c.withoutStateLock(func() { output, executionErr = c.sdkExecutor.Complete(ctx, prompt) })
 			if executionErr == nil {
 				usedSDK = true
 				execCtx.CLICommand = "sdk:complete"
 				execCtx.CLIOutput = output
 				execCtx.CLIExitCode = 0
+				execCtx.ExitOutcome = ExitOutcomeSuccess
 			} else {
 				infoLog("⚠️ SDK 執行失敗，降級使用 CLI 模式: %v", executionErr)
+				sdkFailure = executionErr
 			}
 		}
 	}
 
+	// 恢復次數已達上限：不再降級 CLI，失敗直接交給熔斷器
+	if sdkFailure != nil && c.recoveryCapReached() {
+		c.breaker.RecordSameError(sdkFailure.Error())
+		execCtx.ExitReason = fmt.Sprintf("SDK 執行失敗，已達恢復上限 (%d 次)，不再降級: %v",
+			c.config.MaxRecoveryAttemptsPerRun, sdkFailure)
+		execCtx.ShouldContinue = true
+		infoLog("🛑 %s", execCtx.ExitReason)
+		return c.finishResult(execCtx, true), nil
+	}
+
 	// SDK 失敗/不可用/未啟用，或配置不優先使用 SDK 時，使用 CLI
 	if !usedSDK {
 		infoLog("🔧 使用 CLI 模式執行")
-		result, err := c.executor.ExecutePrompt(ctx, prompt)
+		cliStart := time.Now()
+		var result *ExecutionResult
+		var err, styleErr error
+		c.withoutStateLock(func() {
+			styleErr = c.ensureArgStyle()
+			result, err = c.cliRunner(ctx, prompt)
+		})
+		if styleErr != nil {
+			execCtx.AddWarning("%v，使用 %s 參數風格", styleErr, ArgStyleCurrentName)
+		}
+		if sdkFailure != nil {
+			c.recoveryAttempts++
+			c.recordFallbackRecovery(execCtx, sdkFailure, err, time.Since(cliStart))
+			execCtx.AddWarning("SDK 無法使用，已降級為 CLI 模式: %v", sdkFailure)
+		}
 		if err != nil {
 			// context.Canceled = 使用者中斷（Ctrl+C），立刻停止
 			// context.DeadlineExceeded = 總逾時，立刻停止
@@ -253,25 +774,53 @@ func (c *RalphLoopClient) ExecuteLoop(ctx context.Context, prompt string) (*Loop
 				execCtx.ExitReason = fmt.Sprintf("CLI 執行失敗: %v", err)
 			}
 			execCtx.ShouldContinue = true
-			return c.createResult(execCtx, true), nil
+			return c.finishResult(execCtx, true), nil
 		}
 
 		output = result.Stdout
 		execCtx.CLICommand = result.Command
+		execCtx.RenderedCommand = c.executor.RenderCommand(prompt, c.config.MaskSensitiveOutput)
 		execCtx.CLIOutput = result.Stdout
 		execCtx.CLIExitCode = result.ExitCode
+		if stderr := strings.TrimSpace(result.Stderr); stderr != "" {
+			line, _, _ := strings.Cut(stderr, "\n")
+			execCtx.AddWarning("CLI stderr: %s", tailRunes(strings.TrimSpace(line), 200))
+		}
+
+		// 依 ExitCodeMap 解讀退出碼
+		outcome := c.exitOutcome(result.ExitCode)
+		execCtx.ExitOutcome = outcome
 
-		// exit code != 0 但有輸出（例如 CLI 內部超時但 Copilot 已完成）
-		// 先走正常解析流程，讓 ResponseAnalyzer 判斷是否完成
-		if result.ExitCode != 0 && strings.TrimSpace(result.Stdout) == "" {
-			// 完全沒有輸出才算真正失敗
-			c.breaker.RecordSameError(fmt.Sprintf("exit code %d, no output", result.ExitCode))
-			execCtx.ExitReason = fmt.Sprintf("CLI 退出碼 %d（無輸出），繼續重試", result.ExitCode)
+		switch outcome {
+		case ExitOutcomeRetry:
+			// 可重試的退出碼：不計入熔斷器，直接進入下一個迴圈
+			execCtx.ExitReason = fmt.Sprintf("CLI 退出碼 %d 對應重試，繼續下一個迴圈", result.ExitCode)
 			execCtx.ShouldContinue = true
-			return c.createResult(execCtx, true), nil
+			return c.finishResult(execCtx, true), nil
+
+		case ExitOutcomeNeedsInput:
+			// Copilot 需要使用者輸入，自動迴圈無法繼續
+			execCtx.ExitReason = fmt.Sprintf("Copilot 需要使用者輸入 (退出碼 %d)", result.ExitCode)
+			execCtx.ShouldContinue = false
+			return c.finishResult(execCtx, false), nil
+
+		case ExitOutcomeFailure:
+			// exit code != 0 但有輸出（例如 CLI 內部超時但 Copilot 已完成）
+			// 先走正常解析流程，讓 ResponseAnalyzer 判斷是否完成
+			if strings.TrimSpace(result.Stdout) == "" {
+				// 完全沒有輸出才算真正失敗
+				c.breaker.RecordSameError(fmt.Sprintf("exit code %d, no output", result.ExitCode))
+				execCtx.ExitReason = fmt.Sprintf("CLI 退出碼 %d（無輸出），繼續重試", result.ExitCode)
+				execCtx.ShouldContinue = true
+				return c.finishResult(execCtx, true), nil
+			}
+			execCtx.AddWarning("CLI 退出碼 %d，但仍有輸出，照常解析", result.ExitCode)
 		}
 	}
 
+	// 記錄實際提供回應的模型與用量（伺服器端可能改用其他模型）
+	c.captureModelUsage(execCtx, output)
+
 	// 解析輸出
 	parser := NewOutputParser(output)
 	// #nosec G104 -- Parse 僅解析輸出，失敗不影響繼續執行
@@ -280,15 +829,78 @@ func (c *RalphLoopClient) ExecuteLoop(ctx context.Context, prompt string) (*Loop
 
 	execCtx.ParsedCodeBlocks = codeBlocks
 	execCtx.CleanedOutput = output
+	c.checkCodeBlockLimit(execCtx, parser)
+
+	// 輸出疑似包含真實的密碼或金鑰：記錄已遮蔽，嚴格模式下立即中止
+	if detector := c.checkSecretLeak(execCtx, output); detector != "" && c.config.AbortOnSecretLeak {
+		execCtx.ShouldContinue = false
+		execCtx.ExitReason = fmt.Sprintf("輸出疑似包含敏感資料（偵測器: %s），中止執行", detector)
+		infoLog("🛑 %s", execCtx.ExitReason)
+		return c.finishResult(execCtx, false), nil
+	}
+
+	// 模型表示放棄：立即結束，不再消耗剩餘迴圈
+	if len(c.config.FailurePhrases) > 0 {
+		if phrase := NewFailurePhraseDetector(c.config.FailurePhrases).Check(output); phrase != "" {
+			execCtx.GaveUpPhrase = phrase
+			execCtx.ShouldContinue = false
+			execCtx.ExitReason = fmt.Sprintf("模型放棄任務: %q", phrase)
+			infoLog("🏳️ %s", execCtx.ExitReason)
+			return c.finishResult(execCtx, false), nil
+		}
+	}
 
-	// 使用 ResponseAnalyzer 分析回應（雙重條件驗證）
-	analyzer := NewResponseAnalyzer(output)
-	score := analyzer.CalculateCompletionScore()
-	execCtx.CompletionScore = score
-	completed := analyzer.IsCompleted()
+	// 同一個命令反覆以相同方式失敗：繼續迴圈也只會重複同樣的嘗試
+	if command := c.checkToolFailures(output); command != "" {
+		execCtx.RepeatedToolFailure = command
+		execCtx.ShouldContinue = false
+		execCtx.ExitReason = c.toolFailureReason(command)
+		infoLog("🔁 %s", execCtx.ExitReason)
+		return c.finishResult(execCtx, false), nil
+	}
+
+	// 觀察模式下仍有檔案被修改：禁止的工具沒有攔住，立即中止
+	if changed := c.checkObservation(ctx, execCtx); len(changed) > 0 {
+		execCtx.ObservationViolation = changed
+		execCtx.ShouldContinue = false
+		execCtx.ExitReason = observationReason(changed)
+		infoLog("🚫 %s", execCtx.ExitReason)
+		return c.finishResult(execCtx, false), nil
+	}
+
+	// 格式化本迴圈變更的檔案，避免模型在下一個迴圈反覆修改格式（觀察模式下不修改檔案）
+	if !c.config.ObservationMode {
+		c.runPostLoopFormatters(ctx, execCtx)
+	}
+	c.checkFocusFiles(execCtx)
+
+	// 修改的檔案數超過 MaxChangedFiles：避免失控的代理改寫整個儲存庫
+	if c.checkChangeBudget(ctx, execCtx) {
+		execCtx.ChangeBudgetExceeded = true
+		execCtx.ShouldContinue = false
+		execCtx.ExitReason = c.changeBudgetReason()
+		infoLog("🚧 %s", execCtx.ExitReason)
+		return c.finishResult(execCtx, false), nil
+	}
+
+	// 使用 ResponseAnalyzer 分析回應（雙重條件驗證），依產生輸出的後端選擇解析設定
+	profile := c.parserProfile(execCtx.CLICommand)
+	analyzer := NewResponseAnalyzerWithProfile(output, profile)
+	decision := analyzer.Decide()
+	execCtx.Decision = decision
+	execCtx.CompletionScore = decision.Score
+	completed := decision.Completed
 
 	// 從 RALPH_STATUS 提取 REASON
 	statusBlock := analyzer.ParseStructuredOutput()
+	if statusBlock == nil && profile.StatusBlockExpected {
+		execCtx.AddWarning("輸出缺少 RALPH_STATUS 區塊，完成判定僅依文字分析")
+	}
+	if c.config.RequestConfidence && statusBlock != nil && statusBlock.HasConfidence {
+		confidence := statusBlock.Confidence
+		execCtx.Confidence = &confidence
+		decision.Confidence = &confidence
+	}
 
 	shouldContinue := !completed
 	execCtx.ShouldContinue = shouldContinue
@@ -300,29 +912,119 @@ func (c *RalphLoopClient) ExecuteLoop(ctx context.Context, prompt string) (*Loop
 			reason = statusBlock.Reason
 		}
 		execCtx.ExitReason = reason
+
+		// 自評信心過低：模型宣稱完成但不確定，再跑一個迴圈驗證
+		if c.lowConfidence(execCtx.Confidence) {
+			shouldContinue = true
+			execCtx.ShouldContinue = true
+			execCtx.ExitReason = fmt.Sprintf("自評信心 %d%% 低於門檻 %d%%，繼續驗證",
+				*execCtx.Confidence, c.config.MinExitConfidence)
+			decision.LowConfidence = true
+			infoLog("🤔 %s", execCtx.ExitReason)
+		}
+
+		// 驗證命令：退出碼與輸出都符合預期才真正結束
+		if c.config.VerifyCommand != "" && !shouldContinue {
+			var verify *VerifyResult
+			c.withoutStateLock(func() { verify = c.runVerifyCommand(ctx) })
+			execCtx.Verify = verify
+			if !verify.Passed {
+				shouldContinue = true
+				execCtx.ShouldContinue = true
+				execCtx.ExitReason = fmt.Sprintf("驗證命令未通過: %s", verify.Reason)
+				decision.VerifyFailed = true
+				infoLog("🧪 %s", execCtx.ExitReason)
+			} else {
+				infoLog("🧪 驗證命令通過")
+			}
+		}
+
+		// 結束前的確認步驟（外部審核）：判定完成後仍需簽核才真正結束
+		if !shouldContinue {
+			var approval *ApprovalDecision
+			var err error
+			c.withoutStateLock(func() { approval, err = c.exitDetector.ConfirmExit(ctx, execCtx) })
+			if err != nil {
+				execCtx.AddWarning("外部審核請求失敗: %v", err)
+			}
+			execCtx.ApprovalDecision = approval
+			if approval != nil && !approval.Approved {
+				infoLog("🛑 外部審核未通過，繼續迴圈: %s", approval.Reason)
+				shouldContinue = true
+				execCtx.ShouldContinue = true
+				execCtx.ExitReason = fmt.Sprintf("外部審核拒絕: %s", approval.Reason)
+				decision.ApprovalRejected = true
+			}
+		}
 	} else {
 		// 設定繼續原因（從 RALPH_STATUS REASON 欄位取得）
 		if statusBlock != nil && statusBlock.Reason != "" {
 			execCtx.ExitReason = statusBlock.Reason
 		}
 		// 只在輸出與前一次迴圈完全相同時才記錄無進展（真正卡住）
+		// 含敏感資料的輸出記錄時已遮蔽，改用遮蔽後的內容比較
+		current := output
+		if execCtx.SecretDetector != "" {
+			current = execCtx.CLIOutput
+		}
 		history := c.contextManager.GetLoopHistory()
-		if len(history) > 0 && history[len(history)-1].CLIOutput == output {
+		if len(history) > 0 && history[len(history)-1].CLIOutput == current {
 			c.breaker.RecordNoProgress()
+			decision.Stuck = true
+			decision.StuckReason = "輸出與前一個迴圈完全相同"
+		}
+
+		// 工作目錄連續多個迴圈完全沒有變更：模型只在說話、沒有動手
+		noChange := c.checkWorkdirUnchanged(ctx)
+		decision.NoChangeLoops = c.noChangeLoops
+		if noChange {
+			decision.NoChangeTriggered = true
+			shouldContinue = false
+			execCtx.ShouldContinue = false
+			execCtx.ExitReason = fmt.Sprintf("工作目錄連續 %d 個迴圈無變更，停止執行", c.noChangeLoops)
+			infoLog("⏹️ %s", execCtx.ExitReason)
+		}
+
+		// 連續多個迴圈沒有修改 FocusFiles：模型偏離目標，打開熔斷器
+		if c.focusFileTripped() {
+			decision.Stuck = true
+			decision.StuckReason = c.breaker.OpenReason()
+			infoLog("🎯 %s，打開熔斷器", decision.StuckReason)
+		}
+
+		// 模型在自主模式下仍提出問題：自動回覆或中止
+		if shouldContinue && c.config.OnModelQuestion != "" {
+			if q := c.handleModelQuestion(execCtx, output); q != nil && q.Action == QuestionAbort {
+				shouldContinue = false
+				execCtx.ShouldContinue = false
+				execCtx.ExitReason = fmt.Sprintf("模型提出問題，依設定中止: %s", q.Text)
+			}
 		}
 	}
 
 	execCtx.CircuitBreakerState = string(c.breaker.GetState())
 
 	// 個別執行上下文的持久化（可選）
-	if c.persistence != nil && c.config.EnablePersistence {
-		if err := c.persistence.SaveExecutionContext(execCtx); err != nil {
-			// 記錄警告但不中斷執行流程
-			fmt.Printf("⚠️ 儲存執行上下文失敗: %v\n", err)
+	if c.persistEnabled() {
+		if err := c.persistWithRetry(func() error { return c.backend.SaveExecutionContext(execCtx) }); err != nil {
+			// 記錄警告但不中斷執行流程，除非磁碟空間不足且設定為中止
+			if abortErr := c.handlePersistError("儲存執行上下文", err); abortErr != nil {
+				execCtx.ShouldContinue = false
+				execCtx.ExitReason = "磁碟空間不足，中止執行"
+				return c.finishResult(execCtx, false), abortErr
+			}
+			execCtx.AddWarning("執行上下文未能儲存: %v", err)
 		}
 	}
 
-	return c.createResult(execCtx, shouldContinue), nil
+	return c.finishResult(execCtx, shouldContinue), nil
+}
+
+// withoutStateLock 暫時釋放迴圈狀態鎖執行 fn，用於等待外部程序的長時間呼叫（fn 不可修改迴圈狀態）
+func (c *RalphLoopClient) withoutStateLock(fn func()) {
+	c.stateMu.Unlock()
+	defer c.stateMu.Lock()
+	fn()
 }
 
 // ExecuteUntilCompletion 持續執行迴圈直到完成或錯誤
@@ -333,7 +1035,47 @@ func (c *RalphLoopClient) ExecuteLoop(ctx context.Context, prompt string) (*Loop
 // - Context 被取消
 // - 達到最大迴圈次數
 func (c *RalphLoopClient) ExecuteUntilCompletion(ctx context.Context, initialPrompt string, maxLoops int) ([]*LoopResult, error) {
+	if err := c.lockExecution(); err != nil {
+		return nil, err
+	}
+	defer c.unlockExecution()
+	c.selectBreaker(ctx)
+
+	// 送出前檢查危險的指示：阻擋時不執行任何迴圈，改寫後的 prompt 用於所有迴圈
+	prompt, hits, err := c.applyPromptSafety(initialPrompt)
+	c.promptSafetyHits = hits
+	if err != nil {
+		c.finishProgress(nil, maxLoops, err)
+		return nil, err
+	}
+
+	// 相同的 prompt 最近已經失敗過：除非 ForceRun，否則不再浪費資源重跑
+	if err := c.checkFailedPrompt(initialPrompt); err != nil {
+		c.finishProgress(nil, maxLoops, err)
+		return nil, err
+	}
+	results, err := c.executeUntilCompletion(ctx, maxLoops, func(int, *LoopResult) (string, error) {
+		return c.buildContinuationPrompt(prompt), nil
+	})
+	c.recordPromptOutcome(initialPrompt, err, ctx.Err() != nil)
+	c.finishProgress(results, maxLoops, err)
+	return results, err
+}
+
+// executeUntilCompletion 執行迴圈直到結束（呼叫端需持有執行鎖）
+//
+// nextPrompt 在每個迴圈前產生 prompt（prev 為前一個迴圈的結果），回傳錯誤時中止執行。
+func (c *RalphLoopClient) executeUntilCompletion(ctx context.Context, maxLoops int, nextPrompt func(loopIndex int, prev *LoopResult) (string, error)) ([]*LoopResult, error) {
 	var results []*LoopResult
+	c.breakerAutoResets = 0
+	c.recoveryAttempts = 0
+	c.resetModelEscalation()
+	c.resetFocusFiles()
+	c.resetChangeBudget()
+	c.resetObservation()
+	c.progress = nil
+	c.toolFailures = nil
+	defer func() { c.finalLoop = false }()
 
 	for i := 0; i < maxLoops; i++ {
 		select {
@@ -341,45 +1083,247 @@ func (c *RalphLoopClient) ExecuteUntilCompletion(ctx context.Context, initialPro
 			return results, fmt.Errorf("context cancelled after %d loops", i)
 		default:
 		}
+		if err := c.waitIfPaused(ctx); err != nil {
+			return results, fmt.Errorf("context cancelled after %d loops", i)
+		}
 
 		// 顯示進度
 		if !c.config.Silent {
-			fmt.Printf("\n🔄 迴圈 %d/%d - 正在執行...\n", i+1, maxLoops)
+			fmt.Fprintf(logWriter(), "\n🔄 迴圈 %d/%d - 正在執行...\n", i+1, maxLoops)
 		}
+		c.maybeEscalateModel(i)
+		// 最後一個迴圈要求模型收尾並總結，而不是停在任務中途
+		c.finalLoop = maxLoops > 1 && i == maxLoops-1
 
-		result, err := c.ExecuteLoop(ctx, initialPrompt)
+		var prev *LoopResult
+		if len(results) > 0 {
+			prev = results[len(results)-1]
+		}
+		prompt, err := nextPrompt(i, prev)
+		if err != nil {
+			return results, err
+		}
+
+		result, err := c.executeLoop(ctx, c.progressSummaryPrefix()+prompt)
 		if err != nil {
 			if !c.config.Silent {
-				fmt.Printf("❌ 迴圈 %d 失敗: %v\n", i+1, err)
+				fmt.Fprintf(logWriter(), "❌ 迴圈 %d 失敗: %v\n", i+1, err)
 			}
 			return results, err
 		}
 
 		results = append(results, result)
+		c.updateProgressSummary(result)
+		c.writeProgress(ProgressRunning, i+1, maxLoops, result.ExitReason, nil)
+		if c.config.OnLoopComplete != nil {
+			c.config.OnLoopComplete(result)
+		}
 
 		// 顯示迴圈結果
 		if !c.config.Silent {
 			if result.ShouldContinue {
-				fmt.Printf("✓ 迴圈 %d 完成 - 繼續下一個迴圈\n", i+1)
+				fmt.Fprintf(logWriter(), "✓ 迴圈 %d 完成 - 繼續下一個迴圈\n", i+1)
 			} else {
-				fmt.Printf("✓ 迴圈 %d 完成 - 任務完成: %s\n", i+1, result.ExitReason)
+				fmt.Fprintf(logWriter(), "✓ 迴圈 %d 完成 - 任務完成: %s\n", i+1, result.ExitReason)
+			}
+			if c.config.ExplainDecisions {
+				c.printDecision(result)
 			}
 		}
 
 		// 檢查是否完成
 		if !result.ShouldContinue {
+			if len(result.ObservationViolation) > 0 {
+				return results, fmt.Errorf("%w after %d loops: %d files changed", ErrObservationViolation, i+1, len(result.ObservationViolation))
+			}
+			if result.RepeatedToolFailure != "" {
+				return results, fmt.Errorf("%w after %d loops: %s", ErrRepeatedToolFailure, i+1, result.RepeatedToolFailure)
+			}
+			if result.ChangeBudgetExceeded {
+				return results, fmt.Errorf("%w after %d loops: %d files changed (max %d)",
+					ErrChangeBudgetExceeded, i+1, result.FilesChanged, c.config.MaxChangedFiles)
+			}
+			if result.SecretLeak != "" && c.config.AbortOnSecretLeak {
+				return results, fmt.Errorf("%w after %d loops (detector: %s)", ErrSecretLeak, i+1, result.SecretLeak)
+			}
+			if result.GaveUpPhrase != "" {
+				return results, fmt.Errorf("%w after %d loops: %q", ErrModelGaveUp, i+1, result.GaveUpPhrase)
+			}
+			if result.Question != nil && result.Question.Action == QuestionAbort {
+				return results, fmt.Errorf("model asked a question after %d loops: %s", i+1, result.Question.Text)
+			}
 			return results, nil
 		}
 
 		// 檢查熔斷器
 		if c.breaker.IsOpen() {
-			return results, fmt.Errorf("circuit breaker opened after %d loops", i+1)
+			hookErr := c.notifyBreakerOpen()
+			if !c.waitAndHalfOpenBreaker(ctx) {
+				if hookErr != nil {
+					return results, fmt.Errorf("circuit breaker opened after %d loops: %s (OnBreakerOpen: %w)",
+						i+1, c.breaker.OpenReason(), hookErr)
+				}
+				return results, fmt.Errorf("circuit breaker opened after %d loops", i+1)
+			}
 		}
 	}
 
 	return results, fmt.Errorf("reached maximum loops (%d) without completion", maxLoops)
 }
 
+// maybeEscalateModel 已執行 EscalateAfterLoops 個迴圈仍未完成時改用 EscalateModel
+func (c *RalphLoopClient) maybeEscalateModel(loopsDone int) {
+	if c.config.EscalateAfterLoops <= 0 || c.config.EscalateModel == "" || c.modelEscalatedAt > 0 {
+		return
+	}
+	if loopsDone < c.config.EscalateAfterLoops {
+		return
+	}
+
+	c.modelEscalatedAt = loopsDone + 1
+	c.executor.SetModel(c.config.EscalateModel)
+	infoLog("⬆️ 已執行 %d 個迴圈仍未完成，改用模型 %s", loopsDone, c.config.EscalateModel)
+	if !c.config.Silent {
+		fmt.Fprintf(logWriter(), "⬆️ 已執行 %d 個迴圈仍未完成，改用模型 %s\n", loopsDone, c.config.EscalateModel)
+	}
+}
+
+// resetModelEscalation 還原為設定的模型（每次執行開始時呼叫）
+func (c *RalphLoopClient) resetModelEscalation() {
+	if c.modelEscalatedAt == 0 {
+		return
+	}
+	c.modelEscalatedAt = 0
+	if c.config.Model != "" {
+		c.executor.SetModel(Model(c.config.Model))
+	} else {
+		c.executor.SetModel(DefaultOptions().Model)
+	}
+}
+
+// activeModel 目前使用的模型名稱
+func (c *RalphLoopClient) activeModel() string {
+	if c.modelEscalatedAt > 0 {
+		return string(c.config.EscalateModel)
+	}
+	return c.config.Model
+}
+
+// printDecision 印出迴圈的完成判定依據
+func (c *RalphLoopClient) printDecision(result *LoopResult) {
+	fmt.Fprintln(logWriter(), "🔍 判定依據:")
+	if result.Decision == nil {
+		fmt.Fprintf(logWriter(), "   未進行完成判定: %s\n", result.ExitReason)
+		return
+	}
+	fmt.Fprint(logWriter(), result.Decision.Explain())
+}
+
+// notifyBreakerOpen 呼叫 OnBreakerOpen hook，並傳回它的錯誤
+func (c *RalphLoopClient) notifyBreakerOpen() error {
+	if c.config.OnBreakerOpen == nil {
+		return nil
+	}
+	err := c.config.OnBreakerOpen(c.breaker.GetState(), c.breaker.OpenReason())
+	if err != nil {
+		log.Printf("⚠️ OnBreakerOpen 失敗: %v", err)
+	}
+	return err
+}
+
+// waitAndHalfOpenBreaker 在熔斷器打開時等待冷卻後轉為半開狀態
+//
+// 未啟用自動重置、已達上限或 context 被取消時傳回 false，呼叫端應中止執行。
+func (c *RalphLoopClient) waitAndHalfOpenBreaker(ctx context.Context) bool {
+	if c.config.AutoResetBreakerAfter <= 0 || c.breakerAutoResets >= c.config.MaxBreakerAutoResets {
+		return false
+	}
+
+	c.breakerAutoResets++
+	if !c.config.Silent {
+		fmt.Fprintf(logWriter(), "⏸️ 熔斷器已打開，%v 後自動重置並再試一次 (%d/%d)\n",
+			c.config.AutoResetBreakerAfter, c.breakerAutoResets, c.config.MaxBreakerAutoResets)
+	}
+
+	select {
+	case <-time.After(c.config.AutoResetBreakerAfter):
+	case <-ctx.Done():
+		return false
+	}
+
+	c.breaker.HalfOpen()
+	return true
+}
+
+// recordFallbackRecovery 記錄 SDK 失敗後降級到 CLI 的恢復結果
+func (c *RalphLoopClient) recordFallbackRecovery(execCtx *ExecutionContext, sdkErr, cliErr error, duration time.Duration) {
+	attempt := RecoveryAttempt{
+		Strategy:  RecoveryFallback.String(),
+		Trigger:   fmt.Sprintf("SDK 失敗: %v", sdkErr),
+		Success:   cliErr == nil,
+		Duration:  duration,
+		Timestamp: time.Now(),
+	}
+	if cliErr != nil {
+		attempt.Error = cliErr.Error()
+		infoLog("🩹 SDK 失敗後以 %s 恢復失敗: %v", attempt.Strategy, cliErr)
+	} else {
+		infoLog("🩹 SDK 失敗後已透過 %s 恢復（CLI 模式）", attempt.Strategy)
+	}
+	execCtx.Recoveries = append(execCtx.Recoveries, attempt)
+}
+
+// lowConfidence 傳回自評信心是否低於 MinExitConfidence（未回報信心時不阻擋結束）
+func (c *RalphLoopClient) lowConfidence(confidence *int) bool {
+	return c.config.RequestConfidence && c.config.MinExitConfidence > 0 &&
+		confidence != nil && *confidence < c.config.MinExitConfidence
+}
+
+// recoveryCapReached 傳回本次執行的恢復次數是否已達 MaxRecoveryAttemptsPerRun
+func (c *RalphLoopClient) recoveryCapReached() bool {
+	return c.config.MaxRecoveryAttemptsPerRun > 0 && c.recoveryAttempts >= c.config.MaxRecoveryAttemptsPerRun
+}
+
+// GetRecoveryHistory 取得所有迴圈中發生過的恢復嘗試（依時間排序）
+func (c *RalphLoopClient) GetRecoveryHistory() []RecoveryAttempt {
+	var attempts []RecoveryAttempt
+	for _, loop := range c.contextManager.GetLoopHistory() {
+		attempts = append(attempts, loop.Recoveries...)
+	}
+	return attempts
+}
+
+// checkWorkdirUnchanged 更新工作目錄指紋，傳回是否已連續無變更達門檻
+//
+// 無法取得指紋時（例如目錄不可讀）略過偵測並重置計數。
+func (c *RalphLoopClient) checkWorkdirUnchanged(ctx context.Context) bool {
+	if c.config.NoChangeLoopThreshold <= 0 {
+		return false
+	}
+
+	fp, err := workdirFingerprint(ctx, c.config.WorkDir, c.config.SaveDir)
+	if err != nil {
+		debugLog("無法取得工作目錄指紋，略過無變更偵測: %v", err)
+		c.lastWorkdirFingerprint = ""
+		c.noChangeLoops = 0
+		return false
+	}
+
+	if c.lastWorkdirFingerprint != "" && fp == c.lastWorkdirFingerprint {
+		c.noChangeLoops++
+	} else {
+		c.noChangeLoops = 0
+	}
+	c.lastWorkdirFingerprint = fp
+
+	return c.noChangeLoops >= c.config.NoChangeLoopThreshold
+}
+
+// RunID 傳回本次執行的 ID（未啟用 PerRunSaveDir 時為空字串）
+func (c *RalphLoopClient) RunID() string {
+	return c.runID
+}
+
 // GetHistory 取得執行歷史
 func (c *RalphLoopClient) GetHistory() []*ExecutionContext {
 	return c.contextManager.GetLoopHistory()
@@ -395,19 +1339,80 @@ func (c *RalphLoopClient) GetStatus() *ClientStatus {
 	return &ClientStatus{
 		Initialized:         c.initialized,
 		Closed:              c.closed,
-		CircuitBreakerOpen:  c.breaker.IsOpen(),
-		CircuitBreakerState: c.breaker.GetState(),
+		CircuitBreakerOpen:  c.defaultBreaker().IsOpen(),
+		CircuitBreakerState: c.defaultBreaker().GetState(),
+		TagBreakers:         c.TagBreakerStates(),
+		OpenBreakerTags:     c.OpenBreakerTags(),
 		LoopsExecuted:       len(c.contextManager.GetLoopHistory()),
+		Paused:              c.IsPaused(),
+		Warnings:            append([]string(nil), c.initWarnings...),
+		BreakerAutoResets:   c.breakerAutoResets,
+		RecoveryAttempts:    c.recoveryAttempts,
+		ModelEscalatedAt:    c.modelEscalatedAt,
+		FilesChanged:        len(c.changedFiles),
+		ExecutionModes:      c.GetExecutionModes(),
 		Summary:             c.GetSummary(),
 	}
 }
 
-// ResetCircuitBreaker 重置熔斷器
+// newLoopBreaker 建立套用 CircuitBreakerThreshold 與 SameErrorThreshold 的熔斷器
+func (c *RalphLoopClient) newLoopBreaker() *CircuitBreaker {
+	breaker := NewCircuitBreaker("")
+	breaker.SetNoProgressThreshold(c.config.CircuitBreakerThreshold)
+	breaker.SetSameErrorThreshold(c.config.SameErrorThreshold)
+	return breaker
+}
+
+// SetCircuitBreakerThresholds 調整熔斷器的閾值（含各標籤的熔斷器），小於 1 的值不變更
+//
+// 可在執行中（包括 hook 內）呼叫，從下一個迴圈開始生效。
+func (c *RalphLoopClient) SetCircuitBreakerThresholds(noProgress, sameError int) {
+	c.thresholdMu.Lock()
+	defer c.thresholdMu.Unlock()
+	c.pendingThresholds = &[2]int{noProgress, sameError}
+}
+
+// applyBreakerThresholds 套用 SetCircuitBreakerThresholds 設定的閾值（呼叫端需持有執行鎖）
+func (c *RalphLoopClient) applyBreakerThresholds() {
+	c.thresholdMu.Lock()
+	pending := c.pendingThresholds
+	c.pendingThresholds = nil
+	c.thresholdMu.Unlock()
+	if pending == nil {
+		return
+	}
+
+	noProgress, sameError := pending[0], pending[1]
+	if noProgress > 0 {
+		c.config.CircuitBreakerThreshold = noProgress
+	}
+	if sameError > 0 {
+		c.config.SameErrorThreshold = sameError
+	}
+	c.breaker.SetNoProgressThreshold(noProgress)
+	c.breaker.SetSameErrorThreshold(sameError)
+	for _, breaker := range c.tagBreakers {
+		breaker.SetNoProgressThreshold(noProgress)
+		breaker.SetSameErrorThreshold(sameError)
+	}
+}
+
+// GetCircuitBreakerStats 取得熔斷器目前的計數、閾值與最近的打開記錄（沒有標籤時使用的熔斷器）
+func (c *RalphLoopClient) GetCircuitBreakerStats() *CircuitBreakerStats {
+	stats := c.defaultBreaker().Stats()
+	return &stats
+}
+
+// ResetCircuitBreaker 重置熔斷器（含各標籤的熔斷器）
 func (c *RalphLoopClient) ResetCircuitBreaker() error {
 	if !c.initialized {
 		return fmt.Errorf("client not initialized")
 	}
-	c.breaker.Reset()
+	if err := c.lockExecution(); err != nil {
+		return err
+	}
+	defer c.unlockExecution()
+	c.resetAllBreakers()
 	return nil
 }
 
@@ -429,7 +1434,7 @@ func (c *RalphLoopClient) ExportHistory(outputPath string) error {
 // LoadHistoryFromDisk 從磁盤載入歷史記錄
 //
 // 此方法將從儲存目錄載入所有保存的執行上下文，
-// 並恢復 ContextManager 的狀態。
+// 並恢復 ContextManager 與熔斷器的狀態。
 //
 // 使用時機：
 // - 客戶端初始化後，需要恢復之前的迴圈歷史
@@ -445,14 +1450,28 @@ func (c *RalphLoopClient) LoadHistoryFromDisk() error {
 		return fmt.Errorf("persistence not enabled")
 	}
 
-	// 從磁盤載入 ContextManager (使用預設檔名)
-	loadedManager, err := c.persistence.LoadContextManager("context_manager.json")
+	// 從磁盤載入最新的 ContextManager 存檔
+	filename, err := c.persistence.LatestContextManagerFile()
+	if err != nil {
+		return fmt.Errorf("failed to load context manager: %w", err)
+	}
+	loadedManager, err := c.persistence.LoadContextManager(filename)
 	if err != nil {
 		return fmt.Errorf("failed to load context manager: %w", err)
 	}
 
 	// 使用載入的管理器替換當前的
 	c.contextManager = loadedManager
+
+	// 還原熔斷器，避免重新啟動後忘記已打開的熔斷器（舊的存檔沒有熔斷器狀態時維持原狀）
+	pm := c.persistence
+	if dir := filepath.Dir(filename); dir != pm.GetStorageDir() {
+		// 存檔來自最近的執行子目錄（PerRunSaveDir）
+		pm = pm.withStorageDir(dir)
+	}
+	if _, err := pm.LoadBreakerState(c.defaultBreaker()); err != nil {
+		return fmt.Errorf("failed to load circuit breaker state: %w", err)
+	}
 	return nil
 }
 
@@ -474,14 +1493,20 @@ func (c *RalphLoopClient) SaveHistoryToDisk() error {
 	}
 
 	// 保存 ContextManager
-	if err := c.persistence.SaveContextManager(c.contextManager); err != nil {
+	if err := c.persistence.SaveContextManager(sampledContextManager(c.contextManager, c.config.OutputSamplingPolicy)); err != nil {
 		return fmt.Errorf("failed to save context manager: %w", err)
 	}
+	if err := c.saveBreakerState(); err != nil {
+		return fmt.Errorf("failed to save circuit breaker state: %w", err)
+	}
 
 	// 同時保存當前迴圈（如果有）
-	if len(c.contextManager.GetLoopHistory()) > 0 {
-		lastLoop := c.contextManager.GetLoopByIndex(len(c.contextManager.GetLoopHistory()) - 1)
+	if history := c.contextManager.GetLoopHistory(); len(history) > 0 {
+		lastLoop := history[len(history)-1]
 		if lastLoop != nil {
+			if !c.config.OutputSamplingPolicy.Keep(lastLoop, len(history)-1, len(history)) {
+				lastLoop = omitOutput(lastLoop)
+			}
 			if err := c.persistence.SaveExecutionContext(lastLoop); err != nil {
 				// 不影響主流程，只記錄警告
 				return fmt.Errorf("warning: failed to save last execution context: %w", err)
@@ -509,10 +1534,21 @@ func (c *RalphLoopClient) GetPersistenceStats() map[string]interface{} {
 
 	stats["enabled"] = true
 	stats["storage_dir"] = c.persistence.GetStorageDir()
+	if c.runID != "" {
+		stats["run_id"] = c.runID
+	}
+	stats["disk_full"] = c.diskFullNotified
+	stats["disabled"] = c.persistenceDisabled
 	stats["format"] = "json"
 	if c.config.UseGobFormat {
 		stats["format"] = "gob"
 	}
+	stats["serialized"] = c.writer != nil
+	if c.writer != nil {
+		writes, coalesced := c.writer.Stats()
+		stats["serialized_writes"] = writes
+		stats["coalesced_saves"] = coalesced
+	}
 
 	// 列出已保存的上下文
 	savedContexts, err := c.persistence.ListSavedContexts()
@@ -686,20 +1722,35 @@ func (c *RalphLoopClient) VerifyStateConsistency() (bool, error) {
 }
 
 // Close 關閉客戶端並清理資源
+//
+// Close 是冪等的：第一次呼叫會執行清理並回傳其中發生的錯誤，
+// 之後的呼叫直接回傳 nil，因此可以安全地同時使用 defer client.Close() 與明確關閉。
 func (c *RalphLoopClient) Close() error {
 	if c.closed {
-		return fmt.Errorf("client already closed")
+		return nil
 	}
 
 	var errs []error
 
-	// 執行最後的持久化
-	if c.persistence != nil && c.config.EnablePersistence {
-		if err := c.persistence.SaveContextManager(c.contextManager); err != nil {
+	// 先寫完背景寫入器中排隊的請求，再執行最後的持久化
+	if c.writer != nil {
+		c.writer.Close()
+	}
+
+	// 執行最後的持久化（磁碟空間不足而停用時略過）
+	if c.persistence != nil && c.config.EnablePersistence && !c.persistenceDisabled {
+		if err := c.persistence.SaveContextManager(sampledContextManager(c.contextManager, c.config.OutputSamplingPolicy)); err != nil {
 			errs = append(errs, fmt.Errorf("儲存上下文管理器失敗: %w", err))
 		}
 	}
 
+	// 儲存重試分類器學到的統計
+	if c.retryClassifier != nil && c.config.EnablePersistence && !c.persistenceDisabled {
+		if err := c.retryClassifier.Save(c.retryStatsPath()); err != nil {
+			errs = append(errs, fmt.Errorf("儲存重試統計失敗: %w", err))
+		}
+	}
+
 	// 關閉 SDK 執行器
 	if c.sdkExecutor != nil {
 		if err := c.sdkExecutor.Close(); err != nil {
@@ -855,25 +1906,123 @@ func (c *RalphLoopClient) TerminateSDKSession(sessionID string) error {
 
 func (c *RalphLoopClient) createResult(execCtx *ExecutionContext, shouldContinue bool) *LoopResult {
 	return &LoopResult{
-		LoopID:          execCtx.LoopID,
-		LoopIndex:       execCtx.LoopIndex,
-		ShouldContinue:  shouldContinue,
-		CompletionScore: execCtx.CompletionScore,
-		Output:          execCtx.CLIOutput,
-		ExitReason:      execCtx.ExitReason,
-		Timestamp:       execCtx.Timestamp,
+		LoopID:               execCtx.LoopID,
+		LoopIndex:            execCtx.LoopIndex,
+		ShouldContinue:       shouldContinue,
+		CompletionScore:      execCtx.CompletionScore,
+		Output:               execCtx.CLIOutput,
+		ExitReason:           execCtx.ExitReason,
+		Timestamp:            execCtx.Timestamp,
+		Approval:             execCtx.ApprovalDecision,
+		ExitOutcome:          execCtx.ExitOutcome,
+		Warnings:             execCtx.Warnings,
+		Decision:             execCtx.Decision,
+		Confidence:           execCtx.Confidence,
+		Verify:               execCtx.Verify,
+		Model:                execCtx.Model,
+		ServedModel:          execCtx.ServedModel,
+		Usage:                execCtx.Usage,
+		Escalated:            execCtx.Metadata["model_escalated"] == true,
+		PromptPrefixChars:    execCtx.PromptPrefixChars,
+		CodeBlockCount:       execCtx.CodeBlockCount,
+		RenderedCommand:      execCtx.RenderedCommand,
+		Question:             execCtx.Question,
+		GaveUpPhrase:         execCtx.GaveUpPhrase,
+		PromptSafetyHits:     execCtx.PromptSafetyHits,
+		SecretLeak:           execCtx.SecretDetector,
+		Artifacts:            execCtx.Artifacts,
+		FocusFilesChanged:    execCtx.FocusFilesChanged,
+		FocusMissLoops:       execCtx.FocusMissLoops,
+		WrapUp:               execCtx.WrapUp,
+		TooManyCodeBlocks:    execCtx.Metadata["code_blocks_exceeded"] == true,
+		FilesChanged:         execCtx.FilesChanged,
+		ChangeBudgetExceeded: execCtx.ChangeBudgetExceeded,
+		RepeatedToolFailure:  execCtx.RepeatedToolFailure,
+		ObservationViolation: execCtx.ObservationViolation,
+	}
+}
+
+// exitOutcome 依 ExitCodeMap 解讀退出碼
+func (c *RalphLoopClient) exitOutcome(code int) ExitOutcome {
+	if outcome, ok := c.config.ExitCodeMap[code]; ok {
+		return outcome
+	}
+	if code == 0 {
+		return ExitOutcomeSuccess
 	}
+	return ExitOutcomeFailure
+}
+
+// finishResult 建立迴圈結果並套用 CompletionOverride
+func (c *RalphLoopClient) finishResult(execCtx *ExecutionContext, shouldContinue bool) *LoopResult {
+	if execCtx.Decision != nil {
+		execCtx.Decision.ShouldContinue = shouldContinue
+		execCtx.Decision.Reason = execCtx.ExitReason
+	}
+	c.collectArtifacts(execCtx)
+	result := c.createResult(execCtx, shouldContinue)
+	if c.config.CompletionOverride == nil {
+		return result
+	}
+
+	override, completed, reason := c.config.CompletionOverride(result)
+	if !override {
+		return result
+	}
+
+	result.ShouldContinue = !completed
+	result.Overridden = true
+	if reason != "" {
+		result.ExitReason = reason
+	}
+	execCtx.ShouldContinue = result.ShouldContinue
+	execCtx.ExitReason = result.ExitReason
+	execCtx.Metadata["completion_overridden"] = true
+	if execCtx.Decision != nil {
+		execCtx.Decision.Overridden = true
+		execCtx.Decision.ShouldContinue = result.ShouldContinue
+		execCtx.Decision.Reason = result.ExitReason
+	}
+	infoLog("🔀 迴圈決策被 CompletionOverride 改寫: 繼續=%v, 原因=%s", result.ShouldContinue, result.ExitReason)
+	return result
 }
 
 // LoopResult 表示單個迴圈的結果
 type LoopResult struct {
-	LoopID          string
-	LoopIndex       int
-	ShouldContinue  bool
-	CompletionScore int
-	Output          string
-	ExitReason      string
-	Timestamp       time.Time
+	LoopID               string
+	LoopIndex            int
+	ShouldContinue       bool
+	CompletionScore      int
+	Output               string
+	ExitReason           string
+	Timestamp            time.Time
+	Approval             *ApprovalDecision   // 外部審核結果（未啟用時為 nil）
+	Overridden           bool                // 決策是否被 CompletionOverride 改寫
+	ExitOutcome          ExitOutcome         // CLI 退出碼對應的處理方式
+	Warnings             []string            // 非致命問題（例如降級、stderr 輸出），不影響決策
+	Decision             *CompletionDecision // 完成判定的依據（未進行判定時為 nil）
+	Confidence           *int                // 模型自評的信心 0-100（未啟用 RequestConfidence 或未回報時為 nil）
+	Verify               *VerifyResult       // 驗證命令的結果（未設定 VerifyCommand 或未判定完成時為 nil）
+	Model                string              // 此迴圈使用的模型
+	ServedModel          string              // 輸出回報的實際模型（沒有回報時為空）
+	Usage                *ModelUsage         // 輸出回報的 token 用量（沒有回報時為 nil）
+	Escalated            bool                // 此迴圈是否使用升級後的模型（EscalateModel）
+	PromptPrefixChars    int                 // 加在 prompt 前面的模型前綴字元數（ModelPromptPrefixes，沒有時為 0）
+	CodeBlockCount       int                 // 輸出中的程式碼區塊數
+	RenderedCommand      string              // 實際執行的完整 CLI 命令（SDK 模式時為空）
+	Question             *ModelQuestion      // 偵測到的模型提問（未設定 OnModelQuestion 或沒有提問時為 nil）
+	GaveUpPhrase         string              // 觸發結束的放棄語句（FailurePhrases，沒有時為空）
+	PromptSafetyHits     []PromptSafetyHit   // prompt 命中的安全規則（PromptSafetyRules，沒有時為 nil）
+	SecretLeak           string              // 輸出中命中的敏感資料偵測器名稱（沒有時為空）
+	Artifacts            []string            // 本迴圈保存的產出檔案（相對路徑，未設定 ArtifactGlobs 時為 nil）
+	FocusFilesChanged    []string            // 本迴圈修改的 FocusFiles
+	FocusMissLoops       int                 // 連續沒有修改 FocusFiles 的迴圈數（未設定 FocusFiles 時為 0）
+	WrapUp               bool                // 是否為附加 FinalLoopInstruction 的收尾迴圈
+	TooManyCodeBlocks    bool                // 程式碼區塊數是否超過 MaxCodeBlocksPerLoop
+	FilesChanged         int                 // 到此迴圈為止本次執行修改過的不同檔案數（設定 MaxChangedFiles 時）
+	ChangeBudgetExceeded bool                // 是否因超過 MaxChangedFiles 而中止
+	RepeatedToolFailure  string              // 反覆以相同方式失敗而中止執行的命令（MaxIdenticalToolFailures，沒有時為空）
+	ObservationViolation []string            // 觀察模式下被修改的檔案（沒有時為 nil）
 }
 
 // ClientStatus 表示客戶端的當前狀態
@@ -882,7 +2031,16 @@ type ClientStatus struct {
 	Closed              bool
 	CircuitBreakerOpen  bool
 	CircuitBreakerState CircuitBreakerState
+	TagBreakers         map[string]CircuitBreakerState // 各標籤熔斷器的狀態（PerTagCircuitBreakers，沒有時為 nil）
+	OpenBreakerTags     []string                       // 熔斷器已打開的標籤
 	LoopsExecuted       int
+	Paused              bool       // 是否已被 Pause 暫停
+	Warnings            []string   // 建立客戶端時降級處理的問題（例如 SaveDir 無法寫入而停用持久化）
+	BreakerAutoResets   int        // 本次執行中熔斷器自動重置的次數
+	RecoveryAttempts    int        // 本次執行中的恢復次數
+	ModelEscalatedAt    int        // 本次執行中改用 EscalateModel 的迴圈編號（0 表示未升級）
+	FilesChanged        int        // 本次執行修改過的不同檔案數（設定 MaxChangedFiles 時）
+	ExecutionModes      []ModeInfo // 各執行模式的可用狀態
 	Summary             map[string]interface{}
 }
 
@@ -916,6 +2074,13 @@ func (b *ClientBuilder) WithWorkDir(dir string) *ClientBuilder {
 	return b
 }
 
+// WithEscalation 設定執行 afterLoops 個迴圈仍未完成時改用的較強模型
+func (b *ClientBuilder) WithEscalation(afterLoops int, model Model) *ClientBuilder {
+	b.config.EscalateAfterLoops = afterLoops
+	b.config.EscalateModel = model
+	return b
+}
+
 // WithModel 設定 AI 模型
 func (b *ClientBuilder) WithModel(model string) *ClientBuilder {
 	b.config.Model = model
@@ -934,18 +2099,42 @@ func (b *ClientBuilder) WithMaxHistory(size int) *ClientBuilder {
 	return b
 }
 
+// WithMaxTokenBudget 設定歷史記錄的 token 上限
+func (b *ClientBuilder) WithMaxTokenBudget(tokens int) *ClientBuilder {
+	b.config.MaxTokenBudget = tokens
+	return b
+}
+
+// WithHistorySpill 超過最大歷史記錄的迴圈寫入磁碟而不是捨棄
+func (b *ClientBuilder) WithHistorySpill(enabled bool) *ClientBuilder {
+	b.config.SpillHistoryToDisk = enabled
+	return b
+}
+
 // WithGobFormat 啟用 Gob 格式
 func (b *ClientBuilder) WithGobFormat(enabled bool) *ClientBuilder {
 	b.config.UseGobFormat = enabled
 	return b
 }
 
+// WithDiskFullPolicy 設定持久化遇到磁碟空間不足時的處理方式
+func (b *ClientBuilder) WithDiskFullPolicy(policy DiskFullPolicy) *ClientBuilder {
+	b.config.DiskFullPolicy = policy
+	return b
+}
+
 // WithoutPersistence 禁用持久化
 func (b *ClientBuilder) WithoutPersistence() *ClientBuilder {
 	b.config.EnablePersistence = false
 	return b
 }
 
+// WithCompletionOverride 設定迴圈決策改寫 hook
+func (b *ClientBuilder) WithCompletionOverride(fn func(result *LoopResult) (override bool, completed bool, reason string)) *ClientBuilder {
+	b.config.CompletionOverride = fn
+	return b
+}
+
 // Build 建立客戶端
 func (b *ClientBuilder) Build() *RalphLoopClient {
 	return NewRalphLoopClientWithConfig(b.config)
@@ -2,7 +2,10 @@ package ghcopilot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -139,10 +142,10 @@ func TestClientClose(t *testing.T) {
 		t.Error("Client 應已關閉")
 	}
 
-	// 再次關閉應失敗
+	// 再次關閉應為 no-op
 	err = client.Close()
-	if err == nil {
-		t.Error("再次關閉應傳回錯誤")
+	if err != nil {
+		t.Errorf("再次關閉應傳回 nil，但為 %v", err)
 	}
 }
 
@@ -211,6 +214,39 @@ func TestGetStatus_CircuitBreakerOpen(t *testing.T) {
 	}
 }
 
+// TestGetStatus_InitWarnings 測試 SaveDir 無法寫入時停用持久化，並在狀態中回報原因
+func TestGetStatus_InitWarnings(t *testing.T) {
+	// 以一般檔案擋住 SaveDir 的上層目錄（以 root 執行時權限設定無效）
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultClientConfig()
+	config.SaveDir = filepath.Join(blocker, "saves")
+	config.SpillHistoryToDisk = true
+	client := NewRalphLoopClientWithConfig(config)
+	defer client.Close()
+
+	if client.persistence != nil {
+		t.Fatal("SaveDir 無法建立時應停用持久化")
+	}
+	warnings := client.GetStatus().Warnings
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "持久化管理器初始化失敗") || !strings.Contains(warnings[1], "SpillHistoryToDisk") {
+		t.Errorf("應回報持久化失敗與其影響: %q", warnings)
+	}
+
+	warnings[0] = "已修改"
+	if client.GetStatus().Warnings[0] == "已修改" {
+		t.Error("GetStatus 應傳回警告的副本")
+	}
+
+	config = DefaultClientConfig()
+	config.EnablePersistence = false
+	if w := NewRalphLoopClientWithConfig(config).GetStatus().Warnings; len(w) != 0 {
+		t.Errorf("正常建立時不應有警告: %q", w)
+	}
+}
+
 // TestClientConfiguration 測試客戶端配置應用
 func TestClientConfiguration(t *testing.T) {
 	config := &ClientConfig{
@@ -324,6 +360,70 @@ func TestLoadHistoryFromDisk(t *testing.T) {
 	}
 }
 
+// TestPerRunSaveDir 測試多次執行共用 SaveDir 時不會互相覆蓋歷史
+func TestPerRunSaveDir(t *testing.T) {
+	saveDir := t.TempDir()
+
+	newRun := func(runID string, loops int) *RalphLoopClient {
+		config := DefaultClientConfig()
+		config.SaveDir = saveDir
+		config.PerRunSaveDir = true
+		config.RunID = runID
+		client := NewRalphLoopClientWithConfig(config)
+		for i := 0; i < loops; i++ {
+			client.contextManager.StartLoop(i, fmt.Sprintf("%s 提示 %d", runID, i))
+			client.contextManager.FinishLoop()
+		}
+		if err := client.SaveHistoryToDisk(); err != nil {
+			t.Fatalf("保存 %s 歷史失敗: %v", runID, err)
+		}
+		return client
+	}
+
+	first := newRun("run-a", 1)
+	first.Close()
+	second := newRun("run-b", 3)
+	second.Close()
+
+	if second.RunID() != "run-b" {
+		t.Errorf("RunID 應為 run-b，但為 %q", second.RunID())
+	}
+	if stats := second.GetPersistenceStats(); stats["run_id"] != "run-b" {
+		t.Errorf("持久化統計應包含 run_id，但為 %v", stats["run_id"])
+	}
+
+	// 各執行的歷史都保留在自己的子目錄
+	for runID, want := range map[string]int{"run-a": 1, "run-b": 3} {
+		pm, err := NewPersistenceManager(filepath.Join(saveDir, runID), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := pm.LatestContextManagerFile()
+		if err != nil {
+			t.Fatalf("%s 應有存檔: %v", runID, err)
+		}
+		cm, err := pm.LoadContextManager(file)
+		if err != nil {
+			t.Fatalf("載入 %s 失敗: %v", runID, err)
+		}
+		if got := len(cm.GetLoopHistory()); got != want {
+			t.Errorf("%s 應有 %d 個迴圈，但為 %d", runID, want, got)
+		}
+	}
+
+	// 未指定子目錄的客戶端（如 status）會找到最新一次執行
+	config := DefaultClientConfig()
+	config.SaveDir = saveDir
+	reader := NewRalphLoopClientWithConfig(config)
+	defer reader.Close()
+	if err := reader.LoadHistoryFromDisk(); err != nil {
+		t.Fatalf("應能載入最新執行的歷史: %v", err)
+	}
+	if got := len(reader.contextManager.GetLoopHistory()); got != 3 {
+		t.Errorf("應載入 run-b 的 3 個迴圈，但為 %d", got)
+	}
+}
+
 // TestGetPersistenceStats 測試取得持久化統計
 func TestGetPersistenceStats(t *testing.T) {
 	client := NewRalphLoopClient()
@@ -591,3 +691,539 @@ func TestVerifyStateWithoutPersistence(t *testing.T) {
 		t.Error("應該在禁用持久化時拒絕驗證")
 	}
 }
+
+// newScriptedClient 建立以固定輸出取代 CLI 的測試客戶端
+func newScriptedClient(config *ClientConfig, stdout string) *RalphLoopClient {
+	client, _ := newStepClient(config, func(int, string) (string, error) { return stdout, nil })
+	return client
+}
+
+// newStepClient 建立以 step 取代 CLI 的測試客戶端（不寫入磁碟、靜默），傳回依序記錄每次 prompt 的切片
+//
+// step 收到第幾次呼叫（從 1 起算）與送出的 prompt，傳回該次的 stdout 或錯誤；
+// 可在其中修改工作目錄，模擬模型的編輯。
+func newStepClient(config *ClientConfig, step func(call int, prompt string) (string, error)) (*RalphLoopClient, *[]string) {
+	config.EnablePersistence = false
+	config.Silent = true
+	client := NewRalphLoopClientWithConfig(config)
+	var prompts []string
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		prompts = append(prompts, prompt)
+		stdout, err := step(len(prompts), prompt)
+		if err != nil {
+			return nil, err
+		}
+		return &ExecutionResult{Command: "copilot", Stdout: stdout}, nil
+	}
+	return client, &prompts
+}
+
+// doneOutput 宣告任務完成的 CLI 輸出
+const doneOutput = "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: done\n---END_RALPH_STATUS---"
+
+// doneStep 每次都回傳完成輸出的 step
+func doneStep(call int, prompt string) (string, error) {
+	return doneOutput, nil
+}
+
+// pendingOutput 以 text 為內容、尚未完成的 CLI 輸出
+func pendingOutput(text string) string {
+	return text + "\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---"
+}
+
+// progressStep 每次回傳標示迴圈次數、尚未完成的輸出
+func progressStep(call int, prompt string) (string, error) {
+	return pendingOutput(fmt.Sprintf("迴圈 %d 進行中", call)), nil
+}
+
+// failingStep 前 failures 次呼叫回傳錯誤、之後完成的 step（failures < 0 表示永遠失敗）
+func failingStep(failures int) func(int, string) (string, error) {
+	return func(call int, prompt string) (string, error) {
+		if failures < 0 || call <= failures {
+			return "", fmt.Errorf("service unavailable")
+		}
+		return doneOutput, nil
+	}
+}
+
+// TestCompletionOverrideForceComplete 測試 hook 強制完成
+func TestCompletionOverrideForceComplete(t *testing.T) {
+	config := DefaultClientConfig()
+	config.CompletionOverride = func(result *LoopResult) (bool, bool, string) {
+		return true, true, "外部測試通過"
+	}
+	client := newScriptedClient(config, "還在處理\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "test", 5)
+	if err != nil {
+		t.Fatalf("強制完成不應回傳錯誤: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("應在第 1 個迴圈完成，但執行了 %d 個", len(results))
+	}
+	if results[0].ShouldContinue || !results[0].Overridden {
+		t.Error("結果應被改寫為完成")
+	}
+	if results[0].ExitReason != "外部測試通過" {
+		t.Errorf("結束原因應為 hook 提供的原因，但為 %q", results[0].ExitReason)
+	}
+	if history := client.GetHistory(); history[0].ShouldContinue {
+		t.Error("歷史記錄也應反映改寫後的決策")
+	}
+}
+
+// TestCompletionOverrideForceContinue 測試 hook 強制繼續
+func TestCompletionOverrideForceContinue(t *testing.T) {
+	calls := 0
+	config := DefaultClientConfig()
+	config.CompletionOverride = func(result *LoopResult) (bool, bool, string) {
+		calls++
+		if calls < 2 {
+			return true, false, "外部測試失敗"
+		}
+		return false, false, ""
+	}
+	client := newScriptedClient(config, "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: done\n---END_RALPH_STATUS---")
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "test", 5)
+	if err != nil {
+		t.Fatalf("不應回傳錯誤: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("應執行 2 個迴圈，但為 %d", len(results))
+	}
+	if !results[0].ShouldContinue || results[0].ExitReason != "外部測試失敗" {
+		t.Error("第 1 個迴圈應被改寫為繼續")
+	}
+	if results[1].ShouldContinue || results[1].Overridden {
+		t.Error("第 2 個迴圈應維持內建的完成判定")
+	}
+}
+
+// TestExitCodeMap 測試退出碼對應的迴圈處理方式
+func TestExitCodeMap(t *testing.T) {
+	const completeOutput = "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: done\n---END_RALPH_STATUS---"
+
+	tests := []struct {
+		name         string
+		exitCodeMap  map[int]ExitOutcome
+		exitCode     int
+		stdout       string
+		wantOutcome  ExitOutcome
+		wantContinue bool
+		wantErrors   bool
+	}{
+		{"預設 0 為成功", nil, 0, completeOutput, ExitOutcomeSuccess, false, false},
+		{"預設非 0 無輸出為失敗", nil, 2, "", ExitOutcomeFailure, true, true},
+		{"預設非 0 有輸出仍解析", nil, 2, completeOutput, ExitOutcomeFailure, false, false},
+		{"對應成功", map[int]ExitOutcome{3: ExitOutcomeSuccess}, 3, completeOutput, ExitOutcomeSuccess, false, false},
+		{"對應重試", map[int]ExitOutcome{3: ExitOutcomeRetry}, 3, completeOutput, ExitOutcomeRetry, true, false},
+		{"對應需要輸入", map[int]ExitOutcome{4: ExitOutcomeNeedsInput}, 4, "請問要用哪個資料庫？", ExitOutcomeNeedsInput, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultClientConfig()
+			config.EnablePersistence = false
+			config.Silent = true
+			config.ExitCodeMap = tt.exitCodeMap
+
+			client := NewRalphLoopClientWithConfig(config)
+			client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+				return &ExecutionResult{Command: "copilot", Stdout: tt.stdout, ExitCode: tt.exitCode}, nil
+			}
+
+			result, err := client.ExecuteLoop(context.Background(), "test")
+			if err != nil {
+				t.Fatalf("ExecuteLoop 不應失敗: %v", err)
+			}
+			if result.ExitOutcome != tt.wantOutcome {
+				t.Errorf("ExitOutcome 應為 %s，但為 %s", tt.wantOutcome, result.ExitOutcome)
+			}
+			if result.ShouldContinue != tt.wantContinue {
+				t.Errorf("ShouldContinue 應為 %v，但為 %v (%s)", tt.wantContinue, result.ShouldContinue, result.ExitReason)
+			}
+			if gotErrors := client.breaker.sameErrorLoops > 0; gotErrors != tt.wantErrors {
+				t.Errorf("熔斷器錯誤記錄應為 %v，但為 %v", tt.wantErrors, gotErrors)
+			}
+		})
+	}
+}
+
+// TestAutoResetBreakerRecovers 測試暫時性中斷在冷卻後恢復
+func TestAutoResetBreakerRecovers(t *testing.T) {
+	t.Chdir(t.TempDir()) // 熔斷器狀態檔寫在當前目錄
+	config := DefaultClientConfig()
+	config.AutoResetBreakerAfter = 10 * time.Millisecond
+	config.MaxBreakerAutoResets = 1
+	client, _ := newStepClient(config, failingStep(5)) // 5 次相同錯誤打開熔斷器
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "test", 10)
+	if err != nil {
+		t.Fatalf("冷卻後應恢復並完成: %v", err)
+	}
+	if len(results) != 6 {
+		t.Errorf("應執行 6 個迴圈，但為 %d", len(results))
+	}
+	status := client.GetStatus()
+	if status.BreakerAutoResets != 1 {
+		t.Errorf("應自動重置 1 次，但為 %d", status.BreakerAutoResets)
+	}
+	if status.CircuitBreakerState != StateClosed {
+		t.Errorf("成功後熔斷器應關閉，但為 %s", status.CircuitBreakerState)
+	}
+}
+
+// TestAutoResetBreakerCap 測試自動重置次數上限
+func TestAutoResetBreakerCap(t *testing.T) {
+	t.Chdir(t.TempDir()) // 熔斷器狀態檔寫在當前目錄
+	config := DefaultClientConfig()
+	config.AutoResetBreakerAfter = 10 * time.Millisecond
+	config.MaxBreakerAutoResets = 1
+	client, _ := newStepClient(config, failingStep(-1))
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "test", 20)
+	if err == nil || !strings.Contains(err.Error(), "circuit breaker opened") {
+		t.Fatalf("達到上限後應因熔斷器中止，但為 %v", err)
+	}
+	// 5 次錯誤打開 → 重置 1 次 → 半開試探失敗立即重新打開
+	if len(results) != 6 {
+		t.Errorf("應執行 6 個迴圈，但為 %d", len(results))
+	}
+	if client.GetStatus().BreakerAutoResets != 1 {
+		t.Errorf("自動重置次數應為 1，但為 %d", client.GetStatus().BreakerAutoResets)
+	}
+}
+
+// TestModelEscalation 測試連續未完成後改用較強的模型，且只有升級後才能完成
+func TestModelEscalation(t *testing.T) {
+	config := DefaultClientConfig()
+	config.Model = string(ModelClaudeHaiku45)
+	config.EscalateAfterLoops = 2
+	config.EscalateModel = ModelClaudeOpus45
+	client := newScriptedClient(config, "")
+
+	var models []Model
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		model := client.executor.options.Model
+		models = append(models, model)
+		if model != ModelClaudeOpus45 {
+			return &ExecutionResult{Command: "copilot", Stdout: fmt.Sprintf("嘗試 %d\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---", len(models))}, nil
+		}
+		return &ExecutionResult{Command: "copilot", Stdout: "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: done\n---END_RALPH_STATUS---"}, nil
+	}
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "test", 5)
+	if err != nil {
+		t.Fatalf("升級模型後應完成: %v", err)
+	}
+	want := []Model{ModelClaudeHaiku45, ModelClaudeHaiku45, ModelClaudeOpus45}
+	if fmt.Sprint(models) != fmt.Sprint(want) {
+		t.Fatalf("模型順序應為 %v，但為 %v", want, models)
+	}
+	if results[1].Escalated || results[1].Model != string(ModelClaudeHaiku45) {
+		t.Errorf("升級前的迴圈不應標記為升級: %+v", results[1])
+	}
+	last := results[len(results)-1]
+	if !last.Escalated || last.Model != string(ModelClaudeOpus45) {
+		t.Errorf("最後一個迴圈應使用升級後的模型: escalated=%v model=%s", last.Escalated, last.Model)
+	}
+	if got := client.GetStatus().ModelEscalatedAt; got != 3 {
+		t.Errorf("應在第 3 個迴圈升級，但為 %d", got)
+	}
+
+	// 下一次執行還原為設定的模型
+	models = nil
+	if _, err := client.ExecuteUntilCompletion(context.Background(), "test", 1); err == nil {
+		t.Fatal("未升級時應無法在 1 個迴圈內完成")
+	}
+	if len(models) != 1 || models[0] != ModelClaudeHaiku45 {
+		t.Errorf("新的執行應從設定的模型開始: %v", models)
+	}
+}
+
+// TestOnBreakerOpenHook 測試熔斷器打開時呼叫 hook 並將其錯誤附加在中止錯誤中
+func TestOnBreakerOpenHook(t *testing.T) {
+	t.Chdir(t.TempDir()) // 熔斷器狀態檔寫在當前目錄
+	config := DefaultClientConfig()
+	config.AutoResetBreakerAfter = 10 * time.Millisecond
+	config.MaxBreakerAutoResets = 0
+	client, _ := newStepClient(config, failingStep(-1))
+
+	var calls int
+	var gotState CircuitBreakerState
+	var gotReason string
+	hookErr := fmt.Errorf("通知失敗")
+	client.config.OnBreakerOpen = func(state CircuitBreakerState, reason string) error {
+		calls++
+		gotState, gotReason = state, reason
+		return hookErr
+	}
+
+	_, err := client.ExecuteUntilCompletion(context.Background(), "test", 20)
+	if err == nil || !strings.Contains(err.Error(), "circuit breaker opened") {
+		t.Fatalf("應因熔斷器中止，但為 %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("hook 應呼叫 1 次，但為 %d", calls)
+	}
+	if gotState != StateOpen || gotReason != "相同錯誤已出現 5 次" {
+		t.Errorf("hook 參數錯誤: state=%s reason=%q", gotState, gotReason)
+	}
+	if !errors.Is(err, hookErr) || !strings.Contains(err.Error(), gotReason) {
+		t.Errorf("中止錯誤應包含原因與 hook 錯誤: %v", err)
+	}
+}
+
+// TestOnBreakerOpenHookOnAutoReset 測試自動重置前每次打開都會呼叫 hook
+func TestOnBreakerOpenHookOnAutoReset(t *testing.T) {
+	t.Chdir(t.TempDir()) // 熔斷器狀態檔寫在當前目錄
+	config := DefaultClientConfig()
+	config.AutoResetBreakerAfter = 10 * time.Millisecond
+	config.MaxBreakerAutoResets = 1
+	client, _ := newStepClient(config, failingStep(5))
+
+	var reasons []string
+	client.config.OnBreakerOpen = func(state CircuitBreakerState, reason string) error {
+		reasons = append(reasons, reason)
+		return fmt.Errorf("忽略")
+	}
+
+	if _, err := client.ExecuteUntilCompletion(context.Background(), "test", 10); err != nil {
+		t.Fatalf("自動重置後應完成，hook 錯誤不應中止執行: %v", err)
+	}
+	if len(reasons) != 1 || reasons[0] != "相同錯誤已出現 5 次" {
+		t.Errorf("hook 應以打開原因呼叫 1 次: %v", reasons)
+	}
+}
+
+// TestClientRecordFallbackRecovery 測試記錄 SDK 降級恢復
+func TestClientRecordFallbackRecovery(t *testing.T) {
+	client := NewRalphLoopClient()
+
+	execCtx := client.contextManager.StartLoop(0, "test")
+	client.recordFallbackRecovery(execCtx, fmt.Errorf("sdk not started"), nil, 10*time.Millisecond)
+	if err := client.contextManager.FinishLoop(); err != nil {
+		t.Fatal(err)
+	}
+
+	history := client.GetRecoveryHistory()
+	if len(history) != 1 {
+		t.Fatalf("應有 1 筆恢復記錄，但為 %d", len(history))
+	}
+	if history[0].Strategy != "fallback" || !history[0].Success {
+		t.Errorf("應為成功的 fallback，但為 %+v", history[0])
+	}
+}
+
+// TestLoopWarningsPropagateToSummary 測試非致命問題記錄為警告並彙整到摘要
+func TestLoopWarningsPropagateToSummary(t *testing.T) {
+	config := DefaultClientConfig()
+	config.EnablePersistence = false
+	config.Silent = true
+	client := NewRalphLoopClientWithConfig(config)
+	defer client.Close()
+
+	call := 0
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		call++
+		if call == 1 {
+			// 沒有狀態區塊，且 stderr 有輸出
+			return &ExecutionResult{
+				Command: "copilot",
+				Stdout:  "正在修改檔案",
+				Stderr:  "warning: rate limit nearly reached\nretrying",
+			}, nil
+		}
+		return &ExecutionResult{
+			Command: "copilot",
+			Stdout:  "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: 全部修正\n---END_RALPH_STATUS---",
+		}, nil
+	}
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 5)
+	if err != nil {
+		t.Fatalf("不應回傳錯誤: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("應執行 2 個迴圈，但為 %d", len(results))
+	}
+
+	first := results[0]
+	if len(first.Warnings) != 2 {
+		t.Fatalf("第一個迴圈應有 2 個警告，但為 %v", first.Warnings)
+	}
+	if !strings.Contains(first.Warnings[0], "rate limit nearly reached") || strings.Contains(first.Warnings[0], "retrying") {
+		t.Errorf("stderr 警告應只包含第一行，但為 %q", first.Warnings[0])
+	}
+	if !strings.Contains(first.Warnings[1], "RALPH_STATUS") {
+		t.Errorf("應警告缺少狀態區塊，但為 %q", first.Warnings[1])
+	}
+	if len(results[1].Warnings) != 0 {
+		t.Errorf("第二個迴圈不應有警告，但為 %v", results[1].Warnings)
+	}
+
+	summary := client.GetSummary()
+	if summary["warning_count"] != 2 {
+		t.Errorf("摘要警告數應為 2，但為 %v", summary["warning_count"])
+	}
+	warnings, _ := summary["warnings"].([]string)
+	if len(warnings) != 2 || !strings.HasPrefix(warnings[0], "迴圈 1: ") {
+		t.Errorf("摘要警告應標示迴圈編號，但為 %v", warnings)
+	}
+}
+
+// TestMaxRecoveryAttemptsPerRun 測試達到恢復上限後不再降級 CLI，失敗直接交給熔斷器
+func TestMaxRecoveryAttemptsPerRun(t *testing.T) {
+	t.Chdir(t.TempDir())
+	// PATH 中沒有 copilot，SDK 啟動必定失敗
+	t.Setenv("PATH", t.TempDir())
+
+	config := DefaultClientConfig()
+	config.EnablePersistence = false
+	config.Silent = true
+	config.EnableSDK = true
+	config.PreferSDK = true
+	config.MaxRecoveryAttemptsPerRun = 2
+	client := NewRalphLoopClientWithConfig(config)
+	defer client.Close()
+
+	cliCalls := 0
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		cliCalls++
+		return &ExecutionResult{
+			Command: "copilot",
+			Stdout:  fmt.Sprintf("第 %d 次修改\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---", cliCalls),
+		}, nil
+	}
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 20)
+	if err == nil || !strings.Contains(err.Error(), "circuit breaker") {
+		t.Fatalf("達到上限後應由熔斷器中止，但錯誤為 %v", err)
+	}
+	if cliCalls != 2 {
+		t.Errorf("只應降級 CLI 2 次，但為 %d", cliCalls)
+	}
+	if got := len(client.GetRecoveryHistory()); got != 2 {
+		t.Errorf("應有 2 筆恢復記錄，但為 %d", got)
+	}
+	if status := client.GetStatus(); status.RecoveryAttempts != 2 {
+		t.Errorf("狀態中的恢復次數應為 2，但為 %d", status.RecoveryAttempts)
+	}
+	last := results[len(results)-1]
+	if !strings.Contains(last.ExitReason, "已達恢復上限") {
+		t.Errorf("結束原因應說明已達恢復上限，但為 %q", last.ExitReason)
+	}
+}
+
+// TestRequestConfidenceGatesExit 測試自評信心低於門檻時不結束
+func TestRequestConfidenceGatesExit(t *testing.T) {
+	config := DefaultClientConfig()
+	config.EnablePersistence = false
+	config.Silent = true
+	config.RequestConfidence = true
+	config.MinExitConfidence = 70
+	client := NewRalphLoopClientWithConfig(config)
+	defer client.Close()
+
+	confidences := []string{"40%", "90%"}
+	call := 0
+	var prompts []string
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		prompts = append(prompts, prompt)
+		out := "已修正\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: 完成\nCONFIDENCE: " + confidences[call] + "\n---END_RALPH_STATUS---"
+		call++
+		return &ExecutionResult{Command: "copilot", Stdout: out}, nil
+	}
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 5)
+	if err != nil {
+		t.Fatalf("不應回傳錯誤: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("低信心的迴圈應繼續，共 2 個迴圈，但為 %d", len(results))
+	}
+	if !strings.Contains(prompts[0], "CONFIDENCE:") {
+		t.Error("prompt 應要求回報 CONFIDENCE")
+	}
+
+	first := results[0]
+	if first.Confidence == nil || *first.Confidence != 40 {
+		t.Fatalf("第一個迴圈信心應為 40，但為 %v", first.Confidence)
+	}
+	if !first.ShouldContinue || !strings.Contains(first.ExitReason, "低於門檻") {
+		t.Errorf("低信心應繼續並說明原因，但為 %v / %q", first.ShouldContinue, first.ExitReason)
+	}
+	if first.Decision == nil || !first.Decision.LowConfidence {
+		t.Error("判定依據應標示信心不足")
+	}
+
+	second := results[1]
+	if second.ShouldContinue || second.Confidence == nil || *second.Confidence != 90 {
+		t.Errorf("高信心應結束，但為 %v / %v", second.ShouldContinue, second.Confidence)
+	}
+}
+
+// TestConfidenceNotRecordedByDefault 測試未啟用 RequestConfidence 時不記錄也不阻擋
+func TestConfidenceNotRecordedByDefault(t *testing.T) {
+	config := DefaultClientConfig()
+	config.MinExitConfidence = 70
+	client := newScriptedClient(config, "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nCONFIDENCE: 10%\n---END_RALPH_STATUS---")
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 3)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("應在第一個迴圈結束: %d 個迴圈, %v", len(results), err)
+	}
+	if results[0].Confidence != nil {
+		t.Errorf("未啟用時不應記錄信心，但為 %d", *results[0].Confidence)
+	}
+}
+
+// TestConfigBreakerThresholds 測試 ClientConfig 的閾值會套用到熔斷器
+func TestConfigBreakerThresholds(t *testing.T) {
+	config := DefaultClientConfig()
+	config.CircuitBreakerThreshold = 4
+	config.SameErrorThreshold = 7
+	client := newScriptedClient(config, "")
+	if np, se := client.breaker.Thresholds(); np != 4 || se != 7 {
+		t.Errorf("熔斷器閾值應為 4/7，實際 %d/%d", np, se)
+	}
+}
+
+// TestSetCircuitBreakerThresholds 測試執行期間將無進展閾值降為 1 後，一個無進展迴圈即打開熔斷器
+func TestSetCircuitBreakerThresholds(t *testing.T) {
+	const stuck = "仍在處理\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---"
+	ctx := context.Background()
+
+	for _, threshold := range []int{0, 1} {
+		client := newScriptedClient(DefaultClientConfig(), stuck)
+		if _, err := client.ExecuteLoop(ctx, "修正錯誤"); err != nil {
+			t.Fatal(err)
+		}
+		client.SetCircuitBreakerThresholds(threshold, 0)
+		if _, err := client.ExecuteLoop(ctx, "修正錯誤"); err != nil {
+			t.Fatal(err)
+		}
+
+		if wantOpen := threshold == 1; client.breaker.IsOpen() != wantOpen {
+			t.Errorf("閾值 %d: 一個無進展迴圈後熔斷器打開應為 %v", threshold, wantOpen)
+		}
+		if threshold == 1 && (client.config.CircuitBreakerThreshold != 1 || client.config.SameErrorThreshold != 5) {
+			t.Errorf("設定應更新為 1/5，實際 %d/%d", client.config.CircuitBreakerThreshold, client.config.SameErrorThreshold)
+		}
+	}
+}
+
+// TestGetCircuitBreakerStats 測試客戶端回報熔斷器的計數
+func TestGetCircuitBreakerStats(t *testing.T) {
+	client := newScriptedClient(DefaultClientConfig(), "仍在處理\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+	for i := 0; i < 2; i++ {
+		if _, err := client.ExecuteLoop(context.Background(), "修正錯誤"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := client.GetCircuitBreakerStats()
+	if stats.State != StateClosed || stats.NoProgressLoops != 1 || stats.NoProgressThreshold != 3 {
+		t.Errorf("應回報 1 個無進展迴圈: %+v", stats)
+	}
+}
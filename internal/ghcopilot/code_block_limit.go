@@ -0,0 +1,37 @@
+package ghcopilot
+
+import "fmt"
+
+// focusInstruction 程式碼區塊超過上限後，下一個迴圈附加的提示
+const focusInstruction = "\n\n[注意] 上一個迴圈輸出了 %d 個程式碼區塊（上限 %d）。" +
+	"請一次只專注於一個變更：完成並驗證後再進行下一個，不要一次貼出大量程式碼。"
+
+// checkCodeBlockLimit 記錄本迴圈的程式碼區塊數，超過 MaxCodeBlocksPerLoop 時加上警告
+//
+// 模型一次輸出大量程式碼區塊通常代表它對任務感到混亂；
+// 超過上限的迴圈會在下一個迴圈的 prompt 附加 focusInstruction。
+func (c *RalphLoopClient) checkCodeBlockLimit(execCtx *ExecutionContext, parser *OutputParser) {
+	execCtx.CodeBlockCount = len(parser.ExtractCodeBlocks())
+
+	limit := c.config.MaxCodeBlocksPerLoop
+	if limit <= 0 || execCtx.CodeBlockCount <= limit {
+		return
+	}
+	execCtx.Metadata["code_blocks_exceeded"] = true
+	execCtx.AddWarning("輸出包含 %d 個程式碼區塊，超過上限 %d，下一個迴圈將要求一次只做一個變更",
+		execCtx.CodeBlockCount, limit)
+}
+
+// focusPromptSuffix 上一個迴圈程式碼區塊超過上限時傳回要附加的提示，否則為空字串
+func (c *RalphLoopClient) focusPromptSuffix() string {
+	limit := c.config.MaxCodeBlocksPerLoop
+	history := c.contextManager.GetLoopHistory()
+	if limit <= 0 || len(history) == 0 {
+		return ""
+	}
+	last := history[len(history)-1]
+	if last.CodeBlockCount <= limit {
+		return ""
+	}
+	return fmt.Sprintf(focusInstruction, last.CodeBlockCount, limit)
+}
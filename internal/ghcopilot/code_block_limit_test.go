@@ -0,0 +1,74 @@
+package ghcopilot
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestMaxCodeBlocksPerLoop 測試程式碼區塊超過上限時的警告與下一個迴圈的提示
+func TestMaxCodeBlocksPerLoop(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("嘗試修改多個檔案\n")
+	for i := 0; i < 6; i++ {
+		sb.WriteString("```go\nfunc f() {}\n```\n")
+	}
+	sb.WriteString("---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+
+	config := DefaultClientConfig()
+	config.MaxCodeBlocksPerLoop = 3
+	client := newScriptedClient(config, "")
+
+	var prompts []string
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		prompts = append(prompts, prompt)
+		return &ExecutionResult{Command: "copilot", Stdout: sb.String()}, nil
+	}
+
+	first, err := client.ExecuteLoop(context.Background(), "修正錯誤")
+	if err != nil {
+		t.Fatalf("ExecuteLoop 不應失敗: %v", err)
+	}
+	if first.CodeBlockCount != 6 || !first.TooManyCodeBlocks {
+		t.Fatalf("應記錄 6 個程式碼區塊並標記超過上限: count=%d flag=%v", first.CodeBlockCount, first.TooManyCodeBlocks)
+	}
+	found := false
+	for _, w := range first.Warnings {
+		if strings.Contains(w, "6 個程式碼區塊") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("應加上程式碼區塊過多的警告: %v", first.Warnings)
+	}
+	if strings.Contains(prompts[0], "一次只專注於一個變更") {
+		t.Error("第一個迴圈不應附加提示")
+	}
+
+	if _, err := client.ExecuteLoop(context.Background(), "修正錯誤"); err != nil {
+		t.Fatalf("ExecuteLoop 不應失敗: %v", err)
+	}
+	if !strings.Contains(prompts[1], "一次只專注於一個變更") {
+		t.Errorf("下一個迴圈應附加提示:\n%s", prompts[1])
+	}
+	if history := client.contextManager.GetLoopHistory(); history[0].CodeBlockCount != 6 {
+		t.Errorf("迴圈記錄應包含程式碼區塊數，但為 %d", history[0].CodeBlockCount)
+	}
+}
+
+// TestMaxCodeBlocksPerLoopDisabled 測試未設定上限時只記錄數量
+func TestMaxCodeBlocksPerLoopDisabled(t *testing.T) {
+	output := "```sh\nls\n```\n```sh\npwd\n```\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---"
+	client := newScriptedClient(DefaultClientConfig(), output)
+
+	result, err := client.ExecuteLoop(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("ExecuteLoop 不應失敗: %v", err)
+	}
+	if result.CodeBlockCount != 2 || result.TooManyCodeBlocks {
+		t.Errorf("未設定上限時只應記錄數量: count=%d flag=%v", result.CodeBlockCount, result.TooManyCodeBlocks)
+	}
+	if suffix := client.focusPromptSuffix(); suffix != "" {
+		t.Errorf("未設定上限時不應附加提示: %q", suffix)
+	}
+}
@@ -0,0 +1,142 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// fallbackScoreThreshold 沒有 EXIT_SIGNAL 時，自然語言判定完成所需的最低分數
+	fallbackScoreThreshold = 30
+	// fallbackMinIndicators 沒有 EXIT_SIGNAL 時，自然語言判定完成所需的最少指標數
+	fallbackMinIndicators = 2
+)
+
+// ScoreContribution 完成分數中單一指標的貢獻
+type ScoreContribution struct {
+	Indicator string `json:"indicator"`
+	Points    int    `json:"points"`
+	Detail    string `json:"detail"`
+}
+
+// CompletionDecision 記錄一個迴圈繼續或停止的判定依據
+//
+// 分析器填入分數與 EXIT_SIGNAL 相關欄位，客戶端再補上卡住偵測、
+// 無變更偵測、外部審核與 CompletionOverride 的結果。
+type CompletionDecision struct {
+	// 分析器判定
+	ParserProfile  string              `json:"parser_profile,omitempty"` // 使用的解析設定（cli / sdk）
+	HasStatusBlock bool                `json:"has_status_block"`         // 輸出是否包含 RALPH_STATUS 區塊
+	ExitSignal     bool                `json:"exit_signal"`              // EXIT_SIGNAL 是否為 true
+	Score          int                 `json:"score"`                    // 完成分數
+	Contributions  []ScoreContribution `json:"contributions"`            // 各指標對分數的貢獻
+	ScoreRuleMet   bool                `json:"score_rule_met"`           // 是否達到自然語言備用門檻
+	Completed      bool                `json:"completed"`                // 分析器是否判定完成
+
+	// 迴圈層級的判定
+	Stuck             bool   `json:"stuck,omitempty"`               // 是否判定為無進展
+	StuckReason       string `json:"stuck_reason,omitempty"`        // 無進展原因
+	NoChangeLoops     int    `json:"no_change_loops,omitempty"`     // 工作目錄連續無變更的迴圈數
+	NoChangeTriggered bool   `json:"no_change_triggered,omitempty"` // 是否因無變更而停止
+	ApprovalRejected  bool   `json:"approval_rejected,omitempty"`   // 外部審核是否拒絕
+	Overridden        bool   `json:"overridden,omitempty"`          // 是否被 CompletionOverride 改寫
+	Confidence        *int   `json:"confidence,omitempty"`          // 模型自評的信心（如有）
+	LowConfidence     bool   `json:"low_confidence,omitempty"`      // 是否因信心不足而繼續
+	VerifyFailed      bool   `json:"verify_failed,omitempty"`       // 驗證命令是否未通過
+
+	ShouldContinue bool   `json:"should_continue"` // 最終決策
+	Reason         string `json:"reason"`          // 最終原因
+}
+
+// Decide 依目前的回應產生完成判定（只包含分析器層級的欄位）
+func (ra *ResponseAnalyzer) Decide() *CompletionDecision {
+	score := ra.CalculateCompletionScore()
+	status := ra.ParseStructuredOutput()
+
+	decision := &CompletionDecision{
+		ParserProfile:  ra.profile.Name,
+		HasStatusBlock: status != nil,
+		ExitSignal:     status != nil && status.ExitSignal,
+		Score:          score,
+		Contributions:  append([]ScoreContribution(nil), ra.contributions...),
+		ScoreRuleMet:   score >= fallbackScoreThreshold && len(ra.contributions) >= fallbackMinIndicators,
+		Completed:      ra.IsCompleted(),
+	}
+	decision.ShouldContinue = !decision.Completed
+	return decision
+}
+
+// addContribution 記錄一個指標的分數貢獻
+func (ra *ResponseAnalyzer) addContribution(indicator string, points int, detail string) {
+	ra.contributions = append(ra.contributions, ScoreContribution{
+		Indicator: indicator,
+		Points:    points,
+		Detail:    detail,
+	})
+}
+
+// Explain 以縮排區塊說明判定過程
+func (d *CompletionDecision) Explain() string {
+	var sb strings.Builder
+
+	decision := "停止"
+	if d.ShouldContinue {
+		decision = "繼續"
+	}
+	fmt.Fprintf(&sb, "   決策: %s", decision)
+	if d.Reason != "" {
+		fmt.Fprintf(&sb, " (%s)", d.Reason)
+	}
+	sb.WriteString("\n")
+
+	if !d.HasStatusBlock {
+		sb.WriteString("   RALPH_STATUS: 未找到\n")
+	} else {
+		fmt.Fprintf(&sb, "   RALPH_STATUS: EXIT_SIGNAL=%v\n", d.ExitSignal)
+	}
+
+	fmt.Fprintf(&sb, "   完成分數: %d\n", d.Score)
+	for _, c := range d.Contributions {
+		fmt.Fprintf(&sb, "     +%-3d %s - %s\n", c.Points, c.Indicator, c.Detail)
+	}
+	fmt.Fprintf(&sb, "   備用門檻 (分數 >= %d 且指標 >= %d): %s\n",
+		fallbackScoreThreshold, fallbackMinIndicators, explainYesNo(d.ScoreRuleMet))
+	fmt.Fprintf(&sb, "   分析器判定完成: %s\n", explainYesNo(d.Completed))
+
+	if d.Stuck {
+		fmt.Fprintf(&sb, "   無進展: 是 (%s)\n", d.StuckReason)
+	}
+	if d.NoChangeLoops > 0 {
+		fmt.Fprintf(&sb, "   工作目錄連續無變更: %d 個迴圈", d.NoChangeLoops)
+		if d.NoChangeTriggered {
+			sb.WriteString("，已達門檻")
+		}
+		sb.WriteString("\n")
+	}
+	if d.Confidence != nil {
+		fmt.Fprintf(&sb, "   自評信心: %d%%", *d.Confidence)
+		if d.LowConfidence {
+			sb.WriteString("，低於門檻")
+		}
+		sb.WriteString("\n")
+	}
+	if d.VerifyFailed {
+		sb.WriteString("   驗證命令: 未通過\n")
+	}
+	if d.ApprovalRejected {
+		sb.WriteString("   外部審核: 拒絕\n")
+	}
+	if d.Overridden {
+		sb.WriteString("   CompletionOverride: 已改寫決策\n")
+	}
+
+	return sb.String()
+}
+
+// explainYesNo 將布林值轉為說明文字
+func explainYesNo(v bool) string {
+	if v {
+		return "是"
+	}
+	return "否"
+}
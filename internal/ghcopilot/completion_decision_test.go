@@ -0,0 +1,137 @@
+package ghcopilot
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestDecideExitSignal 測試 EXIT_SIGNAL 判定的分數明細
+func TestDecideExitSignal(t *testing.T) {
+	analyzer := NewResponseAnalyzer("全部完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: 測試通過\n---END_RALPH_STATUS---")
+	d := analyzer.Decide()
+
+	if !d.HasStatusBlock || !d.ExitSignal || !d.Completed || d.ShouldContinue {
+		t.Errorf("EXIT_SIGNAL=true 應判定完成: %+v", d)
+	}
+
+	total := 0
+	for _, c := range d.Contributions {
+		total += c.Points
+	}
+	if total != d.Score {
+		t.Errorf("各指標貢獻總和 %d 應等於分數 %d", total, d.Score)
+	}
+	if d.Contributions[0].Indicator != "explicit_exit_signal" || d.Contributions[0].Points != 100 {
+		t.Errorf("第一個貢獻應為 EXIT_SIGNAL (+100)，但為 %+v", d.Contributions[0])
+	}
+}
+
+// TestDecideFallbackScore 測試沒有狀態區塊時的備用門檻
+func TestDecideFallbackScore(t *testing.T) {
+	d := NewResponseAnalyzer("已全部完成，沒有更多工作").Decide()
+	if d.HasStatusBlock {
+		t.Error("不應找到狀態區塊")
+	}
+	if !d.ScoreRuleMet || !d.Completed {
+		t.Errorf("分數 %d 且 %d 個指標應達到備用門檻", d.Score, len(d.Contributions))
+	}
+
+	d = NewResponseAnalyzer(strings.Repeat("正在修改檔案 ", 100)).Decide()
+	if d.ScoreRuleMet || d.Completed || !d.ShouldContinue {
+		t.Errorf("沒有完成指標不應判定完成: %+v", d)
+	}
+}
+
+// TestCompletionDecisionExplain 測試判定說明的內容
+func TestCompletionDecisionExplain(t *testing.T) {
+	d := &CompletionDecision{
+		HasStatusBlock: true,
+		Score:          10,
+		Contributions:  []ScoreContribution{{Indicator: "short_output", Points: 10, Detail: "輸出少於 500 字元"}},
+		Stuck:          true,
+		StuckReason:    "輸出與前一個迴圈完全相同",
+		NoChangeLoops:  2,
+		ShouldContinue: true,
+		Reason:         "仍在處理",
+	}
+
+	text := d.Explain()
+	for _, want := range []string{
+		"決策: 繼續 (仍在處理)",
+		"EXIT_SIGNAL=false",
+		"完成分數: 10",
+		"+10  short_output",
+		"無進展: 是 (輸出與前一個迴圈完全相同)",
+		"工作目錄連續無變更: 2 個迴圈",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("說明應包含 %q，實際為:\n%s", want, text)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if !strings.HasPrefix(line, "   ") {
+			t.Errorf("每一行都應縮排: %q", line)
+		}
+	}
+}
+
+// TestLoopResultDecision 測試迴圈結果帶有判定依據，並反映無進展與 override
+func TestLoopResultDecision(t *testing.T) {
+	config := DefaultClientConfig()
+	config.CompletionOverride = func(result *LoopResult) (bool, bool, string) {
+		if result.LoopIndex == 1 {
+			return true, true, "外部測試通過"
+		}
+		return false, false, ""
+	}
+	client := newScriptedClient(config, strings.Repeat("正在修改檔案 ", 100)+"\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 5)
+	if err != nil {
+		t.Fatalf("不應回傳錯誤: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("應執行 2 個迴圈，但為 %d", len(results))
+	}
+
+	first := results[0].Decision
+	if first == nil || !first.ShouldContinue || first.Stuck {
+		t.Fatalf("第一個迴圈應繼續且未卡住: %+v", first)
+	}
+
+	second := results[1].Decision
+	if second == nil || !second.Stuck {
+		t.Fatalf("第二個迴圈輸出相同，應標示無進展: %+v", second)
+	}
+	if !second.Overridden || second.ShouldContinue || second.Reason != "外部測試通過" {
+		t.Errorf("override 後判定應反映最終決策: %+v", second)
+	}
+}
+
+// TestExplainDecisionsSilent 測試靜默模式不印出判定依據
+func TestExplainDecisionsSilent(t *testing.T) {
+	config := DefaultClientConfig()
+	config.ExplainDecisions = true
+	client := newScriptedClient(config, "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\n---END_RALPH_STATUS---")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	_, runErr := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 1)
+	os.Stdout = stdout
+	w.Close()
+
+	out, _ := io.ReadAll(r)
+	if runErr != nil {
+		t.Fatalf("不應回傳錯誤: %v", runErr)
+	}
+	if strings.Contains(string(out), "判定依據") {
+		t.Errorf("Silent 模式不應印出判定依據，但輸出為:\n%s", out)
+	}
+}
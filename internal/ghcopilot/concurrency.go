@@ -0,0 +1,33 @@
+package ghcopilot
+
+import "errors"
+
+// ConcurrencyPolicy 多個 goroutine 同時對同一個客戶端呼叫執行方法時的處理方式
+type ConcurrencyPolicy string
+
+const (
+	// ConcurrencySerialize 排隊等待前一個呼叫完成（預設）
+	ConcurrencySerialize ConcurrencyPolicy = "serialize"
+	// ConcurrencyReject 已有呼叫在執行時立即回傳 ErrConcurrentExecution
+	ConcurrencyReject ConcurrencyPolicy = "reject"
+)
+
+// ErrConcurrentExecution 客戶端已有執行中的迴圈（ConcurrencyReject 時）
+var ErrConcurrentExecution = errors.New("client is already executing a loop")
+
+// lockExecution 依 ConcurrencyPolicy 取得執行鎖，成功時呼叫端需 unlockExecution
+func (c *RalphLoopClient) lockExecution() error {
+	if c.config != nil && c.config.ConcurrencyPolicy == ConcurrencyReject {
+		if !c.execMu.TryLock() {
+			return ErrConcurrentExecution
+		}
+		return nil
+	}
+	c.execMu.Lock()
+	return nil
+}
+
+// unlockExecution 釋放執行鎖
+func (c *RalphLoopClient) unlockExecution() {
+	c.execMu.Unlock()
+}
@@ -0,0 +1,97 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestConcurrentExecuteLoopSerialized 測試多個 goroutine 同時呼叫 ExecuteLoop 時依序執行
+func TestConcurrentExecuteLoopSerialized(t *testing.T) {
+	client := newScriptedClient(DefaultClientConfig(), "")
+
+	var active, maxActive, calls int32
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		if n > atomic.LoadInt32(&maxActive) {
+			atomic.StoreInt32(&maxActive, n)
+		}
+		time.Sleep(2 * time.Millisecond)
+		call := atomic.AddInt32(&calls, 1)
+		return &ExecutionResult{Command: "copilot", Stdout: fmt.Sprintf("處理第 %d 部分\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---", call)}, nil
+	}
+
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.ExecuteLoop(context.Background(), "修正錯誤"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("ExecuteLoop 不應失敗: %v", err)
+	}
+
+	if maxActive != 1 {
+		t.Errorf("同一時間應只有一個迴圈在執行，但最多有 %d 個", maxActive)
+	}
+	history := client.contextManager.GetLoopHistory()
+	if len(history) != workers {
+		t.Fatalf("應記錄 %d 個迴圈，但有 %d 個", workers, len(history))
+	}
+	for i, loop := range history {
+		if loop.LoopIndex != i {
+			t.Errorf("迴圈序號應連續: 第 %d 筆為 %d", i, loop.LoopIndex)
+		}
+	}
+}
+
+// TestConcurrentExecuteLoopRejected 測試 ConcurrencyReject 時第二個呼叫立即回傳錯誤
+func TestConcurrentExecuteLoopRejected(t *testing.T) {
+	config := DefaultClientConfig()
+	config.ConcurrencyPolicy = ConcurrencyReject
+	client := newScriptedClient(config, "")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		close(started)
+		<-release
+		return &ExecutionResult{Command: "copilot", Stdout: "完成"}, nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 1)
+		done <- err
+	}()
+	<-started
+
+	if _, err := client.ExecuteLoop(context.Background(), "另一個任務"); !errors.Is(err, ErrConcurrentExecution) {
+		t.Errorf("執行中再呼叫 ExecuteLoop 應回傳 ErrConcurrentExecution，但為 %v", err)
+	}
+	if err := client.ResetCircuitBreaker(); !errors.Is(err, ErrConcurrentExecution) {
+		t.Errorf("執行中重置熔斷器應回傳 ErrConcurrentExecution，但為 %v", err)
+	}
+
+	close(release)
+	<-done
+	if len(client.contextManager.GetLoopHistory()) != 1 {
+		t.Error("被拒絕的呼叫不應記錄迴圈")
+	}
+	if err := client.ResetCircuitBreaker(); err != nil {
+		t.Errorf("執行結束後應可重置熔斷器: %v", err)
+	}
+}
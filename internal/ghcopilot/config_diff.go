@@ -0,0 +1,126 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ConfigField 設定欄位的目前值與預設值
+type ConfigField struct {
+	Field   string      `json:"field"` // 欄位路徑，巢狀結構以 "." 連接，例如 "PersistenceRetryPolicy.MaxAttempts"
+	Value   interface{} `json:"value"`
+	Default interface{} `json:"default"`
+}
+
+// configFuncSet、configFuncUnset 函式欄位無法比較內容，只顯示是否設定
+const (
+	configFuncSet   = "(已設定)"
+	configFuncUnset = "(未設定)"
+)
+
+// DiffClientConfig 以反射比較 config 與 DefaultClientConfig()，只傳回不同的欄位（依宣告順序）
+//
+// 巢狀結構（含指標）逐欄比較；函式欄位只比較是否設定。
+func DiffClientConfig(config *ClientConfig) []ConfigField {
+	return walkClientConfig(config, false)
+}
+
+// ClientConfigFields 傳回 config 的所有欄位與對應的預設值（依宣告順序）
+func ClientConfigFields(config *ClientConfig) []ConfigField {
+	return walkClientConfig(config, true)
+}
+
+// walkClientConfig 走訪 config 的欄位；all 為 false 時略過與預設值相同的欄位
+func walkClientConfig(config *ClientConfig, all bool) []ConfigField {
+	if config == nil {
+		return nil
+	}
+	// 沒有差異時傳回空切片，JSON 輸出為 [] 而不是 null
+	fields := []ConfigField{}
+	walkConfigValue("", reflect.ValueOf(config).Elem(), reflect.ValueOf(DefaultClientConfig()).Elem(), all, &fields)
+	return fields
+}
+
+// walkConfigValue 比較同型別的兩個值，結構展開為各欄位
+func walkConfigValue(path string, v, d reflect.Value, all bool, out *[]ConfigField) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := f.Name
+			if path != "" {
+				name = path + "." + f.Name
+			}
+			walkConfigValue(name, v.Field(i), d.Field(i), all, out)
+		}
+		return
+	case reflect.Pointer:
+		if !v.IsNil() && !d.IsNil() && v.Elem().Kind() == reflect.Struct {
+			walkConfigValue(path, v.Elem(), d.Elem(), all, out)
+			return
+		}
+	}
+
+	var equal bool
+	if v.Kind() == reflect.Func {
+		equal = v.IsNil() == d.IsNil()
+	} else {
+		equal = reflect.DeepEqual(v.Interface(), d.Interface())
+	}
+	if all || !equal {
+		*out = append(*out, ConfigField{Field: path, Value: configValue(v), Default: configValue(d)})
+	}
+}
+
+// configValue 傳回欄位值；函式改為是否設定的說明，讓結果可編碼為 JSON
+func configValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Func {
+		if v.IsNil() {
+			return configFuncUnset
+		}
+		return configFuncSet
+	}
+	return v.Interface()
+}
+
+// ConfigFieldsText 以每行一個欄位的格式顯示；withDefault 時附上預設值
+func ConfigFieldsText(fields []ConfigField, withDefault bool) string {
+	var b strings.Builder
+	for _, f := range fields {
+		if withDefault {
+			fmt.Fprintf(&b, "%s: %s (預設: %s)\n", f.Field, formatConfigValue(f.Value), formatConfigValue(f.Default))
+		} else {
+			fmt.Fprintf(&b, "%s: %s\n", f.Field, formatConfigValue(f.Value))
+		}
+	}
+	return b.String()
+}
+
+// formatConfigValue 文字顯示用的值，字串加上引號以區分空字串
+func formatConfigValue(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		if s == configFuncSet || s == configFuncUnset {
+			return s
+		}
+		return fmt.Sprintf("%q", s)
+	case nil:
+		return "nil"
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return "nil"
+		}
+		if rv.Kind() == reflect.Pointer {
+			return fmt.Sprintf("%+v", rv.Elem().Interface())
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,106 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDiffClientConfigDefaults 測試預設設定沒有差異
+func TestDiffClientConfigDefaults(t *testing.T) {
+	diffs := DiffClientConfig(DefaultClientConfig())
+	if len(diffs) != 0 {
+		t.Errorf("預設設定不應有差異: %+v", diffs)
+	}
+	if data, err := json.Marshal(diffs); err != nil || string(data) != "[]" {
+		t.Errorf("沒有差異時 JSON 應為 []，實際 %s (%v)", data, err)
+	}
+	if diffs := DiffClientConfig(nil); diffs != nil {
+		t.Errorf("nil 設定應傳回 nil: %+v", diffs)
+	}
+}
+
+// TestDiffClientConfigChangedFields 測試只列出修改的欄位，巢狀結構與函式欄位逐欄比較
+func TestDiffClientConfigChangedFields(t *testing.T) {
+	config := DefaultClientConfig()
+	config.Model = "gpt-5.2"
+	config.CLITimeout = time.Minute
+	config.PersistenceRetryPolicy.MaxAttempts = 7
+	config.OutputSamplingPolicy = OutputSamplingPolicy{Mode: KeepLastN, N: 3}
+	config.OnProgress = func(done, total int) {}
+
+	diffs := DiffClientConfig(config)
+	got := map[string]ConfigField{}
+	var names []string
+	for _, d := range diffs {
+		got[d.Field] = d
+		names = append(names, d.Field)
+	}
+	want := []string{"CLITimeout", "Model", "OnProgress", "OutputSamplingPolicy.Mode", "OutputSamplingPolicy.N", "PersistenceRetryPolicy.MaxAttempts"}
+	if len(diffs) != len(want) {
+		t.Fatalf("應只列出 %v，實際 %v", want, names)
+	}
+	for _, name := range want {
+		if _, ok := got[name]; !ok {
+			t.Errorf("缺少欄位 %s，實際 %v", name, names)
+		}
+	}
+
+	if d := got["PersistenceRetryPolicy.MaxAttempts"]; d.Value != 7 || d.Default != DefaultPersistenceRetryPolicy().MaxAttempts {
+		t.Errorf("巢狀欄位應附上預設值: %+v", d)
+	}
+	if d := got["OnProgress"]; d.Value != configFuncSet || d.Default != configFuncUnset {
+		t.Errorf("函式欄位只比較是否設定: %+v", d)
+	}
+
+	text := ConfigFieldsText(diffs, true)
+	if !strings.Contains(text, `Model: "gpt-5.2" (預設: "claude-sonnet-4.5")`) {
+		t.Errorf("文字格式應包含目前值與預設值:\n%s", text)
+	}
+	if !strings.Contains(text, "CLITimeout: 1m0s") {
+		t.Errorf("時間長度應以可讀格式顯示:\n%s", text)
+	}
+	if _, err := json.Marshal(diffs); err != nil {
+		t.Errorf("差異應可編碼為 JSON: %v", err)
+	}
+}
+
+// TestDiffClientConfigProjectConfig 測試 .ralphrc 套用後的差異
+func TestDiffClientConfigProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	rc := `{"model": "gpt-5.1", "completion": {"verify_command": "go test ./..."}}`
+	if err := os.WriteFile(filepath.Join(dir, ".ralphrc"), []byte(rc), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pc, err := LoadProjectConfig(dir)
+	if err != nil {
+		t.Fatalf("載入失敗: %v", err)
+	}
+	config := DefaultClientConfig()
+	pc.Apply(config)
+
+	var names []string
+	for _, d := range DiffClientConfig(config) {
+		names = append(names, d.Field)
+	}
+	if strings.Join(names, ",") != "Model,VerifyCommand" {
+		t.Errorf("應只列出 .ralphrc 設定的欄位，實際 %v", names)
+	}
+}
+
+// TestClientConfigFields 測試列出所有欄位
+func TestClientConfigFields(t *testing.T) {
+	fields := ClientConfigFields(DefaultClientConfig())
+	found := false
+	for _, f := range fields {
+		if f.Field == "PersistenceRetryPolicy.MaxAttempts" {
+			found = true
+		}
+	}
+	if !found || len(fields) < 50 {
+		t.Errorf("應列出所有欄位（含巢狀），實際 %d 個", len(fields))
+	}
+}
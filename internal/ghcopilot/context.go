@@ -3,6 +3,7 @@ package ghcopilot
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 )
@@ -20,14 +21,18 @@ type ExecutionContext struct {
 	UserFeedback string `json:"user_feedback"` // 使用者反饋（如有）
 
 	// CLI 執行結果
-	CLICommand  string `json:"cli_command"`   // 執行的 CLI 指令
-	CLIOutput   string `json:"cli_output"`    // CLI 輸出（完整）
-	CLIExitCode int    `json:"cli_exit_code"` // 退出碼
+	CLICommand      string `json:"cli_command"`                // 執行的 CLI 指令
+	RenderedCommand string `json:"rendered_command,omitempty"` // 完整的 CLI 命令（prompt 依 MaskSensitiveOutput 遮蔽敏感資料）
+	CLIOutput       string `json:"cli_output"`                 // CLI 輸出（完整）
+	CLIExitCode     int    `json:"cli_exit_code"`              // 退出碼
+
+	ExitOutcome ExitOutcome `json:"exit_outcome,omitempty"` // 退出碼對應的處理方式
 
 	// 輸出解析結果
 	ParsedCodeBlocks []string `json:"parsed_code_blocks"` // 提取的程式碼區塊
 	ParsedOptions    []string `json:"parsed_options"`     // 提取的選項
 	CleanedOutput    string   `json:"cleaned_output"`     // 清除 Markdown 後的輸出
+	CodeBlockCount   int      `json:"code_block_count"`   // 輸出中的程式碼區塊數
 
 	// 回應分析
 	CompletionScore      int         `json:"completion_score"`      // 完成分數
@@ -45,9 +50,66 @@ type ExecutionContext struct {
 	ShouldContinue bool   `json:"should_continue"` // 是否應繼續迴圈
 	ExitReason     string `json:"exit_reason"`     // 退出理由（如有）
 
+	// 非致命問題（不影響迴圈決策，但值得讓使用者知道）
+	Warnings []string `json:"warnings,omitempty"`
+
+	// 完成判定的依據
+	Decision *CompletionDecision `json:"decision,omitempty"`
+
+	// 模型自評的信心 0-100（RequestConfidence 啟用且有回報時）
+	Confidence *int `json:"confidence,omitempty"`
+
+	// 觸發結束的放棄語句（FailurePhrases）
+	GaveUpPhrase string `json:"gave_up_phrase,omitempty"`
+
+	// prompt 命中的安全規則（PromptSafetyRules）
+	PromptSafetyHits []PromptSafetyHit `json:"prompt_safety_hits,omitempty"`
+
+	// 輸出中命中的敏感資料偵測器名稱（只記錄名稱，不記錄內容）
+	SecretDetector string `json:"secret_detector,omitempty"`
+
+	// 本迴圈保存的產出檔案（ArtifactGlobs，相對於工作目錄）
+	Artifacts []string `json:"artifacts,omitempty"`
+
+	// 本迴圈修改的 FocusFiles 與連續沒有修改的迴圈數
+	FocusFilesChanged []string `json:"focus_files_changed,omitempty"`
+	FocusMissLoops    int      `json:"focus_miss_loops,omitempty"`
+
+	// 到此迴圈為止本次執行修改過的不同檔案數，與是否因超過 MaxChangedFiles 而中止
+	FilesChanged         int  `json:"files_changed,omitempty"`
+	ChangeBudgetExceeded bool `json:"change_budget_exceeded,omitempty"`
+
+	// 反覆以相同方式失敗而中止執行的命令（MaxIdenticalToolFailures）
+	RepeatedToolFailure string `json:"repeated_tool_failure,omitempty"`
+
+	// 觀察模式下被修改的檔案（ObservationMode）
+	ObservationViolation []string `json:"observation_violation,omitempty"`
+
+	// 是否為附加 FinalLoopInstruction 的收尾迴圈
+	WrapUp bool `json:"wrap_up,omitempty"`
+
+	// 持久化時依 OutputSamplingPolicy 省略了完整輸出（只出現在寫入磁碟的記錄中）
+	OutputOmitted bool `json:"output_omitted,omitempty"`
+
+	// 模型在迴圈結尾提出的問題（設定 OnModelQuestion 且偵測到時）
+	Question *ModelQuestion `json:"question,omitempty"`
+
+	// 驗證命令的結果（設定 VerifyCommand 且判定完成時）
+	Verify *VerifyResult `json:"verify,omitempty"`
+
+	// 故障恢復
+	Recoveries []RecoveryAttempt `json:"recoveries,omitempty"` // 本迴圈中的恢復嘗試（如有）
+
+	// 外部審核
+	ApprovalDecision *ApprovalDecision `json:"approval_decision,omitempty"` // 外部審核結果（如有）
+
 	// Metadata
-	Model    string                 `json:"model,omitempty"` // 使用的 AI 模型
-	Metadata map[string]interface{} `json:"metadata"`        // 其他 metadata
+	Model             string                 `json:"model,omitempty"`               // 使用的 AI 模型
+	ServedModel       string                 `json:"served_model,omitempty"`        // 輸出回報的實際模型（伺服器端改用其他模型時與 Model 不同）
+	Usage             *ModelUsage            `json:"usage,omitempty"`               // 輸出回報的用量（如有）
+	PromptPrefixChars int                    `json:"prompt_prefix_chars,omitempty"` // 加在 prompt 前面的模型前綴字元數（ModelPromptPrefixes）
+	PromptSuffixChars int                    `json:"prompt_suffix_chars,omitempty"` // 附加在 prompt 後面的格式要求等說明的字元數
+	Metadata          map[string]interface{} `json:"metadata"`                      // 其他 metadata
 }
 
 // LoopStatus 代表結構化的迴圈狀態輸出
@@ -61,14 +123,18 @@ type LoopStatus struct {
 
 // ContextManager 管理整個迴圈的上下文歷史記錄
 type ContextManager struct {
-	mu             sync.RWMutex
-	currentLoop    *ExecutionContext
-	loopHistory    []*ExecutionContext
-	maxHistorySize int
-	startTime      time.Time
-	totalDuration  time.Duration
-	successCount   int
-	errorCount     int
+	mu               sync.RWMutex
+	currentLoop      *ExecutionContext
+	loopHistory      []*ExecutionContext
+	maxHistorySize   int
+	maxTokenBudget   int               // 歷史記錄的 token 上限（字元數 / 4 估算），0 表示不限制
+	tokenPrunedLoops int               // 因超過 token 上限而移出的迴圈數
+	spillStore       HistorySpillStore // 超過 maxHistorySize 時保存舊迴圈（nil 表示直接捨棄）
+	spilled          map[int]string    // 已移出記憶體的迴圈索引與 LoopID
+	startTime        time.Time
+	totalDuration    time.Duration
+	successCount     int
+	errorCount       int
 }
 
 // NewContextManager 建立新的上下文管理器
@@ -141,10 +207,12 @@ func (cm *ContextManager) FinishLoop() error {
 	// 加入歷史記錄
 	cm.loopHistory = append(cm.loopHistory, cm.currentLoop)
 
-	// 如果歷史記錄超過最大值，刪除最早的
+	// 如果歷史記錄超過最大值，移出最早的（設定 spillStore 時寫入磁碟）
 	if len(cm.loopHistory) > cm.maxHistorySize {
-		cm.loopHistory = cm.loopHistory[1:]
+		cm.evictUnlocked(1)
 	}
+	// 超過 token 上限時再移出更多的舊迴圈
+	cm.pruneTokenBudgetUnlocked()
 
 	cm.totalDuration += duration
 	cm.currentLoop = nil
@@ -171,16 +239,23 @@ func (cm *ContextManager) GetLoopHistory() []*ExecutionContext {
 }
 
 // GetLoopByIndex 根據迴圈索引取得特定的迴圈上下文
+//
+// 已移出記憶體的迴圈會從磁碟讀回（需先呼叫 SetSpillStore）。
 func (cm *ContextManager) GetLoopByIndex(index int) *ExecutionContext {
 	cm.mu.RLock()
-	defer cm.mu.RUnlock()
-
 	for _, ctx := range cm.loopHistory {
 		if ctx.LoopIndex == index {
+			cm.mu.RUnlock()
 			return ctx
 		}
 	}
-	return nil
+	cm.mu.RUnlock()
+
+	ctx, err := cm.loadSpilled(index)
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	return ctx
 }
 
 // GetSummary 取得整體執行摘要
@@ -188,6 +263,13 @@ func (cm *ContextManager) GetSummary() map[string]interface{} {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
 
+	var warnings []string
+	for _, loop := range cm.loopHistory {
+		for _, w := range loop.Warnings {
+			warnings = append(warnings, fmt.Sprintf("迴圈 %d: %s", loop.LoopIndex+1, w))
+		}
+	}
+
 	totalLoops := len(cm.loopHistory)
 	successRate := 0.0
 	if totalLoops > 0 {
@@ -206,11 +288,20 @@ func (cm *ContextManager) GetSummary() map[string]interface{} {
 			}
 			return 0
 		}(),
-		"start_time": cm.startTime.Format(time.RFC3339),
-		"elapsed":    fmt.Sprintf("%.2f s", time.Since(cm.startTime).Seconds()),
+		"start_time":       cm.startTime.Format(time.RFC3339),
+		"elapsed":          fmt.Sprintf("%.2f s", time.Since(cm.startTime).Seconds()),
+		"warning_count":    len(warnings),
+		"warnings":         warnings,
+		"estimated_tokens": cm.estimatedTokensUnlocked(),
+		"max_token_budget": cm.maxTokenBudget,
 	}
 }
 
+// AddWarning 記錄一個非致命問題
+func (ec *ExecutionContext) AddWarning(format string, args ...interface{}) {
+	ec.Warnings = append(ec.Warnings, fmt.Sprintf(format, args...))
+}
+
 // GetLastErrorContext 取得最後一個包含錯誤的迴圈
 func (cm *ContextManager) GetLastErrorContext() *ExecutionContext {
 	cm.mu.RLock()
@@ -231,10 +322,14 @@ func (cm *ContextManager) Clear() {
 
 	cm.currentLoop = nil
 	cm.loopHistory = make([]*ExecutionContext, 0)
+	if cm.spilled != nil {
+		cm.spilled = make(map[int]string)
+	}
 	cm.startTime = time.Now()
 	cm.totalDuration = 0
 	cm.successCount = 0
 	cm.errorCount = 0
+	cm.tokenPrunedLoops = 0
 }
 
 // SetMaxHistorySize 設定最大歷史記錄大小
@@ -245,7 +340,7 @@ func (cm *ContextManager) SetMaxHistorySize(size int) {
 	cm.maxHistorySize = size
 	// 如果當前歷史記錄超過新的大小，截截
 	if len(cm.loopHistory) > size {
-		cm.loopHistory = cm.loopHistory[len(cm.loopHistory)-size:]
+		cm.evictUnlocked(len(cm.loopHistory) - size)
 	}
 }
 
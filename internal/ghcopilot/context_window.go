@@ -0,0 +1,72 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// loopSummaryMaxRunes 每個迴圈摘要中保留的輸出字數上限
+const loopSummaryMaxRunes = 300
+
+// buildContinuationPrompt 依 ContextWindowLoops 組出下一個迴圈的 prompt
+//
+// 只有最近 N 個迴圈會附上輸出摘要；更早的迴圈壓縮成一行滾動摘要，
+// 因此不論執行多少迴圈，prompt 大小都有上限。未啟用時直接傳回原始 prompt。
+func (c *RalphLoopClient) buildContinuationPrompt(initialPrompt string) string {
+	window := c.config.ContextWindowLoops
+	history := c.contextManager.GetLoopHistory()
+	if window <= 0 || len(history) == 0 {
+		return initialPrompt
+	}
+
+	split := len(history) - window
+	if split < 0 {
+		split = 0
+	}
+	older, recent := history[:split], history[split:]
+
+	var sb strings.Builder
+	sb.WriteString(initialPrompt)
+	sb.WriteString("\n\n[先前迴圈摘要]\n")
+
+	if len(older) > 0 {
+		failed := 0
+		for _, loop := range older {
+			if loop.CLIExitCode != 0 || strings.TrimSpace(loop.CLIOutput) == "" {
+				failed++
+			}
+		}
+		fmt.Fprintf(&sb, "- 較早的 %d 個迴圈已省略（其中 %d 個執行失敗），最後原因: %s\n",
+			len(older), failed, tailRunes(older[len(older)-1].ExitReason, 100))
+	}
+
+	for _, loop := range recent {
+		fmt.Fprintf(&sb, "- 迴圈 %d: %s\n", loop.LoopIndex+1, summarizeLoopOutput(loop))
+	}
+
+	return sb.String()
+}
+
+// summarizeLoopOutput 產生單一迴圈的簡短摘要（結束原因與輸出結尾）
+func summarizeLoopOutput(loop *ExecutionContext) string {
+	output := loop.CLIOutput
+	if idx := strings.Index(output, "---RALPH_STATUS---"); idx >= 0 {
+		output = output[:idx]
+	}
+	output = strings.Join(strings.Fields(output), " ")
+
+	summary := tailRunes(output, loopSummaryMaxRunes)
+	if loop.ExitReason != "" {
+		summary = fmt.Sprintf("(%s) %s", tailRunes(loop.ExitReason, 100), summary)
+	}
+	return summary
+}
+
+// tailRunes 保留字串最後 maxRunes 個字元（以 rune 計，避免切斷多位元組字元）
+func tailRunes(s string, maxRunes int) string {
+	runes := []rune(s)
+	if len(runes) <= maxRunes {
+		return s
+	}
+	return "..." + string(runes[len(runes)-maxRunes:])
+}
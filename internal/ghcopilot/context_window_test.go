@@ -0,0 +1,51 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestContextWindowBoundsPromptSize 測試多個迴圈後 prompt 大小仍有上限
+func TestContextWindowBoundsPromptSize(t *testing.T) {
+	config := DefaultClientConfig()
+	config.ContextWindowLoops = 3
+	config.FinalLoopInstruction = "" // 只比較上下文視窗造成的大小變化
+
+	client, prompts := newStepClient(config, func(call int, prompt string) (string, error) {
+		return pendingOutput(fmt.Sprintf("第 %d 次輸出 %s", call, strings.Repeat("很長的內容", 500))), nil
+	})
+
+	_, _ = client.ExecuteUntilCompletion(context.Background(), "重構模組", 30)
+	if len(*prompts) != 30 {
+		t.Fatalf("應執行 30 個迴圈，但為 %d", len(*prompts))
+	}
+	var promptSizes []int
+	for _, p := range *prompts {
+		promptSizes = append(promptSizes, len(p))
+	}
+	lastPrompt := (*prompts)[29]
+
+	if promptSizes[0] >= promptSizes[1] {
+		t.Error("第 2 個迴圈應附上先前迴圈摘要")
+	}
+	// 視窗填滿後，prompt 大小只會因迴圈編號位數而有些微差異
+	if diff := promptSizes[29] - promptSizes[5]; diff > 20 {
+		t.Errorf("prompt 大小應保持有界，第 6 個迴圈 %d bytes，第 30 個迴圈 %d bytes", promptSizes[5], promptSizes[29])
+	}
+	if !strings.Contains(lastPrompt, "較早的 26 個迴圈已省略") {
+		t.Error("較早的迴圈應壓縮成滾動摘要")
+	}
+	if !strings.Contains(lastPrompt, "迴圈 29:") || strings.Contains(lastPrompt, "迴圈 26:") {
+		t.Error("只應包含最近 3 個迴圈的摘要")
+	}
+}
+
+// TestContextWindowDisabled 測試未啟用時 prompt 不變
+func TestContextWindowDisabled(t *testing.T) {
+	client := NewRalphLoopClient()
+	if got := client.buildContinuationPrompt("原始 prompt"); got != "原始 prompt" {
+		t.Errorf("未啟用時應傳回原始 prompt，但為 %q", got)
+	}
+}
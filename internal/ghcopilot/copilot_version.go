@@ -0,0 +1,182 @@
+package ghcopilot
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultMinCopilotVersion 已驗證可用的最低 copilot CLI 版本（buildArgs 使用的參數自此版本起可用）
+const DefaultMinCopilotVersion = "0.0.330"
+
+// copilotVersionPattern 比對 `copilot --version` 輸出中的版本號（可帶 v 前綴與 pre-release 後綴）
+var copilotVersionPattern = regexp.MustCompile(`\bv?(\d+\.\d+\.\d+)(?:-[0-9A-Za-z.-]+)?\b`)
+
+// errUnknownCopilotVersion 無法從輸出解析版本號
+var errUnknownCopilotVersion = errors.New("無法解析 copilot 版本")
+
+// CopilotVersionRange 支援的 copilot CLI 版本範圍：Min <= 版本 < Max，空值表示不限制
+type CopilotVersionRange struct {
+	Min string // 最低版本（含）
+	Max string // 版本上限（不含）
+}
+
+// DefaultCopilotVersionRange 預設支援範圍（只限制最低版本）
+func DefaultCopilotVersionRange() CopilotVersionRange {
+	return CopilotVersionRange{Min: DefaultMinCopilotVersion}
+}
+
+// String 以 ">=x, <y" 形式呈現範圍
+func (r CopilotVersionRange) String() string {
+	var parts []string
+	if r.Min != "" {
+		parts = append(parts, ">="+r.Min)
+	}
+	if r.Max != "" {
+		parts = append(parts, "<"+r.Max)
+	}
+	if len(parts) == 0 {
+		return "不限"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Contains 檢查版本是否在範圍內；版本或範圍格式無效時回傳錯誤
+func (r CopilotVersionRange) Contains(version string) (bool, error) {
+	if r.Min != "" {
+		c, err := compareVersions(version, r.Min)
+		if err != nil {
+			return false, err
+		}
+		if c < 0 {
+			return false, nil
+		}
+	}
+	if r.Max != "" {
+		c, err := compareVersions(version, r.Max)
+		if err != nil {
+			return false, err
+		}
+		if c >= 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ParseCopilotVersion 從 `copilot --version` 的輸出取得版本號（不含 v 前綴與 pre-release 後綴）
+func ParseCopilotVersion(output string) (string, error) {
+	m := copilotVersionPattern.FindStringSubmatch(output)
+	if m == nil {
+		return "", fmt.Errorf("%w: %q", errUnknownCopilotVersion, tailRunes(strings.TrimSpace(output), 80))
+	}
+	return m[1], nil
+}
+
+// compareVersions 逐段比較 x.y.z 版本號，a < b 時回傳 -1，相等回傳 0，a > b 回傳 1
+func compareVersions(a, b string) (int, error) {
+	pa, err := versionParts(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := versionParts(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// versionParts 將版本號拆成數字（忽略 v 前綴與 pre-release 後綴）
+func versionParts(v string) ([]int, error) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "-")
+	if v == "" {
+		return nil, fmt.Errorf("版本號格式無效: %q", v)
+	}
+	fields := strings.Split(v, ".")
+	parts := make([]int, len(fields))
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("版本號格式無效: %q", v)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// DetectCopilotVersion 執行 `copilot --version` 並解析版本號
+func (dc *DependencyChecker) DetectCopilotVersion() (string, error) {
+	output, err := dc.runCheck(true, "copilot", "--version")
+	if err != nil {
+		return "", err
+	}
+	return ParseCopilotVersion(string(output))
+}
+
+// SetCopilotVersionRange 設定支援的 copilot 版本範圍；strict 為 true 時超出範圍視為錯誤，否則只警告
+func (dc *DependencyChecker) SetCopilotVersionRange(r CopilotVersionRange, strict bool) {
+	dc.versionRange = r
+	dc.strictVersion = strict
+}
+
+// CopilotVersion 傳回最近一次檢查偵測到的 copilot 版本（未偵測到時為空字串）
+func (dc *DependencyChecker) CopilotVersion() string {
+	return dc.copilotVersion
+}
+
+// GetWarnings 取得不影響執行的檢查警告（例如版本超出支援範圍但未設定 strict）
+func (dc *DependencyChecker) GetWarnings() []string {
+	return dc.warnings
+}
+
+// checkCopilotVersion 檢查 `copilot --version` 的輸出是否在支援範圍內
+func (dc *DependencyChecker) checkCopilotVersion(output string) {
+	version, err := ParseCopilotVersion(output)
+	if err != nil {
+		dc.warnings = append(dc.warnings, fmt.Sprintf("%v，略過版本相容性檢查", err))
+		return
+	}
+	dc.copilotVersion = version
+
+	ok, err := dc.versionRange.Contains(version)
+	if err != nil {
+		dc.warnings = append(dc.warnings, fmt.Sprintf("支援版本範圍設定無效（%v），略過版本相容性檢查", err))
+		return
+	}
+	if ok {
+		return
+	}
+
+	message := fmt.Sprintf("copilot 版本 %s 不在支援範圍（%s）內，參數或輸出格式可能不相容", version, dc.versionRange)
+	if !dc.strictVersion {
+		dc.warnings = append(dc.warnings, message)
+		return
+	}
+	dc.errors = append(dc.errors, &DependencyError{
+		Component: "GitHub Copilot CLI",
+		Message:   message,
+		Help: `請安裝支援範圍內的版本，例如：
+      npm install -g @github/copilot@<版本>
+
+   或放寬支援範圍（-copilot-min / -copilot-max），
+   或不使用 -strict-copilot-version 改為只顯示警告。`,
+	})
+}
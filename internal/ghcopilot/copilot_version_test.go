@@ -0,0 +1,121 @@
+package ghcopilot
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestParseCopilotVersion 測試從不同格式的 --version 輸出取得版本號
+func TestParseCopilotVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"0.0.336\n", "0.0.336"},
+		{"GitHub Copilot CLI 0.0.354.\nRun 'copilot update' to check for updates.\n", "0.0.354"},
+		{"copilot version v1.2.3-beta.1 (commit abc)", "1.2.3"},
+	}
+	for _, tt := range tests {
+		got, err := ParseCopilotVersion(tt.output)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseCopilotVersion(%q) = %q, %v；應為 %q", tt.output, got, err, tt.want)
+		}
+	}
+
+	if _, err := ParseCopilotVersion("copilot: unknown flag --version"); err == nil {
+		t.Error("沒有版本號的輸出應回傳錯誤")
+	}
+}
+
+// TestCopilotVersionRangeBoundaries 測試範圍邊界：最低版本包含在內，上限不包含
+func TestCopilotVersionRangeBoundaries(t *testing.T) {
+	r := CopilotVersionRange{Min: "0.0.330", Max: "0.1.0"}
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"0.0.329", false},
+		{"0.0.330", true},
+		{"0.0.999", true},
+		{"0.1.0", false},
+		{"0.1.0-rc.1", false},
+		{"1.0.0", false},
+	}
+	for _, tt := range tests {
+		got, err := r.Contains(tt.version)
+		if err != nil || got != tt.want {
+			t.Errorf("%s.Contains(%q) = %v, %v；應為 %v", r, tt.version, got, err, tt.want)
+		}
+	}
+
+	if ok, err := (CopilotVersionRange{}).Contains("0.0.1"); !ok || err != nil {
+		t.Errorf("空範圍應接受任何版本: %v %v", ok, err)
+	}
+	if _, err := (CopilotVersionRange{Min: "latest"}).Contains("0.0.1"); err == nil {
+		t.Error("無效的範圍設定應回傳錯誤")
+	}
+}
+
+// installFakeCopilotVersion 以輸出固定版本字串的腳本取代 copilot，傳回腳本被執行時建立的標記檔路徑
+func installFakeCopilotVersion(t *testing.T, output string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("使用 shell 腳本模擬 copilot，Windows 上略過")
+	}
+	binDir := t.TempDir()
+	marker := filepath.Join(binDir, "called")
+	script := "#!/bin/sh\ntouch '" + marker + "'\necho '" + output + "'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "copilot"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return marker
+}
+
+// TestCheckCopilotVersionOutsideRange 測試版本超出範圍時預設只警告，strict 時拒絕
+func TestCheckCopilotVersionOutsideRange(t *testing.T) {
+	installFakeCopilotVersion(t, "GitHub Copilot CLI 0.0.329")
+
+	dc := NewDependencyChecker()
+	dc.CheckGitHubCopilotCLI()
+	if dc.HasErrors() {
+		t.Fatalf("非 strict 模式不應產生錯誤: %v", dc.GetErrors())
+	}
+	if dc.CopilotVersion() != "0.0.329" {
+		t.Errorf("應偵測到版本 0.0.329，實際 %q", dc.CopilotVersion())
+	}
+	if w := dc.GetWarnings(); len(w) != 1 || !strings.Contains(w[0], "0.0.329") || !strings.Contains(w[0], ">="+DefaultMinCopilotVersion) {
+		t.Errorf("應警告版本不在支援範圍內: %v", w)
+	}
+
+	strict := NewDependencyChecker()
+	strict.SetCopilotVersionRange(DefaultCopilotVersionRange(), true)
+	strict.CheckGitHubCopilotCLI()
+	errs := strict.GetErrors()
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "不在支援範圍") {
+		t.Fatalf("strict 模式應拒絕不支援的版本: %v", errs)
+	}
+}
+
+// TestCheckCopilotVersionWithinRange 測試範圍內的版本與無法解析的輸出都不阻擋執行
+func TestCheckCopilotVersionWithinRange(t *testing.T) {
+	installFakeCopilotVersion(t, "0.0.330")
+
+	dc := NewDependencyChecker()
+	dc.SetCopilotVersionRange(CopilotVersionRange{Min: "0.0.330", Max: "0.0.331"}, true)
+	dc.CheckGitHubCopilotCLI()
+	if dc.HasErrors() || len(dc.GetWarnings()) != 0 {
+		t.Errorf("範圍內的版本不應有錯誤或警告: %v %v", dc.GetErrors(), dc.GetWarnings())
+	}
+
+	installFakeCopilotVersion(t, "dev build")
+	unknown := NewDependencyChecker()
+	unknown.SetCopilotVersionRange(DefaultCopilotVersionRange(), true)
+	unknown.CheckGitHubCopilotCLI()
+	if unknown.HasErrors() || len(unknown.GetWarnings()) != 1 {
+		t.Errorf("無法解析版本時應只警告: %v %v", unknown.GetErrors(), unknown.GetWarnings())
+	}
+}
@@ -0,0 +1,118 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// DefaultDependencyCacheTTL 依賴檢查結果的預設快取時間
+const DefaultDependencyCacheTTL = 10 * time.Minute
+
+// dependencyCacheFile 快取檔在暫存目錄中的檔名
+const dependencyCacheFile = "ralph-loop-deps.json"
+
+// dependencyCacheEntry 快取檔內容
+type dependencyCacheEntry struct {
+	CheckedAt   time.Time `json:"checked_at"`
+	Fingerprint string    `json:"fingerprint"` // 工具版本與 copilot 執行檔的指紋
+}
+
+// DependencyCache 成功的依賴檢查結果的短期快取
+//
+// 在 TTL 內重複啟動（例如腳本中連續執行多個命令）時略過依賴檢查。
+// 只快取成功的結果；ralph-loop 版本或 copilot 執行檔（路徑、大小、修改時間）
+// 改變時快取自動失效。
+type DependencyCache struct {
+	path    string
+	ttl     time.Duration
+	version string
+	now     func() time.Time
+}
+
+// NewDependencyCache 建立依賴檢查快取；path 為空時使用暫存目錄，ttl <= 0 時使用預設值
+func NewDependencyCache(path string, ttl time.Duration, version string) *DependencyCache {
+	if path == "" {
+		path = filepath.Join(os.TempDir(), dependencyCacheFile)
+	}
+	if ttl <= 0 {
+		ttl = DefaultDependencyCacheTTL
+	}
+	return &DependencyCache{path: path, ttl: ttl, version: version, now: time.Now}
+}
+
+// Path 傳回快取檔路徑
+func (dc *DependencyCache) Path() string {
+	return dc.path
+}
+
+// fingerprint 工具版本與 copilot 執行檔的指紋，任一改變時快取失效
+func (dc *DependencyCache) fingerprint() string {
+	fp := "ralph-loop " + dc.version
+	path, err := exec.LookPath("copilot")
+	if err != nil {
+		return fp + "; copilot: not found"
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fp + "; copilot: " + path
+	}
+	return fmt.Sprintf("%s; copilot: %s %d %d", fp, path, info.Size(), info.ModTime().UnixNano())
+}
+
+// Valid 檢查快取是否仍有效（未過期且指紋相同）
+func (dc *DependencyCache) Valid() bool {
+	data, err := os.ReadFile(dc.path) // #nosec G304 -- 快取檔路徑由程式決定
+	if err != nil {
+		return false
+	}
+	var entry dependencyCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return false
+	}
+	age := dc.now().Sub(entry.CheckedAt)
+	return age >= 0 && age < dc.ttl && entry.Fingerprint == dc.fingerprint()
+}
+
+// Store 記錄一次成功的依賴檢查
+func (dc *DependencyCache) Store() error {
+	data, err := json.Marshal(dependencyCacheEntry{
+		CheckedAt:   dc.now(),
+		Fingerprint: dc.fingerprint(),
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dc.path, data, 0600)
+}
+
+// Invalidate 刪除快取
+func (dc *DependencyCache) Invalidate() error {
+	if err := os.Remove(dc.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Preflight 在快取無效時執行 check，成功時寫入快取
+//
+// refresh 為 true 時忽略現有快取重新檢查。傳回值 cached 表示是否因快取而略過檢查。
+// 快取寫入失敗不影響結果。
+func (dc *DependencyCache) Preflight(check func() error, refresh bool) (cached bool, err error) {
+	if !refresh && dc.Valid() {
+		return true, nil
+	}
+	if err := check(); err != nil {
+		// #nosec G104 -- 失敗的結果不快取，刪除失敗時下次仍會重新檢查
+		dc.Invalidate()
+		return false, err
+	}
+	if err := dc.Store(); err != nil {
+		debugLog("⚠️ 無法寫入依賴檢查快取: %v", err)
+	}
+	return false, nil
+}
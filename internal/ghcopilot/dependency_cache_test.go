@@ -0,0 +1,92 @@
+package ghcopilot
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestDependencyCache 建立使用暫存目錄與可控時間的快取
+func newTestDependencyCache(t *testing.T, version string, now *time.Time) *DependencyCache {
+	t.Helper()
+	cache := NewDependencyCache(filepath.Join(t.TempDir(), "deps.json"), time.Minute, version)
+	cache.now = func() time.Time { return *now }
+	return cache
+}
+
+// TestDependencyCacheHitAndMiss 測試快取命中與未命中
+func TestDependencyCacheHitAndMiss(t *testing.T) {
+	now := time.Now()
+	cache := newTestDependencyCache(t, "1.0.0", &now)
+
+	calls := 0
+	check := func() error {
+		calls++
+		return nil
+	}
+
+	if cached, err := cache.Preflight(check, false); cached || err != nil {
+		t.Fatalf("第一次應執行檢查: cached=%v err=%v", cached, err)
+	}
+	if cached, err := cache.Preflight(check, false); !cached || err != nil {
+		t.Fatalf("TTL 內應使用快取: cached=%v err=%v", cached, err)
+	}
+	if calls != 1 {
+		t.Errorf("檢查應只執行 1 次，但為 %d", calls)
+	}
+
+	if cached, _ := cache.Preflight(check, true); cached || calls != 2 {
+		t.Errorf("refresh 應忽略快取重新檢查: cached=%v calls=%d", cached, calls)
+	}
+}
+
+// TestDependencyCacheTTLExpiry 測試快取過期
+func TestDependencyCacheTTLExpiry(t *testing.T) {
+	now := time.Now()
+	cache := newTestDependencyCache(t, "1.0.0", &now)
+	if err := cache.Store(); err != nil {
+		t.Fatalf("寫入快取失敗: %v", err)
+	}
+
+	now = now.Add(59 * time.Second)
+	if !cache.Valid() {
+		t.Error("TTL 內快取應有效")
+	}
+	now = now.Add(2 * time.Second)
+	if cache.Valid() {
+		t.Error("超過 TTL 後快取應失效")
+	}
+}
+
+// TestDependencyCacheVersionChange 測試版本改變時快取失效
+func TestDependencyCacheVersionChange(t *testing.T) {
+	now := time.Now()
+	cache := newTestDependencyCache(t, "1.0.0", &now)
+	if err := cache.Store(); err != nil {
+		t.Fatalf("寫入快取失敗: %v", err)
+	}
+
+	upgraded := NewDependencyCache(cache.Path(), time.Minute, "1.1.0")
+	upgraded.now = cache.now
+	if upgraded.Valid() {
+		t.Error("版本改變後快取應失效")
+	}
+}
+
+// TestDependencyCacheFailureNotCached 測試失敗的檢查不會被快取
+func TestDependencyCacheFailureNotCached(t *testing.T) {
+	now := time.Now()
+	cache := newTestDependencyCache(t, "1.0.0", &now)
+	if err := cache.Store(); err != nil {
+		t.Fatalf("寫入快取失敗: %v", err)
+	}
+
+	checkErr := errors.New("copilot not found")
+	if _, err := cache.Preflight(func() error { return checkErr }, true); !errors.Is(err, checkErr) {
+		t.Fatalf("應回傳檢查錯誤，但為 %v", err)
+	}
+	if cache.Valid() {
+		t.Error("檢查失敗後不應保留快取")
+	}
+}
@@ -1,12 +1,21 @@
 package ghcopilot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// defaultCheckTimeout 單一依賴檢查命令的預設逾時
+const defaultCheckTimeout = 5 * time.Second
+
+// errCheckTimeout 依賴檢查命令逾時
+var errCheckTimeout = errors.New("dependency check timed out")
+
 // DependencyError 代表依賴檢查失敗的錯誤
 type DependencyError struct {
 	Component string // 元件名稱 (e.g., "GitHub Copilot CLI", "GitHub Auth")
@@ -21,14 +30,60 @@ func (e *DependencyError) Error() string {
 
 // DependencyChecker 用於檢查所有依賴項
 type DependencyChecker struct {
-	errors []*DependencyError
+	errors         []*DependencyError
+	warnings       []string
+	checkTimeout   time.Duration       // 每個檢查命令的逾時
+	versionRange   CopilotVersionRange // 支援的 copilot 版本範圍
+	strictVersion  bool                // 版本超出範圍時視為錯誤（否則只警告）
+	copilotVersion string              // 偵測到的 copilot 版本
 }
 
 // NewDependencyChecker 建立新的依賴檢查器
 func NewDependencyChecker() *DependencyChecker {
 	return &DependencyChecker{
-		errors: []*DependencyError{},
+		errors:       []*DependencyError{},
+		checkTimeout: defaultCheckTimeout,
+		versionRange: DefaultCopilotVersionRange(),
+	}
+}
+
+// SetCheckTimeout 設定每個檢查命令的逾時，<= 0 時使用預設值
+func (dc *DependencyChecker) SetCheckTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
 	}
+	dc.checkTimeout = timeout
+}
+
+// runCheck 執行檢查命令，超過 checkTimeout 時終止並回傳 errCheckTimeout
+func (dc *DependencyChecker) runCheck(combined bool, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dc.checkTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	// 命令被終止後，子進程可能仍持有輸出管線，最多再等一秒
+	cmd.WaitDelay = time.Second
+
+	var output []byte
+	var err error
+	if combined {
+		output, err = cmd.CombinedOutput()
+	} else {
+		output, err = cmd.Output()
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return output, fmt.Errorf("%w: '%s %s' 超過 %v 未回應", errCheckTimeout, name, strings.Join(args, " "), dc.checkTimeout)
+	}
+	return output, err
+}
+
+// addTimeoutError 記錄檢查命令逾時的錯誤
+func (dc *DependencyChecker) addTimeoutError(component string, err error) {
+	dc.errors = append(dc.errors, &DependencyError{
+		Component: component,
+		Message:   err.Error(),
+		Help:      "命令沒有在時限內結束，請手動執行確認是否卡住（例如等待登入或網路），或以 SetCheckTimeout 延長逾時",
+	})
 }
 
 // CheckAll 檢查所有必需的依賴項
@@ -43,10 +98,26 @@ func (dc *DependencyChecker) CheckAll() error {
 	return nil
 }
 
+// CheckRequired 只檢查執行迴圈必需的依賴（Copilot CLI）
+//
+// 新版 Copilot CLI 有自己的認證機制，因此不像 CheckAll 要求 gh 已認證，
+// 適合作為每次啟動時的預檢（搭配 DependencyCache 快取結果）。
+func (dc *DependencyChecker) CheckRequired() error {
+	dc.CheckGitHubCopilotCLI()
+
+	if len(dc.errors) > 0 {
+		return dc.formatErrors()
+	}
+	return nil
+}
+
 // CheckNodeJS 檢查 Node.js 是否已安裝（可選，新版 CLI 不需要）
 func (dc *DependencyChecker) CheckNodeJS() {
-	cmd := exec.Command("node", "--version")
-	output, err := cmd.Output()
+	output, err := dc.runCheck(false, "node", "--version")
+	if errors.Is(err, errCheckTimeout) {
+		dc.addTimeoutError("Node.js", err)
+		return
+	}
 	if err != nil {
 		dc.errors = append(dc.errors, &DependencyError{
 			Component: "Node.js",
@@ -77,8 +148,11 @@ func (dc *DependencyChecker) CheckNodeJS() {
 //   - **`@githubnext/github-copilot-cli` 早已棄用**
 //   - 詳見 VERSION_NOTICE.md
 func (dc *DependencyChecker) CheckGitHubCopilotCLI() {
-	cmd := exec.Command("copilot", "--version")
-	_, err := cmd.Output()
+	output, err := dc.runCheck(false, "copilot", "--version")
+	if errors.Is(err, errCheckTimeout) {
+		dc.addTimeoutError("GitHub Copilot CLI", err)
+		return
+	}
 	if err != nil {
 		dc.errors = append(dc.errors, &DependencyError{
 			Component: "GitHub Copilot CLI",
@@ -106,12 +180,16 @@ func (dc *DependencyChecker) CheckGitHubCopilotCLI() {
 		})
 		return
 	}
+	dc.checkCopilotVersion(string(output))
 }
 
 // CheckGitHubCLI 檢查 GitHub CLI 是否已安裝（可選，新版 CLI 不需要）
 func (dc *DependencyChecker) CheckGitHubCLI() {
-	cmd := exec.Command("gh", "--version")
-	_, err := cmd.Output()
+	_, err := dc.runCheck(false, "gh", "--version")
+	if errors.Is(err, errCheckTimeout) {
+		dc.addTimeoutError("GitHub CLI", err)
+		return
+	}
 	if err != nil {
 		dc.errors = append(dc.errors, &DependencyError{
 			Component: "GitHub CLI",
@@ -124,8 +202,11 @@ func (dc *DependencyChecker) CheckGitHubCLI() {
 // CheckGitHubAuth 檢查 GitHub 認證狀態
 func (dc *DependencyChecker) CheckGitHubAuth() {
 	// 新版 CLI 使用自己的認證機制，先嘗試 gh auth，如失敗則提示使用 copilot /login
-	cmd := exec.Command("gh", "auth", "status")
-	_, err := cmd.CombinedOutput()
+	_, err := dc.runCheck(true, "gh", "auth", "status")
+	if errors.Is(err, errCheckTimeout) {
+		dc.addTimeoutError("GitHub Auth", err)
+		return
+	}
 	if err != nil {
 		// gh 認證失敗不一定是問題，因為新版 CLI 有自己的認證
 		// 這裡只是警告，不阻止執行
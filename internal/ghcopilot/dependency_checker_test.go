@@ -1,7 +1,12 @@
 package ghcopilot
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestNewDependencyChecker 測試建立新的依賴檢查器
@@ -129,3 +134,44 @@ func TestFormatErrors(t *testing.T) {
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && s != "")
 }
+
+// TestCheckTimeout 測試卡住的依賴檢查命令會在逾時後失敗
+func TestCheckTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("使用 shell 腳本模擬 copilot，Windows 上略過")
+	}
+
+	// 以永遠不結束的腳本取代 copilot
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nexec sleep 30\n"
+	if err := os.WriteFile(filepath.Join(binDir, "copilot"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dc := NewDependencyChecker()
+	dc.SetCheckTimeout(200 * time.Millisecond)
+
+	start := time.Now()
+	dc.CheckGitHubCopilotCLI()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("逾時檢查應快速失敗，但花了 %v", elapsed)
+	}
+
+	errs := dc.GetErrors()
+	if len(errs) != 1 {
+		t.Fatalf("應有 1 個錯誤，但有 %d 個", len(errs))
+	}
+	if !strings.Contains(errs[0].Message, "未回應") || !strings.Contains(errs[0].Message, "copilot --version") {
+		t.Errorf("錯誤訊息應說明命令逾時，但為 %q", errs[0].Message)
+	}
+}
+
+// TestSetCheckTimeoutDefault 測試無效的逾時會還原為預設值
+func TestSetCheckTimeoutDefault(t *testing.T) {
+	dc := NewDependencyChecker()
+	dc.SetCheckTimeout(0)
+	if dc.checkTimeout != defaultCheckTimeout {
+		t.Errorf("逾時應為預設值 %v，但為 %v", defaultCheckTimeout, dc.checkTimeout)
+	}
+}
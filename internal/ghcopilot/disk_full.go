@@ -0,0 +1,84 @@
+package ghcopilot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"syscall"
+)
+
+// DiskFullPolicy 決定持久化遇到磁碟空間不足時的處理方式
+type DiskFullPolicy string
+
+const (
+	// DiskFullWarnOnce 只警告一次，之後繼續嘗試儲存但不再重複警告
+	DiskFullWarnOnce DiskFullPolicy = "warn_once"
+	// DiskFullDisablePersistence 停用本次執行剩餘的持久化，只保留在記憶體中
+	DiskFullDisablePersistence DiskFullPolicy = "disable_persistence"
+	// DiskFullAbort 中止執行迴圈
+	DiskFullAbort DiskFullPolicy = "abort"
+)
+
+// persistenceBackend 迴圈中自動持久化使用的儲存介面（*PersistenceManager 實作，測試時可替換）
+type persistenceBackend interface {
+	SaveContextManager(cm *ContextManager) error
+	SaveExecutionContext(ctx *ExecutionContext) error
+}
+
+// IsDiskFullError 判斷錯誤是否為磁碟空間不足（含配額用盡）
+func IsDiskFullError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT) {
+		return true
+	}
+	// Windows 的 ERROR_DISK_FULL 不會對應到 ENOSPC，只能比對訊息
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "no space left on device") ||
+		strings.Contains(msg, "not enough space on the disk")
+}
+
+// persistEnabled 傳回迴圈中是否應執行自動持久化
+func (c *RalphLoopClient) persistEnabled() bool {
+	return c.backend != nil && c.config.EnablePersistence && !c.persistenceDisabled
+}
+
+// handlePersistError 處理自動持久化的錯誤
+//
+// 非磁碟空間不足的錯誤只記錄警告；磁碟空間不足時依 DiskFullPolicy 處理，
+// 並透過 OnDiskFull 通知呼叫端。僅在 DiskFullAbort 時傳回錯誤，呼叫端應中止迴圈。
+func (c *RalphLoopClient) handlePersistError(what string, err error) error {
+	if !IsDiskFullError(err) {
+		log.Printf("⚠️ %s失敗: %v", what, err)
+		return nil
+	}
+
+	policy := c.config.DiskFullPolicy
+	if policy == "" {
+		policy = DiskFullWarnOnce
+	}
+
+	first := !c.diskFullNotified
+	c.diskFullNotified = true
+
+	switch policy {
+	case DiskFullDisablePersistence:
+		c.persistenceDisabled = true
+		log.Printf("⚠️ %s失敗，磁碟空間不足，本次執行剩餘部分將停用持久化: %v", what, err)
+	case DiskFullAbort:
+		c.persistenceAbortErr = fmt.Errorf("persistence aborted: disk full: %w", err)
+		log.Printf("❌ %s失敗，磁碟空間不足，中止執行: %v", what, err)
+	default:
+		if !first {
+			return nil
+		}
+		log.Printf("⚠️ %s失敗，磁碟空間不足（之後不再重複警告）: %v", what, err)
+	}
+
+	if first && c.config.OnDiskFull != nil {
+		c.config.OnDiskFull(policy, err)
+	}
+	return c.persistenceAbortErr
+}
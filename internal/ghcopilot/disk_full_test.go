@@ -0,0 +1,126 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// fullDiskBackend 模擬磁碟已滿的持久化後端
+type fullDiskBackend struct {
+	managerSaves int
+	contextSaves int
+}
+
+func (b *fullDiskBackend) SaveContextManager(cm *ContextManager) error {
+	b.managerSaves++
+	return fmt.Errorf("無法建立檔案: %w", &os.PathError{Op: "open", Path: "context_manager.json", Err: syscall.ENOSPC})
+}
+
+func (b *fullDiskBackend) SaveExecutionContext(ctx *ExecutionContext) error {
+	b.contextSaves++
+	return fmt.Errorf("無法建立檔案: %w", &os.PathError{Op: "open", Path: "loop.json", Err: syscall.ENOSPC})
+}
+
+// newDiskFullClient 建立持久化後端永遠回報磁碟已滿的測試客戶端
+func newDiskFullClient(t *testing.T, policy DiskFullPolicy) (*RalphLoopClient, *fullDiskBackend, *[]DiskFullPolicy) {
+	t.Chdir(t.TempDir())
+
+	var notified []DiskFullPolicy
+	config := DefaultClientConfig()
+	config.DiskFullPolicy = policy
+	config.OnDiskFull = func(p DiskFullPolicy, err error) {
+		notified = append(notified, p)
+	}
+
+	client := newScriptedClient(config, pendingOutput("正在修改檔案"))
+	backend := &fullDiskBackend{}
+	client.config.EnablePersistence = true
+	client.backend = backend
+	return client, backend, &notified
+}
+
+// TestIsDiskFullError 測試磁碟空間不足錯誤的判斷
+func TestIsDiskFullError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("permission denied"), false},
+		{fmt.Errorf("寫入失敗: %w", syscall.ENOSPC), true},
+		{&os.PathError{Op: "write", Path: "x", Err: syscall.EDQUOT}, true},
+		{errors.New("There is not enough space on the disk."), true},
+	}
+
+	for _, tt := range tests {
+		if got := IsDiskFullError(tt.err); got != tt.want {
+			t.Errorf("IsDiskFullError(%v) = %v，應為 %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestDiskFullWarnOnce 測試預設策略只通知一次並繼續執行
+func TestDiskFullWarnOnce(t *testing.T) {
+	client, backend, notified := newDiskFullClient(t, DiskFullWarnOnce)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 3)
+	if err == nil {
+		t.Error("未完成應回傳達到最大迴圈數的錯誤")
+	}
+	if len(results) != 3 {
+		t.Errorf("應執行 3 個迴圈，但為 %d", len(results))
+	}
+	if len(*notified) != 1 || (*notified)[0] != DiskFullWarnOnce {
+		t.Errorf("應只通知一次 warn_once，但為 %v", *notified)
+	}
+	if backend.contextSaves != 3 {
+		t.Errorf("WarnOnce 應繼續嘗試儲存，但只儲存了 %d 次", backend.contextSaves)
+	}
+}
+
+// TestDiskFullDisablePersistence 測試停用持久化後不再寫入
+func TestDiskFullDisablePersistence(t *testing.T) {
+	client, backend, notified := newDiskFullClient(t, DiskFullDisablePersistence)
+
+	results, _ := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 3)
+	if len(results) != 3 {
+		t.Errorf("停用持久化後應繼續執行，但只執行了 %d 個迴圈", len(results))
+	}
+	if backend.contextSaves != 1 || backend.managerSaves != 0 {
+		t.Errorf("第一次失敗後不應再寫入，但 contextSaves=%d managerSaves=%d",
+			backend.contextSaves, backend.managerSaves)
+	}
+	if len(*notified) != 1 || (*notified)[0] != DiskFullDisablePersistence {
+		t.Errorf("應通知一次 disable_persistence，但為 %v", *notified)
+	}
+	if !client.persistenceDisabled {
+		t.Error("持久化應已停用")
+	}
+	if len(client.GetHistory()) != 3 {
+		t.Errorf("記憶體中應保留 3 個迴圈的歷史，但為 %d", len(client.GetHistory()))
+	}
+}
+
+// TestDiskFullAbort 測試中止策略停止迴圈並回傳錯誤
+func TestDiskFullAbort(t *testing.T) {
+	client, _, notified := newDiskFullClient(t, DiskFullAbort)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 3)
+	if err == nil || !IsDiskFullError(err) {
+		t.Fatalf("應回傳磁碟空間不足的錯誤，但為 %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("中止的迴圈不應計入結果，但為 %d", len(results))
+	}
+	if len(*notified) != 1 || (*notified)[0] != DiskFullAbort {
+		t.Errorf("應通知一次 abort，但為 %v", *notified)
+	}
+
+	if _, err := client.ExecuteLoop(context.Background(), "再試一次"); err == nil {
+		t.Error("中止後不應再開始新迴圈")
+	}
+}
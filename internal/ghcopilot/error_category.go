@@ -0,0 +1,120 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// ErrorCategory 錯誤的分類，供 CI 腳本依類別決定後續處理（例如 CategoryQuota 時退避）
+type ErrorCategory string
+
+const (
+	// CategoryAuth 未登入或憑證無效
+	CategoryAuth ErrorCategory = "auth"
+	// CategoryQuota 超過速率限制或用量配額
+	CategoryQuota ErrorCategory = "quota"
+	// CategoryTimeout 執行逾時
+	CategoryTimeout ErrorCategory = "timeout"
+	// CategoryCLIMissing 找不到 copilot 或其依賴的命令
+	CategoryCLIMissing ErrorCategory = "cli_missing"
+	// CategoryNetwork 網路連線失敗
+	CategoryNetwork ErrorCategory = "network"
+	// CategoryUnknown 無法分類
+	CategoryUnknown ErrorCategory = "unknown"
+)
+
+// Remediation 傳回該類別建議的處理方式
+func (c ErrorCategory) Remediation() string {
+	switch c {
+	case CategoryAuth:
+		return "執行 `gh auth login` 或 `copilot` 重新登入，並確認帳號已啟用 Copilot"
+	case CategoryQuota:
+		return "已達速率限制或用量配額，稍後再試（或降低 -max-loops 與並行數）"
+	case CategoryTimeout:
+		return "增加 -timeout 或 -cli-timeout，或將任務拆成較小的步驟"
+	case CategoryCLIMissing:
+		return "安裝 Copilot CLI（npm install -g @github/copilot）並確認在 PATH 中"
+	case CategoryNetwork:
+		return "檢查網路連線與代理設定（HTTPS_PROXY），稍後再試"
+	default:
+		return "查看上方的錯誤訊息與迴圈歷史；加上 RALPH_DEBUG=1 取得詳細日誌"
+	}
+}
+
+// errorCategoryPatterns 依序比對錯誤訊息（小寫）的規則，先比對到的類別優先
+var errorCategoryPatterns = []struct {
+	category ErrorCategory
+	pattern  *regexp.Regexp
+}{
+	{CategoryQuota, regexp.MustCompile(`rate.?limit|quota|too many requests|(status|http|code)[ :=]*429|usage limit|premium requests`)},
+	{CategoryAuth, regexp.MustCompile(`unauthori[sz]ed|authenticat|not logged in|auth login|bad credentials|invalid token|token (has )?expired|(status|http|code)[ :=]*40[13]|forbidden`)},
+	{CategoryCLIMissing, regexp.MustCompile(`executable file not found|command not found|copilot: not found|is not recognized as an internal or external command`)},
+	{CategoryNetwork, regexp.MustCompile(`connection refused|connection reset|no such host|network is unreachable|econnreset|econnrefused|enotfound|tls handshake|dial tcp`)},
+	{CategoryTimeout, regexp.MustCompile(`timed? ?out|deadline exceeded|逾時|超時`)},
+}
+
+// ClassifyError 依錯誤的型別與訊息（含 CLI 的 stderr）判斷錯誤類別，nil 傳回 CategoryUnknown
+func ClassifyError(err error) ErrorCategory {
+	if err == nil {
+		return CategoryUnknown
+	}
+
+	var depErr *DependencyError
+	if errors.As(err, &depErr) {
+		if depErr.Component == "GitHub Auth" {
+			return CategoryAuth
+		}
+		return CategoryCLIMissing
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return CategoryCLIMissing
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errCheckTimeout) {
+		return CategoryTimeout
+	}
+
+	if category := classifyErrorText(err.Error()); category != CategoryUnknown {
+		return category
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return CategoryNetwork
+	}
+	return CategoryUnknown
+}
+
+// classifyErrorText 以 errorCategoryPatterns 比對錯誤訊息
+func classifyErrorText(text string) ErrorCategory {
+	lower := strings.ToLower(text)
+	for _, p := range errorCategoryPatterns {
+		if p.pattern.MatchString(lower) {
+			return p.category
+		}
+	}
+	return CategoryUnknown
+}
+
+// ClassifyRunError 判斷整次執行的錯誤類別
+//
+// 迴圈層級的錯誤（例如熔斷器打開）通常不含根本原因，無法分類時改從最近的迴圈
+// 結束原因與警告（含 CLI stderr）判斷。
+func ClassifyRunError(err error, results []*LoopResult) ErrorCategory {
+	if category := ClassifyError(err); category != CategoryUnknown {
+		return category
+	}
+	for i := len(results) - 1; i >= 0; i-- {
+		r := results[i]
+		if r == nil {
+			continue
+		}
+		text := r.ExitReason + "\n" + strings.Join(r.Warnings, "\n")
+		if category := classifyErrorText(text); category != CategoryUnknown {
+			return category
+		}
+	}
+	return CategoryUnknown
+}
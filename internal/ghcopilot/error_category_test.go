@@ -0,0 +1,83 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+// TestClassifyErrorStderr 測試常見的 stderr 訊息對應到正確的類別
+func TestClassifyErrorStderr(t *testing.T) {
+	tests := []struct {
+		stderr   string
+		expected ErrorCategory
+	}{
+		{"Error: You are not logged in. Run `copilot` and use /login", CategoryAuth},
+		{"error: request failed with status 401: Bad credentials", CategoryAuth},
+		{"HTTP 403 Forbidden: Copilot is not enabled for this account", CategoryAuth},
+		{"Error: rate limit exceeded, retry after 60s", CategoryQuota},
+		{"429 Too Many Requests", CategoryQuota},
+		{"You have reached your monthly quota of premium requests", CategoryQuota},
+		{"exec: \"copilot\": executable file not found in $PATH", CategoryCLIMissing},
+		{"bash: copilot: command not found", CategoryCLIMissing},
+		{"'copilot' is not recognized as an internal or external command", CategoryCLIMissing},
+		{"dial tcp: lookup api.githubcopilot.com: no such host", CategoryNetwork},
+		{"read: connection reset by peer", CategoryNetwork},
+		{"request timed out after 180s", CategoryTimeout},
+		{"CLI 執行逾時", CategoryTimeout},
+		{"syntax error in main.go", CategoryUnknown},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(errors.New(tt.stderr)); got != tt.expected {
+			t.Errorf("%q 應分類為 %s，實際 %s", tt.stderr, tt.expected, got)
+		}
+	}
+}
+
+// TestClassifyErrorTypes 測試依錯誤型別分類，以及每個類別都有建議處理方式
+func TestClassifyErrorTypes(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected ErrorCategory
+	}{
+		{nil, CategoryUnknown},
+		{fmt.Errorf("loop 3: %w", context.DeadlineExceeded), CategoryTimeout},
+		{&exec.Error{Name: "copilot", Err: exec.ErrNotFound}, CategoryCLIMissing},
+		{&DependencyError{Component: "GitHub Auth", Message: "尚未登入"}, CategoryAuth},
+		{&DependencyError{Component: "Node.js", Message: "版本過舊"}, CategoryCLIMissing},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.expected {
+			t.Errorf("%v 應分類為 %s，實際 %s", tt.err, tt.expected, got)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range []ErrorCategory{CategoryAuth, CategoryQuota, CategoryTimeout, CategoryCLIMissing, CategoryNetwork, CategoryUnknown} {
+		r := c.Remediation()
+		if r == "" || seen[r] {
+			t.Errorf("%s 應有獨立的建議處理方式: %q", c, r)
+		}
+		seen[r] = true
+	}
+}
+
+// TestClassifyRunErrorFromResults 測試迴圈層級的錯誤改從迴圈的警告（stderr）分類
+func TestClassifyRunErrorFromResults(t *testing.T) {
+	results := []*LoopResult{
+		{ExitReason: "CLI 退出碼 1（無輸出），繼續重試", Warnings: []string{"CLI stderr: Error: rate limit exceeded"}},
+		{ExitReason: "CLI 退出碼 1（無輸出），繼續重試"},
+	}
+	err := errors.New("circuit breaker opened after 2 loops")
+	if got := ClassifyRunError(err, results); got != CategoryQuota {
+		t.Errorf("應從迴圈警告分類為 quota，實際 %s", got)
+	}
+	if got := ClassifyRunError(context.DeadlineExceeded, results); got != CategoryTimeout {
+		t.Errorf("可直接分類的錯誤應優先，實際 %s", got)
+	}
+	if got := ClassifyRunError(err, nil); got != CategoryUnknown {
+		t.Errorf("沒有線索時應為 unknown，實際 %s", got)
+	}
+}
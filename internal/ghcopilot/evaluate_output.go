@@ -0,0 +1,50 @@
+package ghcopilot
+
+import "fmt"
+
+// EvaluateOutput 以目前的設定判定一段輸出會被視為完成、卡住或繼續，但不執行任何迴圈
+//
+// 依序套用放棄語句、RALPH_STATUS 與完成分數、自評信心與「輸出與前一個迴圈相同」的判定，
+// 解析設定與 CLI 輸出相同。不會記錄到熔斷器或迴圈歷史，也不會執行驗證命令、
+// 外部審核或檢查工作目錄，適合用來預覽判定結果或找出完成語句沒有被辨識的原因。
+func (c *RalphLoopClient) EvaluateOutput(output string) *CompletionDecision {
+	analyzer := NewResponseAnalyzerWithProfile(output, c.parserProfile(BackendCLI))
+	decision := analyzer.Decide()
+	if len(c.config.FailurePhrases) > 0 {
+		if phrase := NewFailurePhraseDetector(c.config.FailurePhrases).Check(output); phrase != "" {
+			decision.ShouldContinue = false
+			decision.Reason = fmt.Sprintf("模型放棄任務: %q", phrase)
+			return decision
+		}
+	}
+
+	statusBlock := analyzer.ParseStructuredOutput()
+	if c.config.RequestConfidence && statusBlock != nil && statusBlock.HasConfidence {
+		confidence := statusBlock.Confidence
+		decision.Confidence = &confidence
+	}
+
+	if decision.Completed {
+		decision.Reason = "任務完成 (EXIT_SIGNAL=true)"
+		if statusBlock != nil && statusBlock.Reason != "" {
+			decision.Reason = statusBlock.Reason
+		}
+		if c.lowConfidence(decision.Confidence) {
+			decision.LowConfidence = true
+			decision.ShouldContinue = true
+			decision.Reason = fmt.Sprintf("自評信心 %d%% 低於門檻 %d%%，繼續驗證",
+				*decision.Confidence, c.config.MinExitConfidence)
+		}
+		return decision
+	}
+
+	if statusBlock != nil && statusBlock.Reason != "" {
+		decision.Reason = statusBlock.Reason
+	}
+	history := c.contextManager.GetLoopHistory()
+	if len(history) > 0 && history[len(history)-1].CLIOutput == output {
+		decision.Stuck = true
+		decision.StuckReason = "輸出與前一個迴圈完全相同"
+	}
+	return decision
+}
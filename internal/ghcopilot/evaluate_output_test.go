@@ -0,0 +1,68 @@
+package ghcopilot
+
+import (
+	"context"
+	"testing"
+)
+
+// TestEvaluateOutputCompleted 測試完成的輸出
+func TestEvaluateOutputCompleted(t *testing.T) {
+	client := newScriptedClient(DefaultClientConfig(), "")
+	decision := client.EvaluateOutput("已修正所有測試\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: 測試全部通過\n---END_RALPH_STATUS---")
+
+	if !decision.Completed || decision.ShouldContinue || !decision.ExitSignal {
+		t.Fatalf("應判定完成: %+v", decision)
+	}
+	if decision.Reason != "測試全部通過" {
+		t.Errorf("原因應取自 REASON 欄位，實際 %q", decision.Reason)
+	}
+}
+
+// TestEvaluateOutputContinue 測試未完成的輸出
+func TestEvaluateOutputContinue(t *testing.T) {
+	client := newScriptedClient(DefaultClientConfig(), "")
+	decision := client.EvaluateOutput("還在修正 parser\n---RALPH_STATUS---\nEXIT_SIGNAL: false\nREASON: 還有 2 個測試失敗\n---END_RALPH_STATUS---")
+
+	if decision.Completed || !decision.ShouldContinue || decision.Stuck {
+		t.Fatalf("應判定繼續且未卡住: %+v", decision)
+	}
+	if decision.Reason != "還有 2 個測試失敗" {
+		t.Errorf("原因應取自 REASON 欄位，實際 %q", decision.Reason)
+	}
+}
+
+// TestEvaluateOutputStuckWithoutMutation 測試與前一個迴圈相同的輸出判定為卡住，且不改變熔斷器與歷史
+func TestEvaluateOutputStuckWithoutMutation(t *testing.T) {
+	output := "還在處理\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---"
+	client := newScriptedClient(DefaultClientConfig(), output)
+	// 執行一個未完成的迴圈，讓歷史中有前一次的輸出（達到迴圈上限的錯誤可忽略）
+	_, _ = client.ExecuteUntilCompletion(context.Background(), "test", 1)
+	stats := client.breaker.GetStats()
+	historyLen := len(client.contextManager.GetLoopHistory())
+
+	for i := 0; i < 5; i++ {
+		decision := client.EvaluateOutput(output)
+		if !decision.Stuck || !decision.ShouldContinue {
+			t.Fatalf("與前一個迴圈相同的輸出應判定卡住: %+v", decision)
+		}
+	}
+
+	if got := client.breaker.GetStats(); got["no_progress_loops"] != stats["no_progress_loops"] || got["state"] != stats["state"] {
+		t.Errorf("不應改變熔斷器: 之前 %v，之後 %v", stats, got)
+	}
+	if got := len(client.contextManager.GetLoopHistory()); got != historyLen {
+		t.Errorf("不應改變迴圈歷史: 之前 %d，之後 %d", historyLen, got)
+	}
+}
+
+// TestEvaluateOutputFailurePhrase 測試放棄語句
+func TestEvaluateOutputFailurePhrase(t *testing.T) {
+	config := DefaultClientConfig()
+	config.FailurePhrases = []string{"I cannot complete this task"}
+	client := newScriptedClient(config, "")
+
+	decision := client.EvaluateOutput("Sorry, I cannot complete this task.")
+	if decision.ShouldContinue || decision.Reason == "" {
+		t.Errorf("放棄語句應判定停止並說明原因: %+v", decision)
+	}
+}
@@ -125,15 +125,25 @@ func (t *Task) SetRequiresSDK(requires bool) *Task {
 
 // ExecutionModeSelector 執行模式選擇器
 type ExecutionModeSelector struct {
-	defaultMode      ExecutionMode
-	fallbackEnabled  bool
-	sdkAvailable     bool
-	cliAvailable     bool
-	metrics          *SelectorMetrics
-	rules            []SelectionRule
-	mu               sync.RWMutex
+	defaultMode            ExecutionMode
+	fallbackEnabled        bool
+	sdkAvailable           bool
+	cliAvailable           bool
+	metrics                *SelectorMetrics
+	rules                  []SelectionRule
+	monitor                *PerformanceMonitor // 設定後依近期錯誤率調整自動選擇的模式
+	adaptiveErrorThreshold float64             // 近期錯誤率超過此值的模式會被降級
+	adaptiveMinSamples     int                 // 近期執行次數少於此值時不降級
+	mu                     sync.RWMutex
 }
 
+const (
+	// DefaultAdaptiveErrorThreshold 預設的降級錯誤率門檻
+	DefaultAdaptiveErrorThreshold = 0.5
+	// DefaultAdaptiveMinSamples 預設降級前需要的近期執行次數
+	DefaultAdaptiveMinSamples = 5
+)
+
 // SelectorMetrics 選擇器指標統計
 type SelectorMetrics struct {
 	TotalSelections   int64
@@ -156,12 +166,14 @@ type SelectionRule struct {
 // NewExecutionModeSelector 建立新的執行模式選擇器
 func NewExecutionModeSelector() *ExecutionModeSelector {
 	return &ExecutionModeSelector{
-		defaultMode:     ModeAuto,
-		fallbackEnabled: true,
-		sdkAvailable:    true,
-		cliAvailable:    true,
-		metrics:         &SelectorMetrics{},
-		rules:           make([]SelectionRule, 0),
+		defaultMode:            ModeAuto,
+		fallbackEnabled:        true,
+		sdkAvailable:           true,
+		cliAvailable:           true,
+		metrics:                &SelectorMetrics{},
+		rules:                  make([]SelectionRule, 0),
+		adaptiveErrorThreshold: DefaultAdaptiveErrorThreshold,
+		adaptiveMinSamples:     DefaultAdaptiveMinSamples,
 	}
 }
 
@@ -221,6 +233,50 @@ func (s *ExecutionModeSelector) IsCLIAvailable() bool {
 	return s.cliAvailable
 }
 
+// SetPerformanceMonitor 設定效能監控器，nil 表示停用依錯誤率的調整
+//
+// 設定後，由規則、任務複雜度或預設模式選出的模式若近期錯誤率超過 AdaptiveErrorThreshold，
+// 會暫時改用另一個模式（需啟用故障轉移）；任務指定的偏好模式與 RequiresSDK 不受影響。
+func (s *ExecutionModeSelector) SetPerformanceMonitor(monitor *PerformanceMonitor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.monitor = monitor
+}
+
+// GetPerformanceMonitor 取得效能監控器
+func (s *ExecutionModeSelector) GetPerformanceMonitor() *PerformanceMonitor {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.monitor
+}
+
+// SetAdaptiveErrorThreshold 設定降級的錯誤率門檻（0-1），超出範圍時不變更
+func (s *ExecutionModeSelector) SetAdaptiveErrorThreshold(threshold float64) {
+	if threshold < 0 || threshold > 1 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.adaptiveErrorThreshold = threshold
+}
+
+// AdaptiveErrorThreshold 取得降級的錯誤率門檻
+func (s *ExecutionModeSelector) AdaptiveErrorThreshold() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.adaptiveErrorThreshold
+}
+
+// SetAdaptiveMinSamples 設定降級前需要的近期執行次數，小於 1 時不變更
+func (s *ExecutionModeSelector) SetAdaptiveMinSamples(n int) {
+	if n < 1 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.adaptiveMinSamples = n
+}
+
 // AddRule 添加選擇規則
 func (s *ExecutionModeSelector) AddRule(rule SelectionRule) {
 	s.mu.Lock()
@@ -259,8 +315,19 @@ func (s *ExecutionModeSelector) Choose(task *Task) ExecutionMode {
 	fallbackEnabled := s.fallbackEnabled
 	sdkAvailable := s.sdkAvailable
 	cliAvailable := s.cliAvailable
+	monitor := s.monitor
+	threshold := s.adaptiveErrorThreshold
+	minSamples := s.adaptiveMinSamples
 	s.mu.RUnlock()
 
+	// 依近期錯誤率調整自動選出的模式
+	adapt := func(mode ExecutionMode) ExecutionMode {
+		if monitor == nil || !fallbackEnabled {
+			return mode
+		}
+		return s.adaptiveDemote(mode, monitor, threshold, minSamples, sdkAvailable, cliAvailable)
+	}
+
 	// 記錄選擇
 	defer func() {
 		s.metrics.mu.Lock()
@@ -292,7 +359,7 @@ func (s *ExecutionModeSelector) Choose(task *Task) ExecutionMode {
 	// 應用規則
 	for _, rule := range rules {
 		if task != nil && rule.Condition(task) {
-			mode := s.validateAndFallback(rule.Mode, sdkAvailable, cliAvailable, fallbackEnabled)
+			mode := adapt(s.validateAndFallback(rule.Mode, sdkAvailable, cliAvailable, fallbackEnabled))
 			s.recordSelection(mode)
 			return mode
 		}
@@ -303,19 +370,19 @@ func (s *ExecutionModeSelector) Choose(task *Task) ExecutionMode {
 		switch task.Complexity {
 		case ComplexitySimple:
 			// 簡單任務使用 CLI
-			mode := s.validateAndFallback(ModeCLI, sdkAvailable, cliAvailable, fallbackEnabled)
+			mode := adapt(s.validateAndFallback(ModeCLI, sdkAvailable, cliAvailable, fallbackEnabled))
 			s.recordSelection(mode)
 			return mode
 		case ComplexityComplex:
 			// 複雜任務使用 SDK
-			mode := s.validateAndFallback(ModeSDK, sdkAvailable, cliAvailable, fallbackEnabled)
+			mode := adapt(s.validateAndFallback(ModeSDK, sdkAvailable, cliAvailable, fallbackEnabled))
 			s.recordSelection(mode)
 			return mode
 		}
 	}
 
 	// 使用預設模式
-	mode := s.resolveAutoMode(defaultMode, sdkAvailable, cliAvailable)
+	mode := adapt(s.resolveAutoMode(defaultMode, sdkAvailable, cliAvailable))
 	s.recordSelection(mode)
 	return mode
 }
@@ -362,6 +429,40 @@ func (s *ExecutionModeSelector) validateAndFallback(
 	}
 }
 
+// adaptiveDemote 模式的近期錯誤率超過門檻時改用另一個模式，並記錄為故障轉移
+//
+// 另一個模式不可用或錯誤率同樣超過門檻時維持原模式。
+func (s *ExecutionModeSelector) adaptiveDemote(
+	mode ExecutionMode,
+	monitor *PerformanceMonitor,
+	threshold float64,
+	minSamples int,
+	sdkAvailable, cliAvailable bool,
+) ExecutionMode {
+	unhealthy := func(m ExecutionMode) bool {
+		samples, _, errorRate := monitor.GetRecentMetrics(m)
+		return samples >= minSamples && errorRate > threshold
+	}
+
+	var alternative ExecutionMode
+	switch mode {
+	case ModeSDK, ModeHybrid:
+		if !cliAvailable || !unhealthy(ModeSDK) || unhealthy(ModeCLI) {
+			return mode
+		}
+		alternative = ModeCLI
+	case ModeCLI:
+		if !sdkAvailable || !unhealthy(ModeCLI) || unhealthy(ModeSDK) {
+			return mode
+		}
+		alternative = ModeSDK
+	default:
+		return mode
+	}
+	s.recordFallback()
+	return alternative
+}
+
 // resolveAutoMode 解析自動模式
 func (s *ExecutionModeSelector) resolveAutoMode(mode ExecutionMode, sdkAvailable, cliAvailable bool) ExecutionMode {
 	if mode == ModeAuto {
@@ -434,10 +535,14 @@ type PerformanceMetrics struct {
 	Throughput   float64
 }
 
+// DefaultPerformanceWindow 近期指標預設涵蓋的執行次數
+const DefaultPerformanceWindow = 20
+
 // PerformanceMonitor 效能監控器
 type PerformanceMonitor struct {
 	cliMetrics  *modeMetrics
 	sdkMetrics  *modeMetrics
+	window      int // 近期指標涵蓋的執行次數
 	mu          sync.RWMutex
 }
 
@@ -446,15 +551,67 @@ type modeMetrics struct {
 	totalExecutions  int64
 	totalTime        time.Duration
 	errorCount       int64
+	recent           []executionSample // 最近的執行（由舊到新，最多 window 筆）
 	mu               sync.Mutex
 }
 
+// executionSample 單次執行的結果
+type executionSample struct {
+	duration time.Duration
+	failed   bool
+}
+
 // NewPerformanceMonitor 建立新的效能監控器
 func NewPerformanceMonitor() *PerformanceMonitor {
 	return &PerformanceMonitor{
 		cliMetrics: &modeMetrics{},
 		sdkMetrics: &modeMetrics{},
+		window:     DefaultPerformanceWindow,
+	}
+}
+
+// SetWindow 設定近期指標涵蓋的執行次數，小於 1 時不變更
+func (p *PerformanceMonitor) SetWindow(n int) {
+	if n < 1 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.window = n
+}
+
+// GetRecentMetrics 取得模式在最近 window 次執行的次數、平均時間與錯誤率
+func (p *PerformanceMonitor) GetRecentMetrics(mode ExecutionMode) (samples int, avgTime time.Duration, errorRate float64) {
+	p.mu.RLock()
+	var metrics *modeMetrics
+	switch mode {
+	case ModeCLI:
+		metrics = p.cliMetrics
+	case ModeSDK:
+		metrics = p.sdkMetrics
 	}
+	p.mu.RUnlock()
+	if metrics == nil {
+		return 0, 0, 0
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	var total time.Duration
+	failures := 0
+	for _, sample := range metrics.recent {
+		total += sample.duration
+		if sample.failed {
+			failures++
+		}
+	}
+	samples = len(metrics.recent)
+	if samples > 0 {
+		avgTime = total / time.Duration(samples)
+		errorRate = float64(failures) / float64(samples)
+	}
+	return
 }
 
 // RecordExecution 記錄執行
@@ -462,6 +619,7 @@ func (p *PerformanceMonitor) RecordExecution(mode ExecutionMode, duration time.D
 	var metrics *modeMetrics
 
 	p.mu.RLock()
+	window := p.window
 	switch mode {
 	case ModeCLI:
 		metrics = p.cliMetrics
@@ -481,6 +639,10 @@ func (p *PerformanceMonitor) RecordExecution(mode ExecutionMode, duration time.D
 	if err != nil {
 		metrics.errorCount++
 	}
+	metrics.recent = append(metrics.recent, executionSample{duration: duration, failed: err != nil})
+	if len(metrics.recent) > window {
+		metrics.recent = metrics.recent[len(metrics.recent)-window:]
+	}
 }
 
 // GetPerformanceMetrics 取得效能指標
@@ -551,12 +713,14 @@ func (p *PerformanceMonitor) Reset() {
 	p.cliMetrics.totalExecutions = 0
 	p.cliMetrics.totalTime = 0
 	p.cliMetrics.errorCount = 0
+	p.cliMetrics.recent = nil
 	p.cliMetrics.mu.Unlock()
 
 	p.sdkMetrics.mu.Lock()
 	p.sdkMetrics.totalExecutions = 0
 	p.sdkMetrics.totalTime = 0
 	p.sdkMetrics.errorCount = 0
+	p.sdkMetrics.recent = nil
 	p.sdkMetrics.mu.Unlock()
 }
 
@@ -574,9 +738,15 @@ func NewHybridExecutor(selector *ExecutionModeSelector) *HybridExecutor {
 	if selector == nil {
 		selector = NewExecutionModeSelector()
 	}
+	monitor := selector.GetPerformanceMonitor()
+	if monitor == nil {
+		// 讓選擇器依這個執行器記錄的錯誤率調整模式
+		monitor = NewPerformanceMonitor()
+		selector.SetPerformanceMonitor(monitor)
+	}
 	return &HybridExecutor{
 		selector: selector,
-		monitor:  NewPerformanceMonitor(),
+		monitor:  monitor,
 		cliFunc:  func(ctx context.Context, prompt string) (string, error) { return "", nil },
 		sdkFunc:  func(ctx context.Context, prompt string) (string, error) { return "", nil },
 	}
@@ -624,7 +794,7 @@ func (h *HybridExecutor) Execute(ctx context.Context, task *Task) (string, error
 		// 混合模式：先嘗試 SDK，失敗則使用 CLI
 		result, err = sdkFunc(ctx, prompt)
 		if err != nil && h.selector.IsFallbackEnabled() && h.selector.IsCLIAvailable() {
-			fmt.Printf("⚠️ SDK 執行失敗，自動切換至 CLI 模式: %v\n", err)
+			fmt.Fprintf(logWriter(), "⚠️ SDK 執行失敗，自動切換至 CLI 模式: %v\n", err)
 			result, err = cliFunc(ctx, prompt)
 			mode = ModeCLI // 更新記錄的模式
 		}
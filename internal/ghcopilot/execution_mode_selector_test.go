@@ -763,3 +763,97 @@ func TestExecutionModeSelector_Concurrent(t *testing.T) {
 		t.Errorf("expected 10 selections, got %d", metrics.TotalSelections)
 	}
 }
+
+// ========================
+// 依錯誤率調整模式 測試
+// ========================
+
+func TestExecutionModeSelector_Adaptive_DemotesFailingSDK(t *testing.T) {
+	selector := NewExecutionModeSelector()
+	monitor := NewPerformanceMonitor()
+	selector.SetPerformanceMonitor(monitor)
+	task := NewTask("1", "prompt").WithComplexity(ComplexityComplex)
+
+	// 樣本不足時維持 SDK
+	for i := 0; i < DefaultAdaptiveMinSamples-1; i++ {
+		monitor.RecordExecution(ModeSDK, time.Second, errors.New("sdk error"))
+	}
+	if mode := selector.Choose(task); mode != ModeSDK {
+		t.Fatalf("expected ModeSDK before enough samples, got %v", mode)
+	}
+
+	monitor.RecordExecution(ModeSDK, time.Second, errors.New("sdk error"))
+	if mode := selector.Choose(task); mode != ModeCLI {
+		t.Errorf("expected ModeCLI when SDK error rate is high, got %v", mode)
+	}
+	if metrics := selector.GetMetrics(); metrics.FallbackCount != 1 {
+		t.Errorf("expected demotion recorded as fallback, got %d", metrics.FallbackCount)
+	}
+
+	// 任務指定的偏好模式不受影響
+	if mode := selector.Choose(NewTask("2", "prompt").WithPreferredMode(ModeSDK)); mode != ModeSDK {
+		t.Errorf("expected preferred ModeSDK to be kept, got %v", mode)
+	}
+
+	// 近期成功後恢復使用 SDK（window 只保留最近的執行）
+	monitor.SetWindow(5)
+	for i := 0; i < 5; i++ {
+		monitor.RecordExecution(ModeSDK, time.Second, nil)
+	}
+	if mode := selector.Choose(task); mode != ModeSDK {
+		t.Errorf("expected ModeSDK after recent successes, got %v", mode)
+	}
+}
+
+func TestExecutionModeSelector_Adaptive_KeepsModeWhenBothFailing(t *testing.T) {
+	selector := NewExecutionModeSelector()
+	monitor := NewPerformanceMonitor()
+	selector.SetPerformanceMonitor(monitor)
+	selector.SetAdaptiveErrorThreshold(0.2)
+	for i := 0; i < 5; i++ {
+		monitor.RecordExecution(ModeSDK, time.Second, errors.New("sdk error"))
+		monitor.RecordExecution(ModeCLI, time.Second, errors.New("cli error"))
+	}
+
+	task := NewTask("1", "prompt").WithComplexity(ComplexityComplex)
+	if mode := selector.Choose(task); mode != ModeSDK {
+		t.Errorf("expected ModeSDK when CLI is failing too, got %v", mode)
+	}
+
+	selector.SetFallbackEnabled(false)
+	monitor.Reset()
+	for i := 0; i < 5; i++ {
+		monitor.RecordExecution(ModeSDK, time.Second, errors.New("sdk error"))
+	}
+	if mode := selector.Choose(task); mode != ModeSDK {
+		t.Errorf("expected no demotion with fallback disabled, got %v", mode)
+	}
+}
+
+func TestHybridExecutor_AdaptiveRouting(t *testing.T) {
+	executor := NewHybridExecutor(nil)
+	executor.GetSelector().AddRule(SelectionRule{
+		Name:      "complex-to-sdk",
+		Priority:  1,
+		Condition: func(task *Task) bool { return task.Complexity == ComplexityComplex },
+		Mode:      ModeSDK,
+	})
+	sdkCalls, cliCalls := 0, 0
+	executor.SetSDKExecutor(func(ctx context.Context, prompt string) (string, error) {
+		sdkCalls++
+		return "", errors.New("sdk unavailable")
+	})
+	executor.SetCLIExecutor(func(ctx context.Context, prompt string) (string, error) {
+		cliCalls++
+		return "ok", nil
+	})
+
+	task := NewTask("1", "prompt").WithComplexity(ComplexityComplex)
+	for i := 0; i < 8; i++ {
+		_, _ = executor.Execute(context.Background(), task)
+	}
+	if sdkCalls != DefaultAdaptiveMinSamples || cliCalls != 8-DefaultAdaptiveMinSamples {
+		t.Errorf("expected complex tasks to move to CLI after %d SDK failures, got sdk=%d cli=%d",
+			DefaultAdaptiveMinSamples, sdkCalls, cliCalls)
+	}
+}
@@ -0,0 +1,96 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// ModeInfo 單一執行模式的可用狀態
+type ModeInfo struct {
+	Mode      ExecutionMode `json:"mode"`      // 執行模式
+	Available bool          `json:"available"` // 目前是否可用
+	Selected  bool          `json:"selected"`  // 下一個迴圈是否會優先使用此模式
+	Reason    string        `json:"reason"`    // 可用或不可用的原因
+}
+
+// GetExecutionModes 列出 CLI 與 SDK 執行模式的可用狀態與原因
+//
+// 結果依 ExecuteLoop 的選擇順序判定：SDK 只有在 EnableSDK 與 PreferSDK
+// 都啟用且可用時才會被選用（MockMode 一律使用 CLI），否則使用 CLI。此函式不會啟動 SDK 執行器。
+func (c *RalphLoopClient) GetExecutionModes() []ModeInfo {
+	cli := c.cliModeInfo()
+	sdk := c.sdkModeInfo()
+
+	if sdk.Available && c.config.PreferSDK && !c.config.MockMode {
+		sdk.Selected = true
+	} else {
+		cli.Selected = cli.Available
+	}
+
+	return []ModeInfo{cli, sdk}
+}
+
+// cliModeInfo 判定 CLI 模式是否可用
+func (c *RalphLoopClient) cliModeInfo() ModeInfo {
+	info := ModeInfo{Mode: ModeCLI}
+
+	if os.Getenv("COPILOT_MOCK_MODE") == "true" {
+		info.Available = true
+		info.Reason = "模擬模式 (COPILOT_MOCK_MODE=true)"
+		return info
+	}
+	if c.config.MockMode {
+		info.Available = true
+		info.Reason = "模擬模式 (MockMode)"
+		return info
+	}
+
+	path, err := exec.LookPath("copilot")
+	if err != nil {
+		info.Reason = "PATH 中找不到 copilot CLI"
+		return info
+	}
+	info.Available = true
+	info.Reason = fmt.Sprintf("copilot CLI: %s", path)
+	return info
+}
+
+// sdkModeInfo 判定 SDK 模式是否可用
+func (c *RalphLoopClient) sdkModeInfo() ModeInfo {
+	info := ModeInfo{Mode: ModeSDK}
+
+	if !c.config.EnableSDK {
+		info.Reason = "SDK 已在設定中停用"
+		return info
+	}
+	if c.sdkExecutor == nil {
+		info.Reason = "SDK 執行器未初始化"
+		return info
+	}
+
+	status := c.sdkExecutor.GetStatus()
+	switch {
+	case status.Closed:
+		info.Reason = "SDK 執行器已關閉"
+		return info
+	case c.sdkExecutor.isHealthy():
+		info.Available = true
+		info.Reason = "SDK 執行器執行中"
+	case status.LastError != nil:
+		info.Reason = fmt.Sprintf("SDK 上次啟動失敗: %v", status.LastError)
+		return info
+	default:
+		if _, err := exec.LookPath(c.sdkExecutor.config.CLIPath); err != nil {
+			info.Reason = fmt.Sprintf("SDK 需要的 %s CLI 不在 PATH 中", c.sdkExecutor.config.CLIPath)
+			return info
+		}
+		info.Available = true
+		info.Reason = "尚未啟動，第一次使用時啟動"
+	}
+
+	if !c.config.PreferSDK {
+		info.Reason += "（PreferSDK 未啟用，不會被選用）"
+	}
+	return info
+}
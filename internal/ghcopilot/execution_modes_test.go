@@ -0,0 +1,149 @@
+package ghcopilot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// executionModes 以 config 建立客戶端並回傳其執行模式清單
+func executionModes(t *testing.T, config *ClientConfig) []ModeInfo {
+	t.Helper()
+	client := newScriptedClient(config, doneOutput)
+	t.Cleanup(func() { client.Close() })
+	return client.GetExecutionModes()
+}
+
+// findMode 從清單中取出指定模式
+func findMode(t *testing.T, modes []ModeInfo, mode ExecutionMode) ModeInfo {
+	t.Helper()
+	for _, m := range modes {
+		if m.Mode == mode {
+			return m
+		}
+	}
+	t.Fatalf("找不到 %s 模式: %+v", mode, modes)
+	return ModeInfo{}
+}
+
+// installFakeCopilot 在暫存目錄放置假的 copilot 並只讓它出現在 PATH 中
+func installFakeCopilot(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("假 copilot 使用 sh 腳本")
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "copilot"), []byte("#!/bin/sh\nexit 0\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+}
+
+// TestExecutionModesMockCLI 測試模擬模式下 CLI 可用並被選用
+func TestExecutionModesMockCLI(t *testing.T) {
+	t.Setenv("COPILOT_MOCK_MODE", "true")
+	t.Setenv("PATH", t.TempDir())
+
+	modes := executionModes(t, DefaultClientConfig())
+	cli := findMode(t, modes, ModeCLI)
+	if !cli.Available || !cli.Selected || !strings.Contains(cli.Reason, "COPILOT_MOCK_MODE") {
+		t.Errorf("模擬模式下 CLI 應可用並被選用: %+v", cli)
+	}
+}
+
+// TestExecutionModesCLIMissing 測試 PATH 中沒有 copilot 時 CLI 不可用
+func TestExecutionModesCLIMissing(t *testing.T) {
+	t.Setenv("COPILOT_MOCK_MODE", "")
+	t.Setenv("PATH", t.TempDir())
+
+	cli := findMode(t, executionModes(t, DefaultClientConfig()), ModeCLI)
+	if cli.Available || cli.Selected {
+		t.Errorf("找不到 copilot 時 CLI 不應可用: %+v", cli)
+	}
+	if !strings.Contains(cli.Reason, "找不到 copilot") {
+		t.Errorf("原因應說明找不到 copilot，但為 %q", cli.Reason)
+	}
+}
+
+// TestExecutionModesSDKDisabled 測試預設設定下 SDK 停用
+func TestExecutionModesSDKDisabled(t *testing.T) {
+	installFakeCopilot(t)
+
+	modes := executionModes(t, DefaultClientConfig())
+	sdk := findMode(t, modes, ModeSDK)
+	if sdk.Available || sdk.Selected || sdk.Reason != "SDK 已在設定中停用" {
+		t.Errorf("SDK 應標示為設定停用: %+v", sdk)
+	}
+	cli := findMode(t, modes, ModeCLI)
+	if !cli.Available || !cli.Selected {
+		t.Errorf("SDK 停用時應選用 CLI: %+v", cli)
+	}
+}
+
+// TestExecutionModesSDKMissingCLI 測試啟用 SDK 但找不到 copilot
+func TestExecutionModesSDKMissingCLI(t *testing.T) {
+	t.Setenv("COPILOT_MOCK_MODE", "true")
+	t.Setenv("PATH", t.TempDir())
+
+	config := DefaultClientConfig()
+	config.EnableSDK = true
+	config.PreferSDK = true
+	modes := executionModes(t, config)
+
+	sdk := findMode(t, modes, ModeSDK)
+	if sdk.Available || sdk.Selected || !strings.Contains(sdk.Reason, "不在 PATH 中") {
+		t.Errorf("找不到 copilot 時 SDK 不應可用: %+v", sdk)
+	}
+	if cli := findMode(t, modes, ModeCLI); !cli.Selected {
+		t.Errorf("SDK 不可用時應降級選用 CLI: %+v", cli)
+	}
+}
+
+// TestExecutionModesSDKPreferred 測試 SDK 可用且優先時被選用
+func TestExecutionModesSDKPreferred(t *testing.T) {
+	installFakeCopilot(t)
+
+	config := DefaultClientConfig()
+	config.EnableSDK = true
+	config.PreferSDK = true
+	modes := executionModes(t, config)
+
+	sdk := findMode(t, modes, ModeSDK)
+	if !sdk.Available || !sdk.Selected {
+		t.Errorf("SDK 可用且優先時應被選用: %+v", sdk)
+	}
+	if cli := findMode(t, modes, ModeCLI); !cli.Available || cli.Selected {
+		t.Errorf("SDK 被選用時 CLI 應可用但不被選用: %+v", cli)
+	}
+
+	config = DefaultClientConfig()
+	config.EnableSDK = true
+	sdk = findMode(t, executionModes(t, config), ModeSDK)
+	if !sdk.Available || sdk.Selected || !strings.Contains(sdk.Reason, "PreferSDK") {
+		t.Errorf("未設定 PreferSDK 時 SDK 可用但不應被選用: %+v", sdk)
+	}
+}
+
+// TestExecutionModesSDKStartFailed 測試 SDK 上次啟動失敗的原因
+func TestExecutionModesSDKStartFailed(t *testing.T) {
+	installFakeCopilot(t)
+
+	config := DefaultClientConfig()
+	config.EnableSDK = true
+	config.PreferSDK = true
+	client := newScriptedClient(config, doneOutput)
+	t.Cleanup(func() { client.Close() })
+	client.sdkExecutor.lastError = errors.New("failed to start copilot client: 連線被拒")
+
+	status := client.GetStatus()
+	sdk := findMode(t, status.ExecutionModes, ModeSDK)
+	if sdk.Available || !strings.Contains(sdk.Reason, "連線被拒") {
+		t.Errorf("SDK 應回報上次啟動失敗的原因: %+v", sdk)
+	}
+	if cli := findMode(t, status.ExecutionModes, ModeCLI); !cli.Selected {
+		t.Errorf("SDK 啟動失敗時應選用 CLI: %+v", cli)
+	}
+}
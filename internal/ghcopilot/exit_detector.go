@@ -1,6 +1,7 @@
 package ghcopilot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -23,6 +24,8 @@ const (
 	PlanCompleteCondition ExitConditionType = "plan_complete"
 	// RateLimitCondition 速率限制條件（達到 API 限制）
 	RateLimitCondition ExitConditionType = "rate_limit"
+	// ApprovalRejectedCondition 判定完成但外部審核未通過
+	ApprovalRejectedCondition ExitConditionType = "approval_rejected"
 )
 
 // ExitSignals 追蹤退出訊號
@@ -43,6 +46,7 @@ type ExitDetector struct {
 	exitConditionsTracker map[ExitConditionType]int
 	rateLimitResetTime    time.Time
 	rateLimitCallCount    int
+	approver              *ExternalApprover // 判定完成後的外部審核（nil 表示不需要）
 	mu                    sync.RWMutex
 }
 
@@ -58,6 +62,44 @@ func NewExitDetector(workDir string) *ExitDetector {
 	}
 }
 
+// SetApprover 設定判定完成後、真正結束前的外部審核步驟（nil 表示停用）
+func (ed *ExitDetector) SetApprover(approver *ExternalApprover) {
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+	ed.approver = approver
+}
+
+// ConfirmExit 迴圈判定完成後執行結束前的確認步驟，傳回 nil 表示不需要確認
+//
+// 目前的步驟為外部審核：審核請求失敗時視為拒絕，err 說明失敗原因。
+// 拒絕會記錄為 ApprovalRejectedCondition。
+func (ed *ExitDetector) ConfirmExit(ctx context.Context, execCtx *ExecutionContext) (decision *ApprovalDecision, err error) {
+	if ed == nil {
+		return nil, nil
+	}
+	ed.mu.RLock()
+	approver := ed.approver
+	ed.mu.RUnlock()
+	if approver == nil {
+		return nil, nil
+	}
+
+	decision, err = approver.RequestApproval(ctx, execCtx)
+	if err != nil {
+		decision = &ApprovalDecision{
+			Approved:  false,
+			Reason:    fmt.Sprintf("審核請求失敗: %v", err),
+			DecidedAt: time.Now(),
+		}
+	}
+	if !decision.Approved {
+		ed.mu.Lock()
+		ed.exitConditionsTracker[ApprovalRejectedCondition]++
+		ed.mu.Unlock()
+	}
+	return decision, err
+}
+
 // RecordTestOnlyLoop 記錄測試專屬迴圈
 func (ed *ExitDetector) RecordTestOnlyLoop() {
 	ed.mu.Lock()
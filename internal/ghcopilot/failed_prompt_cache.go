@@ -0,0 +1,198 @@
+package ghcopilot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FailedPromptsFile 失敗 prompt 快取在 SaveDir 中的檔名（跨執行共用）
+const FailedPromptsFile = "failed_prompts.json"
+
+// DefaultFailedPromptTTL 失敗記錄的預設有效時間
+const DefaultFailedPromptTTL = 24 * time.Hour
+
+// ErrPromptKnownBad 相同的 prompt 與模型最近已經失敗過（未設定 ForceRun 時）
+var ErrPromptKnownBad = errors.New("prompt failed recently")
+
+// FailedPrompt 一筆失敗的 prompt 記錄
+type FailedPrompt struct {
+	Key        string    `json:"key"`
+	Prompt     string    `json:"prompt"` // prompt 開頭（只用於顯示）
+	Model      string    `json:"model"`
+	Reason     string    `json:"reason"` // 最近一次失敗的原因
+	Failures   int       `json:"failures"`
+	LastFailed time.Time `json:"last_failed"`
+}
+
+// FailedPromptCache 最近持續失敗的 prompt 的負向快取
+//
+// 以正規化後的 prompt 與模型為鍵；執行以錯誤結束（熔斷器打開、達到迴圈上限、
+// 模型放棄等）時記錄，成功完成時清除。記錄在 TTL 內有效，過期的記錄在下次寫入時移除。
+type FailedPromptCache struct {
+	mu   sync.Mutex
+	path string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewFailedPromptCache 建立失敗 prompt 快取，ttl <= 0 時使用預設值
+func NewFailedPromptCache(path string, ttl time.Duration) *FailedPromptCache {
+	if ttl <= 0 {
+		ttl = DefaultFailedPromptTTL
+	}
+	return &FailedPromptCache{path: path, ttl: ttl, now: time.Now}
+}
+
+// Path 傳回快取檔路徑
+func (fc *FailedPromptCache) Path() string {
+	return fc.path
+}
+
+// PromptCacheKey 計算 prompt 與模型的快取鍵：忽略大小寫與空白差異
+func PromptCacheKey(prompt, model string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(prompt), " "))
+	sum := sha256.Sum256([]byte(normalized + "\x00" + model))
+	return hex.EncodeToString(sum[:])
+}
+
+// load 讀取快取檔；檔案不存在或格式錯誤時視為空的快取
+func (fc *FailedPromptCache) load() map[string]*FailedPrompt {
+	entries := make(map[string]*FailedPrompt)
+	data, err := os.ReadFile(fc.path) // #nosec G304 -- 路徑由 SaveDir 組成
+	if err != nil {
+		return entries
+	}
+	var list []*FailedPrompt
+	if err := json.Unmarshal(data, &list); err != nil {
+		debugLog("失敗 prompt 快取格式錯誤，重新累積: %v", err)
+		return entries
+	}
+	for _, e := range list {
+		if e != nil && e.Key != "" {
+			entries[e.Key] = e
+		}
+	}
+	return entries
+}
+
+// save 寫入未過期的記錄
+func (fc *FailedPromptCache) save(entries map[string]*FailedPrompt) error {
+	list := make([]*FailedPrompt, 0, len(entries))
+	for _, e := range entries {
+		if fc.now().Sub(e.LastFailed) < fc.ttl {
+			list = append(list, e)
+		}
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("失敗 prompt 快取編碼失敗: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(fc.path), 0750); err != nil {
+		return fmt.Errorf("無法建立目錄: %w", err)
+	}
+	return os.WriteFile(fc.path, data, 0600)
+}
+
+// Lookup 傳回 TTL 內的失敗記錄
+func (fc *FailedPromptCache) Lookup(prompt, model string) (*FailedPrompt, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	e, ok := fc.load()[PromptCacheKey(prompt, model)]
+	if !ok || fc.now().Sub(e.LastFailed) >= fc.ttl {
+		return nil, false
+	}
+	return e, true
+}
+
+// RecordFailure 記錄一次失敗
+func (fc *FailedPromptCache) RecordFailure(prompt, model, reason string) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	entries := fc.load()
+	key := PromptCacheKey(prompt, model)
+	e, ok := entries[key]
+	if !ok || fc.now().Sub(e.LastFailed) >= fc.ttl {
+		e = &FailedPrompt{Key: key, Prompt: truncateRunes(strings.TrimSpace(prompt), 80), Model: model}
+		entries[key] = e
+	}
+	e.Reason = tailRunes(reason, 200)
+	e.Failures++
+	e.LastFailed = fc.now()
+	return fc.save(entries)
+}
+
+// Forget 清除記錄（相同 prompt 成功完成時）
+func (fc *FailedPromptCache) Forget(prompt, model string) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	entries := fc.load()
+	key := PromptCacheKey(prompt, model)
+	if _, ok := entries[key]; !ok {
+		return nil
+	}
+	delete(entries, key)
+	return fc.save(entries)
+}
+
+// truncateRunes 保留字串開頭最多 n 個字元
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n]) + "..."
+	}
+	return s
+}
+
+// failedPromptCache 傳回客戶端的失敗 prompt 快取（未設定 FailedPromptTTL 時為 nil）
+func (c *RalphLoopClient) failedPromptCache() *FailedPromptCache {
+	if c.config.FailedPromptTTL <= 0 {
+		return nil
+	}
+	return NewFailedPromptCache(filepath.Join(c.config.SaveDir, FailedPromptsFile), c.config.FailedPromptTTL)
+}
+
+// checkFailedPrompt 執行開始前檢查 prompt 是否最近已失敗；ForceRun 時只記錄警告
+func (c *RalphLoopClient) checkFailedPrompt(prompt string) error {
+	cache := c.failedPromptCache()
+	if cache == nil {
+		return nil
+	}
+	entry, ok := cache.Lookup(prompt, c.config.Model)
+	if !ok {
+		return nil
+	}
+	if c.config.ForceRun {
+		infoLog("⚠️ 相同的 prompt 在 %s 失敗過 %d 次（%s），強制執行",
+			entry.LastFailed.Format("2006-01-02 15:04:05"), entry.Failures, entry.Reason)
+		return nil
+	}
+	return fmt.Errorf("%w: failed %d time(s), last at %s: %s",
+		ErrPromptKnownBad, entry.Failures, entry.LastFailed.Format(time.RFC3339), entry.Reason)
+}
+
+// recordPromptOutcome 依執行結果更新失敗 prompt 快取；被取消的執行不算失敗
+func (c *RalphLoopClient) recordPromptOutcome(prompt string, runErr error, cancelled bool) {
+	cache := c.failedPromptCache()
+	if cache == nil || cancelled {
+		return
+	}
+	var err error
+	if runErr == nil {
+		err = cache.Forget(prompt, c.config.Model)
+	} else {
+		err = cache.RecordFailure(prompt, c.config.Model, runErr.Error())
+	}
+	if err != nil {
+		debugLog("無法更新失敗 prompt 快取: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// runFailedPrompt 以失敗 prompt 快取執行 prompt，回傳 CLI 被呼叫的次數與執行錯誤
+func runFailedPrompt(ctx context.Context, saveDir string, force, complete bool, prompt string) (int, error) {
+	config := DefaultClientConfig()
+	config.FailedPromptTTL = time.Hour
+	config.ForceRun = force
+	client, prompts := newStepClient(config, func(call int, _ string) (string, error) {
+		if complete {
+			return doneOutput, nil
+		}
+		return pendingOutput(fmt.Sprintf("仍在處理 %d", call)), nil
+	})
+	client.config.SaveDir = saveDir
+	_, err := client.ExecuteUntilCompletion(ctx, prompt, 2)
+	return len(*prompts), err
+}
+
+// TestFailedPromptBlocksRerun 測試失敗過的 prompt 再次執行時被擋下，除非強制執行
+func TestFailedPromptBlocksRerun(t *testing.T) {
+	saveDir := t.TempDir()
+	ctx := context.Background()
+
+	if _, err := runFailedPrompt(ctx, saveDir, false, false, "修正 所有錯誤"); err == nil {
+		t.Fatal("未完成的執行應回傳錯誤")
+	}
+
+	// 空白與大小寫不同仍視為相同的 prompt
+	calls, err := runFailedPrompt(ctx, saveDir, false, false, "  修正  所有錯誤\n")
+	if !errors.Is(err, ErrPromptKnownBad) {
+		t.Fatalf("相同的 prompt 應回傳 ErrPromptKnownBad，但為 %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("被擋下的執行不應呼叫 CLI，但呼叫了 %d 次", calls)
+	}
+
+	// 不同的 prompt 不受影響
+	if _, err := runFailedPrompt(ctx, saveDir, false, true, "新增測試"); err != nil {
+		t.Errorf("不同的 prompt 不應被擋下: %v", err)
+	}
+
+	// 強制執行並成功後清除記錄
+	calls, err = runFailedPrompt(ctx, saveDir, true, true, "修正 所有錯誤")
+	if err != nil {
+		t.Fatalf("強制執行不應被擋下: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("強制執行應呼叫 CLI，但呼叫了 %d 次", calls)
+	}
+	if _, err := runFailedPrompt(ctx, saveDir, false, true, "修正 所有錯誤"); err != nil {
+		t.Errorf("成功完成後不應再擋下相同的 prompt: %v", err)
+	}
+}
+
+// TestFailedPromptCancelledRunNotRecorded 測試被取消的執行不記錄為失敗
+func TestFailedPromptCancelledRunNotRecorded(t *testing.T) {
+	saveDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := runFailedPrompt(ctx, saveDir, false, false, "修正錯誤"); err == nil {
+		t.Fatal("已取消的 context 應回傳錯誤")
+	}
+	if _, ok := NewFailedPromptCache(filepath.Join(saveDir, FailedPromptsFile), time.Hour).Lookup("修正錯誤", "claude-sonnet-4.5"); ok {
+		t.Error("被取消的執行不應記錄為失敗")
+	}
+}
+
+// TestFailedPromptCacheTTL 測試記錄過期與模型不同時不命中
+func TestFailedPromptCacheTTL(t *testing.T) {
+	cache := NewFailedPromptCache(filepath.Join(t.TempDir(), FailedPromptsFile), time.Hour)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	if err := cache.RecordFailure("修正錯誤", "gpt-5", "circuit breaker opened"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.RecordFailure("修正錯誤", "gpt-5", "reached maximum loops"); err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := cache.Lookup("修正錯誤", "gpt-5")
+	if !ok || entry.Failures != 2 || entry.Reason != "reached maximum loops" {
+		t.Fatalf("應記錄 2 次失敗與最近的原因: %+v %v", entry, ok)
+	}
+	if _, ok := cache.Lookup("修正錯誤", "claude-sonnet-4.5"); ok {
+		t.Error("不同模型不應命中")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := cache.Lookup("修正錯誤", "gpt-5"); ok {
+		t.Error("超過 TTL 的記錄不應命中")
+	}
+}
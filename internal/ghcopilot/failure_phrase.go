@@ -0,0 +1,87 @@
+package ghcopilot
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrModelGaveUp 模型輸出了 FailurePhrases 中的放棄語句
+var ErrModelGaveUp = errors.New("model gave up")
+
+// FailurePhraseDetector 偵測模型表示放棄的語句（不分大小寫）
+//
+// 可以一次檢查完整輸出（Check），也可以當作 io.Writer 接收串流片段；
+// 跨片段切開的語句同樣會被偵測到。
+type FailurePhraseDetector struct {
+	mu      sync.Mutex
+	phrases []string // 原始寫法
+	lower   []string // 轉小寫後的語句
+	tail    []byte   // 上一個片段結尾，用於比對跨片段的語句
+	keep    int      // tail 保留的位元組數
+	matched string   // 第一個偵測到的語句（原始寫法）
+	onMatch func(phrase string)
+}
+
+// NewFailurePhraseDetector 建立新的放棄語句偵測器，空白語句會被忽略
+func NewFailurePhraseDetector(phrases []string) *FailurePhraseDetector {
+	d := &FailurePhraseDetector{}
+	for _, p := range phrases {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		d.phrases = append(d.phrases, p)
+		d.lower = append(d.lower, strings.ToLower(p))
+		if len(p)+utf8.UTFMax > d.keep {
+			d.keep = len(p) + utf8.UTFMax
+		}
+	}
+	return d
+}
+
+// Write 實作 io.Writer，檢查串流片段
+func (d *FailurePhraseDetector) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.matched != "" || len(d.phrases) == 0 {
+		return len(p), nil
+	}
+	window := append(d.tail, p...)
+	d.matched = d.Check(string(window))
+	if d.matched != "" && d.onMatch != nil {
+		d.onMatch(d.matched)
+	}
+	if len(window) > d.keep {
+		window = window[len(window)-d.keep:]
+	}
+	d.tail = append([]byte(nil), window...)
+	return len(p), nil
+}
+
+// SetMatchCallback 設定串流中第一次偵測到語句時的回呼（例如提前結束進程）
+func (d *FailurePhraseDetector) SetMatchCallback(fn func(phrase string)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onMatch = fn
+}
+
+// Matched 傳回第一個偵測到的語句，沒有時為空字串
+func (d *FailurePhraseDetector) Matched() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.matched
+}
+
+// Check 檢查完整輸出，傳回第一個出現的語句（沒有時為空字串）
+func (d *FailurePhraseDetector) Check(output string) string {
+	lower := strings.ToLower(output)
+	for i, p := range d.lower {
+		if strings.Contains(lower, p) {
+			return d.phrases[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestFailurePhraseDetectorStream 測試串流片段中的放棄語句偵測（含跨片段與大小寫）
+func TestFailurePhraseDetectorStream(t *testing.T) {
+	d := NewFailurePhraseDetector([]string{"I cannot complete this task", "無法完成此任務", "  "})
+
+	var matched []string
+	d.SetMatchCallback(func(phrase string) { matched = append(matched, phrase) })
+
+	chunks := []string{"Analyzing...\nSorry, i CANNOT com", "plete this ", "task.\nMore text"}
+	for _, c := range chunks {
+		if _, err := d.Write([]byte(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d.Matched() != "I cannot complete this task" {
+		t.Errorf("應偵測到跨片段的語句，但為 %q", d.Matched())
+	}
+	if len(matched) != 1 {
+		t.Errorf("回呼應只觸發一次，但為 %d", len(matched))
+	}
+
+	zh := NewFailurePhraseDetector([]string{"無法完成此任務"})
+	full := []byte("抱歉，我無法完成此任務。")
+	// 在多位元組字元中間切開
+	zh.Write(full[:13])
+	zh.Write(full[13:])
+	if zh.Matched() != "無法完成此任務" {
+		t.Errorf("應偵測到切在字元中間的中文語句，但為 %q", zh.Matched())
+	}
+
+	if got := NewFailurePhraseDetector(nil).Check("I cannot complete this task"); got != "" {
+		t.Errorf("沒有設定語句時不應偵測: %q", got)
+	}
+}
+
+// TestFailurePhraseEndsRunEarly 測試輸出放棄語句時立即結束，不消耗剩餘迴圈
+func TestFailurePhraseEndsRunEarly(t *testing.T) {
+	config := DefaultClientConfig()
+	config.FailurePhrases = []string{"I cannot complete this task"}
+	client := newScriptedClient(config, "Tried everything. I cannot complete this task.\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "test", 10)
+	if !errors.Is(err, ErrModelGaveUp) {
+		t.Fatalf("應回傳 ErrModelGaveUp，但為 %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("應在第 1 個迴圈結束，但執行了 %d 個", len(results))
+	}
+	if results[0].GaveUpPhrase != "I cannot complete this task" || results[0].ShouldContinue {
+		t.Errorf("結果應記錄觸發的語句並停止: %+v", results[0])
+	}
+	if history := client.contextManager.GetLoopHistory(); history[0].GaveUpPhrase == "" {
+		t.Error("迴圈歷史應記錄觸發的語句")
+	}
+}
+
+// TestFailurePhraseStopsCLIStream 測試 CLI 串流中出現放棄語句時提前結束進程
+func TestFailurePhraseStopsCLIStream(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake copilot is a shell script")
+	}
+	t.Setenv("COPILOT_MOCK_MODE", "")
+
+	dir := t.TempDir()
+	// exec 讓 sleep 取代 sh：非 Windows 上只會終止直接子進程，殘留的子進程會持有輸出管線
+	script := "#!/bin/sh\necho 'Looking at the code'\necho 'Sorry, I cannot complete this task.'\nexec sleep 10\n"
+	if err := os.WriteFile(filepath.Join(dir, "copilot"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	executor := NewCLIExecutor(dir)
+	executor.SetTimeout(30 * time.Second)
+	executor.SetFailurePhrases([]string{"I cannot complete this task"})
+
+	start := time.Now()
+	result, err := executor.ExecutePrompt(context.Background(), "fix")
+	if err != nil || result == nil || !result.Success {
+		t.Fatalf("提前結束不應視為失敗: result=%+v err=%v", result, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("應在偵測到語句後提前結束，但耗時 %v", elapsed)
+	}
+}
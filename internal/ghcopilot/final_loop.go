@@ -0,0 +1,13 @@
+package ghcopilot
+
+// DefaultFinalLoopInstruction 最後一個迴圈附加的預設收尾提示
+const DefaultFinalLoopInstruction = "這是最後一個迴圈。請不要開始新的修改：完成並驗證手邊的變更，" +
+	"然後總結已完成的項目、尚未完成的項目與建議的下一步。"
+
+// finalLoopSuffix 最後一個迴圈（ExecuteUntilCompletion 的第 maxLoops 個）傳回要附加的收尾提示
+func (c *RalphLoopClient) finalLoopSuffix() string {
+	if !c.finalLoop || c.config.FinalLoopInstruction == "" {
+		return ""
+	}
+	return "\n\n[最後一個迴圈] " + c.config.FinalLoopInstruction
+}
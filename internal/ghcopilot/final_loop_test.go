@@ -0,0 +1,66 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestFinalLoopInstruction 測試只有最後一個迴圈附加收尾提示並標記為收尾迴圈
+func TestFinalLoopInstruction(t *testing.T) {
+	client := newScriptedClient(DefaultClientConfig(), "")
+	var prompts []string
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		prompts = append(prompts, prompt)
+		stdout := fmt.Sprintf("進行中 %d\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---", len(prompts))
+		return &ExecutionResult{Command: "copilot", Stdout: stdout}, nil
+	}
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "重構模組", 3)
+	if err == nil || !strings.Contains(err.Error(), "reached maximum loops") {
+		t.Fatalf("未完成時仍應回報達到迴圈上限: %v", err)
+	}
+	if len(prompts) != 3 {
+		t.Fatalf("應執行 3 個迴圈，但為 %d", len(prompts))
+	}
+	for i, prompt := range prompts {
+		want := i == 2
+		if got := strings.Contains(prompt, DefaultFinalLoopInstruction); got != want {
+			t.Errorf("迴圈 %d 是否附加收尾提示應為 %v", i+1, want)
+		}
+		if results[i].WrapUp != want {
+			t.Errorf("迴圈 %d 的 WrapUp 應為 %v", i+1, want)
+		}
+	}
+	if history := client.contextManager.GetLoopHistory(); !history[2].WrapUp {
+		t.Error("迴圈記錄應標記收尾迴圈")
+	}
+
+	// 執行結束後單獨呼叫 ExecuteLoop 不應沿用收尾提示
+	if _, err := client.ExecuteLoop(context.Background(), "再看一次"); err != nil {
+		t.Fatalf("ExecuteLoop 不應失敗: %v", err)
+	}
+	if strings.Contains(prompts[3], DefaultFinalLoopInstruction) {
+		t.Error("ExecuteUntilCompletion 以外的迴圈不應附加收尾提示")
+	}
+}
+
+// TestFinalLoopInstructionDisabled 測試清空設定或只有一個迴圈時不附加
+func TestFinalLoopInstructionDisabled(t *testing.T) {
+	config := DefaultClientConfig()
+	config.FinalLoopInstruction = ""
+	client := newScriptedClient(config, "進行中\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+	results, _ := client.ExecuteUntilCompletion(context.Background(), "重構模組", 2)
+	for _, r := range results {
+		if r.WrapUp {
+			t.Error("停用時不應標記收尾迴圈")
+		}
+	}
+
+	client = newScriptedClient(DefaultClientConfig(), "進行中\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+	results, _ = client.ExecuteUntilCompletion(context.Background(), "重構模組", 1)
+	if len(results) != 1 || results[0].WrapUp {
+		t.Error("只有一個迴圈時不應要求收尾")
+	}
+}
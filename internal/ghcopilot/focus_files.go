@@ -0,0 +1,112 @@
+package ghcopilot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultFocusRemindAfter 連續幾個迴圈沒有修改 FocusFiles 後在 prompt 提醒模型
+	defaultFocusRemindAfter = 2
+	// defaultFocusBreakAfter 連續幾個迴圈沒有修改 FocusFiles 後打開熔斷器
+	defaultFocusBreakAfter = 4
+)
+
+// focusFileInstruction 連續多個迴圈沒有修改 FocusFiles 時附加的提示
+const focusFileInstruction = "\n\n[注意] 已連續 %d 個迴圈沒有修改 %s。" +
+	"這個任務必須修改上述檔案，請直接修改它們，不要只修改其他檔案或只做說明。"
+
+// focusFileHash 計算檔案內容的雜湊；檔案不存在時為空字串（刪除也算變更）
+func focusFileHash(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- 路徑來自使用者設定的 FocusFiles
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// snapshotFocusFiles 記錄每個 FocusFiles 目前的內容雜湊
+func (c *RalphLoopClient) snapshotFocusFiles() map[string]string {
+	hashes := make(map[string]string, len(c.config.FocusFiles))
+	for _, name := range c.config.FocusFiles {
+		hashes[name] = focusFileHash(filepath.Join(c.config.WorkDir, name))
+	}
+	return hashes
+}
+
+// snapshotFocusBaseline 記錄第一個迴圈前 FocusFiles 的狀態
+func (c *RalphLoopClient) snapshotFocusBaseline() {
+	if len(c.config.FocusFiles) == 0 || c.focusFileHashes != nil {
+		return
+	}
+	c.focusFileHashes = c.snapshotFocusFiles()
+}
+
+// resetFocusFiles 清除 FocusFiles 的追蹤狀態（每次執行開始時呼叫）
+func (c *RalphLoopClient) resetFocusFiles() {
+	c.focusFileHashes = nil
+	c.focusMissLoops = 0
+}
+
+// checkFocusFiles 比對本迴圈是否修改了 FocusFiles，更新連續未修改的迴圈數
+//
+// 與上一個迴圈結束時的內容不同就算修改（等同 git diff 有變化，但不要求是 git 儲存庫）。
+func (c *RalphLoopClient) checkFocusFiles(execCtx *ExecutionContext) {
+	if len(c.config.FocusFiles) == 0 || c.focusFileHashes == nil {
+		return
+	}
+
+	current := c.snapshotFocusFiles()
+	for _, name := range c.config.FocusFiles {
+		if current[name] != c.focusFileHashes[name] {
+			execCtx.FocusFilesChanged = append(execCtx.FocusFilesChanged, name)
+		}
+	}
+	c.focusFileHashes = current
+
+	if len(execCtx.FocusFilesChanged) > 0 {
+		c.focusMissLoops = 0
+	} else {
+		c.focusMissLoops++
+		execCtx.AddWarning("本迴圈沒有修改 FocusFiles（已連續 %d 個迴圈）", c.focusMissLoops)
+	}
+	execCtx.FocusMissLoops = c.focusMissLoops
+}
+
+// focusRemindAfter 傳回提醒模型前允許的連續未修改迴圈數
+func (c *RalphLoopClient) focusRemindAfter() int {
+	if c.config.FocusRemindAfter > 0 {
+		return c.config.FocusRemindAfter
+	}
+	return defaultFocusRemindAfter
+}
+
+// focusBreakAfter 傳回打開熔斷器前允許的連續未修改迴圈數
+func (c *RalphLoopClient) focusBreakAfter() int {
+	if c.config.FocusBreakAfter > 0 {
+		return c.config.FocusBreakAfter
+	}
+	return defaultFocusBreakAfter
+}
+
+// focusFileTripped 連續未修改 FocusFiles 的迴圈數達 FocusBreakAfter 時打開熔斷器，傳回是否已打開
+func (c *RalphLoopClient) focusFileTripped() bool {
+	if len(c.config.FocusFiles) == 0 || c.focusMissLoops < c.focusBreakAfter() {
+		return false
+	}
+	c.breaker.openCircuit(fmt.Sprintf("連續 %d 個迴圈沒有修改 %s", c.focusMissLoops, strings.Join(c.config.FocusFiles, ", ")))
+	return true
+}
+
+// focusFileSuffix 連續未修改 FocusFiles 達 FocusRemindAfter 時傳回要附加的提示，否則為空字串
+func (c *RalphLoopClient) focusFileSuffix() string {
+	if len(c.config.FocusFiles) == 0 || c.focusMissLoops < c.focusRemindAfter() {
+		return ""
+	}
+	return fmt.Sprintf(focusFileInstruction, c.focusMissLoops, strings.Join(c.config.FocusFiles, ", "))
+}
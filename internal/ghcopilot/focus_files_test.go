@@ -0,0 +1,91 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// focusConfig 建立含 main.go 的工作目錄，並以 main.go 為 FocusFiles 的設定
+func focusConfig(t *testing.T) *ClientConfig {
+	t.Helper()
+	config := DefaultClientConfig()
+	config.WorkDir = t.TempDir()
+	config.FocusFiles = []string{"main.go"}
+	if err := os.WriteFile(filepath.Join(config.WorkDir, "main.go"), []byte("package main\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+// loopComment 回傳標記第 loop 個迴圈的 Go 原始碼
+func loopComment(loop int) string {
+	return fmt.Sprintf("package main\n\n// 迴圈 %d\n", loop)
+}
+
+// TestFocusFilesReminder 測試連續未修改 FocusFiles 時提醒模型，修改後重新計算
+func TestFocusFilesReminder(t *testing.T) {
+	config := focusConfig(t)
+	client, prompts := newStepClient(config, writeFilesStep(t, config.WorkDir, func(loop int) map[string]string {
+		if loop == 3 {
+			return map[string]string{"main.go": loopComment(loop)}
+		}
+		return map[string]string{"helper.go": loopComment(loop)}
+	}))
+
+	var results []*LoopResult
+	for i := 0; i < 4; i++ {
+		result, err := client.ExecuteLoop(context.Background(), "修正 main.go 的錯誤")
+		if err != nil {
+			t.Fatalf("ExecuteLoop 不應失敗: %v", err)
+		}
+		results = append(results, result)
+	}
+
+	for i, want := range []int{1, 2, 0, 1} {
+		if results[i].FocusMissLoops != want {
+			t.Errorf("迴圈 %d 的連續未修改數應為 %d，實際 %d", i+1, want, results[i].FocusMissLoops)
+		}
+	}
+	if len(results[2].FocusFilesChanged) != 1 || results[2].FocusFilesChanged[0] != "main.go" {
+		t.Errorf("迴圈 3 應記錄修改了 main.go: %v", results[2].FocusFilesChanged)
+	}
+	if len(results[0].FocusFilesChanged) != 0 {
+		t.Errorf("迴圈 1 沒有修改 main.go: %v", results[0].FocusFilesChanged)
+	}
+
+	for i, want := range []bool{false, false, true, false} {
+		if got := strings.Contains((*prompts)[i], "必須修改上述檔案"); got != want {
+			t.Errorf("迴圈 %d 的 prompt 是否提醒應為 %v:\n%s", i+1, want, (*prompts)[i])
+		}
+	}
+	if !strings.Contains((*prompts)[2], "main.go") {
+		t.Errorf("提醒應指出要修改的檔案:\n%s", (*prompts)[2])
+	}
+}
+
+// TestFocusFilesTripBreaker 測試一直沒有修改 FocusFiles 時打開熔斷器
+func TestFocusFilesTripBreaker(t *testing.T) {
+	config := focusConfig(t)
+	client, prompts := newStepClient(config, writeFilesStep(t, config.WorkDir, func(loop int) map[string]string {
+		return map[string]string{"helper.go": loopComment(loop)}
+	}))
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正 main.go 的錯誤", 10)
+	if err == nil || !strings.Contains(err.Error(), "circuit breaker opened") {
+		t.Fatalf("應因熔斷器打開而停止，但為 %v", err)
+	}
+	if len(*prompts) != defaultFocusBreakAfter {
+		t.Errorf("應在第 %d 個迴圈打開熔斷器，但執行了 %d 個", defaultFocusBreakAfter, len(*prompts))
+	}
+	last := results[len(results)-1]
+	if last.Decision == nil || !last.Decision.Stuck || !strings.Contains(last.Decision.StuckReason, "main.go") {
+		t.Errorf("判定依據應說明沒有修改 main.go: %+v", last.Decision)
+	}
+	if !strings.Contains(client.breaker.OpenReason(), "main.go") {
+		t.Errorf("熔斷原因應包含 main.go: %q", client.breaker.OpenReason())
+	}
+}
@@ -0,0 +1,71 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"log"
+)
+
+// HistorySpillStore 保存移出記憶體的迴圈並在需要時讀回（PersistenceManager 已實作）
+type HistorySpillStore interface {
+	SaveExecutionContext(ctx *ExecutionContext) error
+	LoadExecutionContext(loopID string) (*ExecutionContext, error)
+}
+
+// SetSpillStore 設定超過 maxHistorySize 時保存舊迴圈的位置，nil 表示直接捨棄（原本的行為）
+func (cm *ContextManager) SetSpillStore(store HistorySpillStore) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.spillStore = store
+	if cm.spilled == nil {
+		cm.spilled = make(map[int]string)
+	}
+}
+
+// evictUnlocked 將最舊的 n 個迴圈移出記憶體；設定 spillStore 時先寫入磁碟（呼叫端需持有寫入鎖）
+func (cm *ContextManager) evictUnlocked(n int) {
+	for _, ctx := range cm.loopHistory[:n] {
+		if cm.spillStore == nil {
+			continue
+		}
+		if err := cm.spillStore.SaveExecutionContext(ctx); err != nil {
+			log.Printf("⚠️ 迴圈 %d 無法寫入磁碟，將從歷史中移除: %v", ctx.LoopIndex+1, err)
+			continue
+		}
+		cm.spilled[ctx.LoopIndex] = ctx.LoopID
+	}
+	cm.loopHistory = cm.loopHistory[n:]
+}
+
+// loadSpilled 從磁碟讀回移出記憶體的迴圈；不存在時回傳 nil
+func (cm *ContextManager) loadSpilled(index int) (*ExecutionContext, error) {
+	cm.mu.RLock()
+	store := cm.spillStore
+	loopID, ok := cm.spilled[index]
+	cm.mu.RUnlock()
+
+	if store == nil || !ok {
+		return nil, nil
+	}
+	ctx, err := store.LoadExecutionContext(loopID)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀回迴圈 %d: %w", index+1, err)
+	}
+	return ctx, nil
+}
+
+// SpilledLoops 傳回已移出記憶體、保存在磁碟的迴圈數
+func (cm *ContextManager) SpilledLoops() int {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	return len(cm.spilled)
+}
+
+// NextLoopIndex 傳回下一個迴圈的索引（包含已移出記憶體的迴圈）
+func (cm *ContextManager) NextLoopIndex() int {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	return len(cm.loopHistory) + len(cm.spilled)
+}
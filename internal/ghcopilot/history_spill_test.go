@@ -0,0 +1,91 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestContextManagerSpillToDisk 測試超過上限的迴圈寫入磁碟並可讀回
+func TestContextManagerSpillToDisk(t *testing.T) {
+	pm, err := NewPersistenceManager(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := NewContextManager()
+	cm.SetMaxHistorySize(2)
+	cm.SetSpillStore(pm)
+
+	for i := 0; i < 5; i++ {
+		ctx := cm.StartLoop(cm.NextLoopIndex(), "test")
+		ctx.CLIOutput = fmt.Sprintf("迴圈 %d 的輸出", i+1)
+		if err := cm.FinishLoop(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := len(cm.GetLoopHistory()); got != 2 {
+		t.Fatalf("記憶體中應只保留 2 個迴圈，實際 %d", got)
+	}
+	if got := cm.SpilledLoops(); got != 3 {
+		t.Fatalf("應有 3 個迴圈寫入磁碟，實際 %d", got)
+	}
+	for i := 0; i < 5; i++ {
+		loop := cm.GetLoopByIndex(i)
+		if loop == nil {
+			t.Fatalf("迴圈 %d 應可取得", i+1)
+		}
+		if want := fmt.Sprintf("迴圈 %d 的輸出", i+1); loop.CLIOutput != want {
+			t.Errorf("迴圈 %d 的輸出應為 %q，實際 %q", i+1, want, loop.CLIOutput)
+		}
+	}
+	if cm.GetLoopByIndex(5) != nil {
+		t.Error("不存在的迴圈應回傳 nil")
+	}
+}
+
+// TestContextManagerWithoutSpillDrops 測試未設定 spillStore 時維持捨棄舊迴圈的行為
+func TestContextManagerWithoutSpillDrops(t *testing.T) {
+	cm := NewContextManager()
+	cm.SetMaxHistorySize(2)
+	for i := 0; i < 3; i++ {
+		cm.StartLoop(i, "test")
+		if err := cm.FinishLoop(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cm.GetLoopByIndex(0) != nil || cm.SpilledLoops() != 0 {
+		t.Error("未設定 spillStore 時舊迴圈應被捨棄")
+	}
+}
+
+// TestSpillHistoryToDiskClient 測試客戶端設定 SpillHistoryToDisk 後可取得被移出記憶體的迴圈
+func TestSpillHistoryToDiskClient(t *testing.T) {
+	config := DefaultClientConfig()
+	config.MaxHistorySize = 1
+	config.SpillHistoryToDisk = true
+	config.SerializePersistence = false
+	config.SaveDir = t.TempDir()
+	config.Silent = true
+	client := NewRalphLoopClientWithConfig(config)
+	defer client.Close()
+
+	loop := 0
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		loop++
+		out := fmt.Sprintf("迴圈 %d\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---", loop)
+		return &ExecutionResult{Command: "copilot", Stdout: out}, nil
+	}
+	if _, err := client.ExecuteUntilCompletion(context.Background(), "test", 3); err == nil {
+		t.Fatal("未完成時應回傳達到迴圈上限的錯誤")
+	}
+
+	first := client.contextManager.GetLoopByIndex(0)
+	if first == nil || !strings.HasPrefix(first.CLIOutput, "迴圈 1\n") {
+		t.Fatalf("第 1 個迴圈應從磁碟讀回: %+v", first)
+	}
+	if last := client.contextManager.GetLoopHistory(); len(last) != 1 || last[0].LoopIndex != 2 {
+		t.Errorf("記憶體中應只剩第 3 個迴圈: %+v", last)
+	}
+}
@@ -0,0 +1,171 @@
+package ghcopilot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HookResult 鉤子命令（驗證、格式化）的執行結果
+type HookResult struct {
+	Command  string        `json:"command"`
+	ExitCode int           `json:"exit_code"` // 退出碼（無法執行、逾時或被取消時為 -1）
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	Duration time.Duration `json:"duration"`
+	TimedOut bool          `json:"timed_out"` // 是否超過逾時
+	Canceled bool          `json:"canceled"`  // 是否因迴圈的 context 被取消而中止
+	Err      error         `json:"-"`         // 無法執行或非零退出時的錯誤
+}
+
+// OK 命令是否在時限內以退出碼 0 結束
+func (r *HookResult) OK() bool {
+	return r.Err == nil && r.ExitCode == 0
+}
+
+// HookRunner 執行鉤子命令，統一套用逾時、取消與環境變數限制
+//
+// 命令在工作目錄中執行，環境變數套用與 copilot 相同的白名單/黑名單。
+// 逾時或迴圈的 context 被取消時終止命令（含子進程），WaitDelay 之後不再等待
+// 仍持有輸出管線的孫進程，因此卡住的鉤子不會拖住整個執行。
+type HookRunner struct {
+	Dir      string
+	Env      []string
+	Timeout  time.Duration                      // 0 表示不限制（仍受 context 約束）
+	OnOutput func(command, stream, line string) // 逐行回報輸出（stream 為 "stdout" 或 "stderr"），可為 nil
+}
+
+// hookWaitDelay 命令被終止後等待輸出管線關閉的時間
+const hookWaitDelay = time.Second
+
+// hookRunner 建立使用客戶端設定的 HookRunner；timeout 會受 HookTimeout 限制
+func (c *RalphLoopClient) hookRunner(timeout time.Duration) *HookRunner {
+	if c.config.HookTimeout > 0 && (timeout <= 0 || c.config.HookTimeout < timeout) {
+		timeout = c.config.HookTimeout
+	}
+	return &HookRunner{
+		Dir:      c.config.WorkDir,
+		Env:      filterEnv(os.Environ(), c.config.EnvAllowlist, c.config.EnvDenylist),
+		Timeout:  timeout,
+		OnOutput: c.config.OnHookOutput,
+	}
+}
+
+// RunShell 以 shell 執行命令（Windows 為 cmd /C），以便使用管線與 &&
+func (r *HookRunner) RunShell(ctx context.Context, command string) *HookResult {
+	if runtime.GOOS == "windows" {
+		return r.run(ctx, command, "cmd", "/C", command)
+	}
+	return r.run(ctx, command, "sh", "-c", command)
+}
+
+// Run 直接執行命令，不經過 shell
+func (r *HookRunner) Run(ctx context.Context, args []string) *HookResult {
+	if len(args) == 0 {
+		return &HookResult{ExitCode: -1, Err: errors.New("沒有要執行的命令")}
+	}
+	return r.run(ctx, strings.Join(args, " "), args[0], args[1:]...)
+}
+
+// run 執行命令並收集結果
+func (r *HookRunner) run(ctx context.Context, display, name string, args ...string) *HookResult {
+	result := &HookResult{Command: display, ExitCode: -1}
+
+	cmdCtx, cancel := ctx, context.CancelFunc(func() {})
+	if r.Timeout > 0 {
+		cmdCtx, cancel = context.WithTimeout(ctx, r.Timeout)
+	}
+	defer cancel()
+
+	// #nosec G204 -- 命令來自使用者設定的鉤子（VerifyCommand、PostLoopFormatters）
+	cmd := exec.CommandContext(cmdCtx, name, args...)
+	cmd.Dir = r.Dir
+	cmd.Env = r.Env
+	cmd.WaitDelay = hookWaitDelay
+	setSysProcAttr(cmd)
+	cmd.Cancel = func() error {
+		killProcessTree(cmd.Process.Pid)
+		return cmd.Process.Kill()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	var streams []*hookLineWriter
+	if r.OnOutput != nil {
+		var mu sync.Mutex // 同時回報 stdout 與 stderr 時保持每一行完整
+		streams = []*hookLineWriter{
+			{buf: &stdout, mu: &mu, emit: func(line string) { r.OnOutput(display, "stdout", line) }},
+			{buf: &stderr, mu: &mu, emit: func(line string) { r.OnOutput(display, "stderr", line) }},
+		}
+		cmd.Stdout, cmd.Stderr = streams[0], streams[1]
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	for _, w := range streams {
+		w.Flush()
+	}
+	result.Duration = time.Since(start)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		result.Canceled = true
+		result.Err = fmt.Errorf("鉤子命令被取消: %w", ctx.Err())
+	case cmdCtx.Err() == context.DeadlineExceeded:
+		result.TimedOut = true
+		result.Err = fmt.Errorf("鉤子命令超過 %v 未完成", r.Timeout)
+	case err == nil:
+		result.ExitCode = 0
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		result.Err = fmt.Errorf("退出碼 %d", result.ExitCode)
+	default:
+		result.Err = fmt.Errorf("無法執行鉤子命令: %w", err)
+	}
+	return result
+}
+
+// hookLineWriter 保留完整輸出，並在每個換行時回報該行
+type hookLineWriter struct {
+	buf     *bytes.Buffer
+	mu      *sync.Mutex
+	emit    func(line string)
+	partial []byte
+}
+
+// Write 實作 io.Writer
+func (w *hookLineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	w.partial = append(w.partial, p...)
+	for {
+		idx := bytes.IndexByte(w.partial, '\n')
+		if idx < 0 {
+			break
+		}
+		w.emit(strings.TrimRight(string(w.partial[:idx]), "\r"))
+		w.partial = w.partial[idx+1:]
+	}
+	return len(p), nil
+}
+
+// Flush 回報最後一行沒有換行結尾的輸出
+func (w *hookLineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.emit(string(w.partial))
+		w.partial = nil
+	}
+}
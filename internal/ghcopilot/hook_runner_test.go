@@ -0,0 +1,123 @@
+package ghcopilot
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// skipOnWindows 鉤子測試使用 sh 語法
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("鉤子測試使用 sh 語法")
+	}
+}
+
+// TestHookRunnerResult 測試成功與非零退出的結構化結果
+func TestHookRunnerResult(t *testing.T) {
+	skipOnWindows(t)
+	runner := &HookRunner{Dir: t.TempDir()}
+
+	ok := runner.RunShell(context.Background(), "echo out; echo err >&2")
+	if !ok.OK() || ok.Stdout != "out\n" || ok.Stderr != "err\n" {
+		t.Errorf("應分別保存 stdout 與 stderr: %+v", ok)
+	}
+
+	failed := runner.RunShell(context.Background(), "echo broken; exit 3")
+	if failed.OK() || failed.ExitCode != 3 || failed.TimedOut || failed.Canceled {
+		t.Errorf("應回報退出碼 3: %+v", failed)
+	}
+	if failed.Err == nil || !strings.Contains(failed.Err.Error(), "3") {
+		t.Errorf("非零退出應回傳錯誤: %v", failed.Err)
+	}
+}
+
+// TestHookRunnerTimeout 測試卡住的鉤子（含仍持有輸出的子進程）在逾時後很快返回
+func TestHookRunnerTimeout(t *testing.T) {
+	skipOnWindows(t)
+	runner := &HookRunner{Dir: t.TempDir(), Timeout: 100 * time.Millisecond}
+
+	start := time.Now()
+	result := runner.RunShell(context.Background(), "sleep 30; echo never")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("逾時後應很快返回，實際 %v", elapsed)
+	}
+	if !result.TimedOut || result.ExitCode != -1 || result.OK() {
+		t.Errorf("應標記為逾時: %+v", result)
+	}
+}
+
+// TestHookRunnerCancellation 測試迴圈的 context 被取消時終止鉤子
+func TestHookRunnerCancellation(t *testing.T) {
+	skipOnWindows(t)
+	runner := &HookRunner{Dir: t.TempDir(), Timeout: time.Minute}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	result := runner.RunShell(ctx, "sleep 30")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("取消後應很快返回，實際 %v", elapsed)
+	}
+	if !result.Canceled || result.TimedOut {
+		t.Errorf("應標記為取消而非逾時: %+v", result)
+	}
+}
+
+// TestHookRunnerStreamsOutput 測試逐行回報輸出
+func TestHookRunnerStreamsOutput(t *testing.T) {
+	skipOnWindows(t)
+	var mu sync.Mutex
+	var lines []string
+	runner := &HookRunner{
+		Dir: t.TempDir(),
+		OnOutput: func(command, stream, line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, stream+":"+line)
+		},
+	}
+
+	result := runner.RunShell(context.Background(), "echo one; echo two >&2; printf three")
+	if !result.OK() || result.Stdout != "one\nthree" {
+		t.Fatalf("串流時仍應保存完整輸出: %+v", result)
+	}
+	want := map[string]bool{"stdout:one": true, "stderr:two": true, "stdout:three": true}
+	if len(lines) != len(want) {
+		t.Fatalf("應回報 3 行，實際 %v", lines)
+	}
+	for _, l := range lines {
+		if !want[l] {
+			t.Errorf("非預期的輸出行 %q", l)
+		}
+	}
+}
+
+// TestHookTimeoutCapsVerifyCommand 測試 HookTimeout 限制驗證命令的逾時，卡住的驗證命令不會拖住迴圈
+func TestHookTimeoutCapsVerifyCommand(t *testing.T) {
+	skipOnWindows(t)
+	config := DefaultClientConfig()
+	config.VerifyCommand = "sleep 30"
+	config.HookTimeout = 100 * time.Millisecond
+	client := newScriptedClient(config, "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: done\n---END_RALPH_STATUS---")
+
+	start := time.Now()
+	result, err := client.ExecuteLoop(context.Background(), "修正測試")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("HookTimeout 應限制驗證命令，實際 %v", elapsed)
+	}
+	if result.Verify == nil || result.Verify.Passed || !strings.Contains(result.Verify.Reason, "100ms") {
+		t.Errorf("驗證命令應因逾時而未通過: %+v", result.Verify)
+	}
+	if !result.ShouldContinue {
+		t.Error("驗證逾時後應繼續迴圈")
+	}
+}
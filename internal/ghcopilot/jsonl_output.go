@@ -0,0 +1,114 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// JSONL 輸出的事件類型
+const (
+	EventTypeLoop    = "loop"
+	EventTypeSummary = "summary"
+)
+
+// LoopEvent JSONL 輸出中單一迴圈的事件
+type LoopEvent struct {
+	Type            string    `json:"type"` // EventTypeLoop
+	Loop            int       `json:"loop"` // 迴圈編號（從 1 起算）
+	LoopID          string    `json:"loop_id"`
+	Timestamp       time.Time `json:"timestamp"`
+	ShouldContinue  bool      `json:"should_continue"`
+	ExitReason      string    `json:"exit_reason,omitempty"`
+	CompletionScore int       `json:"completion_score"`
+	Confidence      *int      `json:"confidence,omitempty"`
+	Model           string    `json:"model,omitempty"`
+	FilesChanged    int       `json:"files_changed,omitempty"`
+	Warnings        []string  `json:"warnings,omitempty"`
+}
+
+// SummaryEvent JSONL 輸出最後一行的執行摘要，以 type 與迴圈事件區分
+type SummaryEvent struct {
+	Type            string `json:"type"` // EventTypeSummary
+	Loops           int    `json:"loops"`
+	DurationMs      int64  `json:"duration_ms"`
+	Status          string `json:"status"`
+	Reason          string `json:"reason"`
+	EstimatedTokens int    `json:"estimated_tokens"`
+	BreakerTrips    int    `json:"breaker_trips"`
+	Warnings        int    `json:"warnings"`
+}
+
+// NewLoopEvent 從迴圈結果建立事件（不含完整輸出）
+func NewLoopEvent(result *LoopResult) *LoopEvent {
+	return &LoopEvent{
+		Type:            EventTypeLoop,
+		Loop:            result.LoopIndex + 1,
+		LoopID:          result.LoopID,
+		Timestamp:       result.Timestamp,
+		ShouldContinue:  result.ShouldContinue,
+		ExitReason:      result.ExitReason,
+		CompletionScore: result.CompletionScore,
+		Confidence:      result.Confidence,
+		Model:           result.Model,
+		FilesChanged:    result.FilesChanged,
+		Warnings:        result.Warnings,
+	}
+}
+
+// JSONLWriter 每個迴圈結束時寫出一行 JSON，最後寫出摘要，供 CI 即時追蹤
+//
+// 將 OnLoopComplete 設為 ClientConfig.OnLoopComplete 即可在迴圈結束時立即輸出。
+type JSONLWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONLWriter 建立寫入 w 的 JSONLWriter
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{enc: json.NewEncoder(w)}
+}
+
+// OnLoopComplete 寫出迴圈事件；寫入錯誤保留到 Err
+func (j *JSONLWriter) OnLoopComplete(result *LoopResult) {
+	if result == nil {
+		return
+	}
+	_ = j.write(NewLoopEvent(result))
+}
+
+// WriteSummary 寫出執行摘要（應為最後一行）
+func (j *JSONLWriter) WriteSummary(s *RunSummary) error {
+	return j.write(&SummaryEvent{
+		Type:            EventTypeSummary,
+		Loops:           s.Loops,
+		DurationMs:      s.DurationMs,
+		Status:          s.Status,
+		Reason:          s.Reason,
+		EstimatedTokens: s.EstimatedTokens,
+		BreakerTrips:    s.BreakerTrips,
+		Warnings:        s.Warnings,
+	})
+}
+
+// Err 傳回第一個寫入錯誤
+func (j *JSONLWriter) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// write 以單一行寫出事件
+func (j *JSONLWriter) write(v interface{}) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.enc.Encode(v); err != nil {
+		if j.err == nil {
+			j.err = err
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package ghcopilot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestJSONLWriterStreamsLoops 測試每個迴圈結束時立即輸出一行，最後一行為摘要
+func TestJSONLWriterStreamsLoops(t *testing.T) {
+	var buf bytes.Buffer
+	jsonl := NewJSONLWriter(&buf)
+
+	config := DefaultClientConfig()
+	config.OnLoopComplete = jsonl.OnLoopComplete
+	client := newScriptedClient(config, "")
+
+	var linesBeforeLoop []int
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		linesBeforeLoop = append(linesBeforeLoop, strings.Count(buf.String(), "\n"))
+		if len(linesBeforeLoop) == 1 {
+			return &ExecutionResult{Command: "copilot", Stdout: "修改中\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---"}, nil
+		}
+		return &ExecutionResult{Command: "copilot", Stdout: "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: done\n---END_RALPH_STATUS---"}, nil
+	}
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 5)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("應執行 2 個迴圈後完成: %d, %v", len(results), err)
+	}
+	if linesBeforeLoop[1] != 1 {
+		t.Errorf("第 2 個迴圈開始前應已輸出第 1 個迴圈的事件，實際 %d 行", linesBeforeLoop[1])
+	}
+	if err := jsonl.WriteSummary(client.BuildRunSummary(err, time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("應有 2 個迴圈事件與 1 個摘要，實際 %d 行:\n%s", len(lines), buf.String())
+	}
+	for i, line := range lines[:2] {
+		var ev LoopEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("第 %d 行不是合法 JSON: %v", i+1, err)
+		}
+		if ev.Type != EventTypeLoop || ev.Loop != i+1 || ev.ShouldContinue != (i == 0) {
+			t.Errorf("第 %d 個迴圈事件不正確: %+v", i+1, ev)
+		}
+	}
+
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[2]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary["type"] != EventTypeSummary || summary["status"] != RunStatusCompleted || summary["loops"] != float64(2) {
+		t.Errorf("摘要事件不正確: %v", summary)
+	}
+	if jsonl.Err() != nil {
+		t.Errorf("不應有寫入錯誤: %v", jsonl.Err())
+	}
+}
@@ -0,0 +1,155 @@
+package ghcopilot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogFileName 監控模式顯示的日誌檔名（位於 SaveDir）
+const LogFileName = "ralph-loop.log"
+
+// LogFilePath 傳回 saveDir 中的日誌檔路徑（run 寫入、watch 讀取）
+func LogFilePath(saveDir string) string {
+	return filepath.Join(saveDir, LogFileName)
+}
+
+// logRotateBytes 日誌檔達到此大小時，下一次開啟前先輪替為 LogFileName + ".1"
+var logRotateBytes int64 = 10 * 1024 * 1024
+
+// OpenLogFile 以附加模式開啟 saveDir 的日誌檔，供 SetLogOutput 寫入
+//
+// 不截斷既有內容，同一個 SaveDir 中另一個仍在執行的 run 不會遺失日誌；
+// 開啟後先寫入一行執行開始的標記，區分不同的執行。
+// 檔案過大時先改名為 .1（覆蓋舊的備份）再建立新檔，LogTailer 會把輪替視為新檔案。
+func OpenLogFile(saveDir string) (*os.File, error) {
+	if err := os.MkdirAll(saveDir, 0750); err != nil {
+		return nil, fmt.Errorf("無法建立日誌目錄: %w", err)
+	}
+	path := LogFilePath(saveDir)
+	if info, err := os.Stat(path); err == nil && info.Size() >= logRotateBytes {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return nil, fmt.Errorf("無法輪替日誌檔: %w", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600) // #nosec G304 -- 路徑由設定的 SaveDir 組成
+	if err != nil {
+		return nil, fmt.Errorf("無法建立日誌檔: %w", err)
+	}
+	fmt.Fprintf(f, "===== %s 開始執行 (pid %d) =====\n", time.Now().Format(time.RFC3339), os.Getpid())
+	return f, nil
+}
+
+// logTailReadLimit 第一次讀取時最多讀取檔案結尾的位元組數，避免讀入整個大檔案
+const logTailReadLimit = 64 * 1024
+
+// LogTailer 追蹤日誌檔最後幾行，每次 Poll 只讀取新寫入的內容
+//
+// 檔案被截斷（大小小於已讀取的位置）或輪替（改名後重新建立）時改從新檔案開頭讀取；
+// 檔案暫時不存在時不視為錯誤。
+type LogTailer struct {
+	path     string
+	maxLines int
+	offset   int64
+	info     os.FileInfo
+	lines    []string
+	partial  string // 尚未以換行結尾的最後一行
+}
+
+// NewLogTailer 建立追蹤 path 最後 maxLines 行的 LogTailer（maxLines < 1 時視為 1）
+func NewLogTailer(path string, maxLines int) *LogTailer {
+	if maxLines < 1 {
+		maxLines = 1
+	}
+	return &LogTailer{path: path, maxLines: maxLines}
+}
+
+// Path 傳回追蹤的檔案路徑
+func (t *LogTailer) Path() string {
+	return t.path
+}
+
+// Poll 讀取新寫入的內容，傳回目前最後的幾行（檔案不存在時傳回 nil）
+func (t *LogTailer) Poll() ([]string, error) {
+	info, err := os.Stat(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.reset(nil)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取日誌: %w", err)
+	}
+	if t.info != nil && (!os.SameFile(t.info, info) || info.Size() < t.offset) {
+		t.reset(info)
+	}
+	t.info = info
+
+	if info.Size() > t.offset {
+		if err := t.readFrom(info.Size()); err != nil {
+			return nil, err
+		}
+	}
+	return t.tail(), nil
+}
+
+// reset 清除已讀取的內容（檔案截斷或輪替時）
+func (t *LogTailer) reset(info os.FileInfo) {
+	t.info = info
+	t.offset = 0
+	t.lines = nil
+	t.partial = ""
+}
+
+// readFrom 讀取 offset 到 size 之間的內容並更新行緩衝
+func (t *LogTailer) readFrom(size int64) error {
+	f, err := os.Open(t.path) // #nosec G304 -- 路徑由呼叫端指定的日誌檔
+	if err != nil {
+		return fmt.Errorf("無法開啟日誌: %w", err)
+	}
+	defer f.Close()
+
+	// 第一次讀取大檔案時只讀結尾，略過被切斷的第一行
+	skipFirst := false
+	if t.offset == 0 && size > logTailReadLimit {
+		t.offset = size - logTailReadLimit
+		skipFirst = true
+	}
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("無法讀取日誌: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(f, size-t.offset))
+	if err != nil {
+		return fmt.Errorf("無法讀取日誌: %w", err)
+	}
+	t.offset += int64(len(data))
+
+	parts := strings.Split(t.partial+string(data), "\n")
+	t.partial = parts[len(parts)-1]
+	parts = parts[:len(parts)-1]
+	if skipFirst && len(parts) > 0 {
+		parts = parts[1:]
+	}
+	for _, line := range parts {
+		t.lines = append(t.lines, strings.TrimRight(line, "\r"))
+	}
+	if len(t.lines) > t.maxLines {
+		t.lines = append([]string(nil), t.lines[len(t.lines)-t.maxLines:]...)
+	}
+	return nil
+}
+
+// tail 傳回最後 maxLines 行（含尚未換行的最後一行）
+func (t *LogTailer) tail() []string {
+	lines := append([]string(nil), t.lines...)
+	if t.partial != "" {
+		lines = append(lines, strings.TrimRight(t.partial, "\r"))
+	}
+	if len(lines) > t.maxLines {
+		lines = lines[len(lines)-t.maxLines:]
+	}
+	return lines
+}
@@ -0,0 +1,167 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// appendLog 在日誌檔結尾寫入 text
+func appendLog(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600) // #nosec G304 -- test temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLogTailerFollowsAppends 測試只保留最後幾行，並接續讀取新寫入（含尚未換行）的內容
+func TestLogTailerFollowsAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LogFileName)
+	tailer := NewLogTailer(path, 3)
+
+	if lines, err := tailer.Poll(); err != nil || lines != nil {
+		t.Fatalf("檔案不存在時應傳回 nil: %v %v", lines, err)
+	}
+
+	appendLog(t, path, "迴圈 1\n迴圈 2\n迴圈 3\n迴圈 4\n正在執行")
+	lines, err := tailer.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"迴圈 3", "迴圈 4", "正在執行"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("應為 %q，實際 %q", want, lines)
+	}
+
+	appendLog(t, path, " go test\r\n迴圈 5\n")
+	lines, _ = tailer.Poll()
+	if want := []string{"迴圈 4", "正在執行 go test", "迴圈 5"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("應接續未換行的內容，實際 %q", lines)
+	}
+}
+
+// TestLogTailerTruncateAndRotate 測試檔案截斷與輪替後改讀新內容
+func TestLogTailerTruncateAndRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LogFileName)
+	tailer := NewLogTailer(path, 5)
+
+	appendLog(t, path, "舊的第一行\n舊的第二行\n")
+	_, _ = tailer.Poll()
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendLog(t, path, "截斷後\n")
+	if lines, _ := tailer.Poll(); !reflect.DeepEqual(lines, []string{"截斷後"}) {
+		t.Errorf("截斷後應只顯示新內容，實際 %q", lines)
+	}
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if lines, _ := tailer.Poll(); lines != nil {
+		t.Errorf("輪替中檔案不存在時應傳回 nil，實際 %q", lines)
+	}
+	appendLog(t, path, "輪替後的一行比原本的內容還要長很多很多\n")
+	if lines, _ := tailer.Poll(); !reflect.DeepEqual(lines, []string{"輪替後的一行比原本的內容還要長很多很多"}) {
+		t.Errorf("輪替後應讀取新檔案，實際 %q", lines)
+	}
+}
+
+// TestLogTailerLargeFile 測試第一次讀取大檔案時只讀結尾
+func TestLogTailerLargeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LogFileName)
+	appendLog(t, path, strings.Repeat("填充的日誌內容\n", logTailReadLimit/8)+"最後一行\n")
+
+	lines, err := NewLogTailer(path, 2).Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"填充的日誌內容", "最後一行"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("應為 %q，實際 %q", want, lines)
+	}
+}
+
+// TestOpenLogFileReceivesLogs 測試設定日誌檔後 infoLog 寫入 LogFileName，LogTailer 可讀到
+func TestOpenLogFileReceivesLogs(t *testing.T) {
+	t.Setenv("RALPH_SILENT", "")
+	dir := filepath.Join(t.TempDir(), "saves")
+	f, err := OpenLogFile(dir)
+	if err != nil {
+		t.Fatalf("OpenLogFile 失敗: %v", err)
+	}
+	defer f.Close()
+	SetLogOutput(f)
+	defer SetLogOutput(nil)
+
+	infoLog("迴圈 %d 開始", 1)
+
+	lines, err := NewLogTailer(filepath.Join(dir, LogFileName), 5).Poll()
+	if err != nil {
+		t.Fatalf("Poll 失敗: %v", err)
+	}
+	if len(lines) != 2 || !strings.Contains(lines[0], "開始執行") || !strings.HasSuffix(lines[1], "迴圈 1 開始") {
+		t.Errorf("日誌檔應包含執行開始標記與 infoLog 的輸出，實際 %q", lines)
+	}
+}
+
+// TestOpenLogFileKeepsOtherRuns 測試再次開啟日誌檔不會清除另一個執行已寫入的內容
+func TestOpenLogFileKeepsOtherRuns(t *testing.T) {
+	dir := t.TempDir()
+	first, err := OpenLogFile(dir)
+	if err != nil {
+		t.Fatalf("OpenLogFile 失敗: %v", err)
+	}
+	defer first.Close()
+	fmt.Fprintln(first, "第一個執行")
+
+	second, err := OpenLogFile(dir)
+	if err != nil {
+		t.Fatalf("OpenLogFile 失敗: %v", err)
+	}
+	defer second.Close()
+	fmt.Fprintln(second, "第二個執行")
+	fmt.Fprintln(first, "第一個執行仍在寫入")
+
+	data, err := os.ReadFile(filepath.Join(dir, LogFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"第一個執行\n", "第二個執行\n", "第一個執行仍在寫入\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("日誌檔應包含 %q:\n%s", want, data)
+		}
+	}
+}
+
+// TestOpenLogFileRotates 測試日誌檔過大時輪替為 .1
+func TestOpenLogFileRotates(t *testing.T) {
+	old := logRotateBytes
+	logRotateBytes = 16
+	defer func() { logRotateBytes = old }()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, LogFileName)
+	if err := os.WriteFile(path, []byte("上一次執行的大量日誌\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := OpenLogFile(dir)
+	if err != nil {
+		t.Fatalf("OpenLogFile 失敗: %v", err)
+	}
+	f.Close()
+
+	if data, err := os.ReadFile(path + ".1"); err != nil || string(data) != "上一次執行的大量日誌\n" {
+		t.Errorf("舊的日誌應輪替到 .1，實際 %q (%v)", data, err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "上一次執行") {
+		t.Errorf("新的日誌檔不應包含舊內容: %q", data)
+	}
+}
@@ -0,0 +1,65 @@
+package ghcopilot
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// newMockClient 建立使用 MockMode 的客戶端（不依賴環境變數與 PATH 中的 copilot）
+func newMockClient(t *testing.T, responses ...string) *RalphLoopClient {
+	t.Helper()
+	t.Setenv("COPILOT_MOCK_MODE", "")
+	t.Setenv("PATH", t.TempDir())
+
+	config := DefaultClientConfig()
+	config.EnablePersistence = false
+	config.Silent = true
+	config.MockMode = true
+	config.MockResponses = responses
+	client := NewRalphLoopClientWithConfig(config)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// TestMockModeExitsOnCompletion 測試依序回傳模擬回應，出現完成訊號時結束迴圈
+func TestMockModeExitsOnCompletion(t *testing.T) {
+	client := newMockClient(t,
+		"正在修正 parser.go\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---",
+		"正在修正 lexer.go\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---",
+		"所有任務已完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\n---END_RALPH_STATUS---",
+		"不應執行到這裡",
+	)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正編譯錯誤", 10)
+	if err != nil {
+		t.Fatalf("應正常完成: %v", err)
+	}
+	if len(results) != 3 || results[2].ShouldContinue {
+		t.Fatalf("應在第 3 個迴圈完成，實際 %d 個迴圈", len(results))
+	}
+	if !strings.Contains(results[0].Output, "parser.go") || !strings.Contains(results[2].Output, "所有任務已完成") {
+		t.Errorf("應依序使用模擬回應: %q / %q", results[0].Output, results[2].Output)
+	}
+
+	cli := findMode(t, client.GetExecutionModes(), ModeCLI)
+	if !cli.Available || !cli.Selected || !strings.Contains(cli.Reason, "MockMode") {
+		t.Errorf("MockMode 下 CLI 應可用並被選用: %+v", cli)
+	}
+}
+
+// TestMockModeCyclesResponses 測試模擬回應用完後從頭循環
+func TestMockModeCyclesResponses(t *testing.T) {
+	client := newMockClient(t, "第一個", "第二個")
+	var outputs []string
+	for i := 0; i < 3; i++ {
+		result, err := client.executor.ExecutePrompt(context.Background(), "修正")
+		if err != nil || !result.Success {
+			t.Fatalf("模擬執行應成功: %v", err)
+		}
+		outputs = append(outputs, result.Stdout)
+	}
+	if strings.Join(outputs, ",") != "第一個,第二個,第一個" {
+		t.Errorf("應循環回傳模擬回應: %v", outputs)
+	}
+}
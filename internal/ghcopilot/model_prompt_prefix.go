@@ -0,0 +1,24 @@
+package ghcopilot
+
+// modelPromptPrefix 傳回目前使用的模型在 ModelPromptPrefixes 中的前綴（沒有設定時為空字串）
+//
+// 模型升級後使用 EscalateModel 的前綴；未指定模型時以預設模型查詢。
+func (c *RalphLoopClient) modelPromptPrefix() string {
+	if len(c.config.ModelPromptPrefixes) == 0 {
+		return ""
+	}
+	model := Model(c.activeModel())
+	if model == "" {
+		model = DefaultOptions().Model
+	}
+	return c.config.ModelPromptPrefixes[model]
+}
+
+// applyModelPromptPrefix 將模型前綴加在 prompt 最前面，回傳新的 prompt 與前綴的字元數
+func (c *RalphLoopClient) applyModelPromptPrefix(prompt string) (string, int) {
+	prefix := c.modelPromptPrefix()
+	if prefix == "" {
+		return prompt, 0
+	}
+	return prefix + "\n\n" + prompt, len([]rune(prefix))
+}
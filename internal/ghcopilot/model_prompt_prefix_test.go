@@ -0,0 +1,71 @@
+package ghcopilot
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestModelPromptPrefixPerModel 測試依設定的模型加上對應的前綴
+func TestModelPromptPrefixPerModel(t *testing.T) {
+	prefixes := map[Model]string{
+		ModelGPT51Codex:     "簡短回答，直接修改程式碼。",
+		ModelClaudeSonnet45: "請詳細說明每個步驟的理由，再進行修改。",
+	}
+	tests := []struct {
+		model  string
+		prefix string
+	}{
+		{string(ModelGPT51Codex), prefixes[ModelGPT51Codex]},
+		{string(ModelClaudeSonnet45), prefixes[ModelClaudeSonnet45]},
+		{"", prefixes[ModelClaudeSonnet45]}, // 未指定時使用預設模型
+		{string(ModelGPT5), ""},
+	}
+	for _, tt := range tests {
+		config := DefaultClientConfig()
+		config.Model = tt.model
+		config.ModelPromptPrefixes = prefixes
+		client, prompts := newStepClient(config, progressStep)
+
+		result, err := client.ExecuteLoop(context.Background(), "修正測試")
+		if err != nil {
+			t.Fatalf("模型 %q: %v", tt.model, err)
+		}
+		if tt.prefix == "" {
+			if !strings.HasPrefix((*prompts)[0], "修正測試") || result.PromptPrefixChars != 0 {
+				t.Errorf("模型 %q 不應加上前綴: %q (%d)", tt.model, (*prompts)[0], result.PromptPrefixChars)
+			}
+			continue
+		}
+		if !strings.HasPrefix((*prompts)[0], tt.prefix+"\n\n修正測試") {
+			t.Errorf("模型 %q 應加上前綴 %q: %q", tt.model, tt.prefix, (*prompts)[0])
+		}
+		if result.PromptPrefixChars != len([]rune(tt.prefix)) {
+			t.Errorf("模型 %q 的前綴字元數應為 %d，實際 %d", tt.model, len([]rune(tt.prefix)), result.PromptPrefixChars)
+		}
+	}
+}
+
+// TestModelPromptPrefixAfterEscalation 測試模型升級後改用 EscalateModel 的前綴
+func TestModelPromptPrefixAfterEscalation(t *testing.T) {
+	config := DefaultClientConfig()
+	config.Model = string(ModelGPT51Codex)
+	config.EscalateAfterLoops = 1
+	config.EscalateModel = ModelClaudeOpus45
+	config.ModelPromptPrefixes = map[Model]string{
+		ModelGPT51Codex:   "[codex]",
+		ModelClaudeOpus45: "[opus]",
+	}
+	client, prompts := newStepClient(config, progressStep)
+
+	results, _ := client.ExecuteUntilCompletion(context.Background(), "修正測試", 2)
+	if len(*prompts) != 2 || len(results) != 2 {
+		t.Fatalf("應執行 2 個迴圈，實際 %d", len(*prompts))
+	}
+	if !strings.HasPrefix((*prompts)[0], "[codex]\n\n") {
+		t.Errorf("迴圈 1 應使用 codex 前綴: %q", (*prompts)[0])
+	}
+	if !strings.HasPrefix((*prompts)[1], "[opus]\n\n") || !results[1].Escalated {
+		t.Errorf("升級後應使用 opus 前綴: %q", (*prompts)[1])
+	}
+}
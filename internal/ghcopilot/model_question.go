@@ -0,0 +1,132 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// QuestionPolicy 模型提出問題時的處理方式
+type QuestionPolicy string
+
+const (
+	// QuestionAutoProceed 下一個迴圈要求模型依最佳判斷繼續
+	QuestionAutoProceed QuestionPolicy = "auto_proceed"
+	// QuestionAnswer 以 QuestionAnswers 回答，找不到答案時同 QuestionAutoProceed
+	QuestionAnswer QuestionPolicy = "answer"
+	// QuestionAbort 中止執行
+	QuestionAbort QuestionPolicy = "abort"
+)
+
+// autoProceedReply 沒有對應答案時附加的回覆
+const autoProceedReply = "目前沒有人可以回答問題，請依你的最佳判斷繼續，不要再詢問。"
+
+// questionTailLines 只檢查輸出最後幾行，避免把過程中的自問自答當成問題
+const questionTailLines = 3
+
+// questionPhrases 不以問號結尾、但要求使用者回覆的句型
+var questionPhrases = regexp.MustCompile(`(?i)^(would you like|do you want|should i|shall i|which (one|option)|please (confirm|let me know)|let me know (if|which|whether|how))|請(確認|告訴我|選擇)|要我|是否要`)
+
+// ModelQuestion 迴圈中偵測到的模型提問
+type ModelQuestion struct {
+	Text   string         `json:"text"`             // 問題內容
+	Action QuestionPolicy `json:"action"`           // 實際採取的處理方式
+	Answer string         `json:"answer,omitempty"` // 下一個迴圈附加的回覆（中止時為空）
+}
+
+// DetectQuestion 檢查輸出結尾是否在向使用者提問，傳回問題內容（沒有時為空字串）
+//
+// 只檢查 RALPH_STATUS 區塊之前、程式碼區塊之外的最後幾行。
+func DetectQuestion(output string) string {
+	if idx := strings.Index(output, "---RALPH_STATUS---"); idx >= 0 {
+		output = output[:idx]
+	}
+
+	var lines []string
+	inCode := false
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			continue
+		}
+		if !inCode && trimmed != "" {
+			lines = append(lines, trimmed)
+		}
+	}
+	if len(lines) > questionTailLines {
+		lines = lines[len(lines)-questionTailLines:]
+	}
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if strings.HasSuffix(line, "?") || strings.HasSuffix(line, "？") || questionPhrases.MatchString(line) {
+			return line
+		}
+	}
+	return ""
+}
+
+// handleModelQuestion 依 OnModelQuestion 處理模型的提問，沒有偵測到問題時傳回 nil
+func (c *RalphLoopClient) handleModelQuestion(execCtx *ExecutionContext, output string) *ModelQuestion {
+	text := DetectQuestion(output)
+	if text == "" {
+		return nil
+	}
+
+	q := &ModelQuestion{Text: text, Action: c.config.OnModelQuestion}
+	switch q.Action {
+	case QuestionAbort:
+	case QuestionAnswer:
+		if answer, ok := c.lookupQuestionAnswer(text); ok {
+			q.Answer = answer
+			break
+		}
+		q.Action = QuestionAutoProceed
+		q.Answer = autoProceedReply
+	default:
+		q.Action = QuestionAutoProceed
+		q.Answer = autoProceedReply
+	}
+
+	execCtx.Question = q
+	execCtx.AddWarning("模型提出問題（%s）: %s", q.Action, tailRunes(text, 200))
+	infoLog("❓ 模型提出問題，處理方式 %s: %s", q.Action, text)
+	return q
+}
+
+// lookupQuestionAnswer 在 QuestionAnswers 中找出關鍵字出現在問題中的回答（多個符合時取最長的關鍵字）
+func (c *RalphLoopClient) lookupQuestionAnswer(question string) (string, bool) {
+	keys := make([]string, 0, len(c.config.QuestionAnswers))
+	for k := range c.config.QuestionAnswers {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	lower := strings.ToLower(question)
+	for _, k := range keys {
+		if k != "" && strings.Contains(lower, strings.ToLower(k)) {
+			return c.config.QuestionAnswers[k], true
+		}
+	}
+	return "", false
+}
+
+// questionReplySuffix 上一個迴圈提出問題時，傳回下一個迴圈要附加的回覆
+func (c *RalphLoopClient) questionReplySuffix() string {
+	history := c.contextManager.GetLoopHistory()
+	if len(history) == 0 {
+		return ""
+	}
+	q := history[len(history)-1].Question
+	if q == nil || q.Answer == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\n[回覆你上一個迴圈的問題]\n問: %s\n答: %s", q.Text, q.Answer)
+}
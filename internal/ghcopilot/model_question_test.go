@@ -0,0 +1,124 @@
+package ghcopilot
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// questionOutput 結尾向使用者提問、未完成的模擬輸出
+const questionOutput = "已找到兩個設定檔。\nShould I update the staging database config too?\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---"
+
+// TestDetectQuestion 測試輸出結尾的提問偵測
+func TestDetectQuestion(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"英文問句", questionOutput, "Should I update the staging database config too?"},
+		{"中文全形問號", "修改完成一半。\n要繼續處理測試檔嗎？", "要繼續處理測試檔嗎？"},
+		{"不以問號結尾的請求", "有兩種做法。\nPlease confirm which approach to use.", "Please confirm which approach to use."},
+		{"程式碼中的問號", "```go\nx := a ? b : c\n```\n已修正。", ""},
+		{"過程中的自問自答", "為什麼會失敗？\n因為缺少 import。\n已補上。\n測試通過。", ""},
+		{"沒有問題", "已修正所有錯誤。", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectQuestion(tt.output); got != tt.want {
+				t.Errorf("DetectQuestion = %q，預期 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// questionStep 第一次呼叫提問、之後完成的 step
+func questionStep(call int, prompt string) (string, error) {
+	if call == 1 {
+		return questionOutput, nil
+	}
+	return doneOutput, nil
+}
+
+// TestModelQuestionAutoProceed 測試自動要求依最佳判斷繼續
+func TestModelQuestionAutoProceed(t *testing.T) {
+	config := DefaultClientConfig()
+	config.OnModelQuestion = QuestionAutoProceed
+	client, prompts := newStepClient(config, questionStep)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "更新設定", 5)
+	if err != nil {
+		t.Fatalf("自動繼續後應完成: %v", err)
+	}
+	q := results[0].Question
+	if q == nil || q.Action != QuestionAutoProceed || !strings.Contains(q.Text, "staging") {
+		t.Fatalf("第一個迴圈應記錄提問: %+v", q)
+	}
+	if !strings.Contains((*prompts)[1], "最佳判斷") {
+		t.Errorf("下一個迴圈應要求依最佳判斷繼續:\n%s", (*prompts)[1])
+	}
+}
+
+// TestModelQuestionAnswer 測試以 QuestionAnswers 回答
+func TestModelQuestionAnswer(t *testing.T) {
+	config := DefaultClientConfig()
+	config.OnModelQuestion = QuestionAnswer
+	config.QuestionAnswers = map[string]string{
+		"database":         "不要修改資料庫設定",
+		"staging database": "只修改 dev 環境，staging 不動",
+	}
+	client, prompts := newStepClient(config, questionStep)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "更新設定", 5)
+	if err != nil {
+		t.Fatalf("回答後應完成: %v", err)
+	}
+	if q := results[0].Question; q == nil || q.Action != QuestionAnswer || q.Answer != "只修改 dev 環境，staging 不動" {
+		t.Fatalf("應以最長的符合關鍵字回答: %+v", q)
+	}
+	if !strings.Contains((*prompts)[1], "答: 只修改 dev 環境") {
+		t.Errorf("下一個迴圈應附加回答:\n%s", (*prompts)[1])
+	}
+
+	// 沒有符合的答案時退回自動繼續
+	config.QuestionAnswers = map[string]string{"deploy": "不要部署"}
+	client, prompts = newStepClient(config, questionStep)
+	results, _ = client.ExecuteUntilCompletion(context.Background(), "更新設定", 5)
+	if q := results[0].Question; q == nil || q.Action != QuestionAutoProceed {
+		t.Errorf("找不到答案時應自動繼續: %+v", q)
+	}
+	if !strings.Contains((*prompts)[1], "最佳判斷") {
+		t.Errorf("找不到答案時應要求依最佳判斷繼續:\n%s", (*prompts)[1])
+	}
+}
+
+// TestModelQuestionAbort 測試提問時中止執行
+func TestModelQuestionAbort(t *testing.T) {
+	config := DefaultClientConfig()
+	config.OnModelQuestion = QuestionAbort
+	client, prompts := newStepClient(config, questionStep)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "更新設定", 5)
+	if err == nil || !strings.Contains(err.Error(), "model asked a question") {
+		t.Fatalf("提問時應中止並回傳錯誤，但為 %v", err)
+	}
+	if len(results) != 1 || len(*prompts) != 1 {
+		t.Fatalf("應在第 1 個迴圈中止: results=%d prompts=%d", len(results), len(*prompts))
+	}
+	if results[0].ShouldContinue || results[0].Question.Action != QuestionAbort {
+		t.Errorf("結果應標記為中止: %+v", results[0].Question)
+	}
+}
+
+// TestModelQuestionDisabled 測試未設定時不偵測
+func TestModelQuestionDisabled(t *testing.T) {
+	client, prompts := newStepClient(DefaultClientConfig(), questionStep)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "更新設定", 5)
+	if err != nil {
+		t.Fatalf("不應失敗: %v", err)
+	}
+	if results[0].Question != nil || strings.Contains((*prompts)[1], "回覆你上一個迴圈的問題") {
+		t.Error("未設定 OnModelQuestion 時不應偵測或回覆提問")
+	}
+}
@@ -0,0 +1,143 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ModelUsage 從 copilot 輸出解析出的實際模型與用量
+type ModelUsage struct {
+	ServedModel     string  `json:"served_model,omitempty"`     // 實際提供回應的模型
+	InputTokens     int     `json:"input_tokens,omitempty"`     // 輸入 token 數
+	OutputTokens    int     `json:"output_tokens,omitempty"`    // 輸出 token 數
+	PremiumRequests float64 `json:"premium_requests,omitempty"` // 估計使用的 premium request 數
+}
+
+// UsagePatterns 解析模型與用量的正規表示式，每個表示式的第一個擷取群組為值；空值使用預設
+//
+// 預設值對應 copilot CLI 結尾的用量摘要（"Usage by model:" 區塊），
+// 以 -s 安靜模式執行時 CLI 不輸出摘要，此時不會解析到任何資料。
+type UsagePatterns struct {
+	ServedModel     string `json:"served_model,omitempty"`
+	InputTokens     string `json:"input_tokens,omitempty"`
+	OutputTokens    string `json:"output_tokens,omitempty"`
+	PremiumRequests string `json:"premium_requests,omitempty"`
+}
+
+// DefaultUsagePatterns 傳回 copilot CLI 用量摘要的預設解析方式
+func DefaultUsagePatterns() UsagePatterns {
+	return UsagePatterns{
+		ServedModel:     `(?m)^\s*Usage by model:\s*\n\s*([A-Za-z0-9][\w.:/-]*)`,
+		InputTokens:     `(?i)([\d.,]+\s*[kmb]?)\s+input\b`,
+		OutputTokens:    `(?i)([\d.,]+\s*[kmb]?)\s+output\b`,
+		PremiumRequests: `(?i)Total usage est:\s*([\d.]+)\s+Premium requests?`,
+	}
+}
+
+// ParseModelUsage 以 patterns 解析輸出中的模型與用量；沒有任何符合時回傳 nil
+func ParseModelUsage(output string, patterns UsagePatterns) (*ModelUsage, error) {
+	defaults := DefaultUsagePatterns()
+	find := func(name, pattern, fallback string) (string, error) {
+		if pattern == "" {
+			pattern = fallback
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("無效的 %s 樣式 %q: %w", name, pattern, err)
+		}
+		if m := re.FindStringSubmatch(output); len(m) > 1 {
+			return strings.TrimSpace(m[1]), nil
+		}
+		return "", nil
+	}
+
+	usage := &ModelUsage{}
+	found := false
+	model, err := find("served_model", patterns.ServedModel, defaults.ServedModel)
+	if err != nil {
+		return nil, err
+	}
+	if model != "" {
+		usage.ServedModel = model
+		found = true
+	}
+	for _, f := range []struct {
+		name, pattern, fallback string
+		dst                     *int
+	}{
+		{"input_tokens", patterns.InputTokens, defaults.InputTokens, &usage.InputTokens},
+		{"output_tokens", patterns.OutputTokens, defaults.OutputTokens, &usage.OutputTokens},
+	} {
+		value, err := find(f.name, f.pattern, f.fallback)
+		if err != nil {
+			return nil, err
+		}
+		if n, ok := parseTokenCount(value); ok {
+			*f.dst = n
+			found = true
+		}
+	}
+	premium, err := find("premium_requests", patterns.PremiumRequests, defaults.PremiumRequests)
+	if err != nil {
+		return nil, err
+	}
+	if v, err := strconv.ParseFloat(premium, 64); err == nil {
+		usage.PremiumRequests = v
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return usage, nil
+}
+
+// parseTokenCount 解析 "12.3k"、"1,024"、"2m" 等 token 數
+func parseTokenCount(s string) (int, bool) {
+	s = strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(s, ",", ""), " ", ""))
+	if s == "" {
+		return 0, false
+	}
+	multiplier := 1.0
+	switch s[len(s)-1] {
+	case 'k':
+		multiplier = 1e3
+	case 'm':
+		multiplier = 1e6
+	case 'b':
+		multiplier = 1e9
+	}
+	if multiplier != 1 {
+		s = s[:len(s)-1]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int(v*multiplier + 0.5), true
+}
+
+// sameModel 判斷實際使用的模型是否就是要求的模型（忽略大小寫與日期等版本後綴）
+func sameModel(requested, served string) bool {
+	requested, served = strings.ToLower(requested), strings.ToLower(served)
+	return requested == served || strings.HasPrefix(served, requested+"-") || strings.HasPrefix(requested, served+"-")
+}
+
+// captureModelUsage 記錄輸出中的實際模型與用量，實際模型與要求的不同時加入警告
+func (c *RalphLoopClient) captureModelUsage(execCtx *ExecutionContext, output string) {
+	usage, err := ParseModelUsage(output, c.config.UsagePatterns)
+	if err != nil {
+		execCtx.AddWarning("無法解析模型用量: %v", err)
+		return
+	}
+	if usage == nil {
+		return
+	}
+	execCtx.Usage = usage
+	execCtx.ServedModel = usage.ServedModel
+	if usage.ServedModel != "" && execCtx.Model != "" && !sameModel(execCtx.Model, usage.ServedModel) {
+		execCtx.AddWarning("要求的模型為 %s，實際使用 %s", execCtx.Model, usage.ServedModel)
+	}
+}
@@ -0,0 +1,86 @@
+package ghcopilot
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// usageSummary 模擬 copilot CLI 結尾的用量摘要
+const usageSummary = `
+Total usage est:       1 Premium request
+Total duration (API):  5.2s
+Total code changes:    0 lines added, 0 lines removed
+Usage by model:
+    claude-haiku-4.5     12.3k input, 456 output, 0 cache read, 0 cache write (Est. 1 Premium request)
+`
+
+// TestParseModelUsage 測試解析 copilot CLI 的用量摘要
+func TestParseModelUsage(t *testing.T) {
+	usage, err := ParseModelUsage("完成\n"+usageSummary, UsagePatterns{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ModelUsage{ServedModel: "claude-haiku-4.5", InputTokens: 12300, OutputTokens: 456, PremiumRequests: 1}
+	if usage == nil || *usage != want {
+		t.Errorf("應解析出 %+v，實際 %+v", want, usage)
+	}
+
+	if usage, err := ParseModelUsage("沒有用量資訊", UsagePatterns{}); err != nil || usage != nil {
+		t.Errorf("沒有用量時應回傳 nil，實際 %+v %v", usage, err)
+	}
+}
+
+// TestParseModelUsageCustomPatterns 測試自訂解析樣式與無效的樣式
+func TestParseModelUsageCustomPatterns(t *testing.T) {
+	patterns := UsagePatterns{ServedModel: `served by (\S+)`, InputTokens: `in=([\d,]+)`}
+	usage, err := ParseModelUsage("served by gpt-5 in=1,024", patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.ServedModel != "gpt-5" || usage.InputTokens != 1024 {
+		t.Errorf("應使用自訂樣式: %+v", usage)
+	}
+
+	if _, err := ParseModelUsage("x", UsagePatterns{OutputTokens: "("}); err == nil {
+		t.Error("無效的樣式應回傳錯誤")
+	}
+}
+
+// TestServedModelMismatchWarning 測試實際模型與要求的不同時記錄並警告
+func TestServedModelMismatchWarning(t *testing.T) {
+	config := DefaultClientConfig()
+	config.Model = "claude-sonnet-4.5"
+	client := newScriptedClient(config, "已完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\n---END_RALPH_STATUS---\n"+usageSummary)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "test", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := results[0]
+	if result.Model != "claude-sonnet-4.5" || result.ServedModel != "claude-haiku-4.5" {
+		t.Errorf("應同時記錄要求與實際的模型: %q / %q", result.Model, result.ServedModel)
+	}
+	if result.Usage == nil || result.Usage.OutputTokens != 456 {
+		t.Errorf("應記錄用量: %+v", result.Usage)
+	}
+
+	history := client.contextManager.GetLoopHistory()
+	warned := false
+	for _, w := range history[len(history)-1].Warnings {
+		warned = warned || strings.Contains(w, "實際使用 claude-haiku-4.5")
+	}
+	if !warned {
+		t.Errorf("應警告模型不同: %v", history[len(history)-1].Warnings)
+	}
+}
+
+// TestSameModel 測試模型名稱比對忽略大小寫與版本後綴
+func TestSameModel(t *testing.T) {
+	if !sameModel("claude-sonnet-4.5", "Claude-Sonnet-4.5-20250929") {
+		t.Error("版本後綴不同應視為相同模型")
+	}
+	if sameModel("claude-sonnet-4.5", "claude-haiku-4.5") {
+		t.Error("不同模型不應視為相同")
+	}
+}
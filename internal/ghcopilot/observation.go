@@ -0,0 +1,83 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// ErrObservationViolation 觀察模式下工作目錄仍被修改
+var ErrObservationViolation = errors.New("observation mode violated")
+
+// observationDeniedTools 觀察模式禁止的工具：檔案寫入與 shell（shell 命令同樣能修改檔案）
+var observationDeniedTools = []string{"write", "shell"}
+
+// applyObservationMode 讓 CLI 以唯讀方式執行：不自動核准任何工具、路徑與網址（不使用 --yolo
+// 與 --allow-all-*），並禁止可修改檔案的工具；MCP 等其他工具也不會被自動核准
+func (ce *CLIExecutor) applyObservationMode() {
+	ce.options.AllowAllTools = false
+	ce.options.AllowAllPaths = false
+	ce.options.AllowAllURLs = false
+	for _, tool := range observationDeniedTools {
+		if !slices.Contains(ce.options.DeniedTools, tool) {
+			ce.options.DeniedTools = append(ce.options.DeniedTools, tool)
+		}
+	}
+}
+
+// snapshotObservationBaseline 記錄第一個迴圈前的檔案狀態；非 git 儲存庫時無法確認，只記錄警告
+func (c *RalphLoopClient) snapshotObservationBaseline(ctx context.Context) {
+	if !c.config.ObservationMode || c.observeBaseline != nil || c.observeSkipped {
+		return
+	}
+	hashes, err := c.changeBudgetHashes(ctx)
+	if err != nil {
+		c.observeSkipped = true
+		infoLog("⚠️ 工作目錄不是 git 儲存庫，觀察模式只能禁止寫入工具，無法確認檔案未被修改: %v", err)
+		return
+	}
+	c.observeBaseline = hashes
+}
+
+// resetObservation 清除觀察模式的基準（每次執行開始時呼叫）
+func (c *RalphLoopClient) resetObservation() {
+	c.observeBaseline = nil
+	c.observeSkipped = false
+}
+
+// checkObservation 傳回與執行前相比被修改的檔案（排序後）；未啟用或無法確認時為 nil
+func (c *RalphLoopClient) checkObservation(ctx context.Context, execCtx *ExecutionContext) []string {
+	if c.observeBaseline == nil {
+		return nil
+	}
+	current, err := c.changeBudgetHashes(ctx)
+	if err != nil {
+		execCtx.AddWarning("無法確認觀察模式下的檔案狀態: %v", err)
+		return nil
+	}
+
+	var changed []string
+	for p, h := range current {
+		if base, ok := c.observeBaseline[p]; !ok || base != h {
+			changed = append(changed, p)
+		}
+	}
+	for p := range c.observeBaseline {
+		if _, ok := current[p]; !ok {
+			changed = append(changed, p) // 執行前的修改被還原
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// observationReason 觀察模式下檔案被修改時的結束原因
+func observationReason(files []string) string {
+	shown := files
+	if len(shown) > 10 {
+		shown = append(shown[:10:10], "...")
+	}
+	return fmt.Sprintf("觀察模式下 %d 個檔案被修改，中止執行: %v", len(files), shown)
+}
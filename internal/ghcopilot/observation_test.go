@@ -0,0 +1,91 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestObservationModeDeniesWriteTools 測試觀察模式不使用 --yolo 並禁止寫入工具
+func TestObservationModeDeniesWriteTools(t *testing.T) {
+	config := DefaultClientConfig()
+	config.ObservationMode = true
+	config.DeniedTools = []string{"url"}
+	client := NewRalphLoopClientWithConfig(config)
+
+	args := client.executor.buildArgs("分析專案")
+	joined := strings.Join(args, " ")
+	for _, flag := range []string{"--yolo", "--allow-all-tools", "--allow-all-paths", "--allow-all-urls"} {
+		if slices.Contains(args, flag) {
+			t.Errorf("觀察模式不應使用 %s: %s", flag, joined)
+		}
+	}
+	for _, tool := range []string{"url", "write", "shell"} {
+		if !strings.Contains(joined, "--deny-tool "+tool) {
+			t.Errorf("應禁止工具 %s: %s", tool, joined)
+		}
+	}
+}
+
+// TestObservationModeAbortsOnChange 測試觀察模式下有檔案被修改時中止，執行前已存在的修改不計入
+func TestObservationModeAbortsOnChange(t *testing.T) {
+	dir := initGitRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main // 執行前的修改\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultClientConfig()
+	config.WorkDir = dir
+	config.ObservationMode = true
+	client, _ := newStepClient(config, writeFilesStep(t, dir, func(loop int) map[string]string {
+		if loop == 2 {
+			return map[string]string{"plan.md": "# 計畫\n"}
+		}
+		return nil
+	}))
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "分析專案", 5)
+	if !errors.Is(err, ErrObservationViolation) {
+		t.Fatalf("應回傳 ErrObservationViolation，實際 %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("第 2 個迴圈修改檔案後應中止，實際執行 %d 個迴圈", len(results))
+	}
+	if len(results[0].ObservationViolation) != 0 {
+		t.Errorf("執行前已存在的修改不應計入: %v", results[0].ObservationViolation)
+	}
+	if got := results[1].ObservationViolation; len(got) != 1 || got[0] != "plan.md" {
+		t.Errorf("應記錄被修改的 plan.md，實際 %v", got)
+	}
+}
+
+// TestObservationModeSubdirPreDirtied 測試工作目錄為子目錄時，執行前已修改的檔案再次改變仍視為違規
+func TestObservationModeSubdirPreDirtied(t *testing.T) {
+	_, sub := initGitRepoWithSubdir(t)
+	tracked := filepath.Join(sub, "a.go")
+	if err := os.WriteFile(tracked, []byte("package sub // 執行前的修改\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultClientConfig()
+	config.WorkDir = sub
+	config.ObservationMode = true
+	client, _ := newStepClient(config, writeFilesStep(t, sub, func(loop int) map[string]string {
+		if loop == 2 {
+			return map[string]string{"a.go": "package sub // 迴圈中的修改\n"}
+		}
+		return nil
+	}))
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "分析專案", 5)
+	if !errors.Is(err, ErrObservationViolation) {
+		t.Fatalf("應回傳 ErrObservationViolation，實際 %v", err)
+	}
+	if got := results[len(results)-1].ObservationViolation; len(got) != 1 || got[0] != "a.go" {
+		t.Errorf("應記錄被修改的 a.go，實際 %v", got)
+	}
+}
@@ -0,0 +1,212 @@
+package ghcopilot
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Diff 輸出中單一檔案的 unified diff
+type Diff struct {
+	FilePath string     // 修改後的路徑（刪除檔案時為原路徑；只有 hunk 時為空字串）
+	OldPath  string     // 修改前的路徑（新增檔案時為空字串）
+	Hunks    []DiffHunk // 依出現順序排列的 hunk
+	Added    int        // 新增的行數
+	Removed  int        // 刪除的行數
+}
+
+// DiffHunk 以 "@@ -a,b +c,d @@" 開頭的一段修改
+//
+// 沒有 hunk 標頭的 ```diff 區塊（只有 +/- 行）視為一個 Header 為空的 hunk。
+type DiffHunk struct {
+	Header   string
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []string // hunk 內容（保留行首的 " "、"+"、"-"）
+}
+
+// diffHunkHeaderPattern hunk 標頭，省略的行數預設為 1
+var diffHunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// isDiffFenceLanguage 判斷程式碼區塊的語言是否為 diff
+func isDiffFenceLanguage(language string) bool {
+	language = strings.ToLower(strings.TrimSpace(language))
+	return language == "diff" || language == "patch" || language == "udiff"
+}
+
+// diffPath 去除 diff 路徑的 a/、b/ 前綴與時間戳記；/dev/null 傳回空字串
+func diffPath(field string) string {
+	if idx := strings.Index(field, "\t"); idx >= 0 {
+		field = field[:idx]
+	}
+	field = strings.TrimSpace(field)
+	if field == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(field, "a/") || strings.HasPrefix(field, "b/") {
+		return field[2:]
+	}
+	return field
+}
+
+// diffAtoi 解析 hunk 標頭中的數字，空字串表示 1
+func diffAtoi(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// diffParser ExtractDiffs 的解析狀態
+type diffParser struct {
+	diffs   []Diff
+	current *Diff
+	hunk    *DiffHunk
+	// 有標頭的 hunk 剩餘的舊/新行數；implicit 為 true 時表示沒有標頭，延續到區塊結束
+	oldRemain, newRemain int
+	implicit             bool
+	fromGitHeader        bool // current 由 "diff --git" 建立且尚未遇到 ---/+++
+}
+
+// endHunk 結束目前的 hunk
+func (p *diffParser) endHunk() {
+	if p.hunk != nil {
+		p.current.Hunks = append(p.current.Hunks, *p.hunk)
+		p.hunk = nil
+	}
+	p.implicit = false
+}
+
+// flush 結束目前的檔案
+func (p *diffParser) flush() {
+	p.endHunk()
+	if p.current != nil && (len(p.current.Hunks) > 0 || p.current.FilePath != "") {
+		p.diffs = append(p.diffs, *p.current)
+	}
+	p.current = nil
+	p.fromGitHeader = false
+}
+
+// startHunk 開始新的 hunk；還沒有檔案時建立一個沒有路徑的 Diff
+func (p *diffParser) startHunk(hunk DiffHunk, implicit bool) {
+	p.endHunk()
+	if p.current == nil {
+		p.current = &Diff{}
+	}
+	p.fromGitHeader = false
+	p.hunk = &hunk
+	p.oldRemain, p.newRemain = hunk.OldLines, hunk.NewLines
+	p.implicit = implicit
+}
+
+// addHunkLine 嘗試將一行加入目前的 hunk，不屬於 hunk 時傳回 false（並結束 hunk）
+func (p *diffParser) addHunkLine(line string) bool {
+	if p.hunk == nil {
+		return false
+	}
+	if !p.implicit && p.oldRemain <= 0 && p.newRemain <= 0 {
+		p.endHunk()
+		return false
+	}
+
+	kind := byte(' ')
+	if line != "" {
+		kind = line[0]
+	}
+	switch kind {
+	case ' ':
+		p.oldRemain--
+		p.newRemain--
+	case '-':
+		if strings.HasPrefix(line, "---RALPH_STATUS---") {
+			p.endHunk()
+			return false
+		}
+		p.oldRemain--
+		p.current.Removed++
+	case '+':
+		p.newRemain--
+		p.current.Added++
+	case '\\': // "\ No newline at end of file"
+	default:
+		p.endHunk()
+		return false
+	}
+	p.hunk.Lines = append(p.hunk.Lines, line)
+	return true
+}
+
+// ExtractDiffs 提取輸出中的 unified diff
+//
+// 支援 ```diff/```patch 程式碼區塊、原始的 "diff --git" 輸出，以及只有 ---/+++ 與 @@ 標頭的 diff。
+// 有標頭的 hunk 依標頭的行數判斷結束位置，因此 hunk 之間的說明文字會被略過；
+// 同一個檔案的多個 hunk 歸在同一個 Diff，直到出現下一個檔案標頭或程式碼區塊結束。
+func (op *OutputParser) ExtractDiffs() []Diff {
+	p := &diffParser{}
+	lines := strings.Split(strings.ReplaceAll(op.rawOutput, "\r\n", "\n"), "\n")
+	inFence, diffFence := false, false
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			if inFence {
+				p.flush()
+				inFence, diffFence = false, false
+			} else {
+				p.endHunk()
+				inFence = true
+				diffFence = isDiffFenceLanguage(strings.TrimPrefix(trimmed, "```"))
+			}
+			continue
+		}
+
+		if p.addHunkLine(line) {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			p.flush()
+			p.current = &Diff{}
+			if fields := strings.Fields(strings.TrimPrefix(line, "diff --git ")); len(fields) == 2 {
+				p.current.OldPath = diffPath(fields[0])
+				p.current.FilePath = diffPath(fields[1])
+			}
+			p.fromGitHeader = true
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if !p.fromGitHeader {
+				p.flush()
+				p.current = &Diff{}
+			}
+			oldPath := diffPath(strings.TrimPrefix(line, "--- "))
+			newPath := diffPath(strings.TrimPrefix(lines[i+1], "+++ "))
+			p.current.OldPath = oldPath
+			p.current.FilePath = newPath
+			if newPath == "" {
+				p.current.FilePath = oldPath
+			}
+			p.fromGitHeader = false
+			i++
+		case diffHunkHeaderPattern.MatchString(line):
+			m := diffHunkHeaderPattern.FindStringSubmatch(line)
+			p.startHunk(DiffHunk{
+				Header:   line,
+				OldStart: diffAtoi(m[1]),
+				OldLines: diffAtoi(m[2]),
+				NewStart: diffAtoi(m[3]),
+				NewLines: diffAtoi(m[4]),
+			}, false)
+		case diffFence && line != "" && strings.ContainsRune(" +-", rune(line[0])):
+			// 沒有 hunk 標頭的 diff 區塊
+			p.startHunk(DiffHunk{}, true)
+			p.addHunkLine(line)
+		}
+	}
+	p.flush()
+	return p.diffs
+}
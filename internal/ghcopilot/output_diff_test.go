@@ -0,0 +1,110 @@
+package ghcopilot
+
+import (
+	"testing"
+)
+
+// TestExtractDiffsFencedBlock 測試 ```diff 區塊，hunk 之間的說明文字會被略過
+func TestExtractDiffsFencedBlock(t *testing.T) {
+	output := "我修正了 handler 的錯誤處理：\n\n```diff\n" +
+		"--- a/internal/api/handler.go\n" +
+		"+++ b/internal/api/handler.go\n" +
+		"@@ -10,4 +10,6 @@ func Handle(w http.ResponseWriter, r *http.Request) {\n" +
+		" \tdata, err := load(r)\n" +
+		"-\tif err != nil {\n" +
+		"-\t\tpanic(err)\n" +
+		"+\tif err != nil {\n" +
+		"+\t\thttp.Error(w, err.Error(), http.StatusBadRequest)\n" +
+		"+\t\treturn\n" +
+		"+\t}\n" +
+		" \twrite(w, data)\n" +
+		"這裡也需要調整回傳值：\n" +
+		"@@ -40,3 +42,3 @@\n" +
+		" func write(w io.Writer, data []byte) {\n" +
+		"-\tw.Write(data)\n" +
+		"+\t_, _ = w.Write(data)\n" +
+		" }\n" +
+		"```\n\n---RALPH_STATUS---\nEXIT_SIGNAL: true\n---END_RALPH_STATUS---"
+
+	diffs := NewOutputParser(output).ExtractDiffs()
+	if len(diffs) != 1 {
+		t.Fatalf("應提取 1 個檔案的 diff，實際 %d 個: %+v", len(diffs), diffs)
+	}
+	d := diffs[0]
+	if d.FilePath != "internal/api/handler.go" || d.OldPath != "internal/api/handler.go" {
+		t.Errorf("路徑錯誤: %q / %q", d.FilePath, d.OldPath)
+	}
+	if len(d.Hunks) != 2 || d.Added != 5 || d.Removed != 3 {
+		t.Fatalf("應有 2 個 hunk、新增 5 行、刪除 3 行，實際 %d / +%d / -%d", len(d.Hunks), d.Added, d.Removed)
+	}
+	h := d.Hunks[1]
+	if h.OldStart != 40 || h.OldLines != 3 || h.NewStart != 42 || h.NewLines != 3 || len(h.Lines) != 4 {
+		t.Errorf("第二個 hunk 解析錯誤: %+v", h)
+	}
+}
+
+// TestExtractDiffsGitOutput 測試原始的 diff --git 輸出，包含新增、刪除與多個檔案
+func TestExtractDiffsGitOutput(t *testing.T) {
+	output := "$ git diff\n" +
+		"diff --git a/README.md b/README.md\n" +
+		"index 3b18e51..a9c2f1d 100644\n" +
+		"--- a/README.md\n" +
+		"+++ b/README.md\n" +
+		"@@ -1 +1,2 @@\n" +
+		" # ralph-loop\n" +
+		"+Autonomous loop for Copilot CLI.\n" +
+		"diff --git a/docs/new.md b/docs/new.md\n" +
+		"new file mode 100644\n" +
+		"index 0000000..e69de29\n" +
+		"--- /dev/null\n" +
+		"+++ b/docs/new.md\n" +
+		"@@ -0,0 +1,2 @@\n" +
+		"+# New\n" +
+		"+--- separator\n" +
+		"diff --git a/old.txt b/old.txt\n" +
+		"deleted file mode 100644\n" +
+		"--- a/old.txt\n" +
+		"+++ /dev/null\n" +
+		"@@ -1,2 +0,0 @@\n" +
+		"-line one\n" +
+		"-line two\n" +
+		"\n完成。"
+
+	diffs := NewOutputParser(output).ExtractDiffs()
+	if len(diffs) != 3 {
+		t.Fatalf("應提取 3 個檔案的 diff，實際 %d 個: %+v", len(diffs), diffs)
+	}
+	expected := []struct {
+		path, old      string
+		added, removed int
+	}{
+		{"README.md", "README.md", 1, 0},
+		{"docs/new.md", "", 2, 0},
+		{"old.txt", "old.txt", 0, 2},
+	}
+	for i, e := range expected {
+		d := diffs[i]
+		if d.FilePath != e.path || d.OldPath != e.old || d.Added != e.added || d.Removed != e.removed {
+			t.Errorf("diff %d 應為 %+v，實際 %q %q +%d -%d", i, e, d.FilePath, d.OldPath, d.Added, d.Removed)
+		}
+	}
+}
+
+// TestExtractDiffsWithoutHeaders 測試只有 +/- 行的 diff 區塊與一般程式碼區塊
+func TestExtractDiffsWithoutHeaders(t *testing.T) {
+	output := "修改 `config.go`：\n```diff\n-\tTimeout: 30,\n+\tTimeout: 60,\n```\n\n" +
+		"範例：\n```go\nx := a - b\n```"
+
+	diffs := NewOutputParser(output).ExtractDiffs()
+	if len(diffs) != 1 {
+		t.Fatalf("應只提取 diff 區塊，實際 %d 個: %+v", len(diffs), diffs)
+	}
+	d := diffs[0]
+	if d.FilePath != "" || len(d.Hunks) != 1 || d.Hunks[0].Header != "" || d.Added != 1 || d.Removed != 1 {
+		t.Errorf("沒有標頭的 diff 解析錯誤: %+v", d)
+	}
+
+	if diffs := NewOutputParser("沒有任何修改\n- 項目一\n- 項目二").ExtractDiffs(); len(diffs) != 0 {
+		t.Errorf("一般的清單不應視為 diff: %+v", diffs)
+	}
+}
@@ -0,0 +1,150 @@
+package ghcopilot
+
+import "fmt"
+
+// OutputSamplingMode 決定哪些迴圈的完整輸出會被持久化
+type OutputSamplingMode string
+
+const (
+	// KeepAll 保存每個迴圈的完整輸出（預設）
+	KeepAll OutputSamplingMode = "keep_all"
+	// KeepFailuresAndEveryNth 只保存失敗的迴圈與每第 N 個迴圈的完整輸出
+	KeepFailuresAndEveryNth OutputSamplingMode = "keep_failures_and_every_nth"
+	// KeepLastN 只保存最近 N 個迴圈的完整輸出
+	KeepLastN OutputSamplingMode = "keep_last_n"
+)
+
+// OutputSamplingPolicy 長時間執行時的輸出取樣策略
+//
+// 只影響寫入磁碟的內容：未被取樣的迴圈仍保存 metadata、決策與警告，
+// 但清除 CLIOutput、CleanedOutput 與 ParsedCodeBlocks，並標記 OutputOmitted。
+// 記憶體中的歷史不受影響。
+type OutputSamplingPolicy struct {
+	Mode OutputSamplingMode
+	N    int // KeepFailuresAndEveryNth 的間隔或 KeepLastN 保留的迴圈數
+}
+
+// Validate 檢查策略設定
+func (p OutputSamplingPolicy) Validate() error {
+	switch p.Mode {
+	case "", KeepAll:
+		return nil
+	case KeepFailuresAndEveryNth, KeepLastN:
+		if p.N <= 0 {
+			return fmt.Errorf("輸出取樣策略 %s 需要 N > 0", p.Mode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("未知的輸出取樣策略: %s", p.Mode)
+	}
+}
+
+// keepsAll 策略是否保存所有輸出（未設定或設定無效時視為 KeepAll）
+func (p OutputSamplingPolicy) keepsAll() bool {
+	return p.Mode == "" || p.Mode == KeepAll || p.Validate() != nil
+}
+
+// Keep 判斷迴圈的完整輸出是否應保存；position 為迴圈在 total 個記錄中的位置（從 0 起算）
+func (p OutputSamplingPolicy) Keep(ctx *ExecutionContext, position, total int) bool {
+	if p.keepsAll() {
+		return true
+	}
+	switch p.Mode {
+	case KeepFailuresAndEveryNth:
+		return loopFailed(ctx) || (ctx.LoopIndex+1)%p.N == 0
+	case KeepLastN:
+		return position >= total-p.N
+	}
+	return true
+}
+
+// loopFailed 判斷迴圈是否失敗：非零退出碼、執行錯誤後重試、熔斷器打開或模型放棄
+func loopFailed(ctx *ExecutionContext) bool {
+	return ctx.CLIExitCode != 0 ||
+		(ctx.ShouldContinue && ctx.ExitReason != "") ||
+		ctx.CircuitBreakerState == string(StateOpen) ||
+		ctx.GaveUpPhrase != ""
+}
+
+// omitOutput 傳回清除完整輸出的淺複本（metadata 與決策保持不變）
+func omitOutput(ctx *ExecutionContext) *ExecutionContext {
+	sampled := *ctx
+	sampled.CLIOutput = ""
+	sampled.CleanedOutput = ""
+	sampled.ParsedCodeBlocks = nil
+	sampled.OutputOmitted = true
+	return &sampled
+}
+
+// sampledContextManager 傳回依策略清除部分輸出的上下文管理器複本；KeepAll 時傳回原物件
+func sampledContextManager(cm *ContextManager, policy OutputSamplingPolicy) *ContextManager {
+	if cm == nil || policy.keepsAll() {
+		return cm
+	}
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	sampled := &ContextManager{
+		currentLoop:    cm.currentLoop,
+		loopHistory:    make([]*ExecutionContext, len(cm.loopHistory)),
+		maxHistorySize: cm.maxHistorySize,
+		startTime:      cm.startTime,
+		totalDuration:  cm.totalDuration,
+		successCount:   cm.successCount,
+		errorCount:     cm.errorCount,
+	}
+	for i, ctx := range cm.loopHistory {
+		if policy.Keep(ctx, i, len(cm.loopHistory)) {
+			sampled.loopHistory[i] = ctx
+		} else {
+			sampled.loopHistory[i] = omitOutput(ctx)
+		}
+	}
+	return sampled
+}
+
+// samplingBackend 在寫入前套用輸出取樣策略的持久化後端
+type samplingBackend struct {
+	next   persistenceBackend
+	policy OutputSamplingPolicy
+	recent []*ExecutionContext // KeepLastN：最近保存完整輸出的迴圈
+}
+
+// newSamplingBackend 包裝持久化後端；KeepAll 時直接傳回原後端
+func newSamplingBackend(next persistenceBackend, policy OutputSamplingPolicy) persistenceBackend {
+	if policy.keepsAll() {
+		return next
+	}
+	return &samplingBackend{next: next, policy: policy}
+}
+
+// SaveContextManager 寫入取樣後的上下文管理器複本
+func (b *samplingBackend) SaveContextManager(cm *ContextManager) error {
+	return b.next.SaveContextManager(sampledContextManager(cm, b.policy))
+}
+
+// SaveExecutionContext 寫入單一迴圈；KeepLastN 時同時將移出視窗的迴圈改寫為不含輸出的版本
+func (b *samplingBackend) SaveExecutionContext(ctx *ExecutionContext) error {
+	if ctx == nil {
+		return b.next.SaveExecutionContext(nil)
+	}
+	if b.policy.Mode != KeepLastN {
+		if !b.policy.Keep(ctx, 0, 1) {
+			ctx = omitOutput(ctx)
+		}
+		return b.next.SaveExecutionContext(ctx)
+	}
+
+	if err := b.next.SaveExecutionContext(ctx); err != nil {
+		return err
+	}
+	b.recent = append(b.recent, ctx)
+	for len(b.recent) > b.policy.N {
+		evicted := b.recent[0]
+		b.recent = b.recent[1:]
+		if err := b.next.SaveExecutionContext(omitOutput(evicted)); err != nil {
+			return fmt.Errorf("無法移除迴圈 %s 的輸出: %w", evicted.LoopID, err)
+		}
+	}
+	return nil
+}
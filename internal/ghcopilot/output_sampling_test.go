@@ -0,0 +1,95 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"testing"
+)
+
+// runSampledLoops 以取樣後端保存 6 個迴圈（迴圈索引 2 失敗），傳回上下文管理器與後端
+func runSampledLoops(t *testing.T, policy OutputSamplingPolicy) (*ContextManager, *PersistenceManager) {
+	t.Helper()
+	pm, err := NewPersistenceManager(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := newSamplingBackend(pm, policy)
+	cm := NewContextManager()
+	for i := 0; i < 6; i++ {
+		ctx := cm.StartLoop(i, "任務")
+		ctx.CLIOutput = fmt.Sprintf("迴圈 %d 的輸出", i)
+		ctx.CleanedOutput = ctx.CLIOutput
+		ctx.ShouldContinue = true
+		if i == 2 {
+			ctx.CLIExitCode = 1
+		}
+		if err := backend.SaveExecutionContext(ctx); err != nil {
+			t.Fatalf("SaveExecutionContext 失敗: %v", err)
+		}
+		if err := cm.FinishLoop(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cm, pm
+}
+
+// assertKeptOutputs 檢查磁碟上的迴圈記錄與上下文管理器複本只保留預期迴圈的輸出
+func assertKeptOutputs(t *testing.T, cm *ContextManager, pm *PersistenceManager, policy OutputSamplingPolicy, want []int) {
+	t.Helper()
+	keep := make(map[int]bool)
+	for _, i := range want {
+		keep[i] = true
+	}
+
+	sampled := sampledContextManager(cm, policy)
+	for i, ctx := range cm.GetLoopHistory() {
+		saved, err := pm.LoadExecutionContext(ctx.LoopID)
+		if err != nil {
+			t.Fatalf("LoadExecutionContext 失敗: %v", err)
+		}
+		inHistory := sampled.GetLoopHistory()[i]
+		for name, got := range map[string]*ExecutionContext{"迴圈檔": saved, "歷史複本": inHistory} {
+			if (got.CLIOutput != "") != keep[i] || got.OutputOmitted == keep[i] {
+				t.Errorf("%s 迴圈 %d: 應保存輸出=%v，實際 output=%q omitted=%v", name, i, keep[i], got.CLIOutput, got.OutputOmitted)
+			}
+			if got.LoopIndex != i || !got.ShouldContinue {
+				t.Errorf("%s 迴圈 %d: metadata 與決策應保留: %+v", name, i, got)
+			}
+		}
+		if ctx.CLIOutput == "" || ctx.OutputOmitted {
+			t.Errorf("記憶體中的迴圈 %d 不應被修改", i)
+		}
+	}
+}
+
+// TestOutputSamplingKeepFailuresAndEveryNth 測試只保存失敗的迴圈與每第 N 個迴圈
+func TestOutputSamplingKeepFailuresAndEveryNth(t *testing.T) {
+	policy := OutputSamplingPolicy{Mode: KeepFailuresAndEveryNth, N: 3}
+	cm, pm := runSampledLoops(t, policy)
+	// 迴圈編號 3、6（索引 2、5）與失敗的索引 2
+	assertKeptOutputs(t, cm, pm, policy, []int{2, 5})
+}
+
+// TestOutputSamplingKeepLastN 測試只保存最近 N 個迴圈，移出視窗的迴圈檔會被改寫
+func TestOutputSamplingKeepLastN(t *testing.T) {
+	policy := OutputSamplingPolicy{Mode: KeepLastN, N: 2}
+	cm, pm := runSampledLoops(t, policy)
+	assertKeptOutputs(t, cm, pm, policy, []int{4, 5})
+}
+
+// TestOutputSamplingKeepAll 測試預設與無效的策略保存所有輸出
+func TestOutputSamplingKeepAll(t *testing.T) {
+	for _, policy := range []OutputSamplingPolicy{{}, {Mode: KeepAll}, {Mode: KeepLastN}} {
+		cm, pm := runSampledLoops(t, policy)
+		if sampledContextManager(cm, policy) != cm {
+			t.Errorf("%+v 不應複製上下文管理器", policy)
+		}
+		assertKeptOutputs(t, cm, pm, policy, []int{0, 1, 2, 3, 4, 5})
+	}
+
+	if err := (OutputSamplingPolicy{Mode: KeepLastN}).Validate(); err == nil {
+		t.Error("KeepLastN 未設定 N 應驗證失敗")
+	}
+	if err := (OutputSamplingPolicy{Mode: "sometimes"}).Validate(); err == nil {
+		t.Error("未知的策略應驗證失敗")
+	}
+}
@@ -0,0 +1,102 @@
+package ghcopilot
+
+import (
+	"strings"
+)
+
+const (
+	// BackendCLI 輸出由 copilot CLI 產生
+	BackendCLI = "cli"
+	// BackendSDK 輸出由 SDK 執行器產生（ExecutionContext.CLICommand 以 "sdk:" 開頭）
+	BackendSDK = "sdk"
+)
+
+// ParserProfile 依執行後端調整完成判定的解析方式
+//
+// CLI 輸出通常包含完整的 RALPH_STATUS 區塊與工具呼叫紀錄；SDK 回應只有模型的訊息，
+// 常以 Markdown 呈現狀態（例如 **EXIT_SIGNAL:** true）或省略區塊的分隔線，
+// 且本來就比 CLI 輸出短，不能以長度當作接近完成的指標。
+type ParserProfile struct {
+	Name string `json:"name"`
+
+	// StatusBlockExpected 輸出應包含狀態區塊，缺少時記錄警告
+	StatusBlockExpected bool `json:"status_block_expected"`
+	// StripMarkdown 解析狀態前移除粗體與行內程式碼標記
+	StripMarkdown bool `json:"strip_markdown"`
+	// LooseStatus 找不到區塊分隔線時，接受獨立的 EXIT_SIGNAL: 等欄位行
+	LooseStatus bool `json:"loose_status"`
+	// ShortOutputChars 輸出少於此字元數時加入 short_output 指標，0 表示停用
+	ShortOutputChars int `json:"short_output_chars"`
+}
+
+// CLIParserProfile CLI 輸出的解析設定（原本的行為）
+func CLIParserProfile() ParserProfile {
+	return ParserProfile{
+		Name:                BackendCLI,
+		StatusBlockExpected: true,
+		ShortOutputChars:    500,
+	}
+}
+
+// SDKParserProfile SDK 回應的解析設定
+func SDKParserProfile() ParserProfile {
+	return ParserProfile{
+		Name:          BackendSDK,
+		StripMarkdown: true,
+		LooseStatus:   true,
+	}
+}
+
+// backendFromCommand 依 ExecutionContext.CLICommand 判斷產生輸出的執行後端
+func backendFromCommand(command string) string {
+	if strings.HasPrefix(command, BackendSDK+":") {
+		return BackendSDK
+	}
+	return BackendCLI
+}
+
+// parserProfile 傳回本迴圈輸出應使用的解析設定；ParserProfiles 中的設定優先
+func (c *RalphLoopClient) parserProfile(command string) ParserProfile {
+	backend := backendFromCommand(command)
+	if profile, ok := c.config.ParserProfiles[backend]; ok {
+		if profile.Name == "" {
+			profile.Name = backend
+		}
+		return profile
+	}
+	if backend == BackendSDK {
+		return SDKParserProfile()
+	}
+	return CLIParserProfile()
+}
+
+// statusMarkdownReplacer 移除 Markdown 的粗體與行內程式碼標記
+var statusMarkdownReplacer = strings.NewReplacer("**", "", "__", "", "`", "")
+
+// stripStatusMarkdown 移除可能包住狀態欄位的 Markdown 標記（程式碼區塊的 ``` 也會被移除）
+func stripStatusMarkdown(response string) string {
+	return statusMarkdownReplacer.Replace(response)
+}
+
+// statusFieldPrefixes 狀態區塊中可辨識的欄位
+var statusFieldPrefixes = []string{"STATUS:", "EXIT_SIGNAL:", "TASKS_DONE:", "REASON:", "CONFIDENCE:"}
+
+// parseLooseStatus 從沒有分隔線的回應中收集狀態欄位行；沒有 EXIT_SIGNAL 時回傳 nil
+func parseLooseStatus(response string) *CopilotStatus {
+	var fields []string
+	hasExitSignal := false
+	for _, line := range strings.Split(strings.ReplaceAll(response, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*>#"))
+		for _, prefix := range statusFieldPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				fields = append(fields, line)
+				hasExitSignal = hasExitSignal || prefix == "EXIT_SIGNAL:"
+				break
+			}
+		}
+	}
+	if !hasExitSignal {
+		return nil
+	}
+	return parseStatusFields(strings.Join(fields, "\n"))
+}
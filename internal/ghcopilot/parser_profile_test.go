@@ -0,0 +1,71 @@
+package ghcopilot
+
+import "testing"
+
+// sdkStyleOutput SDK 回應：以 Markdown 呈現狀態且沒有區塊分隔線
+const sdkStyleOutput = "已修正 parser.go 的邊界條件。\n\n**STATUS:** DONE\n**EXIT_SIGNAL:** true\n**REASON:** `go test ./...` 通過\n"
+
+// cliStyleOutput CLI 輸出：完整的 RALPH_STATUS 區塊
+const cliStyleOutput = "已修正 parser.go 的邊界條件。\n---RALPH_STATUS---\nSTATUS: DONE\nEXIT_SIGNAL: true\nREASON: go test ./... 通過\n---END_RALPH_STATUS---"
+
+// TestParserProfileSDKStyleOutput 測試 SDK 設定能解析 Markdown 形式的狀態，CLI 設定則不會誤判
+func TestParserProfileSDKStyleOutput(t *testing.T) {
+	sdk := NewResponseAnalyzerWithProfile(sdkStyleOutput, SDKParserProfile()).Decide()
+	if !sdk.HasStatusBlock || !sdk.ExitSignal || !sdk.Completed {
+		t.Errorf("SDK 設定應從 Markdown 狀態判定完成: %+v", sdk)
+	}
+	if sdk.ParserProfile != BackendSDK {
+		t.Errorf("判定應記錄使用 sdk 設定，實際 %q", sdk.ParserProfile)
+	}
+	status := NewResponseAnalyzerWithProfile(sdkStyleOutput, SDKParserProfile()).ParseStructuredOutput()
+	if status == nil || status.Status != "DONE" || status.Reason != "go test ./... 通過" {
+		t.Errorf("應解析出 STATUS 與 REASON: %+v", status)
+	}
+
+	cli := NewResponseAnalyzer(sdkStyleOutput).Decide()
+	if cli.HasStatusBlock || cli.ExitSignal {
+		t.Errorf("CLI 設定不應把沒有分隔線的欄位當作狀態區塊: %+v", cli)
+	}
+}
+
+// TestParserProfileCLIStyleOutput 測試兩種設定都能解析完整的狀態區塊
+func TestParserProfileCLIStyleOutput(t *testing.T) {
+	for _, profile := range []ParserProfile{CLIParserProfile(), SDKParserProfile()} {
+		d := NewResponseAnalyzerWithProfile(cliStyleOutput, profile).Decide()
+		if !d.HasStatusBlock || !d.Completed {
+			t.Errorf("%s 設定應解析 RALPH_STATUS 區塊: %+v", profile.Name, d)
+		}
+	}
+}
+
+// TestParserProfileShortOutput 測試 SDK 設定不以輸出長度當作完成指標
+func TestParserProfileShortOutput(t *testing.T) {
+	reply := "完成，沒有更多工作"
+
+	cli := NewResponseAnalyzerWithProfile(reply, CLIParserProfile()).Decide()
+	if cli.Score != 35 || !cli.Completed {
+		t.Errorf("CLI 設定應維持原本的分數 35 並判定完成: %+v", cli)
+	}
+	sdk := NewResponseAnalyzerWithProfile(reply, SDKParserProfile()).Decide()
+	if sdk.Score != 25 || sdk.Completed {
+		t.Errorf("SDK 設定不應計入 short_output，分數應為 25 且不判定完成: %+v", sdk)
+	}
+}
+
+// TestParserProfileSelection 測試依 CLICommand 選擇設定，且 ParserProfiles 可覆寫
+func TestParserProfileSelection(t *testing.T) {
+	client := NewRalphLoopClient()
+	defer client.Close()
+
+	if got := client.parserProfile("sdk:complete"); got != SDKParserProfile() {
+		t.Errorf("sdk:complete 應使用 SDK 設定: %+v", got)
+	}
+	if got := client.parserProfile("copilot -p ..."); got != CLIParserProfile() {
+		t.Errorf("CLI 命令應使用 CLI 設定: %+v", got)
+	}
+
+	client.config.ParserProfiles = map[string]ParserProfile{BackendSDK: {ShortOutputChars: 200}}
+	if got := client.parserProfile("sdk:complete"); got.Name != BackendSDK || got.ShortOutputChars != 200 || got.LooseStatus {
+		t.Errorf("應使用 ParserProfiles 覆寫的 SDK 設定: %+v", got)
+	}
+}
@@ -0,0 +1,94 @@
+package ghcopilot
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// Pause 暫停迴圈：執行中的迴圈會跑完，下一個迴圈開始前等待 Resume
+//
+// 可在另一個 goroutine（例如信號處理）中呼叫。
+func (c *RalphLoopClient) Pause() {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	if !c.paused {
+		c.paused = true
+		infoLog("⏸️ 已暫停，目前的迴圈結束後等待恢復")
+	}
+}
+
+// Resume 恢復被 Pause 暫停的迴圈
+func (c *RalphLoopClient) Resume() {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	if c.paused {
+		c.paused = false
+		infoLog("▶️ 已恢復執行")
+	}
+	c.pauseCondUnlocked().Broadcast()
+}
+
+// IsPaused 傳回迴圈是否處於暫停狀態
+func (c *RalphLoopClient) IsPaused() bool {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	return c.paused
+}
+
+// pauseCondUnlocked 傳回暫停用的條件變數（呼叫端需持有 pauseMu）
+func (c *RalphLoopClient) pauseCondUnlocked() *sync.Cond {
+	if c.pauseCond == nil {
+		c.pauseCond = sync.NewCond(&c.pauseMu)
+	}
+	return c.pauseCond
+}
+
+// waitIfPaused 暫停時等待 Resume，ctx 取消時立即回傳 ctx 的錯誤
+func (c *RalphLoopClient) waitIfPaused(ctx context.Context) error {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	if !c.paused {
+		return nil
+	}
+
+	cond := c.pauseCondUnlocked()
+	// sync.Cond 無法直接等待 ctx，取消時廣播喚醒等待者
+	stop := context.AfterFunc(ctx, func() {
+		c.pauseMu.Lock()
+		defer c.pauseMu.Unlock()
+		cond.Broadcast()
+	})
+	defer stop()
+
+	for c.paused && ctx.Err() == nil {
+		cond.Wait()
+	}
+	return ctx.Err()
+}
+
+// handlePauseSignals 收到 pauseSig 時暫停、收到 resumeSig 時恢復，回傳的函式停止監聽
+func (c *RalphLoopClient) handlePauseSignals(pauseSig, resumeSig os.Signal) (stop func()) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, pauseSig, resumeSig)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-sigCh:
+				if sig == pauseSig {
+					c.Pause()
+				} else {
+					c.Resume()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
@@ -0,0 +1,74 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPauseResumeBetweenLoops 測試暫停時不再開始新的迴圈，恢復後繼續執行
+func TestPauseResumeBetweenLoops(t *testing.T) {
+	client := newScriptedClient(DefaultClientConfig(), "")
+	var calls atomic.Int32
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		n := calls.Add(1)
+		if n == 1 {
+			client.Pause()
+		}
+		out := fmt.Sprintf("迴圈 %d 已修改 main.go\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---", n)
+		return &ExecutionResult{Command: "copilot", Stdout: out}, nil
+	}
+
+	done := make(chan int, 1)
+	go func() {
+		results, _ := client.ExecuteUntilCompletion(context.Background(), "修正測試", 3)
+		done <- len(results)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("暫停後不應開始新的迴圈，實際執行 %d 次", got)
+	}
+	if !client.GetStatus().Paused {
+		t.Error("暫停中 GetStatus 應回報 Paused")
+	}
+
+	client.Resume()
+	select {
+	case n := <-done:
+		if n != 3 || calls.Load() != 3 {
+			t.Errorf("恢復後應執行完 3 個迴圈，實際 %d 個結果、%d 次呼叫", n, calls.Load())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("恢復後迴圈沒有繼續")
+	}
+	if client.GetStatus().Paused {
+		t.Error("恢復後 GetStatus 不應回報 Paused")
+	}
+}
+
+// TestPauseCancelledContext 測試暫停中取消 context 時立即結束
+func TestPauseCancelledContext(t *testing.T) {
+	client := newScriptedClient(DefaultClientConfig(), "")
+	client.Pause()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.ExecuteUntilCompletion(ctx, "修正測試", 3)
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("暫停中取消應回傳錯誤")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("取消 context 後暫停中的執行沒有結束")
+	}
+}
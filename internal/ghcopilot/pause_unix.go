@@ -0,0 +1,10 @@
+//go:build !windows
+
+package ghcopilot
+
+import "syscall"
+
+// HandlePauseSignals 收到 SIGUSR1 時暫停迴圈、SIGUSR2 時恢復，回傳的函式停止監聽信號
+func (c *RalphLoopClient) HandlePauseSignals() (stop func()) {
+	return c.handlePauseSignals(syscall.SIGUSR1, syscall.SIGUSR2)
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package ghcopilot
+
+// HandlePauseSignals on Windows: no-op (沒有 SIGUSR1/SIGUSR2，請直接呼叫 Pause/Resume)
+func (c *RalphLoopClient) HandlePauseSignals() (stop func()) {
+	return func() {}
+}
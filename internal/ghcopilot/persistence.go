@@ -154,6 +154,9 @@ func (pm *PersistenceManager) ListSavedContexts() ([]string, error) {
 
 	var files []string
 	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), breakerStateFile+".") {
+			continue // 熔斷器狀態不是上下文存檔
+		}
 		if !entry.IsDir() && (filepath.Ext(entry.Name()) == ".json" || filepath.Ext(entry.Name()) == ".gob") {
 			files = append(files, entry.Name())
 		}
@@ -162,6 +165,81 @@ func (pm *PersistenceManager) ListSavedContexts() ([]string, error) {
 	return files, nil
 }
 
+// LatestContextManagerFile 傳回儲存目錄中最新的上下文管理器檔案
+//
+// 儲存目錄本身沒有存檔時，會改找最新的執行子目錄（PerRunSaveDir 建立的），
+// 讓 status 等命令不論是否使用分目錄模式都能找到最近一次執行的歷史。
+func (pm *PersistenceManager) LatestContextManagerFile() (string, error) {
+	if file := latestContextManagerIn(pm.storageDir); file != "" {
+		return file, nil
+	}
+
+	runDir, err := LatestRunDir(pm.storageDir)
+	if err != nil {
+		return "", fmt.Errorf("找不到上下文管理器存檔: %s", pm.storageDir)
+	}
+	if file := latestContextManagerIn(runDir); file != "" {
+		return file, nil
+	}
+	return "", fmt.Errorf("找不到上下文管理器存檔: %s", pm.storageDir)
+}
+
+// LatestRunDir 傳回 saveDir 下最近修改的執行子目錄
+func LatestRunDir(saveDir string) (string, error) {
+	entries, err := os.ReadDir(saveDir)
+	if err != nil {
+		return "", err
+	}
+
+	var latest string
+	var latestTime time.Time
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest = filepath.Join(saveDir, entry.Name())
+			latestTime = info.ModTime()
+		}
+	}
+
+	if latest == "" {
+		return "", fmt.Errorf("沒有執行子目錄: %s", saveDir)
+	}
+	return latest, nil
+}
+
+// latestContextManagerIn 傳回目錄中最新的 context_manager_* 檔案（檔名含時間戳，依字典序即可排序）
+func latestContextManagerIn(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	var latest string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "context_manager_") {
+			continue
+		}
+		if ext := filepath.Ext(name); ext != ".json" && ext != ".gob" {
+			continue
+		}
+		if name > latest {
+			latest = name
+		}
+	}
+
+	if latest == "" {
+		return ""
+	}
+	return filepath.Join(dir, latest)
+}
+
 // ExportAsJSON 以 JSON 格式匯出上下文管理器
 func (pm *PersistenceManager) ExportAsJSON(cm *ContextManager, outputPath string) error {
 	jsonStr, err := cm.ToJSON()
@@ -335,3 +413,10 @@ func (pm *PersistenceManager) SetMaxBackups(count int) {
 func (pm *PersistenceManager) GetStorageDir() string {
 	return pm.storageDir
 }
+
+// withStorageDir 傳回使用另一個儲存目錄、其餘設定相同的副本（用於讀取執行子目錄中的存檔）
+func (pm *PersistenceManager) withStorageDir(dir string) *PersistenceManager {
+	clone := *pm
+	clone.storageDir = dir
+	return &clone
+}
@@ -0,0 +1,43 @@
+package ghcopilot
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultPersistenceRetryPolicy 持久化寫入的預設重試策略：最多 3 次，短暫的指數退避
+func DefaultPersistenceRetryPolicy() *RetryPolicy {
+	policy := NewExponentialBackoffPolicy(3)
+	policy.InitialDelay = 50 * time.Millisecond
+	policy.MaxDelay = 500 * time.Millisecond
+	return policy
+}
+
+// newPersistenceRetryExecutor 建立持久化寫入使用的重試執行器；policy 為 nil 或只允許一次嘗試時傳回 nil
+//
+// 磁碟空間不足不會因重試而改善，不重試而直接交由 DiskFullPolicy 處理。
+func newPersistenceRetryExecutor(policy *RetryPolicy) *RetryExecutor {
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return nil
+	}
+	policy = policy.Clone()
+	custom := policy.ShouldRetryFunc
+	policy.ShouldRetryFunc = func(attempt int, err error) (bool, bool) {
+		if IsDiskFullError(err) {
+			return false, true
+		}
+		if custom != nil {
+			return custom(attempt, err)
+		}
+		return false, false
+	}
+	return NewRetryExecutor(policy)
+}
+
+// persistWithRetry 執行一次持久化寫入，暫時性的失敗依 PersistenceRetryPolicy 重試
+func (c *RalphLoopClient) persistWithRetry(save func() error) error {
+	if c.persistRetry == nil {
+		return save()
+	}
+	return c.persistRetry.Execute(context.Background(), save)
+}
@@ -0,0 +1,110 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyBackend 前 failures 次寫入失敗，之後成功的持久化後端
+type flakyBackend struct {
+	failures     int
+	calls        int
+	managerSaved *ContextManager
+	contextSaved *ExecutionContext
+}
+
+func (b *flakyBackend) fail() error {
+	b.calls++
+	if b.calls <= b.failures {
+		return errors.New("connection reset by peer")
+	}
+	return nil
+}
+
+func (b *flakyBackend) SaveContextManager(cm *ContextManager) error {
+	if err := b.fail(); err != nil {
+		return err
+	}
+	b.managerSaved = cm
+	return nil
+}
+
+func (b *flakyBackend) SaveExecutionContext(ctx *ExecutionContext) error {
+	if err := b.fail(); err != nil {
+		return err
+	}
+	b.contextSaved = ctx
+	return nil
+}
+
+// fastPersistenceRetryPolicy 測試用的短間隔重試策略
+func fastPersistenceRetryPolicy() *RetryPolicy {
+	return NewFixedIntervalPolicy(3, time.Millisecond)
+}
+
+// TestPersistenceRetryEventuallyPersists 測試前兩次寫入失敗時重試後成功儲存，不留下警告
+func TestPersistenceRetryEventuallyPersists(t *testing.T) {
+	config := DefaultClientConfig()
+	client := newScriptedClient(config, "正在修改檔案\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+	flaky := &flakyBackend{failures: 2}
+	client.config.EnablePersistence = true
+	client.backend = flaky
+	client.persistRetry = newPersistenceRetryExecutor(fastPersistenceRetryPolicy())
+
+	result, err := client.ExecuteLoop(context.Background(), "修正錯誤")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flaky.contextSaved == nil || flaky.contextSaved.LoopIndex != result.LoopIndex {
+		t.Fatalf("重試後應儲存執行上下文: %+v", flaky.contextSaved)
+	}
+	if flaky.managerSaved == nil {
+		t.Error("重試後應儲存上下文管理器")
+	}
+	if flaky.calls != 4 {
+		t.Errorf("應呼叫 4 次（2 次失敗 + 2 次成功），實際 %d", flaky.calls)
+	}
+	for _, w := range result.Warnings {
+		t.Errorf("重試成功不應記錄警告: %s", w)
+	}
+}
+
+// TestPersistenceRetryGivesUp 測試重試用盡後才回傳錯誤
+func TestPersistenceRetryGivesUp(t *testing.T) {
+	client := &RalphLoopClient{persistRetry: newPersistenceRetryExecutor(fastPersistenceRetryPolicy())}
+	flaky := &flakyBackend{failures: 10}
+
+	err := client.persistWithRetry(func() error { return flaky.SaveExecutionContext(NewExecutionContext(0, "x")) })
+	if err == nil || flaky.calls != 3 {
+		t.Fatalf("應嘗試 3 次後回傳錯誤，實際呼叫 %d 次: %v", flaky.calls, err)
+	}
+}
+
+// TestPersistenceRetrySkipsDiskFull 測試磁碟空間不足不重試，交由 DiskFullPolicy 處理
+func TestPersistenceRetrySkipsDiskFull(t *testing.T) {
+	client := &RalphLoopClient{persistRetry: newPersistenceRetryExecutor(fastPersistenceRetryPolicy())}
+	full := &fullDiskBackend{}
+
+	err := client.persistWithRetry(func() error { return full.SaveContextManager(NewContextManager()) })
+	if !IsDiskFullError(err) {
+		t.Fatalf("應回傳磁碟空間不足的錯誤: %v", err)
+	}
+	if full.managerSaves != 1 {
+		t.Errorf("磁碟空間不足不應重試，實際寫入 %d 次", full.managerSaves)
+	}
+}
+
+// TestPersistenceRetryDisabled 測試未設定策略或只允許一次嘗試時不重試
+func TestPersistenceRetryDisabled(t *testing.T) {
+	if newPersistenceRetryExecutor(nil) != nil {
+		t.Error("nil 策略不應建立重試執行器")
+	}
+	if newPersistenceRetryExecutor(NewFixedIntervalPolicy(1, 0)) != nil {
+		t.Error("MaxAttempts 為 1 時不應建立重試執行器")
+	}
+	if DefaultClientConfig().PersistenceRetryPolicy == nil {
+		t.Error("預設應啟用持久化重試")
+	}
+}
@@ -275,3 +275,25 @@ func TestMultipleFormatSupport(t *testing.T) {
 		t.Error("應該建立 Gob 檔案")
 	}
 }
+
+// TestPersistenceManagerWithStorageDir 測試副本只改變儲存目錄，不影響原本的管理器
+func TestPersistenceManagerWithStorageDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	pm, err := NewPersistenceManager(tmpDir, true)
+	if err != nil {
+		t.Fatalf("建立持久化管理器失敗: %v", err)
+	}
+	pm.SetMaxBackups(3)
+
+	runDir := filepath.Join(tmpDir, "run-1")
+	clone := pm.withStorageDir(runDir)
+	if clone.GetStorageDir() != runDir {
+		t.Errorf("副本的儲存目錄應為 %s，實際 %s", runDir, clone.GetStorageDir())
+	}
+	if !clone.useGob || clone.maxBackups != 3 {
+		t.Errorf("副本應保留其餘設定，實際 useGob=%v maxBackups=%d", clone.useGob, clone.maxBackups)
+	}
+	if pm.GetStorageDir() != tmpDir {
+		t.Errorf("原本的儲存目錄不應改變，實際 %s", pm.GetStorageDir())
+	}
+}
@@ -0,0 +1,160 @@
+package ghcopilot
+
+import (
+	"errors"
+	"sync"
+)
+
+// errPersistenceWriterClosed 寫入器關閉後仍要求儲存時回傳
+var errPersistenceWriterClosed = errors.New("持久化寫入器已關閉")
+
+// saveRequest 排入寫入器的單一儲存請求（cm 與 exec 只會有一個）
+type saveRequest struct {
+	cm     *ContextManager
+	exec   *ExecutionContext
+	result chan error
+}
+
+// persistenceWriter 以單一 goroutine 依序執行持久化寫入
+//
+// 同時進行的迴圈只需把儲存請求排入佇列，實際寫入由背景 goroutine 逐一執行，
+// 避免多個寫入同時建立同名檔案而交錯損毀。佇列中同一個 ContextManager 的
+// 多次快照只會寫入最後一次，所有等待者都會收到該次寫入的結果。
+// 呼叫端仍會等待寫入完成，因此錯誤處理（例如磁碟空間不足）與直接寫入相同。
+type persistenceWriter struct {
+	backend  persistenceBackend
+	requests chan *saveRequest
+	done     chan struct{}
+
+	mu     sync.RWMutex // 保護 closed 與 requests 的關閉
+	closed bool
+
+	statsMu   sync.Mutex
+	writes    int // 實際寫入次數
+	coalesced int // 被合併而略過的快照數
+}
+
+// newPersistenceWriter 建立並啟動寫入器
+func newPersistenceWriter(backend persistenceBackend) *persistenceWriter {
+	w := &persistenceWriter{
+		backend:  backend,
+		requests: make(chan *saveRequest, 64),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// SaveContextManager 排入上下文管理器快照並等待寫入完成
+func (w *persistenceWriter) SaveContextManager(cm *ContextManager) error {
+	if cm == nil {
+		return w.backend.SaveContextManager(nil) // 交由後端回報參數錯誤
+	}
+	return w.enqueue(&saveRequest{cm: cm})
+}
+
+// SaveExecutionContext 排入單一執行上下文並等待寫入完成
+func (w *persistenceWriter) SaveExecutionContext(ctx *ExecutionContext) error {
+	if ctx == nil {
+		return w.backend.SaveExecutionContext(nil)
+	}
+	return w.enqueue(&saveRequest{exec: ctx})
+}
+
+// enqueue 將請求送入佇列並等待結果
+func (w *persistenceWriter) enqueue(req *saveRequest) error {
+	req.result = make(chan error, 1)
+
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return errPersistenceWriterClosed
+	}
+	w.requests <- req
+	w.mu.RUnlock()
+
+	return <-req.result
+}
+
+// Close 停止接受新請求，寫完佇列中剩餘的請求後返回（可重複呼叫）
+func (w *persistenceWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.requests)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+// Stats 傳回實際寫入次數與被合併的快照數
+func (w *persistenceWriter) Stats() (writes, coalesced int) {
+	w.statsMu.Lock()
+	defer w.statsMu.Unlock()
+	return w.writes, w.coalesced
+}
+
+// run 背景 goroutine：每次取出目前排隊中的所有請求，依序寫入
+func (w *persistenceWriter) run() {
+	defer close(w.done)
+
+	for req := range w.requests {
+		batch := []*saveRequest{req}
+	drain:
+		for {
+			select {
+			case next, ok := <-w.requests:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		w.writeBatch(batch)
+	}
+}
+
+// writeBatch 依序寫入一批請求，同一個 ContextManager 只寫入最後一次快照
+func (w *persistenceWriter) writeBatch(batch []*saveRequest) {
+	last := make(map[*ContextManager]int)
+	for i, req := range batch {
+		if req.cm != nil {
+			last[req.cm] = i
+		}
+	}
+
+	waiting := make(map[*ContextManager][]*saveRequest)
+	for i, req := range batch {
+		if req.cm == nil {
+			w.record(false)
+			req.result <- w.backend.SaveExecutionContext(req.exec)
+			continue
+		}
+
+		if last[req.cm] != i {
+			waiting[req.cm] = append(waiting[req.cm], req)
+			w.record(true)
+			continue
+		}
+
+		w.record(false)
+		err := w.backend.SaveContextManager(req.cm)
+		for _, earlier := range waiting[req.cm] {
+			earlier.result <- err
+		}
+		req.result <- err
+	}
+}
+
+// record 更新統計
+func (w *persistenceWriter) record(coalesced bool) {
+	w.statsMu.Lock()
+	defer w.statsMu.Unlock()
+	if coalesced {
+		w.coalesced++
+	} else {
+		w.writes++
+	}
+}
@@ -0,0 +1,199 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingBackend 記錄同時進行中的寫入數量，並可在第一次寫入時暫停
+type countingBackend struct {
+	inFlight     atomic.Int32
+	maxInFlight  atomic.Int32
+	managerSaves atomic.Int32
+	contextSaves atomic.Int32
+
+	started chan struct{} // 第一次寫入開始時關閉
+	release chan struct{} // 關閉後第一次寫入才會完成
+	once    sync.Once
+}
+
+func newCountingBackend() *countingBackend {
+	return &countingBackend{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (b *countingBackend) enter() {
+	n := b.inFlight.Add(1)
+	for {
+		cur := b.maxInFlight.Load()
+		if n <= cur || b.maxInFlight.CompareAndSwap(cur, n) {
+			break
+		}
+	}
+	b.once.Do(func() {
+		close(b.started)
+		<-b.release
+	})
+	time.Sleep(100 * time.Microsecond)
+	b.inFlight.Add(-1)
+}
+
+func (b *countingBackend) SaveContextManager(cm *ContextManager) error {
+	b.enter()
+	b.managerSaves.Add(1)
+	return nil
+}
+
+func (b *countingBackend) SaveExecutionContext(ctx *ExecutionContext) error {
+	b.enter()
+	b.contextSaves.Add(1)
+	return nil
+}
+
+// TestPersistenceWriterSerializesSaves 測試同時的儲存請求依序寫入，且重複快照被合併
+func TestPersistenceWriterSerializesSaves(t *testing.T) {
+	backend := newCountingBackend()
+	w := newPersistenceWriter(backend)
+	defer w.Close()
+
+	cm := NewContextManager()
+	first := make(chan error, 1)
+	go func() { first <- w.SaveContextManager(cm) }()
+	<-backend.started
+
+	// 第一次寫入尚未完成時，同時排入多個請求
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- w.SaveContextManager(cm)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			errs <- w.SaveExecutionContext(NewExecutionContext(i, "測試"))
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+	close(errs)
+
+	if err := <-first; err != nil {
+		t.Fatalf("第一次寫入不應失敗: %v", err)
+	}
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("所有請求都應收到成功結果: %v", err)
+		}
+	}
+
+	if got := backend.maxInFlight.Load(); got != 1 {
+		t.Errorf("同一時間應只有 1 個寫入，但最多為 %d", got)
+	}
+	if got := backend.contextSaves.Load(); got != n {
+		t.Errorf("每個執行上下文都應寫入，預期 %d 次，實際 %d 次", n, got)
+	}
+	if got := backend.managerSaves.Load(); got >= n {
+		t.Errorf("排隊中的上下文快照應被合併，但寫入了 %d 次", got)
+	}
+	if writes, coalesced := w.Stats(); writes+coalesced != 2*n+1 {
+		t.Errorf("寫入 %d 次加上合併 %d 次應等於請求數 %d", writes, coalesced, 2*n+1)
+	}
+}
+
+// TestPersistenceWriterNoCorruption 測試大量同時儲存後所有檔案都是完整的 JSON
+func TestPersistenceWriterNoCorruption(t *testing.T) {
+	dir := t.TempDir()
+	pm, err := NewPersistenceManager(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newPersistenceWriter(pm)
+
+	cm := NewContextManager()
+	for i := 0; i < 20; i++ {
+		cm.StartLoop(i, fmt.Sprintf("提示詞 %d", i))
+		cm.UpdateCurrentLoop(func(ctx *ExecutionContext) {
+			ctx.CLIOutput = fmt.Sprintf("迴圈 %d 的輸出", i)
+		})
+		cm.FinishLoop()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := w.SaveContextManager(cm); err != nil {
+				t.Errorf("儲存上下文管理器失敗: %v", err)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			ctx := NewExecutionContext(i, "測試")
+			ctx.LoopID = fmt.Sprintf("concurrent_%d", i)
+			if err := w.SaveExecutionContext(ctx); err != nil {
+				t.Errorf("儲存執行上下文失敗: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	w.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 51 {
+		t.Fatalf("應至少有 50 個執行上下文與 1 個上下文快照，但只有 %d 個檔案", len(files))
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f) // #nosec G304 -- 測試暫存目錄
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !json.Valid(data) {
+			t.Errorf("%s 不是完整的 JSON（寫入交錯）", filepath.Base(f))
+		}
+	}
+}
+
+// TestPersistenceWriterFlushOnClose 測試關閉客戶端時寫完佇列並拒絕之後的請求
+func TestPersistenceWriterFlushOnClose(t *testing.T) {
+	config := DefaultClientConfig()
+	config.SaveDir = t.TempDir()
+	config.Silent = true
+	client := NewRalphLoopClientWithConfig(config)
+
+	if client.writer == nil {
+		t.Fatal("預設應啟用序列化持久化")
+	}
+	if client.backend != client.writer {
+		t.Error("迴圈持久化應經由背景寫入器")
+	}
+
+	writer := client.writer
+	if err := client.Close(); err != nil {
+		t.Fatalf("關閉不應失敗: %v", err)
+	}
+	if err := writer.SaveContextManager(NewContextManager()); !errors.Is(err, errPersistenceWriterClosed) {
+		t.Errorf("關閉後的請求應被拒絕，但為 %v", err)
+	}
+
+	config.SerializePersistence = false
+	config.SaveDir = t.TempDir()
+	direct := NewRalphLoopClientWithConfig(config)
+	defer direct.Close()
+	if direct.writer != nil || direct.backend != direct.persistence {
+		t.Error("停用 SerializePersistence 時應直接寫入")
+	}
+}
@@ -0,0 +1,168 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultPostLoopFormatTimeout 單一格式化命令的預設逾時
+const defaultPostLoopFormatTimeout = 30 * time.Second
+
+// formatFilesPlaceholder 格式化命令樣板中代表檔案清單的佔位符
+const formatFilesPlaceholder = "{files}"
+
+// changedFiles 以 git 取得工作目錄中相對於 HEAD 變更（含未追蹤）的檔案
+//
+// 只傳回仍存在且位於工作目錄內的檔案；非 git 儲存庫時回傳錯誤。
+func changedFiles(ctx context.Context, dir, saveDir string) ([]string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	paths, err := gitChangedPaths(ctx, dir, saveDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range paths {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.IsDir() {
+			continue // 已刪除的檔案不需要格式化
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// gitChangedPaths 以 git 列出相對於 HEAD 修改、刪除或未追蹤的路徑（相對於 dir，排除 Ralph Loop 的狀態檔）
+func gitChangedPaths(ctx context.Context, dir, saveDir string) ([]string, error) {
+	base, err := gitDiffBase(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", base},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		// #nosec G204 -- 參數為固定的 git 子命令
+		cmdArgs := append([]string{"-C", dir}, args...)
+		cmdArgs = append(cmdArgs, ralphStatePathspec(dir, saveDir)...)
+		out, err := exec.CommandContext(ctx, "git", cmdArgs...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s 失敗: %w", args[0], err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			name := strings.TrimSpace(line)
+			if name == "" || seen[name] || !filepath.IsLocal(name) {
+				continue
+			}
+			seen[name] = true
+			paths = append(paths, name)
+		}
+	}
+	return paths, nil
+}
+
+// gitEmptyTree git 的空樹物件，儲存庫尚無提交時作為比較基準
+const gitEmptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// gitDiffBase 傳回比較變更的基準：HEAD，或尚無提交時的空樹；dir 不是 git 儲存庫時傳回錯誤
+func gitDiffBase(ctx context.Context, dir string) (string, error) {
+	// #nosec G204 -- 參數為固定的 git 子命令
+	if exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--verify", "-q", "HEAD").Run() == nil {
+		return "HEAD", nil
+	}
+	// #nosec G204 -- 參數為固定的 git 子命令
+	if err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--git-dir").Run(); err != nil {
+		return "", fmt.Errorf("git rev-parse 失敗: %w", err)
+	}
+	return gitEmptyTree, nil
+}
+
+// filterFormatFiles 依 glob（比對檔名）過濾檔案，globs 為空時傳回全部
+func filterFormatFiles(files, globs []string) []string {
+	if len(globs) == 0 {
+		return files
+	}
+	var matched []string
+	for _, f := range files {
+		for _, g := range globs {
+			if ok, _ := filepath.Match(g, filepath.Base(f)); ok {
+				matched = append(matched, f)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// expandFormatCommand 將樣板展開為命令參數
+//
+// 樣板以空白切分、不經過 shell；{files} 會展開為檔案清單，
+// 沒有佔位符時檔案清單附加在最後。
+func expandFormatCommand(template string, files []string) []string {
+	var args []string
+	replaced := false
+	for _, field := range strings.Fields(template) {
+		if field == formatFilesPlaceholder {
+			args = append(args, files...)
+			replaced = true
+			continue
+		}
+		args = append(args, field)
+	}
+	if !replaced {
+		args = append(args, files...)
+	}
+	return args
+}
+
+// runPostLoopFormatters 對本迴圈變更的檔案執行 PostLoopFormatters
+//
+// 命令透過 HookRunner 在工作目錄中執行，每個命令受 PostLoopFormatTimeout 限制。失敗只記錄為警告，不影響迴圈決策。
+func (c *RalphLoopClient) runPostLoopFormatters(ctx context.Context, execCtx *ExecutionContext) {
+	if len(c.config.PostLoopFormatters) == 0 {
+		return
+	}
+
+	files, err := changedFiles(ctx, c.config.WorkDir, c.config.SaveDir)
+	if err != nil {
+		debugLog("略過格式化（無法取得變更檔案）: %v", err)
+		return
+	}
+	files = filterFormatFiles(files, c.config.PostLoopFormatGlobs)
+	if len(files) == 0 {
+		return
+	}
+
+	timeout := c.config.PostLoopFormatTimeout
+	if timeout <= 0 {
+		timeout = defaultPostLoopFormatTimeout
+	}
+	runner := c.hookRunner(timeout)
+
+	for _, template := range c.config.PostLoopFormatters {
+		args := expandFormatCommand(template, files)
+		if len(args) == 0 {
+			continue
+		}
+
+		hook := runner.Run(ctx, args)
+		switch {
+		case hook.TimedOut:
+			execCtx.AddWarning("格式化命令 %q 超過 %v 未完成", args[0], runner.Timeout)
+		case hook.Canceled:
+			execCtx.AddWarning("格式化命令 %q 被取消", args[0])
+			return
+		case hook.Err != nil:
+			execCtx.AddWarning("格式化命令 %q 失敗: %v %s", args[0], hook.Err, tailRunes(strings.TrimSpace(hook.Stdout+hook.Stderr), 200))
+		default:
+			infoLog("🧹 已對 %d 個變更檔案執行 %s", len(files), args[0])
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package ghcopilot
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestExpandFormatCommand 測試格式化命令樣板展開
+func TestExpandFormatCommand(t *testing.T) {
+	files := []string{"a.go", "pkg/b.go"}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"gofmt -w {files}", "gofmt -w a.go pkg/b.go"},
+		{"goimports -w", "goimports -w a.go pkg/b.go"},
+		{"fmt-tool {files} --quiet", "fmt-tool a.go pkg/b.go --quiet"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(expandFormatCommand(tt.template, files), " "); got != tt.want {
+			t.Errorf("expandFormatCommand(%q) = %q，應為 %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+// TestFilterFormatFiles 測試依 glob 過濾要格式化的檔案
+func TestFilterFormatFiles(t *testing.T) {
+	files := []string{"main.go", "README.md", "pkg/util.go"}
+	got := filterFormatFiles(files, []string{"*.go"})
+	if strings.Join(got, ",") != "main.go,pkg/util.go" {
+		t.Errorf("應只保留 Go 檔案，但為 %v", got)
+	}
+	if len(filterFormatFiles(files, nil)) != 3 {
+		t.Error("未設定 glob 應保留全部檔案")
+	}
+}
+
+// initGitRepo 建立含一個已提交檔案的 git 儲存庫
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("需要 git")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v 失敗: %v %s", args, err, out)
+		}
+	}
+	return dir
+}
+
+// initGitRepoWithSubdir 建立含已提交 sub/a.go 的 git 儲存庫，傳回儲存庫根目錄與 sub 目錄
+func initGitRepoWithSubdir(t *testing.T) (string, string) {
+	t.Helper()
+	root := initGitRepo(t)
+	sub := filepath.Join(root, "sub")
+	if err := os.MkdirAll(sub, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "a.go"), []byte("package sub\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "sub"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v 失敗: %v %s", args, err, out)
+		}
+	}
+	return root, sub
+}
+
+// TestPostLoopFormatters 測試迴圈後對變更檔案執行格式化命令
+func TestPostLoopFormatters(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("使用 shell 腳本模擬格式化工具，Windows 上略過")
+	}
+	dir := initGitRepo(t)
+
+	// 模擬格式化工具：把收到的參數寫進紀錄檔
+	binDir := t.TempDir()
+	logFile := filepath.Join(binDir, "args.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logFile + "\n"
+	formatter := filepath.Join(binDir, "fake-fmt")
+	if err := os.WriteFile(formatter, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultClientConfig()
+	config.WorkDir = dir
+	config.PostLoopFormatters = []string{formatter + " -w {files}", "missing-formatter-command"}
+	config.PostLoopFormatGlobs = []string{"*.go"}
+	client := newScriptedClient(config, "")
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		_ = os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\nfunc main(){}\n"), 0600)
+		_ = os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0600)
+		_ = os.WriteFile(filepath.Join(dir, "notes.md"), []byte("notes\n"), 0600)
+		return &ExecutionResult{
+			Command: "copilot",
+			Stdout:  "已修改\n---RALPH_STATUS---\nEXIT_SIGNAL: true\n---END_RALPH_STATUS---",
+		}, nil
+	}
+
+	result, err := client.ExecuteLoop(context.Background(), "修正錯誤")
+	if err != nil {
+		t.Fatalf("ExecuteLoop 失敗: %v", err)
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("格式化工具應被呼叫: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "-w main.go new.go" {
+		t.Errorf("格式化工具應收到變更的 Go 檔案，但為 %q", got)
+	}
+
+	// 找不到的命令只記錄為警告，不影響決策
+	if result.ShouldContinue {
+		t.Error("格式化失敗不應影響完成判定")
+	}
+	found := false
+	for _, w := range result.Warnings {
+		if strings.Contains(w, "missing-formatter-command") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("應有格式化失敗的警告，但為 %v", result.Warnings)
+	}
+}
+
+// TestChangedFilesInSubdir 測試工作目錄為儲存庫子目錄時，已追蹤與未追蹤檔案的路徑一致
+func TestChangedFilesInSubdir(t *testing.T) {
+	root, sub := initGitRepoWithSubdir(t)
+
+	// 已追蹤檔案修改、子目錄新增檔案、子目錄外的修改不應列入
+	_ = os.WriteFile(filepath.Join(sub, "a.go"), []byte("package sub\nfunc A() {}\n"), 0600)
+	_ = os.WriteFile(filepath.Join(sub, "b.go"), []byte("package sub\n"), 0600)
+	_ = os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\nfunc main(){}\n"), 0600)
+
+	files, err := changedFiles(context.Background(), sub, "")
+	if err != nil {
+		t.Fatalf("changedFiles 失敗: %v", err)
+	}
+	if got := strings.Join(files, " "); got != "a.go b.go" {
+		t.Errorf("應回傳相對於工作目錄的變更檔案，但為 %q", got)
+	}
+}
@@ -0,0 +1,96 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ProgressFile 中的執行狀態
+const (
+	ProgressRunning   = "running"
+	ProgressCompleted = "completed"
+	ProgressFailed    = "failed"
+)
+
+// ProgressStatus ProgressFile 的內容，供 CI 等外部工具輪詢
+type ProgressStatus struct {
+	RunID          string    `json:"run_id,omitempty"`
+	Loop           int       `json:"loop"`      // 已完成的迴圈數
+	MaxLoops       int       `json:"max_loops"` // 迴圈上限
+	State          string    `json:"state"`     // running、completed 或 failed
+	BreakerState   string    `json:"breaker_state"`
+	LastExitReason string    `json:"last_exit_reason,omitempty"`
+	Error          string    `json:"error,omitempty"` // 執行以錯誤結束時的原因
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// writeFileAtomic 先寫入同目錄的暫存檔再改名，讀取端不會讀到寫到一半的內容
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("無法建立目錄: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("無法建立暫存檔: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("無法寫入暫存檔: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("無法寫入暫存檔: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("無法取代 %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeProgress 將目前進度寫入 ProgressFile（未設定時不做任何事）
+//
+// 寫入失敗只記錄日誌，不影響執行。
+func (c *RalphLoopClient) writeProgress(state string, loops, maxLoops int, lastExitReason string, runErr error) {
+	if c.config.ProgressFile == "" {
+		return
+	}
+	status := ProgressStatus{
+		RunID:          c.runID,
+		Loop:           loops,
+		MaxLoops:       maxLoops,
+		State:          state,
+		BreakerState:   string(c.breaker.GetState()),
+		LastExitReason: lastExitReason,
+		UpdatedAt:      time.Now(),
+	}
+	if runErr != nil {
+		status.Error = runErr.Error()
+	}
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		debugLog("進度檔編碼失敗: %v", err)
+		return
+	}
+	if err := writeFileAtomic(c.config.ProgressFile, data); err != nil {
+		debugLog("無法更新進度檔: %v", err)
+	}
+}
+
+// finishProgress 執行結束時寫入最終狀態
+func (c *RalphLoopClient) finishProgress(results []*LoopResult, maxLoops int, runErr error) {
+	state := ProgressCompleted
+	if runErr != nil {
+		state = ProgressFailed
+	}
+	reason := ""
+	if len(results) > 0 {
+		reason = results[len(results)-1].ExitReason
+	}
+	c.writeProgress(state, len(results), maxLoops, reason, runErr)
+}
@@ -0,0 +1,119 @@
+package ghcopilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// readProgress 讀取並解析進度檔
+func readProgress(path string) (*ProgressStatus, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- test temp dir
+	if err != nil {
+		return nil, err
+	}
+	status := &ProgressStatus{}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, fmt.Errorf("進度檔不是有效的 JSON: %w (%q)", err, data)
+	}
+	return status, nil
+}
+
+// TestProgressFileUpdatesEachLoop 測試每個迴圈後更新進度檔，且讀取端永遠讀到完整的 JSON
+func TestProgressFileUpdatesEachLoop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ci", "progress.json")
+	config := DefaultClientConfig()
+	config.ProgressFile = path
+	client := newScriptedClient(config, "")
+
+	var seen []int
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		loop := len(seen) + 1
+		if loop > 1 {
+			status, err := readProgress(path)
+			if err != nil {
+				t.Errorf("迴圈 %d 開始時應能讀到進度檔: %v", loop, err)
+			} else {
+				seen = append(seen, status.Loop)
+				if status.State != ProgressRunning || status.MaxLoops != 5 {
+					t.Errorf("執行中的進度檔內容不正確: %+v", status)
+				}
+			}
+		} else {
+			seen = append(seen, 0)
+		}
+		stdout := fmt.Sprintf("進行中 %d\n---RALPH_STATUS---\nEXIT_SIGNAL: false\nREASON: 還有 %d 項\n---END_RALPH_STATUS---", loop, 5-loop)
+		if loop == 3 {
+			stdout = "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: done\n---END_RALPH_STATUS---"
+		}
+		return &ExecutionResult{Command: "copilot", Stdout: stdout}, nil
+	}
+
+	// 背景持續讀取：改名寫入下不應讀到寫到一半的內容
+	stop := make(chan struct{})
+	var invalid atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, err := readProgress(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				invalid.Add(1)
+			}
+		}
+	}()
+
+	_, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 5)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("應在第 3 個迴圈完成: %v", err)
+	}
+	if invalid.Load() > 0 {
+		t.Errorf("讀取端讀到 %d 次無效的 JSON", invalid.Load())
+	}
+	if len(seen) != 3 || seen[1] != 1 || seen[2] != 2 {
+		t.Errorf("每個迴圈後都應更新進度檔，實際讀到 %v", seen)
+	}
+
+	final, err := readProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final.State != ProgressCompleted || final.Loop != 3 || final.LastExitReason == "" || final.Error != "" {
+		t.Errorf("最終狀態應為完成: %+v", final)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".*tmp*")); len(matches) > 0 {
+		t.Errorf("不應留下暫存檔: %v", matches)
+	}
+}
+
+// TestProgressFileFailedRun 測試執行失敗時寫入錯誤原因
+func TestProgressFileFailedRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+	config := DefaultClientConfig()
+	config.ProgressFile = path
+	client := newScriptedClient(config, "進行中\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+
+	if _, err := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 2); err == nil {
+		t.Fatal("未完成時應回傳錯誤")
+	}
+	status, err := readProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != ProgressFailed || status.Loop != 2 || status.Error == "" {
+		t.Errorf("失敗的執行應記錄狀態與錯誤: %+v", status)
+	}
+}
@@ -0,0 +1,93 @@
+package ghcopilot
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// tasksDonePattern 匹配狀態區塊中的 TASKS_DONE: n/m
+var tasksDonePattern = regexp.MustCompile(`TASKS_DONE:\s*(\d+)\s*/\s*(\d+)`)
+
+// ParseTasksDone 從單行文字解析 TASKS_DONE 進度
+func ParseTasksDone(line string) (done, total int, ok bool) {
+	m := tasksDonePattern.FindStringSubmatch(line)
+	if m == nil {
+		return 0, 0, false
+	}
+	done, err1 := strconv.Atoi(m[1])
+	total, err2 := strconv.Atoi(m[2])
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return done, total, true
+}
+
+// ProgressWriter 在 CLI 串流輸出時即時解析 TASKS_DONE 進度
+//
+// 與 ResponseAnalyzer 只看最後的狀態區塊不同，它逐行檢查串流，
+// 單次呼叫中出現多次進度更新時，每次變化都會觸發回呼（以最新者為準）。
+type ProgressWriter struct {
+	mu        sync.Mutex
+	buf       []byte
+	onUpdate  func(done, total int)
+	lastDone  int
+	lastTotal int
+	reported  bool
+}
+
+// NewProgressWriter 建立新的進度解析 writer
+func NewProgressWriter(onUpdate func(done, total int)) *ProgressWriter {
+	return &ProgressWriter{onUpdate: onUpdate}
+}
+
+// Write 實作 io.Writer，逐行解析進度
+func (pw *ProgressWriter) Write(p []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	pw.buf = append(pw.buf, p...)
+	for {
+		idx := bytes.IndexByte(pw.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		pw.handleLine(string(pw.buf[:idx]))
+		pw.buf = pw.buf[idx+1:]
+	}
+	return len(p), nil
+}
+
+// Flush 處理串流結束時尚未換行的最後一行
+func (pw *ProgressWriter) Flush() {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if len(pw.buf) > 0 {
+		pw.handleLine(string(pw.buf))
+		pw.buf = nil
+	}
+}
+
+// Latest 傳回最後一次解析到的進度
+func (pw *ProgressWriter) Latest() (done, total int, ok bool) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	return pw.lastDone, pw.lastTotal, pw.reported
+}
+
+// handleLine 解析單行並在進度變化時觸發回呼（呼叫端需持有鎖）
+func (pw *ProgressWriter) handleLine(line string) {
+	done, total, ok := ParseTasksDone(line)
+	if !ok {
+		return
+	}
+	if pw.reported && done == pw.lastDone && total == pw.lastTotal {
+		return
+	}
+	pw.lastDone, pw.lastTotal, pw.reported = done, total, true
+	if pw.onUpdate != nil {
+		pw.onUpdate(done, total)
+	}
+}
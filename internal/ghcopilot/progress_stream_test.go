@@ -0,0 +1,92 @@
+package ghcopilot
+
+import (
+	"context"
+	"testing"
+)
+
+// TestParseTasksDone 測試單行進度解析
+func TestParseTasksDone(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantDone  int
+		wantTotal int
+		wantOK    bool
+	}{
+		{"TASKS_DONE: 3/5", 3, 5, true},
+		{"  TASKS_DONE:1 / 4  ", 1, 4, true},
+		{"TASKS_DONE: n/m", 0, 0, false},
+		{"正在修改檔案", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		done, total, ok := ParseTasksDone(tt.line)
+		if done != tt.wantDone || total != tt.wantTotal || ok != tt.wantOK {
+			t.Errorf("ParseTasksDone(%q) = %d, %d, %v；應為 %d, %d, %v",
+				tt.line, done, total, ok, tt.wantDone, tt.wantTotal, tt.wantOK)
+		}
+	}
+}
+
+// TestProgressWriterIncremental 測試串流中多次進度更新
+func TestProgressWriterIncremental(t *testing.T) {
+	var updates [][2]int
+	pw := NewProgressWriter(func(done, total int) {
+		updates = append(updates, [2]int{done, total})
+	})
+
+	chunks := []string{
+		"開始處理\nTASKS_",
+		"DONE: 1/3\n修改 main.go\n",
+		"TASKS_DONE: 1/3\n", // 重複的進度不應再次觸發
+		"TASKS_DONE: 2/3\n執行測試\n",
+		"TASKS_DONE: 3/3", // 最後一行沒有換行
+	}
+	for _, chunk := range chunks {
+		if _, err := pw.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write 失敗: %v", err)
+		}
+	}
+
+	if len(updates) != 2 {
+		t.Fatalf("Flush 前應有 2 次更新，但為 %d", len(updates))
+	}
+
+	pw.Flush()
+
+	want := [][2]int{{1, 3}, {2, 3}, {3, 3}}
+	if len(updates) != len(want) {
+		t.Fatalf("應有 %d 次更新，但為 %d: %v", len(want), len(updates), updates)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Errorf("第 %d 次更新應為 %v，但為 %v", i+1, want[i], updates[i])
+		}
+	}
+
+	if done, total, ok := pw.Latest(); !ok || done != 3 || total != 3 {
+		t.Errorf("Latest 應為 3/3，但為 %d/%d (%v)", done, total, ok)
+	}
+}
+
+// TestCLIExecutorProgressCallback 測試執行器在輸出中回報進度
+func TestCLIExecutorProgressCallback(t *testing.T) {
+	t.Setenv("COPILOT_MOCK_MODE", "true")
+
+	var lastDone, lastTotal, calls int
+	executor := NewCLIExecutor(".")
+	executor.SetProgressCallback(func(done, total int) {
+		calls++
+		lastDone, lastTotal = done, total
+	})
+
+	if _, err := executor.ExecutePrompt(context.Background(), "修正錯誤"); err != nil {
+		t.Fatalf("ExecutePrompt 失敗: %v", err)
+	}
+	if calls == 0 {
+		t.Fatal("應觸發進度回呼")
+	}
+	if lastTotal == 0 || lastDone > lastTotal {
+		t.Errorf("進度不合理: %d/%d", lastDone, lastTotal)
+	}
+}
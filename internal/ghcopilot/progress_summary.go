@@ -0,0 +1,130 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultProgressSummaryMaxRunes 進度摘要的預設字數上限
+const DefaultProgressSummaryMaxRunes = 1200
+
+// progressSummaryLineRunes 每個迴圈在摘要中的說明字數上限
+const progressSummaryLineRunes = 120
+
+// progressSummary 跨迴圈累積的進度摘要（以本地樣板產生，不額外呼叫模型）
+//
+// 每個迴圈新增一行；總長度超過上限時，從最舊的行開始併入一行「迴圈 a-b」的彙總，
+// 因此摘要保持時間順序且長度有上限（上限過小時只剩彙總行）。
+type progressSummary struct {
+	maxRunes    int
+	rolledLoops int             // 已併入彙總的迴圈數
+	rolledFrom  int             // 彙總的第一個迴圈編號
+	rolledTo    int             // 彙總的最後一個迴圈編號
+	rolledLast  string          // 彙總中最後一個迴圈的說明
+	entries     []progressEntry // 尚未併入彙總的迴圈
+}
+
+// progressEntry 單一迴圈在摘要中的說明
+type progressEntry struct {
+	loop int // 迴圈編號（從 1 起算）
+	text string
+}
+
+// newProgressSummary 建立進度摘要，maxRunes <= 0 時使用預設值
+func newProgressSummary(maxRunes int) *progressSummary {
+	if maxRunes <= 0 {
+		maxRunes = DefaultProgressSummaryMaxRunes
+	}
+	return &progressSummary{maxRunes: maxRunes}
+}
+
+// progressLine 從迴圈結果產生一行說明：TASKS_DONE、輸出的第一句與未完成的原因
+func progressLine(result *LoopResult) string {
+	var parts []string
+	status := NewResponseAnalyzer(result.Output).ParseStructuredOutput()
+	if status != nil && status.TasksDone != "" {
+		parts = append(parts, "任務 "+status.TasksDone)
+	}
+
+	output := result.Output
+	if idx := strings.Index(output, "---RALPH_STATUS---"); idx >= 0 {
+		output = output[:idx]
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, truncateRunes(line, progressSummaryLineRunes))
+			break
+		}
+	}
+
+	if result.ShouldContinue && result.ExitReason != "" {
+		parts = append(parts, "("+truncateRunes(result.ExitReason, 60)+")")
+	}
+	if len(parts) == 0 {
+		return "沒有輸出"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Add 加入一個迴圈的結果並維持長度上限
+func (s *progressSummary) Add(result *LoopResult) {
+	s.entries = append(s.entries, progressEntry{loop: result.LoopIndex + 1, text: progressLine(result)})
+	for len(s.entries) > 0 && len([]rune(s.String())) > s.maxRunes {
+		s.roll()
+	}
+}
+
+// roll 將最舊的一行併入彙總
+func (s *progressSummary) roll() {
+	oldest := s.entries[0]
+	s.entries = s.entries[1:]
+	if s.rolledLoops == 0 {
+		s.rolledFrom = oldest.loop
+	}
+	s.rolledTo = oldest.loop
+	s.rolledLoops++
+	s.rolledLast = truncateRunes(oldest.text, 60)
+}
+
+// String 傳回摘要內容（沒有任何迴圈時為空字串）
+func (s *progressSummary) String() string {
+	if s == nil || (s.rolledLoops == 0 && len(s.entries) == 0) {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("[目前進度]\n")
+	if s.rolledLoops > 0 {
+		fmt.Fprintf(&sb, "- 迴圈 %d-%d: 已執行 %d 個迴圈，最後: %s\n", s.rolledFrom, s.rolledTo, s.rolledLoops, s.rolledLast)
+	}
+	for _, e := range s.entries {
+		fmt.Fprintf(&sb, "- 迴圈 %d: %s\n", e.loop, e.text)
+	}
+	return sb.String()
+}
+
+// progressSummaryPrefix 傳回要加在 prompt 前面的進度摘要（未啟用或還沒有迴圈時為空字串）
+func (c *RalphLoopClient) progressSummaryPrefix() string {
+	if !c.config.EnableProgressSummary {
+		return ""
+	}
+	if summary := c.progress.String(); summary != "" {
+		return summary + "\n"
+	}
+	return ""
+}
+
+// updateProgressSummary 以迴圈結果更新進度摘要
+func (c *RalphLoopClient) updateProgressSummary(result *LoopResult) {
+	if !c.config.EnableProgressSummary || result == nil {
+		return
+	}
+	if c.progress == nil {
+		c.progress = newProgressSummary(c.config.ProgressSummaryMaxRunes)
+	}
+	c.progress.Add(result)
+}
+
+// ProgressSummary 傳回目前的進度摘要（未啟用 EnableProgressSummary 時為空字串）
+func (c *RalphLoopClient) ProgressSummary() string {
+	return c.progress.String()
+}
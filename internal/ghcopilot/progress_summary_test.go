@@ -0,0 +1,62 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestProgressSummaryPrependedToPrompt 測試每個迴圈的 prompt 前面帶有先前迴圈的進度摘要
+func TestProgressSummaryPrependedToPrompt(t *testing.T) {
+	config := DefaultClientConfig()
+	config.EnableProgressSummary = true
+	client := newScriptedClient(config, "")
+
+	var prompts []string
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		prompts = append(prompts, prompt)
+		n := len(prompts)
+		out := fmt.Sprintf("修正第 %d 個測試\n---RALPH_STATUS---\nEXIT_SIGNAL: false\nTASKS_DONE: %d/5\n---END_RALPH_STATUS---", n, n)
+		return &ExecutionResult{Command: "copilot", Stdout: out}, nil
+	}
+
+	if _, err := client.ExecuteUntilCompletion(context.Background(), "修正測試", 3); err == nil {
+		t.Fatal("未完成的執行應回傳錯誤")
+	}
+	if strings.Contains(prompts[0], "[目前進度]") {
+		t.Error("第一個迴圈沒有進度可摘要")
+	}
+	if !strings.HasPrefix(prompts[2], "[目前進度]\n- 迴圈 1: 任務 1/5 修正第 1 個測試\n- 迴圈 2: 任務 2/5 修正第 2 個測試\n") {
+		t.Errorf("第三個迴圈的 prompt 應以依序累積的摘要開頭:\n%s", prompts[2])
+	}
+	if !strings.Contains(client.ProgressSummary(), "- 迴圈 3: 任務 3/5") {
+		t.Errorf("摘要應包含最後一個迴圈:\n%s", client.ProgressSummary())
+	}
+}
+
+// TestProgressSummaryBounded 測試摘要超過上限時將最舊的迴圈併入彙總
+func TestProgressSummaryBounded(t *testing.T) {
+	s := newProgressSummary(300)
+	for i := 0; i < 30; i++ {
+		s.Add(&LoopResult{
+			LoopIndex:      i,
+			ShouldContinue: true,
+			Output:         fmt.Sprintf("迴圈 %d 處理了 %s", i+1, strings.Repeat("很多檔案", 20)),
+		})
+		if n := len([]rune(s.String())); n > 300 {
+			t.Fatalf("加入迴圈 %d 後摘要長度 %d 超過上限 300", i+1, n)
+		}
+	}
+
+	summary := s.String()
+	if !strings.Contains(summary, "- 迴圈 1-") {
+		t.Errorf("較早的迴圈應併入彙總:\n%s", summary)
+	}
+	if !strings.Contains(summary, "- 迴圈 30: 迴圈 30 處理了") {
+		t.Errorf("最新的迴圈應完整保留:\n%s", summary)
+	}
+	if s.rolledTo+len(s.entries) != 30 || s.entries[0].loop != s.rolledTo+1 {
+		t.Errorf("彙總與保留的迴圈應連續: rolled %d-%d, entries %+v", s.rolledFrom, s.rolledTo, s.entries)
+	}
+}
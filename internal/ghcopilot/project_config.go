@@ -0,0 +1,169 @@
+package ghcopilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfigNames 專案設定檔名稱（依序尋找）
+var projectConfigNames = []string{".ralphrc", ".ralphrc.json", ".ralphrc.yaml", ".ralphrc.yml"}
+
+// ProjectConfig 專案層級的預設設定，從工作目錄的 .ralphrc 載入
+//
+// 設定的優先順序（高到低）：
+//  1. 命令列參數
+//  2. .ralphrc（工作目錄，或往上直到 git 根目錄的第一個）
+//  3. DefaultClientConfig 的預設值
+//
+// 目前沒有使用者層級的全域設定檔，.ralphrc 之下直接是內建預設值。
+// 環境變數（例如 COPILOT_MOCK_MODE）作用於執行器層級，不受 .ralphrc 影響，也不會覆蓋其中的設定。
+// 設定檔可使用 JSON 或 YAML（以 "{" 開頭時視為 JSON）；未設定的欄位不會覆蓋原本的值。
+type ProjectConfig struct {
+	Model        string   `json:"model,omitempty" yaml:"model,omitempty"`                 // AI 模型名稱
+	AllowedTools []string `json:"allowed_tools,omitempty" yaml:"allowed_tools,omitempty"` // 只允許這些工具（設定後不再允許所有工具）
+	DeniedTools  []string `json:"denied_tools,omitempty" yaml:"denied_tools,omitempty"`   // 禁止的工具
+
+	ArtifactGlobs    []string `json:"artifact_globs,omitempty" yaml:"artifact_globs,omitempty"`         // 每個迴圈保存符合這些 glob 的產出檔案
+	MaxArtifactBytes int64    `json:"max_artifact_bytes,omitempty" yaml:"max_artifact_bytes,omitempty"` // 產出檔案總大小上限
+
+	RetryableErrors    []string `json:"retryable_errors,omitempty" yaml:"retryable_errors,omitempty"`         // 只重試包含這些字串的 CLI 錯誤
+	NonRetryableErrors []string `json:"non_retryable_errors,omitempty" yaml:"non_retryable_errors,omitempty"` // 包含這些字串的 CLI 錯誤不重試
+
+	Completion *ProjectCompletionPolicy `json:"completion,omitempty" yaml:"completion,omitempty"` // 完成判定相關設定
+
+	// Path 載入的設定檔路徑
+	Path string `json:"-" yaml:"-"`
+}
+
+// ProjectCompletionPolicy .ralphrc 中的完成判定設定（nil 表示未設定）
+type ProjectCompletionPolicy struct {
+	CircuitBreakerThreshold *int `json:"circuit_breaker_threshold,omitempty" yaml:"circuit_breaker_threshold,omitempty"`
+	SameErrorThreshold      *int `json:"same_error_threshold,omitempty" yaml:"same_error_threshold,omitempty"`
+	NoChangeLoopThreshold   *int `json:"no_change_loop_threshold,omitempty" yaml:"no_change_loop_threshold,omitempty"`
+	ContextWindowLoops      *int `json:"context_window_loops,omitempty" yaml:"context_window_loops,omitempty"`
+
+	// 驗證命令：判定完成後執行，退出碼與輸出都符合才結束
+	VerifyCommand       *string `json:"verify_command,omitempty" yaml:"verify_command,omitempty"`
+	VerifyExpectExit    *int    `json:"verify_expect_exit,omitempty" yaml:"verify_expect_exit,omitempty"`
+	VerifyExpectPattern *string `json:"verify_expect_pattern,omitempty" yaml:"verify_expect_pattern,omitempty"`
+}
+
+// LoadProjectConfig 從 workdir 往上尋找並載入 .ralphrc
+//
+// 搜尋會停在第一個包含 .git 的目錄（git 根目錄）或檔案系統根目錄。
+// 找不到設定檔時傳回 nil, nil。
+func LoadProjectConfig(workdir string) (*ProjectConfig, error) {
+	if workdir == "" {
+		workdir = "."
+	}
+	dir, err := filepath.Abs(workdir)
+	if err != nil {
+		return nil, fmt.Errorf("無法解析工作目錄: %w", err)
+	}
+
+	for {
+		for _, name := range projectConfigNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return readProjectConfig(path)
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return nil, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// readProjectConfig 解析單一設定檔
+func readProjectConfig(path string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- 路徑由 LoadProjectConfig 在工作目錄範圍內組成
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取 %s: %w", path, err)
+	}
+
+	pc := &ProjectConfig{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(pc); err != nil {
+			return nil, fmt.Errorf("%s 格式錯誤: %w", path, err)
+		}
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(pc); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s 格式錯誤: %w", path, err)
+		}
+	}
+	if err := ValidateRetryErrorLists(pc.RetryableErrors, pc.NonRetryableErrors); err != nil {
+		return nil, fmt.Errorf("%s 設定錯誤: %w", path, err)
+	}
+	pc.Path = path
+	return pc, nil
+}
+
+// Apply 將專案設定套用到 config（只覆蓋有設定的欄位）
+func (pc *ProjectConfig) Apply(config *ClientConfig) {
+	if pc == nil || config == nil {
+		return
+	}
+
+	if pc.Model != "" {
+		config.Model = pc.Model
+	}
+	if len(pc.AllowedTools) > 0 {
+		config.AllowedTools = append([]string(nil), pc.AllowedTools...)
+	}
+	if len(pc.DeniedTools) > 0 {
+		config.DeniedTools = append([]string(nil), pc.DeniedTools...)
+	}
+	if len(pc.ArtifactGlobs) > 0 {
+		config.ArtifactGlobs = append([]string(nil), pc.ArtifactGlobs...)
+	}
+	if pc.MaxArtifactBytes > 0 {
+		config.MaxArtifactBytes = pc.MaxArtifactBytes
+	}
+	if len(pc.RetryableErrors) > 0 {
+		config.RetryableErrors = append([]string(nil), pc.RetryableErrors...)
+	}
+	if len(pc.NonRetryableErrors) > 0 {
+		config.NonRetryableErrors = append([]string(nil), pc.NonRetryableErrors...)
+	}
+
+	if c := pc.Completion; c != nil {
+		if c.CircuitBreakerThreshold != nil {
+			config.CircuitBreakerThreshold = *c.CircuitBreakerThreshold
+		}
+		if c.SameErrorThreshold != nil {
+			config.SameErrorThreshold = *c.SameErrorThreshold
+		}
+		if c.NoChangeLoopThreshold != nil {
+			config.NoChangeLoopThreshold = *c.NoChangeLoopThreshold
+		}
+		if c.ContextWindowLoops != nil {
+			config.ContextWindowLoops = *c.ContextWindowLoops
+		}
+		if c.VerifyCommand != nil {
+			config.VerifyCommand = *c.VerifyCommand
+		}
+		if c.VerifyExpectExit != nil {
+			config.VerifyExpectExit = *c.VerifyExpectExit
+		}
+		if c.VerifyExpectPattern != nil {
+			config.VerifyExpectPattern = *c.VerifyExpectPattern
+		}
+	}
+}
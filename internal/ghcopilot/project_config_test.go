@@ -0,0 +1,199 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newProjectFixture 建立含 .git 與 .ralphrc 的專案目錄，傳回根目錄與子目錄
+func newProjectFixture(t *testing.T, rc string) (root, sub string) {
+	t.Helper()
+	root = t.TempDir()
+	sub = filepath.Join(root, "pkg", "service")
+	if err := os.MkdirAll(sub, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if rc != "" {
+		if err := os.WriteFile(filepath.Join(root, ".ralphrc"), []byte(rc), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root, sub
+}
+
+// TestLoadProjectConfigApply 測試從子目錄找到 .ralphrc 並套用覆蓋值
+func TestLoadProjectConfigApply(t *testing.T) {
+	root, sub := newProjectFixture(t, `{
+  "model": "gpt-5",
+  "allowed_tools": ["shell(go test)", "write"],
+  "denied_tools": ["shell(rm)"],
+  "completion": {"no_change_loop_threshold": 3, "circuit_breaker_threshold": 0, "verify_command": "make test", "verify_expect_pattern": "PASS"}
+}`)
+
+	pc, err := LoadProjectConfig(sub)
+	if err != nil {
+		t.Fatalf("載入失敗: %v", err)
+	}
+	if pc == nil {
+		t.Fatal("應找到 git 根目錄的 .ralphrc")
+	}
+	if pc.Path != filepath.Join(root, ".ralphrc") {
+		t.Errorf("設定檔路徑應為根目錄，但為 %s", pc.Path)
+	}
+
+	config := DefaultClientConfig()
+	pc.Apply(config)
+
+	if config.Model != "gpt-5" {
+		t.Errorf("模型應被覆蓋為 gpt-5，但為 %s", config.Model)
+	}
+	if config.NoChangeLoopThreshold != 3 {
+		t.Errorf("NoChangeLoopThreshold 應為 3，但為 %d", config.NoChangeLoopThreshold)
+	}
+	if config.CircuitBreakerThreshold != 0 {
+		t.Errorf("明確設定為 0 的值也應套用，但為 %d", config.CircuitBreakerThreshold)
+	}
+	if config.VerifyCommand != "make test" || config.VerifyExpectPattern != "PASS" || config.VerifyExpectExit != 0 {
+		t.Errorf("驗證命令設定應被套用: %q %q %d", config.VerifyCommand, config.VerifyExpectPattern, config.VerifyExpectExit)
+	}
+	if config.SameErrorThreshold != 5 {
+		t.Errorf("未設定的欄位應保留預設值，但 SameErrorThreshold 為 %d", config.SameErrorThreshold)
+	}
+
+	// 允許的工具會傳給 CLI，並取代 --yolo
+	config.EnablePersistence = false
+	client := NewRalphLoopClientWithConfig(config)
+	defer client.Close()
+	args := strings.Join(client.executor.buildArgs("test"), " ")
+	for _, want := range []string{"--model gpt-5", "--allow-tool shell(go test)", "--deny-tool shell(rm)", "--allow-all-paths"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("參數應包含 %q: %s", want, args)
+		}
+	}
+	if strings.Contains(args, "--yolo") || strings.Contains(args, "--allow-all-tools") {
+		t.Errorf("限定工具時不應允許所有工具: %s", args)
+	}
+}
+
+// TestLoadProjectConfigYAML 測試 YAML 格式的 .ralphrc 與 JSON 套用相同的覆蓋值
+func TestLoadProjectConfigYAML(t *testing.T) {
+	_, sub := newProjectFixture(t, `# 專案預設
+model: gpt-5
+allowed_tools:
+  - shell(go test)
+completion:
+  circuit_breaker_threshold: 1
+  verify_command: make test
+`)
+
+	pc, err := LoadProjectConfig(sub)
+	if err != nil {
+		t.Fatalf("載入失敗: %v", err)
+	}
+	config := DefaultClientConfig()
+	pc.Apply(config)
+	if config.Model != "gpt-5" || config.CircuitBreakerThreshold != 1 || config.VerifyCommand != "make test" {
+		t.Errorf("YAML 設定應被套用: %q %d %q", config.Model, config.CircuitBreakerThreshold, config.VerifyCommand)
+	}
+	if !reflect.DeepEqual(config.AllowedTools, []string{"shell(go test)"}) {
+		t.Errorf("AllowedTools 應為 [shell(go test)]，但為 %v", config.AllowedTools)
+	}
+	if config.SameErrorThreshold != 5 {
+		t.Errorf("未設定的欄位應保留預設值，但 SameErrorThreshold 為 %d", config.SameErrorThreshold)
+	}
+}
+
+// TestLoadProjectConfigYAMLExtension 測試找得到 .ralphrc.yaml
+func TestLoadProjectConfigYAMLExtension(t *testing.T) {
+	root, sub := newProjectFixture(t, "")
+	path := filepath.Join(root, ".ralphrc.yaml")
+	if err := os.WriteFile(path, []byte("model: gpt-5\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pc, err := LoadProjectConfig(sub)
+	if err != nil {
+		t.Fatalf("載入失敗: %v", err)
+	}
+	if pc == nil || pc.Path != path || pc.Model != "gpt-5" {
+		t.Errorf("應載入 %s: %+v", path, pc)
+	}
+}
+
+// TestLoadProjectConfigStopsAtGitRoot 測試不會讀取 git 根目錄以外的設定
+func TestLoadProjectConfigStopsAtGitRoot(t *testing.T) {
+	outer := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outer, ".ralphrc"), []byte(`{"model": "outer"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(outer, "repo")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	pc, err := LoadProjectConfig(repo)
+	if err != nil {
+		t.Fatalf("不應回傳錯誤: %v", err)
+	}
+	if pc != nil {
+		t.Errorf("不應讀取 git 根目錄以外的設定: %s", pc.Path)
+	}
+}
+
+// TestLoadProjectConfigInvalid 測試格式錯誤的設定檔
+func TestLoadProjectConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rc   string
+		want string
+	}{
+		{"YAML 未知欄位", "modle: gpt-5\n", "modle"},
+		{"YAML 型別錯誤", "completion:\n  circuit_breaker_threshold: many\n", "many"},
+		{"未知欄位", `{"modle": "gpt-5"}`, "modle"},
+		{"空白錯誤字串", `{"non_retryable_errors": ["quota", " "]}`, "empty"},
+		{"重複錯誤字串", `{"retryable_errors": ["Timeout"], "non_retryable_errors": ["timeout"]}`, "both retryable and non-retryable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, sub := newProjectFixture(t, tt.rc)
+			_, err := LoadProjectConfig(sub)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("錯誤應包含 %q，但為 %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestProjectConfigRetryErrorsRoundTrip 測試重試錯誤清單寫入 .ralphrc 後可載入並套用
+func TestProjectConfigRetryErrorsRoundTrip(t *testing.T) {
+	original := &ProjectConfig{
+		RetryableErrors:    []string{"overloaded", "timeout"},
+		NonRetryableErrors: []string{"quota", "auth failed"},
+	}
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"non_retryable_errors":["quota","auth failed"]`) {
+		t.Errorf("JSON 欄位名稱錯誤: %s", data)
+	}
+
+	_, sub := newProjectFixture(t, string(data))
+	pc, err := LoadProjectConfig(sub)
+	if err != nil {
+		t.Fatalf("載入失敗: %v", err)
+	}
+	config := DefaultClientConfig()
+	pc.Apply(config)
+	if !reflect.DeepEqual(config.RetryableErrors, original.RetryableErrors) ||
+		!reflect.DeepEqual(config.NonRetryableErrors, original.NonRetryableErrors) {
+		t.Errorf("應套用重試錯誤清單: %v / %v", config.RetryableErrors, config.NonRetryableErrors)
+	}
+}
@@ -0,0 +1,40 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PromptFunc 在每個迴圈前產生 prompt；loopIndex 從 0 起算，prev 為前一個迴圈的結果（第一個迴圈為 nil）
+type PromptFunc func(loopIndex int, prev *LoopResult) string
+
+// ExecuteUntilCompletionFunc 與 ExecuteUntilCompletion 相同，但每個迴圈的 prompt 由 promptFn 產生
+//
+// 可將前一個迴圈的輸出帶入下一個 prompt，建立逐步改進的鏈式迴圈，
+// 熔斷器、完成判定、OnLoopComplete 等處理與 ExecuteUntilCompletion 相同。
+// 每個 prompt 都會經過 PromptSafetyRules 檢查，並附加狀態區塊等格式要求；
+// ContextWindowLoops 的先前迴圈摘要不會附加（由 promptFn 自行決定要帶入的內容），
+// 也不檢查 FailedPromptTTL。promptFn 回傳空字串時中止執行。
+func (c *RalphLoopClient) ExecuteUntilCompletionFunc(ctx context.Context, maxLoops int, promptFn PromptFunc) ([]*LoopResult, error) {
+	if promptFn == nil {
+		return nil, fmt.Errorf("prompt function is nil")
+	}
+	if err := c.lockExecution(); err != nil {
+		return nil, err
+	}
+	defer c.unlockExecution()
+	c.selectBreaker(ctx)
+
+	results, err := c.executeUntilCompletion(ctx, maxLoops, func(loopIndex int, prev *LoopResult) (string, error) {
+		prompt := promptFn(loopIndex, prev)
+		if strings.TrimSpace(prompt) == "" {
+			return "", fmt.Errorf("prompt function returned an empty prompt for loop %d", loopIndex+1)
+		}
+		prompt, hits, err := c.applyPromptSafety(prompt)
+		c.promptSafetyHits = hits
+		return prompt, err
+	})
+	c.finishProgress(results, maxLoops, err)
+	return results, err
+}
@@ -0,0 +1,71 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestExecuteUntilCompletionFunc 測試每個迴圈的 prompt 由函式產生並帶入前一個迴圈的輸出
+func TestExecuteUntilCompletionFunc(t *testing.T) {
+	client, prompts := newStepClient(DefaultClientConfig(), func(call int, prompt string) (string, error) {
+		if call < 3 {
+			return pendingOutput(fmt.Sprintf("草稿 v%d", call)), nil
+		}
+		return doneOutput, nil
+	})
+
+	var prevs []*LoopResult
+	results, err := client.ExecuteUntilCompletionFunc(context.Background(), 5, func(loopIndex int, prev *LoopResult) string {
+		prevs = append(prevs, prev)
+		if prev == nil {
+			return "撰寫草稿"
+		}
+		return fmt.Sprintf("改進第 %d 版: %s", loopIndex, strings.SplitN(prev.Output, "\n", 2)[0])
+	})
+	if err != nil || len(results) != 3 {
+		t.Fatalf("應執行 3 個迴圈後完成: %d, %v", len(results), err)
+	}
+	if prevs[0] != nil || prevs[1] != results[0] || prevs[2] != results[1] {
+		t.Error("prev 應為前一個迴圈的結果（第一個迴圈為 nil）")
+	}
+	if !strings.HasPrefix((*prompts)[0], "撰寫草稿") || !strings.HasPrefix((*prompts)[2], "改進第 2 版: 草稿 v2") {
+		t.Errorf("prompt 應由函式產生: %q / %q", (*prompts)[0], (*prompts)[2])
+	}
+	for i, p := range *prompts {
+		if !strings.Contains(p, "---RALPH_STATUS---") {
+			t.Errorf("迴圈 %d 的 prompt 應附加狀態區塊要求", i+1)
+		}
+	}
+}
+
+// TestExecuteUntilCompletionFuncStops 測試空 prompt 與被阻擋的 prompt 會中止執行
+func TestExecuteUntilCompletionFuncStops(t *testing.T) {
+	const stuck = "處理中\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---"
+
+	client := newScriptedClient(DefaultClientConfig(), stuck)
+	results, err := client.ExecuteUntilCompletionFunc(context.Background(), 5, func(loopIndex int, prev *LoopResult) string {
+		if loopIndex == 1 {
+			return ""
+		}
+		return "繼續"
+	})
+	if err == nil || len(results) != 1 {
+		t.Errorf("空 prompt 應在第 2 個迴圈前中止: %d, %v", len(results), err)
+	}
+
+	config := DefaultClientConfig()
+	config.PromptSafetyRules = []PromptSafetyRule{{Pattern: `(?i)delete everything`, Action: PromptSafetyBlock}}
+	client = newScriptedClient(config, stuck)
+	results, err = client.ExecuteUntilCompletionFunc(context.Background(), 5, func(loopIndex int, prev *LoopResult) string {
+		if prev != nil {
+			return "Delete everything and start over"
+		}
+		return "修正錯誤"
+	})
+	if !errors.Is(err, ErrPromptBlocked) || len(results) != 1 {
+		t.Errorf("被阻擋的 prompt 應中止執行: %d, %v", len(results), err)
+	}
+}
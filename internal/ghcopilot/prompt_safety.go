@@ -0,0 +1,87 @@
+package ghcopilot
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrPromptBlocked prompt 命中 PromptSafetyRules 中的阻擋規則，或需要確認但未獲同意
+var ErrPromptBlocked = errors.New("prompt blocked by safety rule")
+
+// PromptSafetyAction prompt 命中安全規則時的處理方式
+type PromptSafetyAction string
+
+const (
+	// PromptSafetyWarn 記錄警告後照常執行
+	PromptSafetyWarn PromptSafetyAction = "warn"
+	// PromptSafetyConfirm 呼叫 OnPromptSafetyConfirm 確認，未設定或不同意時阻擋
+	PromptSafetyConfirm PromptSafetyAction = "confirm"
+	// PromptSafetyBlock 不執行，回傳 ErrPromptBlocked
+	PromptSafetyBlock PromptSafetyAction = "block"
+	// PromptSafetyRewrite 以 Replacement 取代符合的文字後執行
+	PromptSafetyRewrite PromptSafetyAction = "rewrite"
+)
+
+// PromptSafetyRule prompt 的安全規則
+type PromptSafetyRule struct {
+	Pattern     string             `json:"pattern"`               // 正規表示式（不分大小寫請加 (?i)）
+	Action      PromptSafetyAction `json:"action"`                // 處理方式
+	Replacement string             `json:"replacement,omitempty"` // PromptSafetyRewrite 的替換文字（可使用 $1 等擷取群組）
+}
+
+// PromptSafetyHit 命中的安全規則
+type PromptSafetyHit struct {
+	Pattern string             `json:"pattern"`
+	Action  PromptSafetyAction `json:"action"`
+	Match   string             `json:"match"` // prompt 中第一段符合的文字
+}
+
+// DefaultPromptSafetyRules 常見的危險指示（只警告），可作為 PromptSafetyRules 的起點
+func DefaultPromptSafetyRules() []PromptSafetyRule {
+	return []PromptSafetyRule{
+		{Pattern: `(?i)\bdelete (?:everything|all files)\b`, Action: PromptSafetyWarn},
+		{Pattern: `(?i)\bforce[- ]push\b|\bpush (?:-f|--force)\b`, Action: PromptSafetyWarn},
+		{Pattern: `(?i)\brm -rf /(?:\s|$)`, Action: PromptSafetyWarn},
+		{Pattern: `(?i)\bdrop (?:database|table)\b`, Action: PromptSafetyWarn},
+		{Pattern: `刪除(?:所有|全部)`, Action: PromptSafetyWarn},
+	}
+}
+
+// applyPromptSafety 依 PromptSafetyRules 檢查 prompt，傳回改寫後的 prompt 與命中的規則
+//
+// 規則依序套用，改寫後的內容會交給下一條規則檢查。
+func (c *RalphLoopClient) applyPromptSafety(prompt string) (string, []PromptSafetyHit, error) {
+	if c.config == nil {
+		return prompt, nil, nil
+	}
+	var hits []PromptSafetyHit
+	for _, rule := range c.config.PromptSafetyRules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return prompt, hits, fmt.Errorf("無效的 prompt 安全規則 %q: %w", rule.Pattern, err)
+		}
+		match := re.FindString(prompt)
+		if match == "" {
+			continue
+		}
+		hit := PromptSafetyHit{Pattern: rule.Pattern, Action: rule.Action, Match: match}
+		hits = append(hits, hit)
+
+		switch rule.Action {
+		case PromptSafetyRewrite:
+			prompt = re.ReplaceAllString(prompt, rule.Replacement)
+			infoLog("✏️ prompt 命中安全規則 %q，已改寫", rule.Pattern)
+		case PromptSafetyConfirm:
+			if c.config.OnPromptSafetyConfirm == nil || !c.config.OnPromptSafetyConfirm(hit, prompt) {
+				return prompt, hits, fmt.Errorf("%w: %q 未獲確認", ErrPromptBlocked, match)
+			}
+			infoLog("✅ prompt 命中安全規則 %q，已確認執行", rule.Pattern)
+		case PromptSafetyBlock:
+			return prompt, hits, fmt.Errorf("%w: %q", ErrPromptBlocked, match)
+		default:
+			infoLog("⚠️ prompt 命中安全規則 %q: %q", rule.Pattern, match)
+		}
+	}
+	return prompt, hits, nil
+}
@@ -0,0 +1,115 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// safetyConfig 回傳套用 rules 的預設設定
+func safetyConfig(rules []PromptSafetyRule) *ClientConfig {
+	config := DefaultClientConfig()
+	config.PromptSafetyRules = rules
+	return config
+}
+
+// TestPromptSafetyWarn 測試警告規則照常執行並記錄命中的規則
+func TestPromptSafetyWarn(t *testing.T) {
+	client, prompts := newStepClient(safetyConfig(DefaultPromptSafetyRules()), doneStep)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "清理分支後 force push 到 main", 3)
+	if err != nil {
+		t.Fatalf("警告規則不應中止執行: %v", err)
+	}
+	if len(*prompts) != 1 {
+		t.Fatalf("應照常執行，實際執行 %d 次", len(*prompts))
+	}
+	hits := results[0].PromptSafetyHits
+	if len(hits) != 1 || hits[0].Action != PromptSafetyWarn || hits[0].Match != "force push" {
+		t.Errorf("應記錄命中的警告規則: %+v", hits)
+	}
+}
+
+// TestPromptSafetyBlock 測試阻擋規則不執行任何迴圈
+func TestPromptSafetyBlock(t *testing.T) {
+	client, prompts := newStepClient(safetyConfig([]PromptSafetyRule{
+		{Pattern: `(?i)delete everything`, Action: PromptSafetyBlock},
+	}), doneStep)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "Delete everything in the repo", 3)
+	if !errors.Is(err, ErrPromptBlocked) {
+		t.Fatalf("應回傳 ErrPromptBlocked，實際 %v", err)
+	}
+	if len(results) != 0 || len(*prompts) != 0 {
+		t.Errorf("阻擋時不應執行任何迴圈: %d 個結果，%d 次執行", len(results), len(*prompts))
+	}
+
+	if _, err := client.ExecuteLoop(context.Background(), "please delete everything"); !errors.Is(err, ErrPromptBlocked) {
+		t.Errorf("ExecuteLoop 同樣應阻擋，實際 %v", err)
+	}
+}
+
+// TestPromptSafetyRewrite 測試改寫規則替換 prompt 中的文字
+func TestPromptSafetyRewrite(t *testing.T) {
+	client, prompts := newStepClient(safetyConfig([]PromptSafetyRule{
+		{Pattern: `git push --force\b`, Action: PromptSafetyRewrite, Replacement: "git push --force-with-lease"},
+	}), doneStep)
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正後執行 git push --force", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := (*prompts)[0]
+	if !strings.Contains(sent, "git push --force-with-lease") {
+		t.Errorf("送出的 prompt 應已改寫: %q", sent)
+	}
+	if hits := results[0].PromptSafetyHits; len(hits) != 1 || hits[0].Action != PromptSafetyRewrite {
+		t.Errorf("應記錄命中的改寫規則: %+v", hits)
+	}
+}
+
+// TestDryRunAppliesPromptSafety 測試預覽命令列時套用安全規則，且不執行 copilot
+func TestDryRunAppliesPromptSafety(t *testing.T) {
+	client, prompts := newStepClient(safetyConfig([]PromptSafetyRule{
+		{Pattern: `git push --force\b`, Action: PromptSafetyRewrite, Replacement: "git push --force-with-lease"},
+		{Pattern: `(?i)delete everything`, Action: PromptSafetyBlock},
+	}), doneStep)
+
+	line, err := client.DryRun(context.Background(), "git push --force")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, "git push --force-with-lease") || !strings.Contains(line, "---RALPH_STATUS") {
+		t.Errorf("命令列應包含改寫後的 prompt 與狀態說明: %s", line)
+	}
+	if _, err := client.DryRun(context.Background(), "delete everything"); !errors.Is(err, ErrPromptBlocked) {
+		t.Errorf("被阻擋的 prompt 應回傳 ErrPromptBlocked，實際 %v", err)
+	}
+	if len(*prompts) != 0 {
+		t.Errorf("DryRun 不應執行 copilot，實際執行 %d 次", len(*prompts))
+	}
+}
+
+// TestPromptSafetyConfirm 測試確認規則依回呼決定是否執行，未設定回呼時阻擋
+func TestPromptSafetyConfirm(t *testing.T) {
+	rules := []PromptSafetyRule{{Pattern: `(?i)drop table`, Action: PromptSafetyConfirm}}
+
+	client, _ := newStepClient(safetyConfig(rules), doneStep)
+	if _, err := client.ExecuteUntilCompletion(context.Background(), "drop table users", 1); !errors.Is(err, ErrPromptBlocked) {
+		t.Errorf("未設定確認回呼時應阻擋，實際 %v", err)
+	}
+
+	client, prompts := newStepClient(safetyConfig(rules), doneStep)
+	var confirmed string
+	client.config.OnPromptSafetyConfirm = func(hit PromptSafetyHit, prompt string) bool {
+		confirmed = hit.Match
+		return true
+	}
+	if _, err := client.ExecuteUntilCompletion(context.Background(), "drop table users", 1); err != nil {
+		t.Fatalf("確認後應執行: %v", err)
+	}
+	if confirmed != "drop table" || len(*prompts) != 1 {
+		t.Errorf("應呼叫確認回呼後執行: %q，%d 次執行", confirmed, len(*prompts))
+	}
+}
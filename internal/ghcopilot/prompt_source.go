@@ -0,0 +1,50 @@
+package ghcopilot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// StdinPrompt 以此值作為 prompt 時改從標準輸入讀取
+const StdinPrompt = "-"
+
+// ReadPrompt 依命令列參數取得初始 prompt
+//
+// prompt 與 promptFile 必須恰好提供一個：promptFile 非空時讀取該檔案，
+// prompt 為 "-" 時讀取 stdin 的全部內容，否則直接使用 prompt。前後的空白會被去除，結果為空時回傳錯誤。
+func ReadPrompt(prompt, promptFile string, stdin io.Reader) (string, error) {
+	switch {
+	case prompt != "" && promptFile != "":
+		return "", errors.New("-prompt 與 -prompt-file 只能擇一")
+	case prompt == "" && promptFile == "":
+		return "", errors.New("必須提供 -prompt 或 -prompt-file")
+	}
+
+	source := "-prompt"
+	switch {
+	case promptFile != "":
+		data, err := os.ReadFile(promptFile) // #nosec G304 -- 路徑由使用者在命令列指定
+		if err != nil {
+			return "", fmt.Errorf("無法讀取 prompt 檔案: %w", err)
+		}
+		prompt, source = string(data), promptFile
+	case prompt == StdinPrompt:
+		if stdin == nil {
+			return "", errors.New("沒有可讀取的標準輸入")
+		}
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("無法從標準輸入讀取 prompt: %w", err)
+		}
+		prompt, source = string(data), "標準輸入"
+	}
+
+	prompt = strings.TrimSpace(strings.ReplaceAll(prompt, "\r\n", "\n"))
+	if prompt == "" {
+		return "", fmt.Errorf("prompt 內容為空 (%s)", source)
+	}
+	return prompt, nil
+}
@@ -0,0 +1,56 @@
+package ghcopilot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReadPrompt 測試從參數、檔案與 stdin 取得 prompt
+func TestReadPrompt(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "prompt.md")
+	if err := os.WriteFile(file, []byte("# 任務\r\n修正 \"parser\" 的錯誤\r\n\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, prompt, file, stdin string
+		want                      string
+	}{
+		{"參數", "修正編譯錯誤", "", "", "修正編譯錯誤"},
+		{"檔案", "", file, "", "# 任務\n修正 \"parser\" 的錯誤"},
+		{"stdin", "-", "", "  第一行\n第二行\n", "第一行\n第二行"},
+	}
+	for _, tt := range tests {
+		got, err := ReadPrompt(tt.prompt, tt.file, strings.NewReader(tt.stdin))
+		if err != nil || got != tt.want {
+			t.Errorf("%s: 應為 %q，實際 %q (%v)", tt.name, tt.want, got, err)
+		}
+	}
+}
+
+// TestReadPromptErrors 測試來源衝突、缺少來源、檔案不存在與內容為空
+func TestReadPromptErrors(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(empty, []byte(" \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, prompt, file, stdin string
+		wantErr                   string
+	}{
+		{"同時提供", "修正", empty, "", "只能擇一"},
+		{"都未提供", "", "", "", "必須提供"},
+		{"檔案不存在", "", filepath.Join(t.TempDir(), "missing.txt"), "", "無法讀取"},
+		{"檔案為空", "", empty, "", "內容為空"},
+		{"stdin 為空", "-", "", "\n\n", "內容為空"},
+	}
+	for _, tt := range tests {
+		_, err := ReadPrompt(tt.prompt, tt.file, strings.NewReader(tt.stdin))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: 應回傳包含 %q 的錯誤，實際 %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
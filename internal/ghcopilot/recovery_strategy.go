@@ -2,6 +2,7 @@ package ghcopilot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -33,6 +34,19 @@ func (r RecoveryStrategyType) String() string {
 	}
 }
 
+// RecoveryAttempt 記錄一次恢復策略的嘗試與結果
+type RecoveryAttempt struct {
+	Strategy  string        `json:"strategy"`        // 策略類型 (auto_reconnect/session_restore/fallback)
+	Trigger   string        `json:"trigger"`         // 觸發恢復的錯誤
+	Success   bool          `json:"success"`         // 是否恢復成功
+	Error     string        `json:"error,omitempty"` // 恢復失敗的原因
+	Duration  time.Duration `json:"duration"`        // 恢復耗時
+	Timestamp time.Time     `json:"timestamp"`       // 恢復時間
+}
+
+// maxRecoveryHistory 協調器保留的恢復記錄上限
+const maxRecoveryHistory = 100
+
 // RecoveryStrategy 恢復策略介面
 type RecoveryStrategy interface {
 	// Recover 嘗試恢復
@@ -82,38 +96,38 @@ func (r *AutoReconnectRecovery) Recover(ctx context.Context, err error) error {
 	retryDelay := r.retryDelay
 	r.mu.Unlock()
 
-	fmt.Printf("🔄 開始恢復策略（最多重試 %d 次）: %v\n", maxRetries, err)
+	fmt.Fprintf(logWriter(), "🔄 開始恢復策略（最多重試 %d 次）: %v\n", maxRetries, err)
 
 	var lastErr error
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		select {
 		case <-ctx.Done():
-			fmt.Printf("⚠️ 恢復策略被取消: %v\n", ctx.Err())
+			fmt.Fprintf(logWriter(), "⚠️ 恢復策略被取消: %v\n", ctx.Err())
 			return ctx.Err()
 		default:
 		}
 
-		fmt.Printf("🔄 恢復嘗試 %d/%d...\n", attempt, maxRetries)
+		fmt.Fprintf(logWriter(), "🔄 恢復嘗試 %d/%d...\n", attempt, maxRetries)
 		lastErr = connectFunc(ctx)
 		if lastErr == nil {
-			fmt.Printf("✅ 恢復成功（嘗試 %d 次）\n", attempt)
+			fmt.Fprintf(logWriter(), "✅ 恢復成功（嘗試 %d 次）\n", attempt)
 			return nil
 		}
-		fmt.Printf("⚠️ 恢復嘗試 %d 失敗: %v\n", attempt, lastErr)
+		fmt.Fprintf(logWriter(), "⚠️ 恢復嘗試 %d 失敗: %v\n", attempt, lastErr)
 
 		// 指數退避
 		delay := retryDelay * time.Duration(attempt)
-		fmt.Printf("⏳ 等待 %v 後重試...\n", delay)
+		fmt.Fprintf(logWriter(), "⏳ 等待 %v 後重試...\n", delay)
 		select {
 		case <-time.After(delay):
 		case <-ctx.Done():
-			fmt.Printf("⚠️ 恢復策略被取消: %v\n", ctx.Err())
+			fmt.Fprintf(logWriter(), "⚠️ 恢復策略被取消: %v\n", ctx.Err())
 			return ctx.Err()
 		}
 	}
 
 	finalErr := fmt.Errorf("自動重連失敗（已嘗試 %d 次）: %w", maxRetries, lastErr)
-	fmt.Printf("❌ %v\n", finalErr)
+	fmt.Fprintf(logWriter(), "❌ %v\n", finalErr)
 	return finalErr
 }
 
@@ -168,19 +182,19 @@ func (r *SessionRestoreRecovery) Recover(ctx context.Context, err error) error {
 
 	select {
 	case <-ctx.Done():
-		fmt.Printf("⚠️ 會話恢復被取消: %v\n", ctx.Err())
+		fmt.Fprintf(logWriter(), "⚠️ 會話恢復被取消: %v\n", ctx.Err())
 		return ctx.Err()
 	default:
 	}
 
-	fmt.Printf("🔄 嘗試恢復會話: %s\n", sessionID)
+	fmt.Fprintf(logWriter(), "🔄 嘗試恢復會話: %s\n", sessionID)
 	if restoreErr := restoreFunc(ctx, sessionID); restoreErr != nil {
 		finalErr := fmt.Errorf("會話恢復失敗: %w", restoreErr)
-		fmt.Printf("❌ %v\n", finalErr)
+		fmt.Fprintf(logWriter(), "❌ %v\n", finalErr)
 		return finalErr
 	}
 
-	fmt.Printf("✅ 會話恢復成功: %s\n", sessionID)
+	fmt.Fprintf(logWriter(), "✅ 會話恢復成功: %s\n", sessionID)
 	return nil
 }
 
@@ -225,16 +239,16 @@ func (r *FallbackRecovery) Recover(ctx context.Context, err error) error {
 
 	select {
 	case <-ctx.Done():
-		fmt.Printf("⚠️ 故障轉移被取消: %v\n", ctx.Err())
+		fmt.Fprintf(logWriter(), "⚠️ 故障轉移被取消: %v\n", ctx.Err())
 		return ctx.Err()
 	default:
 	}
 
-	fmt.Printf("🔄 執行故障轉移策略: %v\n", err)
+	fmt.Fprintf(logWriter(), "🔄 執行故障轉移策略: %v\n", err)
 	result, fallbackErr := fallbackFunc(ctx)
 	if fallbackErr != nil {
 		finalErr := fmt.Errorf("故障轉移失敗: %w", fallbackErr)
-		fmt.Printf("❌ %v\n", finalErr)
+		fmt.Fprintf(logWriter(), "❌ %v\n", finalErr)
 		return finalErr
 	}
 
@@ -242,7 +256,7 @@ func (r *FallbackRecovery) Recover(ctx context.Context, err error) error {
 	r.lastResult = result
 	r.mu.Unlock()
 
-	fmt.Printf("✅ 故障轉移成功\n")
+	fmt.Fprintf(logWriter(), "✅ 故障轉移成功\n")
 	return nil
 }
 
@@ -263,11 +277,16 @@ func (r *FallbackRecovery) GetPriority() int {
 	return 3 // 低優先級
 }
 
+// ErrRecoveryCapReached 恢復次數已達上限，呼叫端應直接視為失敗
+var ErrRecoveryCapReached = errors.New("recovery attempt cap reached")
+
 // RecoveryCoordinator 恢復協調器
 type RecoveryCoordinator struct {
-	strategies []RecoveryStrategy
-	metrics    *RecoveryMetrics
-	mu         sync.RWMutex
+	strategies  []RecoveryStrategy
+	metrics     *RecoveryMetrics
+	history     []RecoveryAttempt
+	maxAttempts int // 恢復次數上限，0 表示不限制
+	mu          sync.RWMutex
 }
 
 // RecoveryMetrics 恢復指標統計
@@ -304,11 +323,22 @@ func (c *RecoveryCoordinator) AddStrategy(strategy RecoveryStrategy) {
 	}
 }
 
+// SetMaxAttempts 設定恢復次數上限（ResetMetrics 會重新計算），0 表示不限制
+func (c *RecoveryCoordinator) SetMaxAttempts(max int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxAttempts = max
+}
+
 // Recover 嘗試恢復，按優先級依次嘗試各策略
+//
+// 已達 SetMaxAttempts 設定的上限時不再嘗試，直接回傳 ErrRecoveryCapReached，
+// 避免恢復不斷「成功」卻掩蓋了根本沒有進展的問題。
 func (c *RecoveryCoordinator) Recover(ctx context.Context, originalErr error) error {
 	c.mu.RLock()
 	strategies := make([]RecoveryStrategy, len(c.strategies))
 	copy(strategies, c.strategies)
+	maxAttempts := c.maxAttempts
 	c.mu.RUnlock()
 
 	if len(strategies) == 0 {
@@ -316,6 +346,10 @@ func (c *RecoveryCoordinator) Recover(ctx context.Context, originalErr error) er
 	}
 
 	c.metrics.mu.Lock()
+	if maxAttempts > 0 && c.metrics.TotalAttempts >= int64(maxAttempts) {
+		c.metrics.mu.Unlock()
+		return fmt.Errorf("%w (%d): %v", ErrRecoveryCapReached, maxAttempts, originalErr)
+	}
 	c.metrics.TotalAttempts++
 	c.metrics.mu.Unlock()
 
@@ -328,7 +362,9 @@ func (c *RecoveryCoordinator) Recover(ctx context.Context, originalErr error) er
 		default:
 		}
 
+		start := time.Now()
 		err := strategy.Recover(ctx, originalErr)
+		c.recordAttempt(strategy.GetType(), originalErr, err, time.Since(start))
 		if err == nil {
 			c.recordSuccess(strategy.GetType())
 			return nil
@@ -340,6 +376,36 @@ func (c *RecoveryCoordinator) Recover(ctx context.Context, originalErr error) er
 	return fmt.Errorf("all recovery strategies failed: %w", lastErr)
 }
 
+// recordAttempt 記錄單一策略的嘗試結果
+func (c *RecoveryCoordinator) recordAttempt(recoveryType RecoveryStrategyType, trigger, err error, duration time.Duration) {
+	attempt := RecoveryAttempt{
+		Strategy:  recoveryType.String(),
+		Success:   err == nil,
+		Duration:  duration,
+		Timestamp: time.Now(),
+	}
+	if trigger != nil {
+		attempt.Trigger = trigger.Error()
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.history = append(c.history, attempt)
+	if len(c.history) > maxRecoveryHistory {
+		c.history = c.history[len(c.history)-maxRecoveryHistory:]
+	}
+}
+
+// GetRecoveryHistory 取得最近的恢復嘗試記錄（最多 100 筆）
+func (c *RecoveryCoordinator) GetRecoveryHistory() []RecoveryAttempt {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]RecoveryAttempt(nil), c.history...)
+}
+
 // recordSuccess 記錄成功恢復
 func (c *RecoveryCoordinator) recordSuccess(recoveryType RecoveryStrategyType) {
 	c.metrics.mu.Lock()
@@ -373,6 +439,17 @@ func (c *RecoveryCoordinator) GetMetrics() *RecoveryMetrics {
 	}
 }
 
+// CapReached 傳回恢復次數是否已達上限
+func (c *RecoveryCoordinator) CapReached() bool {
+	c.mu.RLock()
+	maxAttempts := c.maxAttempts
+	c.mu.RUnlock()
+
+	c.metrics.mu.RLock()
+	defer c.metrics.mu.RUnlock()
+	return maxAttempts > 0 && c.metrics.TotalAttempts >= int64(maxAttempts)
+}
+
 // GetStrategyCount 取得策略數量
 func (c *RecoveryCoordinator) GetStrategyCount() int {
 	c.mu.RLock()
@@ -462,6 +539,12 @@ func (e *FaultTolerantExecutor) Execute(ctx context.Context, fn func() error) er
 		return result.Error
 	}
 
+	// 恢復次數已達上限：不再嘗試恢復，直接回傳原始錯誤
+	if e.coordinator.CapReached() {
+		e.recordFailure()
+		return result.Error
+	}
+
 	// 嘗試恢復
 	e.metrics.mu.Lock()
 	e.metrics.TotalRecoveryAttempts++
@@ -551,6 +634,16 @@ func (e *FaultTolerantExecutor) GetRecoveryMetrics() *RecoveryMetrics {
 	return e.coordinator.GetMetrics()
 }
 
+// SetMaxRecoveryAttempts 設定恢復次數上限，超過後失敗不再嘗試恢復，0 表示不限制
+func (e *FaultTolerantExecutor) SetMaxRecoveryAttempts(max int) {
+	e.coordinator.SetMaxAttempts(max)
+}
+
+// GetRecoveryHistory 取得恢復嘗試記錄
+func (e *FaultTolerantExecutor) GetRecoveryHistory() []RecoveryAttempt {
+	return e.coordinator.GetRecoveryHistory()
+}
+
 // SetRetryPolicy 設定重試策略
 func (e *FaultTolerantExecutor) SetRetryPolicy(policy *RetryPolicy) error {
 	return e.retryExecutor.SetPolicy(policy)
@@ -691,3 +691,77 @@ func TestFaultTolerance_Concurrent(t *testing.T) {
 		t.Errorf("expected 10 executions, got %d", metrics.TotalExecutions)
 	}
 }
+
+func TestRecoveryCoordinator_GetRecoveryHistory(t *testing.T) {
+	coordinator := NewRecoveryCoordinator()
+
+	reconnect := NewAutoReconnectRecovery(1)
+	reconnect.SetRetryDelay(time.Millisecond)
+	reconnect.SetConnectFunc(func(ctx context.Context) error {
+		return errors.New("still down")
+	})
+	coordinator.AddStrategy(reconnect)
+
+	fallback := NewFallbackRecovery()
+	fallback.SetFallbackFunc(func(ctx context.Context) (interface{}, error) {
+		return "cli", nil
+	})
+	coordinator.AddStrategy(fallback)
+
+	if err := coordinator.Recover(context.Background(), errors.New("sdk failure")); err != nil {
+		t.Fatalf("expected recovery via fallback, got %v", err)
+	}
+
+	history := coordinator.GetRecoveryHistory()
+	if len(history) != 2 {
+		t.Fatalf("expected 2 recovery attempts, got %d", len(history))
+	}
+	if history[0].Strategy != "auto_reconnect" || history[0].Success || history[0].Error == "" {
+		t.Errorf("expected failed auto_reconnect attempt, got %+v", history[0])
+	}
+	if history[1].Strategy != "fallback" || !history[1].Success {
+		t.Errorf("expected successful fallback attempt, got %+v", history[1])
+	}
+	if history[1].Trigger != "sdk failure" {
+		t.Errorf("expected trigger to be recorded, got %q", history[1].Trigger)
+	}
+}
+
+func TestRecoveryCoordinator_MaxAttempts(t *testing.T) {
+	coordinator := NewRecoveryCoordinator()
+	coordinator.SetMaxAttempts(2)
+
+	calls := 0
+	fallback := NewFallbackRecovery()
+	fallback.SetFallbackFunc(func(ctx context.Context) (interface{}, error) {
+		calls++
+		return "cli", nil
+	})
+	coordinator.AddStrategy(fallback)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := coordinator.Recover(ctx, errors.New("sdk failure")); err != nil {
+			t.Fatalf("attempt %d: expected recovery, got %v", i+1, err)
+		}
+	}
+	if !coordinator.CapReached() {
+		t.Error("expected cap to be reached after 2 attempts")
+	}
+
+	err := coordinator.Recover(ctx, errors.New("sdk failure"))
+	if !errors.Is(err, ErrRecoveryCapReached) {
+		t.Fatalf("expected ErrRecoveryCapReached, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected strategy to run 2 times, got %d", calls)
+	}
+	if got := coordinator.GetMetrics().TotalAttempts; got != 2 {
+		t.Errorf("expected capped attempts not to be counted, got %d", got)
+	}
+
+	coordinator.ResetMetrics()
+	if err := coordinator.Recover(ctx, errors.New("sdk failure")); err != nil {
+		t.Errorf("expected recovery after reset, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package ghcopilot
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maskedValue 取代敏感資料的字串
+const maskedValue = "****"
+
+// secretAssignment 比對 key=value / key: value 形式的密碼、token 設定（只取代值）
+var secretAssignment = regexp.MustCompile(`(?i)\b((?:password|passwd|secret|token|api[_-]?key|access[_-]?key)\s*[:=]\s*)("[^"]*"|'[^']*'|\S+)`)
+
+// MaskSecrets 將文字中看起來像密碼或 token 的部分以 **** 取代（使用 secretDetectors 的所有偵測器）
+func MaskSecrets(s string) string {
+	for _, d := range secretDetectors {
+		s = d.pattern.ReplaceAllStringFunc(s, func(m string) string {
+			if d.check != nil && !d.check(m) {
+				return m
+			}
+			return maskedValue
+		})
+	}
+	return secretAssignment.ReplaceAllString(s, "${1}"+maskedValue)
+}
+
+// RenderCommand 產生實際執行的完整命令字串（含 buildArgs 的所有參數）
+//
+// mask 為 true 時 prompt（-p 的值）中的密碼與 token 會被遮蔽；
+// 含空白或特殊字元的參數會加上引號，方便複製到終端重現。
+func (ce *CLIExecutor) RenderCommand(prompt string, mask bool) string {
+	args := ce.buildArgs(prompt)
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, "copilot")
+	for i, arg := range args {
+		if mask && i > 0 && args[i-1] == "-p" {
+			arg = MaskSecrets(arg)
+		}
+		parts = append(parts, quoteArg(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteArg 需要時為參數加上引號
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\r\"'`$\\|&;<>()*?!#~") {
+		return arg
+	}
+	return strconv.Quote(arg)
+}
@@ -1,17 +1,26 @@
 package ghcopilot
 
 import (
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
-// CopilotStatus 代表 Copilot 的狀態輸出
+// CopilotStatus 代表 Copilot 的狀態輸出（JSON 格式見 StatusBlockSchema）
 type CopilotStatus struct {
-	Status     string
-	ExitSignal bool
-	TasksDone  string
-	Reason     string // REASON 欄位
-	RawBlock   string
+	Status     string `json:"status"`
+	ExitSignal bool   `json:"exit_signal"`
+	TasksDone  string `json:"tasks_done"`
+	Reason     string `json:"reason"` // REASON 欄位
+	RawBlock   string `json:"raw_block"`
+
+	// CONFIDENCE 欄位（模型自評的信心，0-100），HasConfidence 為 false 表示未提供
+	Confidence    int  `json:"confidence"`
+	HasConfidence bool `json:"has_confidence"`
+
+	// Extra 區塊中其他 KEY: value 欄位（例如 PROGRESS: 40%），沒有時為 nil
+	Extra map[string]string `json:"extra,omitempty"`
 }
 
 // ResponseAnalyzer 用於分析 Copilot 回應
@@ -20,8 +29,10 @@ type ResponseAnalyzer struct {
 	completionScore      int
 	isTestOnlyLoop       bool
 	completionIndicators []string
+	contributions        []ScoreContribution
 	previousErrors       []string
 	consecutiveErrors    int
+	profile              ParserProfile // 依產生輸出的執行後端選擇的解析設定
 }
 
 // NewResponseAnalyzer 建立新的回應分析器
@@ -33,22 +44,51 @@ func NewResponseAnalyzer(response string) *ResponseAnalyzer {
 		completionIndicators: []string{},
 		previousErrors:       []string{},
 		consecutiveErrors:    0,
+		profile:              CLIParserProfile(),
 	}
 }
 
+// NewResponseAnalyzerWithProfile 建立使用指定解析設定的回應分析器
+func NewResponseAnalyzerWithProfile(response string, profile ParserProfile) *ResponseAnalyzer {
+	ra := NewResponseAnalyzer(response)
+	ra.profile = profile
+	return ra
+}
+
+// Profile 傳回分析器使用的解析設定
+func (ra *ResponseAnalyzer) Profile() ParserProfile {
+	return ra.profile
+}
+
 // ParseStructuredOutput 解析結構化輸出區塊
 func (ra *ResponseAnalyzer) ParseStructuredOutput() *CopilotStatus {
 	// 查找 ---COPILOT_STATUS--- 或 ---RALPH_STATUS--- 區塊，支援 CRLF
 	pattern := `(?s)---(?:COPILOT_STATUS|RALPH_STATUS)---\r?\n(.*?)\r?\n---END(?:_STATUS|_RALPH_STATUS)---`
 	re := regexp.MustCompile(pattern)
-	matches := re.FindStringSubmatch(ra.response)
+	response := ra.response
+	if ra.profile.StripMarkdown {
+		response = stripStatusMarkdown(response)
+	}
+	matches := re.FindStringSubmatch(response)
 
 	if len(matches) < 2 {
+		if ra.profile.LooseStatus {
+			return parseLooseStatus(response)
+		}
 		return nil
 	}
 
 	// 正規化 CRLF
-	block := strings.ReplaceAll(matches[1], "\r\n", "\n")
+	return parseStatusFields(strings.ReplaceAll(matches[1], "\r\n", "\n"))
+}
+
+// statusExtraFieldPattern 狀態區塊中的其他欄位（大寫鍵名）
+var statusExtraFieldPattern = regexp.MustCompile(`^([A-Z][A-Z0-9_]*):\s*(.*)$`)
+
+// parseStatusFields 解析狀態區塊內的各個欄位
+//
+// 重複的欄位以最後一次出現的值為準；無法辨識的 KEY: value 行收集到 Extra。
+func parseStatusFields(block string) *CopilotStatus {
 	status := &CopilotStatus{
 		RawBlock: block,
 	}
@@ -66,21 +106,46 @@ func (ra *ResponseAnalyzer) ParseStructuredOutput() *CopilotStatus {
 			status.TasksDone = strings.TrimSpace(strings.TrimPrefix(line, "TASKS_DONE:"))
 		} else if strings.HasPrefix(line, "REASON:") {
 			status.Reason = strings.TrimSpace(strings.TrimPrefix(line, "REASON:"))
+		} else if strings.HasPrefix(line, "CONFIDENCE:") {
+			status.Confidence, status.HasConfidence = parseConfidence(strings.TrimPrefix(line, "CONFIDENCE:"))
+		} else if m := statusExtraFieldPattern.FindStringSubmatch(line); m != nil {
+			if status.Extra == nil {
+				status.Extra = make(map[string]string)
+			}
+			status.Extra[m[1]] = strings.TrimSpace(m[2])
 		}
 	}
 
 	return status
 }
 
+// parseConfidence 解析 CONFIDENCE 欄位，支援 "85%"、"85" 與 "0.85"
+func parseConfidence(value string) (int, bool) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0, false
+	}
+	if f <= 1 && strings.Contains(value, ".") {
+		f *= 100
+	}
+	if f > 100 {
+		return 0, false
+	}
+	return int(f + 0.5), true
+}
+
 // CalculateCompletionScore 計算完成分數
 func (ra *ResponseAnalyzer) CalculateCompletionScore() int {
 	score := 0
+	ra.contributions = nil
 
 	// 檢查結構化輸出
 	status := ra.ParseStructuredOutput()
 	if status != nil && status.ExitSignal {
 		score += 100
 		ra.completionIndicators = append(ra.completionIndicators, "explicit_exit_signal")
+		ra.addContribution("explicit_exit_signal", 100, "RALPH_STATUS 中 EXIT_SIGNAL: true")
 	}
 
 	// 檢查完成關鍵字
@@ -93,6 +158,7 @@ func (ra *ResponseAnalyzer) CalculateCompletionScore() int {
 		if strings.Contains(strings.ToLower(ra.response), strings.ToLower(keyword)) {
 			score += 10
 			ra.completionIndicators = append(ra.completionIndicators, keyword)
+			ra.addContribution("completion_keyword", 10, "包含完成關鍵字 \""+keyword+"\"")
 			break
 		}
 	}
@@ -113,14 +179,16 @@ func (ra *ResponseAnalyzer) CalculateCompletionScore() int {
 		if strings.Contains(strings.ToLower(ra.response), strings.ToLower(pattern)) {
 			score += 15
 			ra.completionIndicators = append(ra.completionIndicators, "no_work_mode")
+			ra.addContribution("no_work_mode", 15, "包含無工作描述 \""+pattern+"\"")
 			break
 		}
 	}
 
 	// 檢查輸出長度下降（表示逐漸接近完成）
-	if len(ra.response) < 500 {
+	if limit := ra.profile.ShortOutputChars; limit > 0 && len(ra.response) < limit {
 		score += 10
 		ra.completionIndicators = append(ra.completionIndicators, "short_output")
+		ra.addContribution("short_output", 10, fmt.Sprintf("輸出少於 %d 字元", limit))
 	}
 
 	ra.completionScore = score
@@ -238,7 +306,7 @@ func (ra *ResponseAnalyzer) IsCompleted() bool {
 	}
 
 	// 備用：無結構化輸出，但自然語言分數夠高（≥ 30）且有 2 個指標
-	if ra.completionScore >= 30 && len(ra.completionIndicators) >= 2 {
+	if ra.completionScore >= fallbackScoreThreshold && len(ra.completionIndicators) >= fallbackMinIndicators {
 		return true
 	}
 
@@ -218,3 +218,34 @@ EXIT_SIGNAL: true
 		t.Errorf("應有至少 2 個指標，但只有 %d 個", len(ra.completionIndicators))
 	}
 }
+
+// TestParseConfidence 測試狀態區塊中的 CONFIDENCE 欄位
+func TestParseConfidence(t *testing.T) {
+	tests := []struct {
+		line     string
+		want     int
+		wantHave bool
+	}{
+		{"CONFIDENCE: 85%", 85, true},
+		{"CONFIDENCE: 60", 60, true},
+		{"CONFIDENCE: 0.9", 90, true},
+		{"CONFIDENCE: 150%", 0, false},
+		{"CONFIDENCE: high", 0, false},
+	}
+
+	for _, tt := range tests {
+		ra := NewResponseAnalyzer("---RALPH_STATUS---\nEXIT_SIGNAL: true\n" + tt.line + "\n---END_RALPH_STATUS---")
+		status := ra.ParseStructuredOutput()
+		if status == nil {
+			t.Fatalf("%q: 應解析到狀態區塊", tt.line)
+		}
+		if status.Confidence != tt.want || status.HasConfidence != tt.wantHave {
+			t.Errorf("%q: 信心應為 %d (%v)，但為 %d (%v)", tt.line, tt.want, tt.wantHave, status.Confidence, status.HasConfidence)
+		}
+	}
+
+	status := NewResponseAnalyzer("---RALPH_STATUS---\nEXIT_SIGNAL: true\n---END_RALPH_STATUS---").ParseStructuredOutput()
+	if status.HasConfidence {
+		t.Error("沒有 CONFIDENCE 欄位時不應有信心值")
+	}
+}
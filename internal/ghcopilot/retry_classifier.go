@@ -0,0 +1,240 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryStatsFile 重試統計在 SaveDir 中的檔名（跨執行共用，不放在每次執行的子目錄）
+const RetryStatsFile = "retry_stats.json"
+
+// defaultFutileThreshold 特徵連續幾次重試都未恢復後降級為不重試
+const defaultFutileThreshold = 2
+
+// maxSignatureLength 錯誤特徵的最大長度
+const maxSignatureLength = 120
+
+var (
+	// signatureHex 比對十六進位 ID（UUID、request ID 等）
+	signatureHex = regexp.MustCompile(`\b[0-9a-fA-F][0-9a-fA-F-]{7,}\b`)
+	// signatureDigits 比對數字（行號、毫秒、port）
+	signatureDigits = regexp.MustCompile(`\d+`)
+	// signatureSpaces 比對連續空白
+	signatureSpaces = regexp.MustCompile(`\s+`)
+)
+
+// SignatureStats 單一錯誤特徵的重試統計
+type SignatureStats struct {
+	Signature string    `json:"signature"`
+	Example   string    `json:"example"`   // 最近一次的原始錯誤訊息
+	Recovered int       `json:"recovered"` // 重試後成功的次數
+	Futile    int       `json:"futile"`    // 重試耗盡仍失敗的次數
+	Skipped   int       `json:"skipped"`   // 因降級而未重試的次數
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Demoted 傳回此特徵是否已被降級為不重試（從未恢復且失敗次數達門檻）
+func (s *SignatureStats) Demoted(threshold int) bool {
+	return s.Recovered == 0 && s.Futile >= threshold
+}
+
+// RetryClassifier 依錯誤特徵的歷史結果決定是否值得重試
+//
+// 每次重試結束時記錄途中遇到的錯誤特徵最後是否恢復；從未恢復且失敗次數
+// 達到門檻的特徵會被降級，之後遇到時直接放棄而不浪費重試時間。
+// 統計可儲存到檔案，下一次執行時載入，讓工具跨執行「學習」哪些錯誤重試無效。
+type RetryClassifier struct {
+	mu        sync.Mutex
+	stats     map[string]*SignatureStats
+	threshold int
+}
+
+// NewRetryClassifier 建立新的重試分類器
+func NewRetryClassifier() *RetryClassifier {
+	return &RetryClassifier{
+		stats:     make(map[string]*SignatureStats),
+		threshold: defaultFutileThreshold,
+	}
+}
+
+// SetFutileThreshold 設定降級所需的未恢復次數（小於 1 時使用預設值）
+func (rc *RetryClassifier) SetFutileThreshold(n int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if n < 1 {
+		n = defaultFutileThreshold
+	}
+	rc.threshold = n
+}
+
+// ErrorSignature 將錯誤訊息正規化為特徵：取第一個非空行、去除數字與 ID、轉小寫
+func ErrorSignature(msg string) string {
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		line = signatureHex.ReplaceAllString(line, "<id>")
+		line = signatureDigits.ReplaceAllString(line, "<n>")
+		line = signatureSpaces.ReplaceAllString(line, " ")
+		line = strings.ToLower(line)
+		if runes := []rune(line); len(runes) > maxSignatureLength {
+			line = string(runes[:maxSignatureLength])
+		}
+		return line
+	}
+	return ""
+}
+
+// ShouldRetry 傳回此特徵是否仍值得重試；已降級時記錄一次略過
+func (rc *RetryClassifier) ShouldRetry(signature string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	s, ok := rc.stats[signature]
+	if !ok || !s.Demoted(rc.threshold) {
+		return true
+	}
+	s.Skipped++
+	s.LastSeen = time.Now()
+	return false
+}
+
+// RecordOutcome 記錄一次重試的結果：examples 為途中遇到的錯誤特徵與原始訊息
+func (rc *RetryClassifier) RecordOutcome(examples map[string]string, recovered bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	now := time.Now()
+	for signature, example := range examples {
+		if signature == "" {
+			continue
+		}
+		s, ok := rc.stats[signature]
+		if !ok {
+			s = &SignatureStats{Signature: signature}
+			rc.stats[signature] = s
+		}
+		s.Example = tailRunes(example, 200)
+		s.LastSeen = now
+		if recovered {
+			s.Recovered++
+		} else {
+			s.Futile++
+		}
+	}
+}
+
+// Stats 傳回所有特徵的統計（依最近出現時間排序，新的在前）
+func (rc *RetryClassifier) Stats() []SignatureStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	list := make([]SignatureStats, 0, len(rc.stats))
+	for _, s := range rc.stats {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastSeen.After(list[j].LastSeen)
+	})
+	return list
+}
+
+// IsDemoted 傳回特徵是否已被降級
+func (rc *RetryClassifier) IsDemoted(signature string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	s, ok := rc.stats[signature]
+	return ok && s.Demoted(rc.threshold)
+}
+
+// FutileThreshold 傳回目前的降級門檻
+func (rc *RetryClassifier) FutileThreshold() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.threshold
+}
+
+// Save 將統計寫入 JSON 檔案
+func (rc *RetryClassifier) Save(path string) error {
+	data, err := json.MarshalIndent(rc.Stats(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("重試統計編碼失敗: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("無法建立目錄: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadRetryClassifier 從檔案載入重試統計；檔案不存在時傳回空的分類器
+func LoadRetryClassifier(path string) (*RetryClassifier, error) {
+	rc := NewRetryClassifier()
+
+	data, err := os.ReadFile(path) // #nosec G304 -- 路徑由 SaveDir 組成
+	if errors.Is(err, os.ErrNotExist) {
+		return rc, nil
+	}
+	if err != nil {
+		return rc, fmt.Errorf("無法讀取重試統計: %w", err)
+	}
+
+	var list []SignatureStats
+	if err := json.Unmarshal(data, &list); err != nil {
+		return rc, fmt.Errorf("重試統計格式錯誤: %w", err)
+	}
+	for i := range list {
+		s := list[i]
+		rc.stats[s.Signature] = &s
+	}
+	return rc, nil
+}
+
+// retryFailureMessage 取得用來計算錯誤特徵的訊息：優先使用 stderr，否則使用錯誤本身
+func retryFailureMessage(result *ExecutionResult, err error) string {
+	if result != nil {
+		if stderr := strings.TrimSpace(result.Stderr); stderr != "" {
+			return stderr
+		}
+		if result.Error != nil {
+			return result.Error.Error()
+		}
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// retryStatsPath 重試統計檔案路徑
+func (c *RalphLoopClient) retryStatsPath() string {
+	return filepath.Join(c.config.SaveDir, RetryStatsFile)
+}
+
+// loadRetryClassifier 建立重試分類器，啟用持久化時載入先前執行的統計
+func (c *RalphLoopClient) loadRetryClassifier() *RetryClassifier {
+	if !c.config.EnablePersistence {
+		return NewRetryClassifier()
+	}
+	rc, err := LoadRetryClassifier(c.retryStatsPath())
+	if err != nil {
+		c.initWarning("%v（重新累積統計）", err)
+	}
+	return rc
+}
+
+// GetRetryStats 傳回重試分類器學到的錯誤特徵統計（未啟用時為 nil）
+func (c *RalphLoopClient) GetRetryStats() []SignatureStats {
+	if c.retryClassifier == nil {
+		return nil
+	}
+	return c.retryClassifier.Stats()
+}
@@ -0,0 +1,165 @@
+package ghcopilot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorSignature(t *testing.T) {
+	a := ErrorSignature("\n  Error: rate limit exceeded (request 3f2a9c1e-77aa, retry in 120ms)\nstack...")
+	b := ErrorSignature("Error: Rate limit exceeded (request 9b0c4d2f-11bb, retry in 80ms)")
+	if a != b {
+		t.Errorf("signatures should ignore ids, numbers and case: %q vs %q", a, b)
+	}
+	if strings.Contains(a, "stack") {
+		t.Errorf("signature should only use the first non-empty line: %q", a)
+	}
+	if ErrorSignature("  \n ") != "" {
+		t.Error("blank message should have empty signature")
+	}
+}
+
+func TestRetryClassifier_DemotesFutileSignature(t *testing.T) {
+	rc := NewRetryClassifier()
+	futile := map[string]string{"auth failed": "auth failed"}
+
+	rc.RecordOutcome(futile, false)
+	if !rc.ShouldRetry("auth failed") {
+		t.Fatal("one futile retry should not demote yet")
+	}
+	rc.RecordOutcome(futile, false)
+	if rc.ShouldRetry("auth failed") {
+		t.Fatal("signature that never recovered should be demoted")
+	}
+
+	stats := rc.Stats()
+	if len(stats) != 1 || stats[0].Futile != 2 || stats[0].Skipped != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if !rc.ShouldRetry("timeout") {
+		t.Error("unknown signatures should still be retried")
+	}
+}
+
+func TestRetryClassifier_RecoveredSignatureKeepsRetrying(t *testing.T) {
+	rc := NewRetryClassifier()
+	flaky := map[string]string{"connection reset": "connection reset"}
+
+	rc.RecordOutcome(flaky, true)
+	for i := 0; i < 5; i++ {
+		rc.RecordOutcome(flaky, false)
+	}
+	if !rc.ShouldRetry("connection reset") {
+		t.Error("signature that recovered at least once should keep being retried")
+	}
+}
+
+func TestRetryClassifier_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saves", RetryStatsFile)
+
+	rc := NewRetryClassifier()
+	rc.RecordOutcome(map[string]string{"auth failed": "Auth failed: 401"}, false)
+	rc.RecordOutcome(map[string]string{"auth failed": "Auth failed: 401"}, false)
+	if err := rc.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	loaded, err := LoadRetryClassifier(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if !loaded.IsDemoted("auth failed") {
+		t.Error("demotion should carry over to the next run")
+	}
+
+	empty, err := LoadRetryClassifier(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(empty.Stats()) != 0 {
+		t.Errorf("missing file should give an empty classifier, got %v %v", empty.Stats(), err)
+	}
+}
+
+// TestRetryClassifier_DemotedMidRun 連續失敗的錯誤在同一次執行中被降級，之後不再重試
+func TestRetryClassifier_DemotedMidRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake copilot is a shell script")
+	}
+	t.Setenv("COPILOT_MOCK_MODE", "")
+
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho x >> " + counter + "\necho \"Error: model overloaded (request $$)\" >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "copilot"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	executor := NewCLIExecutor(dir)
+	executor.SetMaxRetries(2)
+	executor.retryDelay = time.Millisecond
+	classifier := NewRetryClassifier()
+	executor.SetRetryClassifier(classifier)
+
+	calls := func() int {
+		data, _ := os.ReadFile(counter) // #nosec G304 -- test temp dir
+		return strings.Count(string(data), "x")
+	}
+
+	// 前兩次：每次都完整重試（1 + 2 次）且從未恢復
+	for i := 1; i <= 2; i++ {
+		if res, _ := executor.ExecutePrompt(context.Background(), "fix"); res == nil || res.Success {
+			t.Fatal("fake copilot should fail")
+		}
+		if got := calls(); got != 3*i {
+			t.Fatalf("run %d: expected %d invocations, got %d", i, 3*i, got)
+		}
+	}
+
+	signature := ErrorSignature("Error: model overloaded (request 1)")
+	if !classifier.IsDemoted(signature) {
+		t.Fatalf("signature should be demoted after two futile retries: %+v", classifier.Stats())
+	}
+
+	// 第三次：已降級，只執行一次就放棄
+	if res, _ := executor.ExecutePrompt(context.Background(), "fix"); res == nil || res.Success {
+		t.Fatal("fake copilot should fail")
+	}
+	if got := calls(); got != 7 {
+		t.Errorf("demoted error should not be retried, expected 7 invocations, got %d", got)
+	}
+}
+
+func TestAdaptiveRetryClassificationPersistsAcrossClients(t *testing.T) {
+	config := DefaultClientConfig()
+	config.SaveDir = t.TempDir()
+	config.Silent = true
+	config.AdaptiveRetryClassification = true
+
+	client := NewRalphLoopClientWithConfig(config)
+	if client.executor.classifier == nil || client.executor.classifier != client.retryClassifier {
+		t.Fatal("executor should use the client's retry classifier")
+	}
+	client.retryClassifier.RecordOutcome(map[string]string{"auth failed": "auth failed"}, false)
+	client.retryClassifier.RecordOutcome(map[string]string{"auth failed": "auth failed"}, false)
+	if err := client.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	next := NewRalphLoopClientWithConfig(config)
+	defer next.Close()
+	stats := next.GetRetryStats()
+	if len(stats) != 1 || !next.retryClassifier.IsDemoted("auth failed") {
+		t.Errorf("learned stats should be loaded by the next client: %+v", stats)
+	}
+
+	config.AdaptiveRetryClassification = false
+	plain := NewRalphLoopClientWithConfig(config)
+	defer plain.Close()
+	if plain.GetRetryStats() != nil || plain.executor.classifier != nil {
+		t.Error("classifier should be off by default")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 )
@@ -35,6 +36,32 @@ func (s RetryStrategyType) String() string {
 	}
 }
 
+// DeadlineStrategy 定義 context 即將到期時如何決定退避等待時間
+type DeadlineStrategy int
+
+const (
+	// DeadlineClamp 將等待時間壓縮到 context 剩餘時間內（扣除保留給嘗試本身的時間），
+	// 確保最後一次嘗試真的會執行，而不是在等待中被取消
+	DeadlineClamp DeadlineStrategy = iota
+	// DeadlineIgnore 忽略 context 期限，照常等待完整退避時間
+	DeadlineIgnore
+)
+
+// String 返回期限策略的字串表示
+func (s DeadlineStrategy) String() string {
+	switch s {
+	case DeadlineClamp:
+		return "clamp"
+	case DeadlineIgnore:
+		return "ignore"
+	default:
+		return "unknown"
+	}
+}
+
+// defaultAttemptReserve 未設定 AttemptReserve 時為單次嘗試保留的時間
+const defaultAttemptReserve = 50 * time.Millisecond
+
 // RetryPolicy 定義重試策略配置
 type RetryPolicy struct {
 	// MaxAttempts 最大重試次數 (包括初始嘗試)
@@ -57,19 +84,31 @@ type RetryPolicy struct {
 	RetryableErrors []string
 	// NonRetryableErrors 不可重試的錯誤類型清單
 	NonRetryableErrors []string
+	// ShouldRetryFunc 自訂的重試判斷，優先於上面兩個字串清單；
+	// matched 為 false 時改用字串清單判斷（MaxAttempts 仍然有效）
+	ShouldRetryFunc func(attempt int, err error) (retry bool, matched bool)
+	// DeadlineStrategy context 接近期限時的等待策略 (預設 DeadlineClamp)
+	DeadlineStrategy DeadlineStrategy
+	// AttemptReserve 為實際嘗試保留的時間；剩餘時間不足此值時直接放棄 (預設 50ms)
+	AttemptReserve time.Duration
+	// RespectContextDeadline 下一次退避等待（加上 AttemptReserve）會超過 context 期限時
+	// 直接放棄，不壓縮等待時間 (預設 false)
+	RespectContextDeadline bool
 }
 
 // DefaultRetryPolicy 返回預設的重試策略
 func DefaultRetryPolicy() *RetryPolicy {
 	return &RetryPolicy{
-		MaxAttempts:  5,
-		InitialDelay: 100 * time.Millisecond,
-		MaxDelay:     30 * time.Second,
-		Multiplier:   2.0,
-		Increment:    100 * time.Millisecond,
-		Strategy:     StrategyExponentialBackoff,
-		Jitter:       true,
-		JitterFactor: 0.1,
+		MaxAttempts:      5,
+		InitialDelay:     100 * time.Millisecond,
+		MaxDelay:         30 * time.Second,
+		Multiplier:       2.0,
+		Increment:        100 * time.Millisecond,
+		Strategy:         StrategyExponentialBackoff,
+		Jitter:           true,
+		JitterFactor:     0.1,
+		DeadlineStrategy: DeadlineClamp,
+		AttemptReserve:   defaultAttemptReserve,
 	}
 }
 
@@ -145,6 +184,46 @@ func (p *RetryPolicy) NextWaitDuration(attempt int) time.Duration {
 	return delay
 }
 
+// waitWithinDeadline 依期限策略調整等待時間
+//
+// 傳回 false 表示剩餘時間已不足以再嘗試一次，應立即放棄。
+func (p *RetryPolicy) waitWithinDeadline(ctx context.Context, wait time.Duration) (time.Duration, bool) {
+	if p.DeadlineStrategy == DeadlineIgnore {
+		return wait, true
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return wait, true
+	}
+
+	reserve := p.AttemptReserve
+	if reserve <= 0 {
+		reserve = defaultAttemptReserve
+	}
+
+	remaining := time.Until(deadline) - reserve
+	if remaining <= 0 {
+		return 0, false
+	}
+	if wait > remaining {
+		wait = remaining
+	}
+	return wait, true
+}
+
+// exceedsDeadline 判斷等待 wait 後是否已來不及在 context 期限前再嘗試一次
+func (p *RetryPolicy) exceedsDeadline(ctx context.Context, wait time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return false
+	}
+	reserve := p.AttemptReserve
+	if reserve <= 0 {
+		reserve = defaultAttemptReserve
+	}
+	return wait+reserve > time.Until(deadline)
+}
+
 // ShouldRetry 判斷是否應該重試
 func (p *RetryPolicy) ShouldRetry(attempt int, err error) bool {
 	if attempt >= p.MaxAttempts {
@@ -154,18 +233,27 @@ func (p *RetryPolicy) ShouldRetry(attempt int, err error) bool {
 		return false
 	}
 
-	errMsg := err.Error()
+	if p.ShouldRetryFunc != nil {
+		if retry, matched := p.ShouldRetryFunc(attempt, err); matched {
+			return retry
+		}
+	}
+
+	return retryAllowedByLists(err.Error(), p.RetryableErrors, p.NonRetryableErrors)
+}
 
+// retryAllowedByLists 依可重試與不可重試清單（不區分大小寫的子字串）判斷錯誤訊息是否可重試
+func retryAllowedByLists(errMsg string, retryable, nonRetryable []string) bool {
 	// 檢查是否在不可重試清單中
-	for _, pattern := range p.NonRetryableErrors {
+	for _, pattern := range nonRetryable {
 		if containsString(errMsg, pattern) {
 			return false
 		}
 	}
 
 	// 如果有可重試清單，檢查是否匹配
-	if len(p.RetryableErrors) > 0 {
-		for _, pattern := range p.RetryableErrors {
+	if len(retryable) > 0 {
+		for _, pattern := range retryable {
 			if containsString(errMsg, pattern) {
 				return true
 			}
@@ -177,6 +265,26 @@ func (p *RetryPolicy) ShouldRetry(attempt int, err error) bool {
 	return true
 }
 
+// ValidateRetryErrorLists 檢查可重試與不可重試清單：不可有空白項目，也不可同時出現在兩個清單
+func ValidateRetryErrorLists(retryable, nonRetryable []string) error {
+	seen := make(map[string]bool, len(retryable))
+	for _, pattern := range retryable {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("retryable errors cannot contain empty patterns")
+		}
+		seen[strings.ToLower(pattern)] = true
+	}
+	for _, pattern := range nonRetryable {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("non-retryable errors cannot contain empty patterns")
+		}
+		if seen[strings.ToLower(pattern)] {
+			return fmt.Errorf("error pattern %q is both retryable and non-retryable", pattern)
+		}
+	}
+	return nil
+}
+
 // Validate 驗證策略配置的有效性
 func (p *RetryPolicy) Validate() error {
 	if p.MaxAttempts < 1 {
@@ -197,22 +305,29 @@ func (p *RetryPolicy) Validate() error {
 	if p.JitterFactor < 0 || p.JitterFactor > 1 {
 		return fmt.Errorf("jitter factor must be between 0 and 1")
 	}
-	return nil
+	if p.AttemptReserve < 0 {
+		return fmt.Errorf("attempt reserve cannot be negative")
+	}
+	return ValidateRetryErrorLists(p.RetryableErrors, p.NonRetryableErrors)
 }
 
 // Clone 複製策略配置
 func (p *RetryPolicy) Clone() *RetryPolicy {
 	return &RetryPolicy{
-		MaxAttempts:        p.MaxAttempts,
-		InitialDelay:       p.InitialDelay,
-		MaxDelay:           p.MaxDelay,
-		Multiplier:         p.Multiplier,
-		Increment:          p.Increment,
-		Strategy:           p.Strategy,
-		Jitter:             p.Jitter,
-		JitterFactor:       p.JitterFactor,
-		RetryableErrors:    append([]string{}, p.RetryableErrors...),
-		NonRetryableErrors: append([]string{}, p.NonRetryableErrors...),
+		MaxAttempts:            p.MaxAttempts,
+		InitialDelay:           p.InitialDelay,
+		MaxDelay:               p.MaxDelay,
+		Multiplier:             p.Multiplier,
+		Increment:              p.Increment,
+		Strategy:               p.Strategy,
+		Jitter:                 p.Jitter,
+		JitterFactor:           p.JitterFactor,
+		RetryableErrors:        append([]string{}, p.RetryableErrors...),
+		NonRetryableErrors:     append([]string{}, p.NonRetryableErrors...),
+		ShouldRetryFunc:        p.ShouldRetryFunc,
+		DeadlineStrategy:       p.DeadlineStrategy,
+		AttemptReserve:         p.AttemptReserve,
+		RespectContextDeadline: p.RespectContextDeadline,
 	}
 }
 
@@ -317,8 +432,31 @@ func (e *RetryExecutor) ExecuteWithResult(ctx context.Context, fn func() (interf
 			return result
 		}
 
-		// 計算等待時間
-		waitDuration := e.policy.NextWaitDuration(attempt)
+		// 等待後會超過期限時不再重試，避免重試拖垮整體的時間預算
+		nextWait := e.policy.NextWaitDuration(attempt)
+		if e.policy.RespectContextDeadline && e.policy.exceedsDeadline(ctx, nextWait) {
+			result.Error = fmt.Errorf("next retry delay %v exceeds context deadline after %d attempts: %w", nextWait, attempt, err)
+			result.Duration = time.Since(startTime)
+
+			e.mu.Lock()
+			e.metrics.FailedRetries++
+			e.mu.Unlock()
+
+			return result
+		}
+
+		// 計算等待時間（依期限策略壓縮到 context 剩餘時間內）
+		waitDuration, ok := e.policy.waitWithinDeadline(ctx, nextWait)
+		if !ok {
+			result.Error = fmt.Errorf("insufficient time for another attempt after %d attempts: %w", attempt, err)
+			result.Duration = time.Since(startTime)
+
+			e.mu.Lock()
+			e.metrics.FailedRetries++
+			e.mu.Unlock()
+
+			return result
+		}
 
 		e.mu.Lock()
 		e.metrics.TotalWaitTime += waitDuration
@@ -462,6 +600,30 @@ func (b *RetryPolicyBuilder) WithNonRetryableErrors(patterns ...string) *RetryPo
 	return b
 }
 
+// WithRetryPredicate 設定自訂的重試判斷，優先於錯誤字串清單
+func (b *RetryPolicyBuilder) WithRetryPredicate(fn func(attempt int, err error) (retry bool, matched bool)) *RetryPolicyBuilder {
+	b.policy.ShouldRetryFunc = fn
+	return b
+}
+
+// WithDeadlineStrategy 設定 context 接近期限時的等待策略
+func (b *RetryPolicyBuilder) WithDeadlineStrategy(strategy DeadlineStrategy) *RetryPolicyBuilder {
+	b.policy.DeadlineStrategy = strategy
+	return b
+}
+
+// WithAttemptReserve 設定為實際嘗試保留的時間
+func (b *RetryPolicyBuilder) WithAttemptReserve(reserve time.Duration) *RetryPolicyBuilder {
+	b.policy.AttemptReserve = reserve
+	return b
+}
+
+// WithRespectContextDeadline 設定下一次等待會超過 context 期限時是否直接放棄
+func (b *RetryPolicyBuilder) WithRespectContextDeadline(enabled bool) *RetryPolicyBuilder {
+	b.policy.RespectContextDeadline = enabled
+	return b
+}
+
 // Build 建立重試策略
 func (b *RetryPolicyBuilder) Build() (*RetryPolicy, error) {
 	if err := b.policy.Validate(); err != nil {
@@ -3,6 +3,7 @@ package ghcopilot
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -240,6 +241,58 @@ func TestShouldRetry_WithNonRetryableErrors(t *testing.T) {
 	}
 }
 
+// httpStatusError 測試用：帶 HTTP 狀態碼的錯誤
+type httpStatusError struct{ code int }
+
+func (e *httpStatusError) Error() string { return fmt.Sprintf("http status %d", e.code) }
+
+// statusPredicate 依包裝的 HTTP 狀態碼判斷：5xx 重試、4xx 不重試，其他交給字串清單
+func statusPredicate(attempt int, err error) (bool, bool) {
+	var he *httpStatusError
+	if !errors.As(err, &he) {
+		return false, false
+	}
+	return he.code >= 500, true
+}
+
+func TestShouldRetry_PredicateOverridesLists(t *testing.T) {
+	policy := NewRetryPolicyBuilder().
+		WithMaxAttempts(5).
+		WithRetryableErrors("timeout").
+		WithNonRetryableErrors("status 503").
+		WithRetryPredicate(statusPredicate).
+		MustBuild()
+
+	// 503 命中不可重試清單，但 predicate 優先
+	if !policy.ShouldRetry(1, fmt.Errorf("request failed: %w", &httpStatusError{code: 503})) {
+		t.Error("predicate should retry wrapped 5xx errors despite the lists")
+	}
+	if policy.ShouldRetry(1, &httpStatusError{code: 404}) {
+		t.Error("predicate should reject 4xx errors")
+	}
+	if policy.ShouldRetry(5, &httpStatusError{code: 503}) {
+		t.Error("predicate should not bypass MaxAttempts")
+	}
+}
+
+func TestShouldRetry_PredicateDefersToLists(t *testing.T) {
+	policy := NewRetryPolicyBuilder().
+		WithMaxAttempts(5).
+		WithRetryableErrors("timeout").
+		WithRetryPredicate(statusPredicate).
+		MustBuild()
+
+	if !policy.ShouldRetry(1, errors.New("connection timeout")) {
+		t.Error("unmatched errors should fall back to RetryableErrors")
+	}
+	if policy.ShouldRetry(1, errors.New("invalid input")) {
+		t.Error("unmatched errors not in RetryableErrors should not retry")
+	}
+	if policy.Clone().ShouldRetryFunc == nil {
+		t.Error("Clone should keep ShouldRetryFunc")
+	}
+}
+
 func TestPolicyValidate_Valid(t *testing.T) {
 	policy := DefaultRetryPolicy()
 	if err := policy.Validate(); err != nil {
@@ -793,3 +846,128 @@ func TestRetryExecutor_Concurrent(t *testing.T) {
 		t.Errorf("expected 10 total attempts, got %d", metrics.TotalAttempts)
 	}
 }
+
+// ========================
+// DeadlineStrategy 測試
+// ========================
+
+func TestRetryExecutor_DeadlineClamp_FinalAttemptRuns(t *testing.T) {
+	policy := NewFixedIntervalPolicy(3, time.Second)
+	policy.AttemptReserve = 50 * time.Millisecond
+	executor := NewRetryExecutor(policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	callCount := 0
+	err := executor.Execute(ctx, func() error {
+		callCount++
+		if callCount < 2 {
+			return errors.New("temporary error")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("expected final attempt to succeed, got %v", err)
+	}
+	if callCount != 2 {
+		t.Errorf("expected 2 calls, got %d", callCount)
+	}
+}
+
+func TestRetryExecutor_DeadlineClamp_FailFast(t *testing.T) {
+	policy := NewFixedIntervalPolicy(3, time.Second)
+	policy.AttemptReserve = 100 * time.Millisecond
+	executor := NewRetryExecutor(policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	callCount := 0
+	err := executor.Execute(ctx, func() error {
+		callCount++
+		return errors.New("temporary error")
+	})
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected fail-fast error, got context error: %v", err)
+	}
+	if callCount != 1 {
+		t.Errorf("expected 1 call, got %d", callCount)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("expected to fail fast without sleeping, took %v", elapsed)
+	}
+}
+
+func TestRetryExecutor_DeadlineIgnore(t *testing.T) {
+	policy := NewFixedIntervalPolicy(3, time.Second)
+	policy.DeadlineStrategy = DeadlineIgnore
+	executor := NewRetryExecutor(policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	callCount := 0
+	err := executor.Execute(ctx, func() error {
+		callCount++
+		return errors.New("temporary error")
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context deadline error, got %v", err)
+	}
+	if callCount != 1 {
+		t.Errorf("expected 1 call, got %d", callCount)
+	}
+}
+
+func TestRetryExecutor_RespectContextDeadline(t *testing.T) {
+	policy := NewRetryPolicyBuilder().
+		WithMaxAttempts(5).
+		WithStrategy(StrategyFixedInterval).
+		WithInitialDelay(200 * time.Millisecond).
+		WithJitter(false).
+		WithAttemptReserve(10 * time.Millisecond).
+		WithRespectContextDeadline(true).
+		MustBuild()
+	executor := NewRetryExecutor(policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	callCount := 0
+	err := executor.Execute(ctx, func() error {
+		callCount++
+		return errors.New("temporary error")
+	})
+
+	// 第一次等待 200ms 仍在期限內；第二次等待會超過期限，應立即放棄
+	if callCount != 2 {
+		t.Errorf("expected 2 calls, got %d", callCount)
+	}
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline budget error, got %v", err)
+	}
+	if !policy.Clone().RespectContextDeadline {
+		t.Error("Clone should keep RespectContextDeadline")
+	}
+	if elapsed := time.Since(start); elapsed > 280*time.Millisecond {
+		t.Errorf("expected to stop before the deadline, took %v", elapsed)
+	}
+}
+
+func TestDeadlineStrategyString(t *testing.T) {
+	if DeadlineClamp.String() != "clamp" {
+		t.Errorf("expected clamp, got %s", DeadlineClamp.String())
+	}
+	if DeadlineIgnore.String() != "ignore" {
+		t.Errorf("expected ignore, got %s", DeadlineIgnore.String())
+	}
+}
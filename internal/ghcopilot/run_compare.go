@@ -0,0 +1,219 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// charsPerToken 估計 token 數時每個 token 對應的字元數
+const charsPerToken = 4
+
+// RunHistory 匯出的執行歷史（ExportHistory 與 context_manager_*.json 的格式）
+type RunHistory struct {
+	Summary map[string]interface{} `json:"summary"`
+	History []*ExecutionContext    `json:"history"`
+}
+
+// LoadRunHistory 載入匯出的執行歷史
+func LoadRunHistory(path string) (*RunHistory, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- 路徑由使用者指定
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取 %s: %w", path, err)
+	}
+	h := &RunHistory{}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("%s 不是匯出的執行歷史: %w", path, err)
+	}
+	return h, nil
+}
+
+// RunMetrics 單次執行用於比較的指標
+type RunMetrics struct {
+	Loops           int   `json:"loops"`             // 迴圈數
+	Completed       bool  `json:"completed"`         // 最後一個迴圈是否判定完成（且熔斷器未打開）
+	TotalDurationMs int64 `json:"total_duration_ms"` // 所有迴圈的執行時間總和
+	EstimatedTokens int   `json:"estimated_tokens"`  // prompt 與輸出的估計 token 數（字元數 / 4）
+	BreakerTrips    int   `json:"breaker_trips"`     // 熔斷器打開的次數
+	Warnings        int   `json:"warnings"`          // 警告數
+}
+
+// Metrics 計算執行歷史的比較指標
+func (h *RunHistory) Metrics() RunMetrics {
+	var m RunMetrics
+	m.Loops = len(h.History)
+
+	prevState := string(StateClosed)
+	for _, loop := range h.History {
+		if loop == nil {
+			continue
+		}
+		m.TotalDurationMs += loop.DurationMs
+		m.EstimatedTokens += estimateLoopTokens(loop)
+		m.Warnings += len(loop.Warnings)
+		if loop.CircuitBreakerState == string(StateOpen) && prevState != string(StateOpen) {
+			m.BreakerTrips++
+		}
+		if loop.CircuitBreakerState != "" {
+			prevState = loop.CircuitBreakerState
+		}
+	}
+
+	if m.Loops > 0 {
+		last := h.History[m.Loops-1]
+		m.Completed = last != nil && !last.ShouldContinue && last.CircuitBreakerState != string(StateOpen)
+	}
+	return m
+}
+
+// 比較結果中的勝出者
+const (
+	WinnerA   = "a"
+	WinnerB   = "b"
+	WinnerTie = "tie"
+	WinnerNA  = "n/a"
+)
+
+// MetricDelta 單一指標的比較結果（Delta 為 B - A）
+type MetricDelta struct {
+	Metric string  `json:"metric"`
+	A      float64 `json:"a"`
+	B      float64 `json:"b"`
+	Delta  float64 `json:"delta"`
+	Winner string  `json:"winner"`
+	Note   string  `json:"note,omitempty"`
+}
+
+// RunComparison 兩次執行的比較報告
+type RunComparison struct {
+	A      RunMetrics    `json:"a"`
+	B      RunMetrics    `json:"b"`
+	Deltas []MetricDelta `json:"deltas"`
+	Winner string        `json:"winner"` // 勝出指標較多的一方
+	Notes  []string      `json:"notes,omitempty"`
+}
+
+// CompareRuns 比較兩次執行（例如 A/B 測試不同 prompt 或設定）
+//
+// 迴圈數不同時各自以完整歷史計算；只有一方完成時「完成所需迴圈數」
+// 判給完成的一方，雙方都未完成時不比較。
+func CompareRuns(a, b *RunHistory) *RunComparison {
+	ma, mb := a.Metrics(), b.Metrics()
+	rc := &RunComparison{A: ma, B: mb}
+
+	if ma.Loops != mb.Loops {
+		rc.Notes = append(rc.Notes, fmt.Sprintf("迴圈數不同（A: %d, B: %d），各自以完整歷史計算", ma.Loops, mb.Loops))
+	}
+	if ma.Loops == 0 || mb.Loops == 0 {
+		rc.Notes = append(rc.Notes, "至少一方沒有任何迴圈記錄")
+	}
+
+	rc.Deltas = append(rc.Deltas, compareLoopsToCompletion(ma, mb))
+	rc.Deltas = append(rc.Deltas,
+		newMetricDelta("success", boolMetric(ma.Completed), boolMetric(mb.Completed), false),
+		newMetricDelta("total_duration_ms", float64(ma.TotalDurationMs), float64(mb.TotalDurationMs), true),
+		newMetricDelta("estimated_tokens", float64(ma.EstimatedTokens), float64(mb.EstimatedTokens), true),
+		newMetricDelta("breaker_trips", float64(ma.BreakerTrips), float64(mb.BreakerTrips), true),
+		newMetricDelta("warnings", float64(ma.Warnings), float64(mb.Warnings), true),
+	)
+
+	winsA, winsB := 0, 0
+	for _, d := range rc.Deltas {
+		switch d.Winner {
+		case WinnerA:
+			winsA++
+		case WinnerB:
+			winsB++
+		}
+	}
+	switch {
+	case winsA > winsB:
+		rc.Winner = WinnerA
+	case winsB > winsA:
+		rc.Winner = WinnerB
+	default:
+		rc.Winner = WinnerTie
+	}
+	return rc
+}
+
+// compareLoopsToCompletion 比較完成所需的迴圈數
+func compareLoopsToCompletion(ma, mb RunMetrics) MetricDelta {
+	d := MetricDelta{Metric: "loops_to_completion", A: float64(ma.Loops), B: float64(mb.Loops)}
+	switch {
+	case ma.Completed && mb.Completed:
+		return newMetricDelta(d.Metric, d.A, d.B, true)
+	case ma.Completed:
+		d.Winner = WinnerA
+		d.Note = "只有 A 完成"
+	case mb.Completed:
+		d.Winner = WinnerB
+		d.Note = "只有 B 完成"
+	default:
+		d.Winner = WinnerNA
+		d.Note = "雙方都未完成"
+	}
+	d.Delta = d.B - d.A
+	return d
+}
+
+// newMetricDelta 建立指標比較，lowerIsBetter 決定數值較小的一方勝出
+func newMetricDelta(metric string, a, b float64, lowerIsBetter bool) MetricDelta {
+	d := MetricDelta{Metric: metric, A: a, B: b, Delta: b - a, Winner: WinnerTie}
+	if a == b {
+		return d
+	}
+	if (a < b) == lowerIsBetter {
+		d.Winner = WinnerA
+	} else {
+		d.Winner = WinnerB
+	}
+	return d
+}
+
+// boolMetric 將布林值轉為 0/1
+func boolMetric(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// Text 以表格文字呈現比較報告
+func (rc *RunComparison) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-20s %12s %12s %12s  %s\n", "指標", "A", "B", "差異(B-A)", "勝出")
+	for _, d := range rc.Deltas {
+		fmt.Fprintf(&sb, "%-20s %12s %12s %12s  %s", d.Metric,
+			formatMetric(d.Metric, d.A), formatMetric(d.Metric, d.B), formatDelta(d.Metric, d.Delta), d.Winner)
+		if d.Note != "" {
+			fmt.Fprintf(&sb, " (%s)", d.Note)
+		}
+		sb.WriteString("\n")
+	}
+	for _, note := range rc.Notes {
+		fmt.Fprintf(&sb, "註: %s\n", note)
+	}
+	fmt.Fprintf(&sb, "整體勝出: %s\n", rc.Winner)
+	return sb.String()
+}
+
+// formatMetric 格式化指標數值
+func formatMetric(metric string, v float64) string {
+	if metric == "success" {
+		if v > 0 {
+			return "是"
+		}
+		return "否"
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+// formatDelta 格式化差異（帶正負號）
+func formatDelta(metric string, v float64) string {
+	if metric == "success" {
+		return "-"
+	}
+	return fmt.Sprintf("%+.0f", v)
+}
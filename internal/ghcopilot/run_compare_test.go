@@ -0,0 +1,163 @@
+package ghcopilot
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixtureLoop 測試用的迴圈紀錄
+type fixtureLoop struct {
+	durationMs int64
+	output     string
+	cont       bool
+	breaker    CircuitBreakerState
+	warnings   int
+}
+
+// writeRunFixture 以 ExportAsJSON 匯出一份執行歷史並傳回路徑
+func writeRunFixture(t *testing.T, name string, loops []fixtureLoop) string {
+	t.Helper()
+	dir := t.TempDir()
+	pm, err := NewPersistenceManager(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cm := NewContextManager()
+	for i, l := range loops {
+		cm.StartLoop(i, "修正錯誤")
+		if err := cm.UpdateCurrentLoop(func(ctx *ExecutionContext) {
+			ctx.CLIOutput = l.output
+			ctx.ShouldContinue = l.cont
+			ctx.CircuitBreakerState = string(l.breaker)
+			for w := 0; w < l.warnings; w++ {
+				ctx.AddWarning("警告 %d", w)
+			}
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := cm.FinishLoop(); err != nil {
+			t.Fatal(err)
+		}
+		// FinishLoop 會以實際時間覆寫 DurationMs，改成固定值方便斷言
+		history := cm.GetLoopHistory()
+		history[len(history)-1].DurationMs = l.durationMs
+	}
+
+	path := filepath.Join(dir, name)
+	if err := pm.ExportAsJSON(cm, path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestCompareRunsDeltas 測試兩份歷史的指標差異與勝出者
+func TestCompareRunsDeltas(t *testing.T) {
+	// A：5 個迴圈才完成，中間熔斷器打開一次
+	pathA := writeRunFixture(t, "a.json", []fixtureLoop{
+		{durationMs: 1000, output: strings.Repeat("a", 400), cont: true, breaker: StateClosed},
+		{durationMs: 1000, output: strings.Repeat("a", 400), cont: true, breaker: StateOpen, warnings: 1},
+		{durationMs: 1000, output: strings.Repeat("a", 400), cont: true, breaker: StateHalfOpen},
+		{durationMs: 1000, output: strings.Repeat("a", 400), cont: true, breaker: StateClosed},
+		{durationMs: 1000, output: strings.Repeat("a", 400), cont: false, breaker: StateClosed},
+	})
+	// B：3 個迴圈完成
+	pathB := writeRunFixture(t, "b.json", []fixtureLoop{
+		{durationMs: 2000, output: strings.Repeat("b", 800), cont: true, breaker: StateClosed},
+		{durationMs: 500, output: strings.Repeat("b", 800), cont: true, breaker: StateClosed},
+		{durationMs: 500, output: strings.Repeat("b", 800), cont: false, breaker: StateClosed, warnings: 2},
+	})
+
+	a, err := LoadRunHistory(pathA)
+	if err != nil {
+		t.Fatalf("載入 A 失敗: %v", err)
+	}
+	b, err := LoadRunHistory(pathB)
+	if err != nil {
+		t.Fatalf("載入 B 失敗: %v", err)
+	}
+
+	rc := CompareRuns(a, b)
+	if !rc.A.Completed || !rc.B.Completed {
+		t.Fatalf("兩份歷史都應判定完成: A=%+v B=%+v", rc.A, rc.B)
+	}
+
+	want := map[string]struct {
+		a, b, delta float64
+		winner      string
+	}{
+		"loops_to_completion": {5, 3, -2, WinnerB},
+		"success":             {1, 1, 0, WinnerTie},
+		"total_duration_ms":   {5000, 3000, -2000, WinnerB},
+		// (4 + 400) / 4 = 101 per loop for A, (4 + 800) / 4 = 201 per loop for B
+		"estimated_tokens": {505, 603, 98, WinnerA},
+		"breaker_trips":    {1, 0, -1, WinnerB},
+		"warnings":         {1, 2, 1, WinnerA},
+	}
+	if len(rc.Deltas) != len(want) {
+		t.Fatalf("應有 %d 個指標，但有 %d 個", len(want), len(rc.Deltas))
+	}
+	for _, d := range rc.Deltas {
+		w, ok := want[d.Metric]
+		if !ok {
+			t.Errorf("未預期的指標 %s", d.Metric)
+			continue
+		}
+		if d.A != w.a || d.B != w.b || d.Delta != w.delta || d.Winner != w.winner {
+			t.Errorf("%s: 預期 A=%v B=%v 差異=%v 勝出=%s，實際 %+v", d.Metric, w.a, w.b, w.delta, w.winner, d)
+		}
+	}
+	if rc.Winner != WinnerB {
+		t.Errorf("B 勝出的指標較多，整體應為 b，但為 %s", rc.Winner)
+	}
+	if len(rc.Notes) == 0 || !strings.Contains(rc.Notes[0], "迴圈數不同") {
+		t.Errorf("迴圈數不同時應附註說明: %v", rc.Notes)
+	}
+
+	text := rc.Text()
+	for _, s := range []string{"loops_to_completion", "-2", "整體勝出: b"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("文字報告應包含 %q:\n%s", s, text)
+		}
+	}
+}
+
+// TestCompareRunsOnlyOneCompleted 測試只有一方完成與空歷史的情況
+func TestCompareRunsOnlyOneCompleted(t *testing.T) {
+	done := &RunHistory{History: []*ExecutionContext{{ShouldContinue: false, CircuitBreakerState: string(StateClosed)}}}
+	stuck := &RunHistory{History: []*ExecutionContext{
+		{ShouldContinue: true, CircuitBreakerState: string(StateClosed)},
+		{ShouldContinue: false, CircuitBreakerState: string(StateOpen)},
+	}}
+
+	rc := CompareRuns(stuck, done)
+	if rc.A.Completed {
+		t.Error("熔斷器打開而停止不應視為完成")
+	}
+	loops := rc.Deltas[0]
+	if loops.Metric != "loops_to_completion" || loops.Winner != WinnerB || loops.Note != "只有 B 完成" {
+		t.Errorf("只有 B 完成時應判給 B: %+v", loops)
+	}
+
+	rc = CompareRuns(&RunHistory{}, stuck)
+	if rc.Deltas[0].Winner != WinnerNA {
+		t.Errorf("雙方都未完成時不應比較迴圈數: %+v", rc.Deltas[0])
+	}
+	found := false
+	for _, n := range rc.Notes {
+		if strings.Contains(n, "沒有任何迴圈") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("空歷史應附註說明: %v", rc.Notes)
+	}
+}
+
+// TestLoadRunHistoryInvalid 測試載入非歷史格式的檔案
+func TestLoadRunHistoryInvalid(t *testing.T) {
+	if _, err := LoadRunHistory(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("檔案不存在時應回傳錯誤")
+	}
+}
@@ -0,0 +1,110 @@
+package ghcopilot
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 執行摘要區塊的分隔線
+const (
+	RunSummaryStart = "---RALPH_SUMMARY---"
+	RunSummaryEnd   = "---END_SUMMARY---"
+)
+
+// 執行摘要的最終狀態
+const (
+	RunStatusCompleted = "completed"
+	RunStatusFailed    = "failed"
+)
+
+// RunSummary 執行結束時附加在輸出結尾、方便腳本擷取的摘要
+type RunSummary struct {
+	Loops           int
+	DurationMs      int64
+	Status          string // RunStatusCompleted 或 RunStatusFailed
+	Reason          string
+	EstimatedTokens int // prompt 與輸出的估計 token 數（字元數 / 4）
+	BreakerTrips    int
+	Warnings        int
+}
+
+// BuildRunSummary 依迴圈歷史與 ExecuteUntilCompletion 的結果建立執行摘要
+func (c *RalphLoopClient) BuildRunSummary(runErr error, duration time.Duration) *RunSummary {
+	m := (&RunHistory{History: c.contextManager.GetLoopHistory()}).Metrics()
+	s := &RunSummary{
+		Loops:           m.Loops,
+		DurationMs:      duration.Milliseconds(),
+		Status:          RunStatusCompleted,
+		Reason:          "任務完成",
+		EstimatedTokens: m.EstimatedTokens,
+		BreakerTrips:    m.BreakerTrips,
+		Warnings:        m.Warnings,
+	}
+	if runErr != nil {
+		s.Status = RunStatusFailed
+		s.Reason = runErr.Error()
+	}
+	return s
+}
+
+// Block 以 KEY: value 格式輸出摘要區塊（與 RALPH_STATUS 相同風格）
+func (s *RunSummary) Block() string {
+	var sb strings.Builder
+	sb.WriteString(RunSummaryStart + "\n")
+	fmt.Fprintf(&sb, "LOOPS: %d\n", s.Loops)
+	fmt.Fprintf(&sb, "DURATION_MS: %d\n", s.DurationMs)
+	fmt.Fprintf(&sb, "STATUS: %s\n", s.Status)
+	fmt.Fprintf(&sb, "REASON: %s\n", strings.Join(strings.Fields(s.Reason), " "))
+	fmt.Fprintf(&sb, "ESTIMATED_TOKENS: %d\n", s.EstimatedTokens)
+	fmt.Fprintf(&sb, "BREAKER_TRIPS: %d\n", s.BreakerTrips)
+	fmt.Fprintf(&sb, "WARNINGS: %d\n", s.Warnings)
+	sb.WriteString(RunSummaryEnd + "\n")
+	return sb.String()
+}
+
+// ParseRunSummary 從輸出中擷取最後一個摘要區塊
+func ParseRunSummary(output string) (*RunSummary, error) {
+	start := strings.LastIndex(output, RunSummaryStart)
+	if start < 0 {
+		return nil, fmt.Errorf("找不到 %s 區塊", RunSummaryStart)
+	}
+	body := output[start+len(RunSummaryStart):]
+	end := strings.Index(body, RunSummaryEnd)
+	if end < 0 {
+		return nil, fmt.Errorf("摘要區塊缺少 %s", RunSummaryEnd)
+	}
+
+	s := &RunSummary{}
+	scanner := bufio.NewScanner(strings.NewReader(body[:end]))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch strings.TrimSpace(key) {
+		case "LOOPS":
+			s.Loops, err = strconv.Atoi(value)
+		case "DURATION_MS":
+			s.DurationMs, err = strconv.ParseInt(value, 10, 64)
+		case "STATUS":
+			s.Status = value
+		case "REASON":
+			s.Reason = value
+		case "ESTIMATED_TOKENS":
+			s.EstimatedTokens, err = strconv.Atoi(value)
+		case "BREAKER_TRIPS":
+			s.BreakerTrips, err = strconv.Atoi(value)
+		case "WARNINGS":
+			s.Warnings, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("摘要欄位 %s 格式錯誤: %w", key, err)
+		}
+	}
+	return s, nil
+}
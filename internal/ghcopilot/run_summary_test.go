@@ -0,0 +1,50 @@
+package ghcopilot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunSummaryBlockRoundTrip 測試執行摘要區塊可以從串流輸出的結尾解析回來
+func TestRunSummaryBlockRoundTrip(t *testing.T) {
+	config := DefaultClientConfig()
+	client := newScriptedClient(config, strings.Repeat("x", 400)+"\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+
+	_, runErr := client.ExecuteUntilCompletion(context.Background(), "修正錯誤", 2)
+	if runErr == nil {
+		t.Fatal("未完成時應回傳錯誤")
+	}
+
+	summary := client.BuildRunSummary(runErr, 1500*time.Millisecond)
+	stdout := "🔄 迴圈 1/2 - 正在執行...\n人類可讀的輸出\n" + summary.Block()
+
+	parsed, err := ParseRunSummary(stdout)
+	if err != nil {
+		t.Fatalf("解析摘要失敗: %v", err)
+	}
+	if parsed.Loops != 2 || parsed.DurationMs != 1500 || parsed.Status != RunStatusFailed {
+		t.Errorf("摘要內容錯誤: %+v", parsed)
+	}
+	if !strings.Contains(parsed.Reason, "maximum loops") {
+		t.Errorf("結束原因應來自錯誤訊息: %q", parsed.Reason)
+	}
+	if parsed.EstimatedTokens < 200 || parsed.Warnings != summary.Warnings {
+		t.Errorf("token 與警告數應與原摘要一致: %+v vs %+v", parsed, summary)
+	}
+}
+
+// TestParseRunSummaryMissing 測試缺少或不完整的摘要區塊
+func TestParseRunSummaryMissing(t *testing.T) {
+	if _, err := ParseRunSummary("沒有摘要"); err == nil {
+		t.Error("沒有摘要區塊時應回傳錯誤")
+	}
+	if _, err := ParseRunSummary(RunSummaryStart + "\nLOOPS: 1\n"); err == nil {
+		t.Error("缺少結尾時應回傳錯誤")
+	}
+	s, err := ParseRunSummary(RunSummaryStart + "\nSTATUS: completed\nREASON: 任務完成: 全部通過\n" + RunSummaryEnd)
+	if err != nil || s.Status != RunStatusCompleted || s.Reason != "任務完成: 全部通過" {
+		t.Errorf("應正確解析含冒號的原因: %+v %v", s, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	copilot "github.com/github/copilot-sdk/go"
@@ -17,7 +18,9 @@ type SDKConfig struct {
 	WorkDir        string        // 工作目錄
 	Timeout        time.Duration // 執行逾時
 	SessionTimeout time.Duration // 會話逾時
-	MaxSessions    int           // 最大會話數
+	MaxSessions    int           // 最大會話數（同時執行的 Complete 呼叫數上限，<= 0 表示不限制）
+	QueueTimeout   time.Duration // 達到 MaxSessions 時排隊等待空位的上限，0 表示只受 context 限制
+	MaxQueued      int           // 排隊中的呼叫上限，超過時立即失敗，0 表示不限制
 	LogLevel       string        // 日誌級別
 	EnableMetrics  bool          // 啟用指標
 	AutoReconnect  bool          // 自動重新連接
@@ -31,6 +34,8 @@ func DefaultSDKConfig() *SDKConfig {
 		Timeout:        30 * time.Second,
 		SessionTimeout: 5 * time.Minute,
 		MaxSessions:    100,
+		QueueTimeout:   2 * time.Minute,
+		MaxQueued:      100,
 		LogLevel:       "info",
 		EnableMetrics:  true,
 		AutoReconnect:  true,
@@ -49,6 +54,14 @@ type SDKExecutor struct {
 	closed      bool
 	lastError   error
 	metrics     *SDKExecutorMetrics
+
+	// 同時執行的呼叫數限制（MaxSessions），超過時排隊
+	slots  chan struct{}
+	active int32
+	queued int32
+
+	// complete 實際執行單次呼叫（預設為 completeWithSession，測試時可替換）
+	complete func(ctx context.Context, prompt string) (string, error)
 }
 
 // SDKExecutorMetrics 執行器指標
@@ -66,11 +79,16 @@ func NewSDKExecutor(config *SDKConfig) *SDKExecutor {
 		config = DefaultSDKConfig()
 	}
 
-	return &SDKExecutor{
+	e := &SDKExecutor{
 		config:   config,
 		sessions: NewSDKSessionPool(config.MaxSessions, config.SessionTimeout),
 		metrics:  &SDKExecutorMetrics{StartTime: time.Now()},
 	}
+	if config.MaxSessions > 0 {
+		e.slots = make(chan struct{}, config.MaxSessions)
+	}
+	e.complete = e.completeWithSession
+	return e
 }
 
 // Start 啟動 SDK 執行器
@@ -127,7 +145,7 @@ func (e *SDKExecutor) Stop(ctx context.Context) error {
 	if err := e.sessions.ClearAll(); err != nil {
 		e.lastError = fmt.Errorf("清理會話失敗: %w", err)
 		errs = append(errs, e.lastError)
-		fmt.Printf("⚠️ %v\n", e.lastError)
+		fmt.Fprintf(logWriter(), "⚠️ %v\n", e.lastError)
 	}
 
 	// 停止客戶端
@@ -135,7 +153,7 @@ func (e *SDKExecutor) Stop(ctx context.Context) error {
 		if err := e.client.Stop(); err != nil {
 			e.lastError = fmt.Errorf("停止客戶端時發生錯誤: %v", err)
 			errs = append(errs, e.lastError)
-			fmt.Printf("⚠️ %v\n", e.lastError)
+			fmt.Fprintf(logWriter(), "⚠️ %v\n", e.lastError)
 		}
 	}
 
@@ -157,11 +175,27 @@ func (e *SDKExecutor) Stop(ctx context.Context) error {
 }
 
 // Complete 執行 AI 任務（使用新版 SDK session API）
+//
+// 同時執行的呼叫數達到 MaxSessions 時排隊等待空位，而不是直接失敗。
 func (e *SDKExecutor) Complete(ctx context.Context, prompt string) (string, error) {
 	if !e.isHealthy() {
 		return "", fmt.Errorf("sdk executor not healthy")
 	}
 
+	release, err := e.acquireSlot(ctx)
+	if err != nil {
+		e.mu.Lock()
+		e.metrics.FailedCalls++
+		e.mu.Unlock()
+		return "", err
+	}
+	defer release()
+
+	return e.complete(ctx, prompt)
+}
+
+// completeWithSession 建立 SDK session 執行單次呼叫
+func (e *SDKExecutor) completeWithSession(ctx context.Context, prompt string) (string, error) {
 	// 防禦：client 必須是真實連線，否則 CreateSession 會 panic
 	if e.client == nil {
 		return "", fmt.Errorf("sdk executor: client not initialized, call Start() first")
@@ -212,14 +246,14 @@ func (e *SDKExecutor) Complete(ctx context.Context, prompt string) (string, erro
 			}
 			argSummary := formatToolArgs(event.Data.Arguments)
 			if argSummary != "" {
-				fmt.Printf("● %s\n  $ %s\n", toolName, argSummary)
+				fmt.Fprintf(logWriter(), "● %s\n  $ %s\n", toolName, argSummary)
 			} else {
-				fmt.Printf("● %s\n", toolName)
+				fmt.Fprintf(logWriter(), "● %s\n", toolName)
 			}
 		case copilot.ToolExecutionPartialResult:
 			// 顯示工具串流輸出
 			if event.Data.PartialOutput != nil && *event.Data.PartialOutput != "" {
-				fmt.Printf("  │ %s\n", *event.Data.PartialOutput)
+				fmt.Fprintf(logWriter(), "  │ %s\n", *event.Data.PartialOutput)
 			}
 		case copilot.ToolExecutionComplete:
 			// 顯示工具執行結果
@@ -231,13 +265,13 @@ func (e *SDKExecutor) Complete(ctx context.Context, prompt string) (string, erro
 					limit := 20
 					for i, line := range lines {
 						if i >= limit {
-							fmt.Printf("  │ ... (共 %d 行)\n", len(lines))
+							fmt.Fprintf(logWriter(), "  │ ... (共 %d 行)\n", len(lines))
 							break
 						}
-						fmt.Printf("  │ %s\n", line)
+						fmt.Fprintf(logWriter(), "  │ %s\n", line)
 					}
 				}
-				fmt.Printf("  └ 完成\n")
+				fmt.Fprintf(logWriter(), "  └ 完成\n")
 			} else {
 				errMsg := ""
 				if event.Data.Error != nil {
@@ -247,20 +281,20 @@ func (e *SDKExecutor) Complete(ctx context.Context, prompt string) (string, erro
 						errMsg = *event.Data.Error.String
 					}
 				}
-				fmt.Printf("  └ ❌ 失敗: %s\n", errMsg)
+				fmt.Fprintf(logWriter(), "  └ ❌ 失敗: %s\n", errMsg)
 			}
 		case copilot.ToolExecutionProgress:
 			if event.Data.ProgressMessage != nil {
-				fmt.Printf("  … %s\n", *event.Data.ProgressMessage)
+				fmt.Fprintf(logWriter(), "  … %s\n", *event.Data.ProgressMessage)
 			}
 		case "assistant.message_delta":
 			if event.Data.DeltaContent != nil {
-				fmt.Print(*event.Data.DeltaContent)
+				fmt.Fprint(logWriter(), *event.Data.DeltaContent)
 				assistantContent.WriteString(*event.Data.DeltaContent)
 			}
 		case "assistant.message":
 			if event.Data.Content != nil && assistantContent.Len() == 0 {
-				fmt.Println(*event.Data.Content)
+				fmt.Fprintln(logWriter(), *event.Data.Content)
 				assistantContent.WriteString(*event.Data.Content)
 			}
 		}
@@ -272,7 +306,7 @@ func (e *SDKExecutor) Complete(ctx context.Context, prompt string) (string, erro
 		e.metrics.FailedCalls++
 		return "", fmt.Errorf("sdk execute failed: %w", err)
 	}
-	fmt.Println()
+	fmt.Fprintln(logWriter())
 
 	// 優先用收集到的串流內容，否則用最後事件
 	result := assistantContent.String()
@@ -437,6 +471,8 @@ func (e *SDKExecutor) GetStatus() *SDKStatus {
 		Running:      e.running,
 		Closed:       e.closed,
 		SessionCount: e.sessions.GetSessionCount(),
+		ActiveCalls:  int(atomic.LoadInt32(&e.active)),
+		QueuedCalls:  int(atomic.LoadInt32(&e.queued)),
 		LastError:    e.lastError,
 		Uptime:       time.Since(e.metrics.StartTime),
 	}
@@ -448,6 +484,8 @@ type SDKStatus struct {
 	Running      bool
 	Closed       bool
 	SessionCount int
+	ActiveCalls  int // 正在執行的 Complete 呼叫數
+	QueuedCalls  int // 因達到 MaxSessions 而排隊中的呼叫數
 	LastError    error
 	Uptime       time.Duration
 }
@@ -0,0 +1,64 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// errSDKQueueFull 排隊中的呼叫已達 MaxQueued
+	errSDKQueueFull = errors.New("sdk session queue full")
+	// errSDKQueueTimeout 排隊超過 QueueTimeout 仍沒有空位
+	errSDKQueueTimeout = errors.New("timed out waiting for a free sdk session")
+)
+
+// acquireSlot 取得一個執行空位，達到 MaxSessions 時排隊等待
+//
+// 排隊數超過 MaxQueued 時立即失敗；等待超過 QueueTimeout 或 ctx 結束時放棄。
+// 成功時傳回的 release 必須呼叫一次以釋放空位。
+func (e *SDKExecutor) acquireSlot(ctx context.Context) (release func(), err error) {
+	if e.slots == nil {
+		atomic.AddInt32(&e.active, 1)
+		return func() { atomic.AddInt32(&e.active, -1) }, nil
+	}
+
+	release = func() {
+		atomic.AddInt32(&e.active, -1)
+		<-e.slots
+	}
+
+	// 有空位時不排隊
+	select {
+	case e.slots <- struct{}{}:
+		atomic.AddInt32(&e.active, 1)
+		return release, nil
+	default:
+	}
+
+	queued := atomic.AddInt32(&e.queued, 1)
+	defer atomic.AddInt32(&e.queued, -1)
+	if e.config.MaxQueued > 0 && int(queued) > e.config.MaxQueued {
+		return nil, fmt.Errorf("%w (MaxSessions=%d, MaxQueued=%d)", errSDKQueueFull, e.config.MaxSessions, e.config.MaxQueued)
+	}
+	infoLog("⏳ SDK 會話已達上限 %d，排隊等待中（%d 個排隊）", e.config.MaxSessions, queued)
+
+	var timeout <-chan time.Time
+	if e.config.QueueTimeout > 0 {
+		timer := time.NewTimer(e.config.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case e.slots <- struct{}{}:
+		atomic.AddInt32(&e.active, 1)
+		return release, nil
+	case <-timeout:
+		return nil, fmt.Errorf("%w (%v)", errSDKQueueTimeout, e.config.QueueTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
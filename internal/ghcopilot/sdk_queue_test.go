@@ -0,0 +1,112 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newQueueTestExecutor 建立以假的 complete 執行、已視為啟動的 SDK 執行器
+func newQueueTestExecutor(maxSessions int, hold time.Duration) (*SDKExecutor, *int32) {
+	config := DefaultSDKConfig()
+	config.MaxSessions = maxSessions
+	executor := NewSDKExecutor(config)
+	executor.initialized = true
+	executor.running = true
+
+	var peak, current int32
+	executor.complete = func(ctx context.Context, prompt string) (string, error) {
+		n := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(hold)
+		return "ok: " + prompt, nil
+	}
+	return executor, &peak
+}
+
+// TestSDKExecutorQueuesBeyondMaxSessions 測試超過 MaxSessions 的並行呼叫會排隊並全部成功
+func TestSDKExecutorQueuesBeyondMaxSessions(t *testing.T) {
+	executor, peak := newQueueTestExecutor(2, 30*time.Millisecond)
+
+	const calls = 6
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := executor.Complete(context.Background(), "task"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	// 執行中應能看到排隊的呼叫
+	deadline := time.Now().Add(time.Second)
+	sawQueue := false
+	for time.Now().Before(deadline) && !sawQueue {
+		status := executor.GetStatus()
+		sawQueue = status.QueuedCalls > 0 && status.ActiveCalls == 2
+		time.Sleep(time.Millisecond)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("排隊的呼叫應最終成功: %v", err)
+	}
+	if got := atomic.LoadInt32(peak); got > 2 {
+		t.Errorf("同時執行數不應超過 MaxSessions=2，但為 %d", got)
+	}
+	if !sawQueue {
+		t.Error("GetStatus 應回報排隊中的呼叫")
+	}
+	if status := executor.GetStatus(); status.ActiveCalls != 0 || status.QueuedCalls != 0 {
+		t.Errorf("全部完成後不應有執行中或排隊的呼叫: %+v", status)
+	}
+}
+
+// TestSDKExecutorQueueLimits 測試排隊上限與等待逾時
+func TestSDKExecutorQueueLimits(t *testing.T) {
+	executor, _ := newQueueTestExecutor(1, 200*time.Millisecond)
+	executor.config.MaxQueued = 1
+	executor.config.QueueTimeout = 50 * time.Millisecond
+
+	go func() { _, _ = executor.Complete(context.Background(), "busy") }() // 佔住唯一的空位
+	for executor.GetStatus().ActiveCalls == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := executor.Complete(context.Background(), "waiting")
+			results <- err
+		}()
+	}
+
+	var full, timedOut int
+	for i := 0; i < 2; i++ {
+		err := <-results
+		switch {
+		case errors.Is(err, errSDKQueueFull):
+			full++
+		case errors.Is(err, errSDKQueueTimeout):
+			timedOut++
+		default:
+			t.Errorf("未預期的結果: %v", err)
+		}
+	}
+	if full != 1 || timedOut != 1 {
+		t.Errorf("應有 1 個因排隊已滿失敗、1 個逾時，但為 full=%d timeout=%d", full, timedOut)
+	}
+}
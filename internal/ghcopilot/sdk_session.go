@@ -45,6 +45,11 @@ type SDKSessionPool struct {
 	sessions map[string]*SDKSession
 	maxSize  int
 	timeout  time.Duration // 會話逾時
+
+	// 彙總指標（SessionMetrics 之外，涵蓋已移除的會話）
+	created        int64         // 建立過的會話總數
+	closedCount    int64         // 已移除的會話數
+	closedLifetime time.Duration // 已移除會話的存活時間總和
 }
 
 // NewSDKSessionPool 建立新的會話池
@@ -81,6 +86,7 @@ func (p *SDKSessionPool) CreateSession(sessionID string) (*SDKSession, error) {
 	}
 
 	p.sessions[sessionID] = session
+	p.created++
 	return session, nil
 }
 
@@ -130,8 +136,7 @@ func (p *SDKSessionPool) RemoveSession(sessionID string) error {
 		return fmt.Errorf("session not found")
 	}
 
-	session.Status = SessionClosed
-	delete(p.sessions, sessionID)
+	p.closeSessionUnlocked(session, time.Now())
 	return nil
 }
 
@@ -162,10 +167,9 @@ func (p *SDKSessionPool) CleanupExpiredSessions() int {
 	now := time.Now()
 	count := 0
 
-	for sessionID, session := range p.sessions {
+	for _, session := range p.sessions {
 		if session.Status == SessionActive && now.Sub(session.LastUsed) > p.timeout {
-			session.Status = SessionClosed
-			delete(p.sessions, sessionID)
+			p.closeSessionUnlocked(session, now)
 			count++
 		}
 	}
@@ -178,8 +182,9 @@ func (p *SDKSessionPool) ClearAll() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	now := time.Now()
 	for _, session := range p.sessions {
-		session.Status = SessionClosed
+		p.closeSessionUnlocked(session, now)
 	}
 
 	p.sessions = make(map[string]*SDKSession)
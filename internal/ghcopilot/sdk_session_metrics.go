@@ -0,0 +1,98 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"time"
+)
+
+// SDKSessionMetrics SDK 會話池的彙總指標
+type SDKSessionMetrics struct {
+	TotalCreated    int64         `json:"total_created"`     // 建立過的會話總數
+	Active          int           `json:"active"`            // 目前仍在池中的會話數
+	AverageLifetime time.Duration `json:"average_lifetime"`  // 所有會話（含已移除）的平均存活時間，現存會話以目前為止計算
+	OldestActiveAge time.Duration `json:"oldest_active_age"` // 最舊的現存會話已存在的時間
+}
+
+// closeSessionUnlocked 關閉並移除會話，累計其存活時間（呼叫端需持有寫入鎖）
+func (p *SDKSessionPool) closeSessionUnlocked(session *SDKSession, now time.Time) {
+	session.Status = SessionClosed
+	delete(p.sessions, session.ID)
+	p.closedCount++
+	p.closedLifetime += now.Sub(session.StartTime)
+}
+
+// Metrics 計算會話池的彙總指標
+func (p *SDKSessionPool) Metrics() SDKSessionMetrics {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	now := time.Now()
+	metrics := SDKSessionMetrics{
+		TotalCreated: p.created,
+		Active:       len(p.sessions),
+	}
+	lifetime := p.closedLifetime
+	for _, session := range p.sessions {
+		age := now.Sub(session.StartTime)
+		lifetime += age
+		if age > metrics.OldestActiveAge {
+			metrics.OldestActiveAge = age
+		}
+	}
+	if count := p.closedCount + int64(len(p.sessions)); count > 0 {
+		metrics.AverageLifetime = lifetime / time.Duration(count)
+	}
+	return metrics
+}
+
+// RemoveIdleSessions 移除超過 olderThan 未使用的會話（不論狀態），傳回移除的數量
+//
+// olderThan <= 0 時使用會話池的逾時（SessionTimeout）。
+func (p *SDKSessionPool) RemoveIdleSessions(olderThan time.Duration) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if olderThan <= 0 {
+		olderThan = p.timeout
+	}
+	now := time.Now()
+	count := 0
+	for _, session := range p.sessions {
+		if now.Sub(session.LastUsed) > olderThan {
+			p.closeSessionUnlocked(session, now)
+			count++
+		}
+	}
+	return count
+}
+
+// GetSessionMetrics 取得會話池的彙總指標
+func (e *SDKExecutor) GetSessionMetrics() SDKSessionMetrics {
+	return e.sessions.Metrics()
+}
+
+// TerminateIdleSessions 移除超過 olderThan 未使用的會話，olderThan <= 0 時使用 SessionTimeout
+func (e *SDKExecutor) TerminateIdleSessions(olderThan time.Duration) int {
+	return e.sessions.RemoveIdleSessions(olderThan)
+}
+
+// GetSDKSessionMetrics 取得 SDK 會話的彙總指標（沒有 SDK 執行器時傳回 nil）
+func (c *RalphLoopClient) GetSDKSessionMetrics() *SDKSessionMetrics {
+	if c.sdkExecutor == nil {
+		return nil
+	}
+	metrics := c.sdkExecutor.GetSessionMetrics()
+	return &metrics
+}
+
+// TerminateIdleSessions 終止超過 olderThan 未使用的 SDK 會話，olderThan <= 0 時使用 SessionTimeout
+func (c *RalphLoopClient) TerminateIdleSessions(olderThan time.Duration) (int, error) {
+	if c.sdkExecutor == nil {
+		return 0, fmt.Errorf("SDK executor not available")
+	}
+	n := c.sdkExecutor.TerminateIdleSessions(olderThan)
+	if n > 0 {
+		infoLog("🧹 已終止 %d 個閒置的 SDK 會話", n)
+	}
+	return n, nil
+}
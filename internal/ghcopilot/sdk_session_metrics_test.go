@@ -0,0 +1,83 @@
+package ghcopilot
+
+import (
+	"testing"
+	"time"
+)
+
+// backdateSession 將會話的建立與最後使用時間往前調整
+func backdateSession(t *testing.T, pool *SDKSessionPool, id string, age, idle time.Duration) {
+	t.Helper()
+	if _, err := pool.CreateSession(id); err != nil {
+		t.Fatal(err)
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	now := time.Now()
+	pool.sessions[id].StartTime = now.Add(-age)
+	pool.sessions[id].LastUsed = now.Add(-idle)
+}
+
+// TestSDKSessionPoolMetrics 測試建立總數、現存數、平均存活時間與最舊的會話
+func TestSDKSessionPoolMetrics(t *testing.T) {
+	pool := NewSDKSessionPool(10, time.Minute)
+	if m := pool.Metrics(); m.TotalCreated != 0 || m.AverageLifetime != 0 || m.OldestActiveAge != 0 {
+		t.Fatalf("空的會話池指標應為 0: %+v", m)
+	}
+
+	backdateSession(t, pool, "old", 30*time.Minute, 0)
+	backdateSession(t, pool, "new", 10*time.Minute, 0)
+	backdateSession(t, pool, "done", 20*time.Minute, 0)
+	if err := pool.RemoveSession("done"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := pool.Metrics()
+	if m.TotalCreated != 3 || m.Active != 2 {
+		t.Errorf("應建立 3 個、現存 2 個，實際 %+v", m)
+	}
+	if m.OldestActiveAge < 30*time.Minute || m.OldestActiveAge > 31*time.Minute {
+		t.Errorf("最舊的會話約為 30 分鐘，實際 %v", m.OldestActiveAge)
+	}
+	// (30 + 10 + 20) / 3 = 20 分鐘（已移除的會話也計入）
+	if m.AverageLifetime < 20*time.Minute || m.AverageLifetime > 21*time.Minute {
+		t.Errorf("平均存活時間約為 20 分鐘，實際 %v", m.AverageLifetime)
+	}
+}
+
+// TestTerminateIdleSessions 測試只移除閒置超過時限的會話，0 表示使用會話池的逾時
+func TestTerminateIdleSessions(t *testing.T) {
+	client := newScriptedClient(DefaultClientConfig(), "")
+	defer client.Close()
+	pool := NewSDKSessionPool(10, 5*time.Minute)
+	client.sdkExecutor.sessions = pool
+
+	backdateSession(t, pool, "busy", time.Hour, time.Second)
+	backdateSession(t, pool, "idle", time.Hour, 10*time.Minute)
+	backdateSession(t, pool, "stale", time.Hour, 2*time.Hour)
+	if err := pool.UpdateSession("stale", func(s *SDKSession) error { s.Status = SessionIdle; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	pool.sessions["stale"].LastUsed = time.Now().Add(-2 * time.Hour)
+
+	n, err := client.TerminateIdleSessions(time.Hour)
+	if err != nil || n != 1 {
+		t.Fatalf("應只移除閒置超過 1 小時的會話: %d %v", n, err)
+	}
+	if n, _ := client.TerminateIdleSessions(0); n != 1 {
+		t.Errorf("0 應使用 SessionTimeout（5 分鐘）移除 idle，實際 %d 個", n)
+	}
+
+	m := client.GetSDKSessionMetrics()
+	if m == nil || m.Active != 1 || m.TotalCreated != 3 {
+		t.Errorf("應剩下 1 個會話: %+v", m)
+	}
+
+	client.sdkExecutor = nil
+	if client.GetSDKSessionMetrics() != nil {
+		t.Error("沒有 SDK 執行器時應傳回 nil")
+	}
+	if _, err := client.TerminateIdleSessions(0); err == nil {
+		t.Error("沒有 SDK 執行器時應傳回錯誤")
+	}
+}
@@ -0,0 +1,101 @@
+package ghcopilot
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"slices"
+	"unicode"
+)
+
+// ErrSecretLeak 模型輸出疑似包含真實的密碼或金鑰
+var ErrSecretLeak = errors.New("secret detected in model output")
+
+// highEntropyThreshold 高熵 token 的每字元熵門檻（bits）；十六進位雜湊最多 4.0，不會誤判
+const highEntropyThreshold = 4.3
+
+// secretDetector 具名的敏感資料偵測器
+type secretDetector struct {
+	name    string
+	pattern *regexp.Regexp
+	check   func(match string) bool // 額外檢查（nil 表示符合即命中）
+}
+
+// secretDetectors 內建的偵測器，同時用於遮蔽（MaskSecrets）與中止判定（DetectSecret）
+var secretDetectors = []secretDetector{
+	{name: "github-token", pattern: regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{20,}\b`)},
+	{name: "github-pat", pattern: regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{20,}\b`)},
+	{name: "api-key", pattern: regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}\b`)}, // OpenAI / Anthropic 風格
+	{name: "aws-access-key", pattern: regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`)},
+	{name: "bearer-token", pattern: regexp.MustCompile(`(?i)\bBearer\s+[A-Za-z0-9._~+/-]{8,}=*`)},
+	{name: "private-key", pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----(?s:.*?)(?:-----END (?:[A-Z]+ )?PRIVATE KEY-----|\z)`)},
+	{name: "high-entropy", pattern: regexp.MustCompile(`[A-Za-z0-9+=_-]{32,}`), check: isHighEntropy},
+}
+
+// SecretDetectorNames 傳回所有內建偵測器的名稱
+func SecretDetectorNames() []string {
+	names := make([]string, len(secretDetectors))
+	for i, d := range secretDetectors {
+		names[i] = d.name
+	}
+	return names
+}
+
+// DetectSecret 傳回第一個命中的偵測器名稱（不傳回內容本身），沒有命中時為空字串
+//
+// enabled 為空時使用全部偵測器。
+func DetectSecret(text string, enabled []string) string {
+	for _, d := range secretDetectors {
+		if len(enabled) > 0 && !slices.Contains(enabled, d.name) {
+			continue
+		}
+		for _, m := range d.pattern.FindAllString(text, -1) {
+			if d.check == nil || d.check(m) {
+				return d.name
+			}
+		}
+	}
+	return ""
+}
+
+// isHighEntropy 判斷字串是否像隨機產生的 token：同時含字母與數字，且每字元熵高於門檻
+func isHighEntropy(s string) bool {
+	var hasLetter, hasDigit bool
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+		hasLetter = hasLetter || unicode.IsLetter(r)
+		hasDigit = hasDigit || unicode.IsDigit(r)
+	}
+	if !hasLetter || !hasDigit {
+		return false
+	}
+
+	n := float64(len(s))
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= highEntropyThreshold
+}
+
+// checkSecretLeak 檢查輸出是否疑似包含真實的密碼或金鑰，傳回命中的偵測器名稱
+//
+// 命中時只記錄偵測器名稱；儲存的輸出會被遮蔽，避免密碼留在歷史記錄中。
+func (c *RalphLoopClient) checkSecretLeak(execCtx *ExecutionContext, output string) string {
+	if !c.config.AbortOnSecretLeak && !c.config.MaskSensitiveOutput {
+		return ""
+	}
+	detector := DetectSecret(output, c.config.SecretDetectors)
+	if detector == "" {
+		return ""
+	}
+
+	execCtx.SecretDetector = detector
+	execCtx.CLIOutput = MaskSecrets(execCtx.CLIOutput)
+	execCtx.CleanedOutput = MaskSecrets(execCtx.CleanedOutput)
+	execCtx.AddWarning("輸出疑似包含敏感資料（偵測器: %s），已遮蔽記錄中的內容", detector)
+	infoLog("🔐 輸出疑似包含敏感資料（偵測器: %s）", detector)
+	return detector
+}
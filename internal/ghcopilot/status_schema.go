@@ -0,0 +1,45 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+)
+
+// statusBlockSchema CopilotStatus JSON 格式的 JSON Schema
+const statusBlockSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "RALPH_STATUS / COPILOT_STATUS block",
+  "description": "Fields parsed from a ---RALPH_STATUS--- (or ---COPILOT_STATUS---) block.",
+  "type": "object",
+  "properties": {
+    "status": {"type": "string", "description": "STATUS field"},
+    "exit_signal": {"type": "boolean", "description": "EXIT_SIGNAL field; true only when the value is \"true\""},
+    "tasks_done": {"type": "string", "description": "TASKS_DONE field, e.g. \"3/5\""},
+    "reason": {"type": "string", "description": "REASON field"},
+    "raw_block": {"type": "string", "description": "Block content between the markers"},
+    "confidence": {"type": "integer", "minimum": 0, "maximum": 100, "description": "CONFIDENCE field as a percentage; 0 when has_confidence is false"},
+    "has_confidence": {"type": "boolean", "description": "Whether a valid CONFIDENCE field was present"},
+    "extra": {
+      "type": "object",
+      "description": "Other KEY: value lines in the block, e.g. PROGRESS",
+      "propertyNames": {"pattern": "^[A-Z][A-Z0-9_]*$"},
+      "additionalProperties": {"type": "string"}
+    }
+  },
+  "required": ["status", "exit_signal", "tasks_done", "reason", "raw_block", "confidence", "has_confidence"],
+  "additionalProperties": false
+}
+`
+
+// StatusBlockSchema 傳回解析後狀態區塊（CopilotStatus.ToJSON 的輸出）的 JSON Schema
+func StatusBlockSchema() string {
+	return statusBlockSchema
+}
+
+// ToJSON 將解析後的狀態轉為 JSON（格式見 StatusBlockSchema）
+func (s *CopilotStatus) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
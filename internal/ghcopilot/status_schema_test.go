@@ -0,0 +1,93 @@
+package ghcopilot
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestStatusBlockToJSONMatchesSchema 測試 ToJSON 的輸出符合 StatusBlockSchema 的欄位定義
+func TestStatusBlockToJSONMatchesSchema(t *testing.T) {
+	var schema struct {
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal([]byte(StatusBlockSchema()), &schema); err != nil {
+		t.Fatalf("schema 應為合法的 JSON: %v", err)
+	}
+
+	output := "完成\n---RALPH_STATUS---\nSTATUS: COMPLETE\nEXIT_SIGNAL: true\nTASKS_DONE: 5/5\nPROGRESS: 100%\nCONFIDENCE: 90%\n---END_RALPH_STATUS---"
+	status := NewResponseAnalyzer(output).ParseStructuredOutput()
+	data, err := status.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		t.Fatal(err)
+	}
+	jsonTypes := map[string]string{"string": "string", "boolean": "bool", "integer": "float64", "object": "map[string]interface {}"}
+	for key, value := range fields {
+		prop, ok := schema.Properties[key]
+		if !ok {
+			t.Errorf("欄位 %s 不在 schema 中", key)
+			continue
+		}
+		if got := typeName(value); got != jsonTypes[prop.Type] {
+			t.Errorf("欄位 %s 的型別應為 %s，實際 %s", key, prop.Type, got)
+		}
+	}
+	for _, key := range schema.Required {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("必填欄位 %s 不在輸出中", key)
+		}
+	}
+	if fields["confidence"] != float64(90) || fields["extra"].(map[string]interface{})["PROGRESS"] != "100%" {
+		t.Errorf("輸出內容錯誤: %s", data)
+	}
+}
+
+// typeName 傳回 JSON 解碼後值的 Go 型別名稱
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64:
+		return "float64"
+	case map[string]interface{}:
+		return "map[string]interface {}"
+	}
+	return "unknown"
+}
+
+// TestStatusBlockExtraAndMalformed 測試其他欄位、重複欄位與缺少結束標記的區塊
+func TestStatusBlockExtraAndMalformed(t *testing.T) {
+	output := "---COPILOT_STATUS---\nSTATUS: IN_PROGRESS\nPROGRESS: 40%\nEXIT_SIGNAL: false\nNEXT_STEP: run tests\nEXIT_SIGNAL: true\nPROGRESS: 60%\nnot a field\n---END_STATUS---"
+	status := NewResponseAnalyzer(output).ParseStructuredOutput()
+	if status == nil {
+		t.Fatal("應解析狀態區塊")
+	}
+	if !status.ExitSignal {
+		t.Error("重複的 EXIT_SIGNAL 應以最後一個為準")
+	}
+	if len(status.Extra) != 2 || status.Extra["PROGRESS"] != "60%" || status.Extra["NEXT_STEP"] != "run tests" {
+		t.Errorf("應收集其他欄位（重複時以最後一個為準）: %v", status.Extra)
+	}
+
+	if status := NewResponseAnalyzer("---RALPH_STATUS---\nSTATUS: DONE\nREASON: ok\n---END_RALPH_STATUS---").ParseStructuredOutput(); status.Extra != nil {
+		t.Errorf("沒有其他欄位時 Extra 應為 nil: %v", status.Extra)
+	}
+
+	missingEnd := "---RALPH_STATUS---\nEXIT_SIGNAL: true\nPROGRESS: 100%\n"
+	if status := NewResponseAnalyzer(missingEnd).ParseStructuredOutput(); status != nil {
+		t.Errorf("缺少結束標記的區塊不應解析: %+v", status)
+	}
+	loose := NewResponseAnalyzerWithProfile(missingEnd, SDKParserProfile()).ParseStructuredOutput()
+	if loose == nil || !loose.ExitSignal {
+		t.Errorf("SDK 解析設定應從缺少結束標記的區塊讀出 EXIT_SIGNAL: %+v", loose)
+	}
+}
@@ -0,0 +1,91 @@
+package ghcopilot
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// ThrottledWriter 合併串流輸出，依時間間隔或位元組門檻批次寫入底層 writer
+//
+// 模型輸出很快時，每個小片段都同步寫入終端會產生大量 write 系統呼叫並拖慢執行。
+// ThrottledWriter 先將資料累積在緩衝區，累積達 maxBytes 或距離上次寫入超過
+// interval 時才一次寫出；順序與內容不變。串流結束時必須呼叫 Flush 寫出剩餘資料。
+// interval 與 maxBytes 都為 0 時直接轉寫，不做任何緩衝。
+type ThrottledWriter struct {
+	mu       sync.Mutex
+	dst      io.Writer
+	buf      []byte
+	interval time.Duration
+	maxBytes int
+	timer    *time.Timer // 緩衝區有資料時，interval 後自動寫出
+	writes   int         // 對底層 writer 的實際寫入次數
+	err      error       // 第一個寫入錯誤
+}
+
+// NewThrottledWriter 建立新的節流 writer
+func NewThrottledWriter(dst io.Writer, interval time.Duration, maxBytes int) *ThrottledWriter {
+	return &ThrottledWriter{
+		dst:      dst,
+		interval: interval,
+		maxBytes: maxBytes,
+	}
+}
+
+// Write 實作 io.Writer；資料一律接受，錯誤在下一次 Write 或 Flush 時回報
+func (tw *ThrottledWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.err != nil {
+		return 0, tw.err
+	}
+	if tw.interval <= 0 && tw.maxBytes <= 0 {
+		tw.writes++
+		return tw.dst.Write(p)
+	}
+
+	tw.buf = append(tw.buf, p...)
+	if tw.maxBytes > 0 && len(tw.buf) >= tw.maxBytes {
+		tw.flushLocked()
+	} else if tw.interval > 0 && tw.timer == nil {
+		tw.timer = time.AfterFunc(tw.interval, func() {
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timer = nil
+			tw.flushLocked()
+		})
+	}
+	return len(p), tw.err
+}
+
+// Flush 寫出緩衝區中剩餘的資料
+func (tw *ThrottledWriter) Flush() error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.flushLocked()
+	return tw.err
+}
+
+// Writes 傳回對底層 writer 的實際寫入次數
+func (tw *ThrottledWriter) Writes() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.writes
+}
+
+// flushLocked 寫出緩衝區（呼叫端需持有鎖）
+func (tw *ThrottledWriter) flushLocked() {
+	if tw.timer != nil {
+		tw.timer.Stop()
+		tw.timer = nil
+	}
+	if len(tw.buf) == 0 {
+		return
+	}
+	tw.writes++
+	if _, err := tw.dst.Write(tw.buf); err != nil && tw.err == nil {
+		tw.err = err
+	}
+	tw.buf = tw.buf[:0]
+}
@@ -0,0 +1,147 @@
+package ghcopilot
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingWriter 記錄寫入次數與內容
+type countingWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func (w *countingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+// TestThrottledWriterCoalesces 測試依位元組門檻合併寫入，並保留順序
+func TestThrottledWriterCoalesces(t *testing.T) {
+	dst := &countingWriter{}
+	tw := NewThrottledWriter(dst, 0, 10)
+
+	var want strings.Builder
+	for i := 0; i < 25; i++ {
+		chunk := string(rune('a' + i%26))
+		want.WriteString(chunk)
+		if _, err := tw.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dst.writes != 2 {
+		t.Errorf("25 個位元組、門檻 10 應寫出 2 次，實際 %d 次", dst.writes)
+	}
+
+	if err := tw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if dst.String() != want.String() {
+		t.Errorf("內容或順序不符: %q", dst.String())
+	}
+	if tw.Writes() != 3 {
+		t.Errorf("Flush 應寫出最後的部分緩衝，總共 3 次，實際 %d 次", tw.Writes())
+	}
+}
+
+// TestThrottledWriterInterval 測試串流停頓時在間隔後自動寫出
+func TestThrottledWriterInterval(t *testing.T) {
+	dst := &countingWriter{}
+	tw := NewThrottledWriter(dst, 20*time.Millisecond, 0)
+
+	_, _ = tw.Write([]byte("hello "))
+	_, _ = tw.Write([]byte("world"))
+	if dst.String() != "" {
+		t.Fatal("間隔內不應寫出")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for dst.String() == "" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if dst.String() != "hello world" || tw.Writes() != 1 {
+		t.Errorf("間隔後應合併寫出一次，實際 %q（%d 次）", dst.String(), tw.Writes())
+	}
+}
+
+// TestThrottledWriterPassthrough 測試未設定節流時直接轉寫
+func TestThrottledWriterPassthrough(t *testing.T) {
+	dst := &countingWriter{}
+	tw := NewThrottledWriter(dst, 0, 0)
+	_, _ = tw.Write([]byte("a"))
+	_, _ = tw.Write([]byte("b"))
+	if dst.writes != 2 || dst.String() != "ab" {
+		t.Errorf("未節流時應逐次寫出，實際 %d 次 %q", dst.writes, dst.String())
+	}
+}
+
+// TestStreamThrottleKeepsFullOutput 測試節流不影響擷取到的完整輸出
+func TestStreamThrottleKeepsFullOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("使用 shell 腳本模擬 copilot，Windows 上略過")
+	}
+	t.Setenv("COPILOT_MOCK_MODE", "")
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\nfor i in 1 2 3 4 5 6 7 8 9 10; do printf 'chunk-%s ' $i; done\necho done\n"
+	if err := os.WriteFile(filepath.Join(dir, "copilot"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	executor := NewCLIExecutor(dir)
+	executor.SetStreamThrottle(time.Hour, 1<<20)
+	result, err := executor.ExecutePrompt(context.Background(), "test")
+	if err != nil || !result.Success {
+		t.Fatalf("執行失敗: %v %+v", err, result)
+	}
+	if !strings.Contains(result.Stdout, "chunk-1 ") || !strings.HasSuffix(strings.TrimSpace(result.Stdout), "chunk-10 done") {
+		t.Errorf("應擷取完整輸出: %q", result.Stdout)
+	}
+}
+
+// BenchmarkStreamWrites 比較節流前後對終端的寫入次數（writes/op）
+func BenchmarkStreamWrites(b *testing.B) {
+	chunk := []byte("token ")
+	const chunksPerStream = 2000
+
+	for _, bc := range []struct {
+		name     string
+		interval time.Duration
+		maxBytes int
+	}{
+		{"unthrottled", 0, 0},
+		{"4KiB", 0, 4096},
+		{"50ms+4KiB", 50 * time.Millisecond, 4096},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			totalWrites := 0
+			for i := 0; i < b.N; i++ {
+				dst := &countingWriter{}
+				tw := NewThrottledWriter(dst, bc.interval, bc.maxBytes)
+				for j := 0; j < chunksPerStream; j++ {
+					_, _ = tw.Write(chunk)
+				}
+				_ = tw.Flush()
+				totalWrites += tw.Writes()
+			}
+			b.ReportMetric(float64(totalWrites)/float64(b.N), "writes/op")
+		})
+	}
+}
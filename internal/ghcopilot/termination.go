@@ -0,0 +1,88 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// DefaultTerminateFlushTimeout 收到終止信號時寫入狀態的預設時限（需短於容器的終止寬限期）
+const DefaultTerminateFlushTimeout = 5 * time.Second
+
+// Flush 立即寫入目前的迴圈歷史（含執行中的迴圈）與重試統計，最多等待 timeout
+//
+// 可以在另一個 goroutine 執行迴圈時呼叫；逾時時寫入仍在背景進行，但立即回傳錯誤。
+func (c *RalphLoopClient) Flush(timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultTerminateFlushTimeout
+	}
+	done := make(chan error, 1)
+	go func() { done <- c.flushState() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("寫入狀態超過 %v", timeout)
+	}
+}
+
+// flushState 寫入迴圈歷史、執行中的迴圈與重試統計
+//
+// 持有迴圈狀態鎖，迴圈正在更新執行上下文時會等到更新完成。
+func (c *RalphLoopClient) flushState() error {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	var errs []error
+	if c.persistEnabled() {
+		if current := c.contextManager.GetCurrentLoop(); current != nil {
+			if err := c.backend.SaveExecutionContext(current); err != nil {
+				errs = append(errs, fmt.Errorf("儲存執行中的迴圈失敗: %w", err))
+			}
+		}
+		if err := c.backend.SaveContextManager(c.contextManager); err != nil {
+			errs = append(errs, fmt.Errorf("儲存上下文管理器失敗: %w", err))
+		}
+	}
+	if c.retryClassifier != nil && c.config.EnablePersistence && !c.persistenceDisabled {
+		if err := c.retryClassifier.Save(c.retryStatsPath()); err != nil {
+			errs = append(errs, fmt.Errorf("儲存重試統計失敗: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HandleTermination 收到 signals 時先寫入狀態（FlushOnTerminate），再呼叫 cancel 停止執行中的迴圈
+//
+// 回傳的函式停止監聽信號。
+func (c *RalphLoopClient) HandleTermination(cancel context.CancelFunc, signals ...os.Signal) (stop func()) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, signals...)
+	done := make(chan struct{})
+	go c.watchTermination(sigCh, cancel, done)
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
+
+// watchTermination 等待第一個信號：寫入狀態後取消執行
+func (c *RalphLoopClient) watchTermination(sigCh <-chan os.Signal, cancel context.CancelFunc, done <-chan struct{}) {
+	select {
+	case sig := <-sigCh:
+		infoLog("🛑 收到 %v，正在停止...", sig)
+		if c.config.FlushOnTerminate {
+			if err := c.Flush(c.config.TerminateFlushTimeout); err != nil {
+				infoLog("⚠️ 終止前寫入狀態失敗: %v", err)
+			} else {
+				infoLog("💾 已在終止前寫入迴圈歷史")
+			}
+		}
+		cancel()
+	case <-done:
+	}
+}
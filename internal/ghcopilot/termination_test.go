@@ -0,0 +1,118 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestTerminationFlushesMidRun 測試執行中收到 SIGTERM 時先寫入迴圈歷史再取消
+func TestTerminationFlushesMidRun(t *testing.T) {
+	config := DefaultClientConfig()
+	config.SaveDir = t.TempDir()
+	config.Silent = true
+	client := NewRalphLoopClientWithConfig(config)
+	defer client.Close()
+
+	sigCh := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	flushedBeforeCancel := make(chan bool, 1)
+	cancelAfterFlush := func() {
+		// 執行中的第 2 個迴圈（索引 1）只會由終止前的寫入保存
+		files, _ := filepath.Glob(filepath.Join(config.SaveDir, "loop_loop-*-1.json"))
+		flushedBeforeCancel <- len(files) > 0
+		cancel()
+	}
+	go client.watchTermination(sigCh, cancelAfterFlush, make(chan struct{}))
+
+	loop := 0
+	client.cliRunner = func(runCtx context.Context, prompt string) (*ExecutionResult, error) {
+		loop++
+		if loop == 2 {
+			// 第 2 個迴圈執行中收到 SIGTERM，等待取消
+			sigCh <- syscall.SIGTERM
+			<-runCtx.Done()
+			return nil, runCtx.Err()
+		}
+		out := fmt.Sprintf("迴圈 %d\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---", loop)
+		return &ExecutionResult{Command: "copilot", Stdout: out}, nil
+	}
+
+	_, _ = client.ExecuteUntilCompletion(ctx, "test", 5)
+
+	select {
+	case ok := <-flushedBeforeCancel:
+		if !ok {
+			t.Error("取消前應已寫入執行中的迴圈")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("收到信號後應取消執行")
+	}
+}
+
+// TestTerminationWithoutFlush 測試停用 FlushOnTerminate 時只取消執行
+func TestTerminationWithoutFlush(t *testing.T) {
+	config := DefaultClientConfig()
+	config.SaveDir = t.TempDir()
+	config.FlushOnTerminate = false
+	client := NewRalphLoopClientWithConfig(config)
+
+	sigCh := make(chan os.Signal, 1)
+	cancelled := make(chan struct{})
+	go client.watchTermination(sigCh, func() { close(cancelled) }, make(chan struct{}))
+	sigCh <- syscall.SIGTERM
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("收到信號後應取消執行")
+	}
+	if files, _ := filepath.Glob(filepath.Join(config.SaveDir, "*")); len(files) != 0 {
+		t.Errorf("停用 FlushOnTerminate 時不應寫入: %v", files)
+	}
+}
+
+// TestFlushDuringLoop 測試迴圈更新執行上下文時從其他 goroutine 呼叫 Flush（以 -race 執行確認沒有資料競爭）
+func TestFlushDuringLoop(t *testing.T) {
+	config := DefaultClientConfig()
+	config.SaveDir = t.TempDir()
+	config.Silent = true
+	client := NewRalphLoopClientWithConfig(config)
+	defer client.Close()
+
+	loop := 0
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		loop++
+		out := fmt.Sprintf("迴圈 %d\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---", loop)
+		return &ExecutionResult{Command: "copilot", Stdout: out}, nil
+	}
+
+	stop := make(chan struct{})
+	flushed := make(chan int)
+	go func() {
+		n := 0
+		for {
+			select {
+			case <-stop:
+				flushed <- n
+				return
+			default:
+			}
+			if err := client.Flush(time.Second); err != nil {
+				t.Errorf("Flush 失敗: %v", err)
+			}
+			n++
+		}
+	}()
+
+	_, _ = client.ExecuteUntilCompletion(context.Background(), "test", 20)
+	close(stop)
+	if n := <-flushed; n == 0 {
+		t.Error("迴圈執行期間應至少寫入一次")
+	}
+}
@@ -0,0 +1,63 @@
+package ghcopilot
+
+import (
+	"unicode/utf8"
+)
+
+// estimateLoopTokens 估算單一迴圈的 prompt 與輸出佔用的 token 數（字元數 / charsPerToken）
+func estimateLoopTokens(ctx *ExecutionContext) int {
+	return (utf8.RuneCountInString(ctx.UserPrompt) + utf8.RuneCountInString(ctx.CLIOutput)) / charsPerToken
+}
+
+// EstimatedTokens 估算記憶體中所有迴圈的 prompt 與輸出佔用的 token 數（字元數 / 4）
+func (cm *ContextManager) EstimatedTokens() int {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.estimatedTokensUnlocked()
+}
+
+// estimatedTokensUnlocked 內部使用的 token 估算（不加鎖）
+func (cm *ContextManager) estimatedTokensUnlocked() int {
+	total := 0
+	for _, ctx := range cm.loopHistory {
+		total += estimateLoopTokens(ctx)
+	}
+	return total
+}
+
+// SetMaxTokenBudget 設定歷史記錄的 token 上限，0 表示不限制
+//
+// 超過上限時從最舊的迴圈開始移出（與 MaxHistorySize 相同，設定 spillStore 時寫入磁碟），
+// 但至少保留最新的一個迴圈。
+func (cm *ContextManager) SetMaxTokenBudget(tokens int) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.maxTokenBudget = tokens
+	cm.pruneTokenBudgetUnlocked()
+}
+
+// pruneTokenBudgetUnlocked 移出最舊的迴圈直到不超過 token 上限，傳回移出的迴圈數（呼叫端需持有寫入鎖）
+func (cm *ContextManager) pruneTokenBudgetUnlocked() int {
+	if cm.maxTokenBudget <= 0 {
+		return 0
+	}
+	total := cm.estimatedTokensUnlocked()
+	n := 0
+	for total > cm.maxTokenBudget && n < len(cm.loopHistory)-1 {
+		total -= estimateLoopTokens(cm.loopHistory[n])
+		n++
+	}
+	if n > 0 {
+		cm.evictUnlocked(n)
+		cm.tokenPrunedLoops += n
+	}
+	return n
+}
+
+// TokenPrunedLoops 傳回因超過 token 上限而移出的迴圈數
+func (cm *ContextManager) TokenPrunedLoops() int {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.tokenPrunedLoops
+}
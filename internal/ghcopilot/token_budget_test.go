@@ -0,0 +1,96 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// addTokenLoop 加入一個輸出約 tokens 個 token（prompt 為空）的迴圈
+func addTokenLoop(cm *ContextManager, index, tokens int) {
+	cm.StartLoop(index, "")
+	cm.UpdateCurrentLoop(func(ctx *ExecutionContext) {
+		ctx.CLIOutput = strings.Repeat("x", tokens*charsPerToken)
+		ctx.ShouldContinue = true
+	})
+	cm.FinishLoop()
+}
+
+// TestMaxTokenBudgetBoundary 測試剛好達到上限時不移出，超過時從最舊的迴圈開始移出
+func TestMaxTokenBudgetBoundary(t *testing.T) {
+	cm := NewContextManager()
+	cm.SetMaxTokenBudget(300)
+
+	for i := 0; i < 3; i++ {
+		addTokenLoop(cm, i, 100)
+	}
+	if got := cm.EstimatedTokens(); got != 300 || len(cm.GetLoopHistory()) != 3 {
+		t.Fatalf("剛好 300 tokens 時不應移出: %d tokens，%d 個迴圈", got, len(cm.GetLoopHistory()))
+	}
+
+	addTokenLoop(cm, 3, 100)
+	history := cm.GetLoopHistory()
+	if len(history) != 3 || history[0].LoopIndex != 1 || cm.EstimatedTokens() != 300 {
+		t.Errorf("超過上限時應移出最舊的迴圈: %d 個迴圈，第一個為 %d", len(history), history[0].LoopIndex)
+	}
+
+	addTokenLoop(cm, 4, 250)
+	history = cm.GetLoopHistory()
+	if len(history) != 1 || history[0].LoopIndex != 4 {
+		t.Errorf("大迴圈應移出所有較舊的迴圈: %d 個迴圈", len(history))
+	}
+	if cm.TokenPrunedLoops() != 4 {
+		t.Errorf("應累計移出 4 個迴圈，實際 %d", cm.TokenPrunedLoops())
+	}
+
+	addTokenLoop(cm, 5, 500)
+	if history = cm.GetLoopHistory(); len(history) != 1 || history[0].LoopIndex != 5 {
+		t.Errorf("單一迴圈超過上限時仍應保留最新的迴圈: %+v", history)
+	}
+
+	summary := cm.GetSummary()
+	if summary["estimated_tokens"] != 500 || summary["max_token_budget"] != 300 {
+		t.Errorf("摘要應包含 token 用量: %v / %v", summary["estimated_tokens"], summary["max_token_budget"])
+	}
+}
+
+// TestSetMaxTokenBudgetPrunesExisting 測試設定上限時立即移出既有的迴圈，0 表示不限制
+func TestSetMaxTokenBudgetPrunesExisting(t *testing.T) {
+	cm := NewContextManager()
+	for i := 0; i < 5; i++ {
+		addTokenLoop(cm, i, 100)
+	}
+	if got := cm.EstimatedTokens(); got != 500 {
+		t.Fatalf("應估算為 500 tokens，實際 %d", got)
+	}
+
+	cm.SetMaxTokenBudget(250)
+	if history := cm.GetLoopHistory(); len(history) != 2 || history[0].LoopIndex != 3 {
+		t.Errorf("應只保留最新的 2 個迴圈: %d 個", len(history))
+	}
+
+	cm.SetMaxTokenBudget(0)
+	addTokenLoop(cm, 5, 1000)
+	if len(cm.GetLoopHistory()) != 3 {
+		t.Errorf("上限為 0 時不應移出迴圈")
+	}
+}
+
+// TestClientMaxTokenBudget 測試客戶端依 MaxTokenBudget 移出舊迴圈
+func TestClientMaxTokenBudget(t *testing.T) {
+	config := DefaultClientConfig()
+	config.MaxTokenBudget = 200
+	client, _ := newStepClient(config, func(call int, prompt string) (string, error) {
+		return pendingOutput(fmt.Sprintf("迴圈 %d %s", call, strings.Repeat("x", 400))), nil
+	})
+
+	results, _ := client.ExecuteUntilCompletion(context.Background(), "產生報告", 4)
+	if len(results) != 4 {
+		t.Fatalf("應執行 4 個迴圈，實際 %d", len(results))
+	}
+	cm := client.contextManager
+	if cm.TokenPrunedLoops() == 0 || len(cm.GetLoopHistory()) >= 4 || (cm.EstimatedTokens() > 200 && len(cm.GetLoopHistory()) > 1) {
+		t.Errorf("應移出舊迴圈以維持上限: 移出 %d 個，目前 %d tokens", cm.TokenPrunedLoops(), cm.EstimatedTokens())
+	}
+}
@@ -0,0 +1,111 @@
+package ghcopilot
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrRepeatedToolFailure 模型反覆執行同一個命令且每次以相同方式失敗（MaxIdenticalToolFailures）
+var ErrRepeatedToolFailure = errors.New("repeated identical tool failure")
+
+// ToolInvocation 輸出中的一次命令執行（copilot CLI 以 "$ <命令>" 顯示，後面接著命令的輸出）
+type ToolInvocation struct {
+	Command string
+	Output  string
+	Failed  bool
+}
+
+var (
+	// toolFailurePattern 命令輸出中代表失敗的內容
+	toolFailurePattern = regexp.MustCompile(`(?im)exit(?:ed)?\s+(?:with\s+)?(?:code|status)\s*[1-9]\d*|^\s*(?:---\s*)?FAIL\b|command failed`)
+	// toolDurationPattern 命令輸出中的執行時間（比對失敗是否相同時忽略）
+	toolDurationPattern = regexp.MustCompile(`\b\d+(?:\.\d+)?(?:ms|s|m)\b`)
+)
+
+// isToolMarker 判斷是否為工具呼叫的狀態行（✓ 成功、✗ 失敗、● 執行中）
+func isToolMarker(line string) bool {
+	return strings.HasPrefix(line, "✓") || strings.HasPrefix(line, "✗") || strings.HasPrefix(line, "●")
+}
+
+// ParseToolInvocations 從輸出中解析命令執行
+//
+// 命令行以 "$ " 開頭，輸出延續到空行、下一個命令、工具狀態行或 RALPH_STATUS 區塊為止。
+// 前一行為 "✗" 狀態行，或輸出包含非零退出碼、FAIL 等內容時視為失敗。
+func ParseToolInvocations(output string) []ToolInvocation {
+	var invocations []ToolInvocation
+	var current *ToolInvocation
+	var body []string
+	failedMark := false
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Output = strings.Join(body, "\n")
+		current.Failed = current.Failed || toolFailurePattern.MatchString(current.Output)
+		invocations = append(invocations, *current)
+		current, body = nil, nil
+	}
+
+	for _, raw := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case strings.HasPrefix(line, "$ "):
+			flush()
+			current = &ToolInvocation{Command: strings.TrimSpace(line[2:]), Failed: failedMark}
+			failedMark = false
+		case isToolMarker(line):
+			flush()
+			failedMark = strings.HasPrefix(line, "✗")
+		case line == "" || strings.HasPrefix(line, "---RALPH_STATUS---"):
+			flush()
+			failedMark = false
+		case current != nil:
+			body = append(body, line)
+		}
+	}
+	flush()
+	return invocations
+}
+
+// toolFailureKey 相同命令且失敗輸出相同（忽略行號、路徑與執行時間）的鍵
+func toolFailureKey(inv ToolInvocation) string {
+	output := toolDurationPattern.ReplaceAllString(inv.Output, "")
+	return inv.Command + "\x00" + NewResponseAnalyzer("").normalizeError(output)
+}
+
+// checkToolFailures 累計本次執行中相同的命令失敗，達到 MaxIdenticalToolFailures 時傳回該命令
+//
+// 同一個命令成功執行後，先前的失敗次數歸零。
+func (c *RalphLoopClient) checkToolFailures(output string) string {
+	if c.config.MaxIdenticalToolFailures <= 0 {
+		return ""
+	}
+	if c.toolFailures == nil {
+		c.toolFailures = make(map[string]int)
+	}
+
+	for _, inv := range ParseToolInvocations(output) {
+		if !inv.Failed {
+			for key := range c.toolFailures {
+				if strings.HasPrefix(key, inv.Command+"\x00") {
+					delete(c.toolFailures, key)
+				}
+			}
+			continue
+		}
+		key := toolFailureKey(inv)
+		c.toolFailures[key]++
+		if c.toolFailures[key] >= c.config.MaxIdenticalToolFailures {
+			return inv.Command
+		}
+	}
+	return ""
+}
+
+// toolFailureReason 因相同的命令失敗而中止時的原因
+func (c *RalphLoopClient) toolFailureReason(command string) string {
+	return fmt.Sprintf("命令 `%s` 已 %d 次以相同方式失敗，中止執行", command, c.config.MaxIdenticalToolFailures)
+}
@@ -0,0 +1,87 @@
+package ghcopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// failingGoTest 模擬 copilot CLI 顯示一次失敗的 go test（執行時間每次不同）
+func failingGoTest(loop int) string {
+	return fmt.Sprintf("✗ Run tests\n  $ go test ./...\n  --- FAIL: TestParse (0.0%ds)\n  FAIL\tgithub.com/example/app\t0.%03ds\n  Command exited with code 1\n", loop, loop*7)
+}
+
+// TestParseToolInvocations 測試解析命令、輸出與失敗狀態
+func TestParseToolInvocations(t *testing.T) {
+	output := "先執行建置\n✓ Build\n  $ go build ./...\n\n" + failingGoTest(1) + "\n$ git status\nnothing to commit\n"
+	invocations := ParseToolInvocations(output)
+	if len(invocations) != 3 {
+		t.Fatalf("應解析出 3 個命令，實際 %+v", invocations)
+	}
+
+	want := []struct {
+		command string
+		failed  bool
+	}{{"go build ./...", false}, {"go test ./...", true}, {"git status", false}}
+	for i, w := range want {
+		if invocations[i].Command != w.command || invocations[i].Failed != w.failed {
+			t.Errorf("命令 %d 應為 %q failed=%v，實際 %+v", i, w.command, w.failed, invocations[i])
+		}
+	}
+	if !strings.Contains(invocations[1].Output, "--- FAIL: TestParse") {
+		t.Errorf("應保留命令的輸出: %q", invocations[1].Output)
+	}
+}
+
+// TestMaxIdenticalToolFailuresAborts 測試同一個命令反覆以相同方式失敗時中止並指出命令
+func TestMaxIdenticalToolFailuresAborts(t *testing.T) {
+	config := DefaultClientConfig()
+	config.MaxIdenticalToolFailures = 3
+	client := newScriptedClient(config, "")
+
+	loop := 0
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		loop++
+		out := fmt.Sprintf("嘗試修正 parser（第 %d 次）\n%s\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---", loop, failingGoTest(loop))
+		return &ExecutionResult{Command: "copilot", Stdout: out}, nil
+	}
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "修正測試", 10)
+	if !errors.Is(err, ErrRepeatedToolFailure) || !strings.Contains(err.Error(), "go test ./...") {
+		t.Fatalf("應回傳指出 go test 的 ErrRepeatedToolFailure，實際 %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("第 3 次相同失敗後應中止，實際執行 %d 個迴圈", len(results))
+	}
+	last := results[2]
+	if last.RepeatedToolFailure != "go test ./..." || !strings.Contains(last.ExitReason, "`go test ./...`") {
+		t.Errorf("結果應記錄失敗的命令: %+v", last)
+	}
+}
+
+// TestMaxIdenticalToolFailuresResetOnSuccess 測試命令成功或失敗方式改變時不中止
+func TestMaxIdenticalToolFailuresResetOnSuccess(t *testing.T) {
+	config := DefaultClientConfig()
+	config.MaxIdenticalToolFailures = 2
+	client := newScriptedClient(config, "")
+
+	outputs := []string{
+		failingGoTest(1),
+		"✓ Run tests\n  $ go test ./...\n  ok\tgithub.com/example/app\t0.1s\n",
+		failingGoTest(3),
+		"✗ Run tests\n  $ go test ./...\n  --- FAIL: TestOther\n",
+	}
+	loop := 0
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		out := fmt.Sprintf("迴圈 %d\n%s\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---", loop+1, outputs[loop])
+		loop++
+		return &ExecutionResult{Command: "copilot", Stdout: out}, nil
+	}
+
+	_, err := client.ExecuteUntilCompletion(context.Background(), "修正測試", len(outputs))
+	if errors.Is(err, ErrRepeatedToolFailure) {
+		t.Fatalf("成功執行後或失敗方式不同時不應中止: %v", err)
+	}
+}
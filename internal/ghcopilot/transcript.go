@@ -0,0 +1,137 @@
+package ghcopilot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExportTranscript 將整次執行的迴圈歷史匯出為 Markdown 紀錄
+//
+// 與 ExportHistory 的 JSON 不同，這份紀錄是給人閱讀與分享用的：
+// 包含執行摘要標頭、目錄，以及每個迴圈的 prompt、模型、輸出與決策。
+func (c *RalphLoopClient) ExportTranscript(path string) error {
+	if !c.initialized {
+		return fmt.Errorf("client not initialized")
+	}
+	if path == "" {
+		return fmt.Errorf("transcript path is empty")
+	}
+
+	content := renderTranscript(c.contextManager.GetLoopHistory(), time.Now(), c.config.MaskSensitiveOutput)
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("無法建立紀錄目錄: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return fmt.Errorf("無法寫入執行紀錄: %w", err)
+	}
+	return nil
+}
+
+// renderTranscript 產生 Markdown 格式的執行紀錄；mask 為 true 時遮蔽 prompt、輸出與警告中的密碼與 token
+func renderTranscript(history []*ExecutionContext, generatedAt time.Time, mask bool) string {
+	var sb strings.Builder
+	masked := func(s string) string {
+		if mask {
+			return MaskSecrets(s)
+		}
+		return s
+	}
+
+	sb.WriteString("# Ralph Loop 執行紀錄\n\n")
+
+	// 執行摘要
+	fmt.Fprintf(&sb, "- 產生時間: %s\n", generatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "- 總迴圈數: %d\n", len(history))
+	if len(history) > 0 {
+		first := history[0]
+		last := history[len(history)-1]
+		end := last.Timestamp.Add(time.Duration(last.DurationMs) * time.Millisecond)
+		fmt.Fprintf(&sb, "- 開始時間: %s\n", first.Timestamp.Format(time.RFC3339))
+		fmt.Fprintf(&sb, "- 總耗時: %v\n", end.Sub(first.Timestamp).Round(time.Millisecond))
+		fmt.Fprintf(&sb, "- 最終狀態: %s\n", transcriptDecision(last))
+	}
+	sb.WriteString("\n")
+
+	// 目錄
+	sb.WriteString("## 目錄\n\n")
+	for i, loop := range history {
+		fmt.Fprintf(&sb, "%d. [迴圈 %d](#loop-%d) - %s\n", i+1, i+1, i+1, transcriptDecision(loop))
+	}
+	sb.WriteString("\n")
+
+	// 每個迴圈
+	for i, loop := range history {
+		fmt.Fprintf(&sb, "<a id=\"loop-%d\"></a>\n\n", i+1)
+		fmt.Fprintf(&sb, "## 迴圈 %d\n\n", i+1)
+		fmt.Fprintf(&sb, "- Loop ID: `%s`\n", loop.LoopID)
+		if loop.Model != "" {
+			fmt.Fprintf(&sb, "- 模型: %s\n", loop.Model)
+		}
+		fmt.Fprintf(&sb, "- 時間: %s\n", loop.Timestamp.Format(time.RFC3339))
+		fmt.Fprintf(&sb, "- 耗時: %dms\n", loop.DurationMs)
+		fmt.Fprintf(&sb, "- 完成分數: %d\n", loop.CompletionScore)
+		fmt.Fprintf(&sb, "- 決策: %s\n", transcriptDecision(loop))
+		for _, w := range loop.Warnings {
+			fmt.Fprintf(&sb, "- ⚠️ 警告: %s\n", masked(w))
+		}
+		sb.WriteString("\n")
+
+		if loop.RenderedCommand != "" {
+			sb.WriteString("### 命令\n\n")
+			writeFencedBlock(&sb, masked(loop.RenderedCommand))
+		}
+
+		sb.WriteString("### Prompt\n\n")
+		writeFencedBlock(&sb, masked(transcriptPrompt(loop)))
+
+		sb.WriteString("### 輸出\n\n")
+		writeFencedBlock(&sb, masked(loop.CLIOutput))
+	}
+
+	return sb.String()
+}
+
+// transcriptPrompt 傳回使用者原本的 prompt（移除模型前綴與附加的格式要求等說明）
+func transcriptPrompt(loop *ExecutionContext) string {
+	prompt := []rune(loop.UserPrompt)
+	if n := loop.PromptSuffixChars; n > 0 && n <= len(prompt) {
+		prompt = prompt[:len(prompt)-n]
+	}
+	if n := loop.PromptPrefixChars; n > 0 && n+2 <= len(prompt) {
+		prompt = prompt[n+2:] // 前綴與 prompt 之間以空行分隔
+	}
+	if loop.PromptSuffixChars > 0 {
+		return string(prompt)
+	}
+	// 舊版紀錄沒有附加說明的字數，只能移除狀態格式說明
+	return strings.TrimSuffix(strings.TrimSuffix(string(prompt), confidenceSuffix), ralphStatusSuffix)
+}
+
+// transcriptDecision 描述迴圈的決策結果
+func transcriptDecision(loop *ExecutionContext) string {
+	decision := "完成"
+	if loop.ShouldContinue {
+		decision = "繼續"
+	}
+	if loop.ExitReason != "" {
+		decision += fmt.Sprintf(" (%s)", loop.ExitReason)
+	}
+	return decision
+}
+
+// writeFencedBlock 以足夠長的反引號圍欄包住內容，避免內容中的 ``` 破壞格式
+func writeFencedBlock(sb *strings.Builder, content string) {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	sb.WriteString(fence + "text\n")
+	sb.WriteString(strings.TrimRight(content, "\n"))
+	sb.WriteString("\n" + fence + "\n\n")
+}
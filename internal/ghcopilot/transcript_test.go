@@ -0,0 +1,108 @@
+package ghcopilot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExportTranscript 測試匯出 Markdown 執行紀錄
+func TestExportTranscript(t *testing.T) {
+	config := DefaultClientConfig()
+	config.EnablePersistence = false
+	config.Silent = true
+
+	client := NewRalphLoopClientWithConfig(config)
+	outputs := []string{
+		"還在處理\n```go\nfmt.Println(\"hi\")\n```\n---RALPH_STATUS---\nEXIT_SIGNAL: false\nREASON: 進行中\n---END_RALPH_STATUS---",
+		"完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: 全部完成\n---END_RALPH_STATUS---",
+	}
+	call := 0
+	client.cliRunner = func(ctx context.Context, prompt string) (*ExecutionResult, error) {
+		out := outputs[call]
+		call++
+		if call == 1 {
+			return &ExecutionResult{Command: "copilot", Stdout: out, Stderr: "deprecated flag"}, nil
+		}
+		return &ExecutionResult{Command: "copilot", Stdout: out}, nil
+	}
+
+	if _, err := client.ExecuteUntilCompletion(context.Background(), "修正測試", 5); err != nil {
+		t.Fatalf("ExecuteUntilCompletion 不應失敗: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "reports", "transcript.md")
+	if err := client.ExportTranscript(path); err != nil {
+		t.Fatalf("ExportTranscript 失敗: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("無法讀取紀錄: %v", err)
+	}
+	content := string(data)
+
+	for _, want := range []string{
+		"# Ralph Loop 執行紀錄",
+		"- 總迴圈數: 2",
+		"- 最終狀態: 完成 (全部完成)",
+		"## 目錄",
+		"[迴圈 2](#loop-2)",
+		"- 模型: claude-sonnet-4.5",
+		"修正測試",
+		"````text",
+		"- ⚠️ 警告: CLI stderr: deprecated flag",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("紀錄應包含 %q", want)
+		}
+	}
+	if strings.Contains(content, "---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: <完成原因>") {
+		t.Error("紀錄的 prompt 不應包含狀態格式說明")
+	}
+}
+
+// TestExportTranscriptEmptyPath 測試空路徑
+func TestExportTranscriptEmptyPath(t *testing.T) {
+	client := NewRalphLoopClient()
+	if err := client.ExportTranscript(""); err == nil {
+		t.Error("空路徑應回傳錯誤")
+	}
+}
+
+// TestTranscriptMasksAndStripsPrompt 測試 MaskSensitiveOutput 時遮蔽 prompt 與輸出，並移除所有附加的前綴與說明
+func TestTranscriptMasksAndStripsPrompt(t *testing.T) {
+	config := DefaultClientConfig()
+	config.MaskSensitiveOutput = true
+	config.RequestConfidence = true
+	config.ModelPromptPrefixes = map[Model]string{ModelClaudeSonnet45: "請先說明再修改。"}
+	client, prompts := newStepClient(config, func(int, string) (string, error) {
+		return pendingOutput("已設定 token: ghs_fakevalue"), nil
+	})
+
+	// 只有一個迴圈時會附加收尾提示
+	_, _ = client.ExecuteUntilCompletion(context.Background(), "修正登入 password=hunter2", 1)
+	if len(*prompts) != 1 || !strings.Contains((*prompts)[0], "請先說明再修改。") {
+		t.Fatalf("prompt 應包含模型前綴: %q", *prompts)
+	}
+
+	history := client.contextManager.GetLoopHistory()
+	content := renderTranscript(history, time.Now(), true)
+	_, section, _ := strings.Cut(content, "### Prompt\n\n")
+	section, _, _ = strings.Cut(section, "### 輸出")
+	if section != "```text\n修正登入 password=****\n```\n\n" {
+		t.Errorf("紀錄的 prompt 應只有遮蔽後的使用者 prompt，實際 %q", section)
+	}
+	for _, leaked := range []string{"hunter2", "ghs_fakevalue"} {
+		if strings.Contains(content, leaked) {
+			t.Errorf("紀錄不應包含 %q:\n%s", leaked, content)
+		}
+	}
+
+	if unmasked := renderTranscript(history, time.Now(), false); !strings.Contains(unmasked, "password=hunter2") {
+		t.Error("未啟用遮蔽時應保留原始 prompt")
+	}
+}
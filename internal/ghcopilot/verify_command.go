@@ -0,0 +1,88 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultVerifyTimeout 驗證命令的預設逾時
+const defaultVerifyTimeout = 5 * time.Minute
+
+// verifyOutputLimit 結果中保留的 stdout 末尾字元數
+const verifyOutputLimit = 2000
+
+// VerifyResult 驗證命令的執行結果
+type VerifyResult struct {
+	Command        string        `json:"command"`
+	ExitCode       int           `json:"exit_code"`       // 退出碼（無法執行或逾時為 -1）
+	Output         string        `json:"output"`          // stdout 末尾
+	ExitOK         bool          `json:"exit_ok"`         // 退出碼是否等於 VerifyExpectExit
+	PatternMatched bool          `json:"pattern_matched"` // stdout 是否符合 VerifyExpectPattern（未設定時為 true）
+	Passed         bool          `json:"passed"`          // 兩個條件都成立
+	Reason         string        `json:"reason,omitempty"`
+	Duration       time.Duration `json:"duration"`
+}
+
+// runVerifyCommand 執行 VerifyCommand 並依退出碼與輸出判定是否通過
+//
+// 命令透過 HookRunner 以 shell 執行（Windows 為 cmd /C），以便使用管線與 &&。退出碼必須等於
+// VerifyExpectExit，且設定 VerifyExpectPattern 時 stdout 也必須符合，才算通過。
+func (c *RalphLoopClient) runVerifyCommand(ctx context.Context) *VerifyResult {
+	result := &VerifyResult{
+		Command:        c.config.VerifyCommand,
+		ExitCode:       -1,
+		PatternMatched: true,
+	}
+
+	var pattern *regexp.Regexp
+	if c.config.VerifyExpectPattern != "" {
+		re, err := regexp.Compile(c.config.VerifyExpectPattern)
+		if err != nil {
+			result.PatternMatched = false
+			result.Reason = fmt.Sprintf("VerifyExpectPattern 無效: %v", err)
+			return result
+		}
+		pattern = re
+	}
+
+	timeout := c.config.VerifyTimeout
+	if timeout <= 0 {
+		timeout = defaultVerifyTimeout
+	}
+	runner := c.hookRunner(timeout)
+	hook := runner.RunShell(ctx, c.config.VerifyCommand)
+	result.Duration = hook.Duration
+	result.Output = tailRunes(hook.Stdout, verifyOutputLimit)
+
+	switch {
+	case hook.TimedOut:
+		result.Reason = fmt.Sprintf("驗證命令超過 %v 未完成", runner.Timeout)
+		return result
+	case hook.Canceled:
+		result.Reason = "驗證命令被取消"
+		return result
+	case hook.ExitCode < 0:
+		result.Reason = fmt.Sprintf("無法執行驗證命令: %v", hook.Err)
+		return result
+	}
+	result.ExitCode = hook.ExitCode
+
+	result.ExitOK = result.ExitCode == c.config.VerifyExpectExit
+	if pattern != nil {
+		result.PatternMatched = pattern.MatchString(hook.Stdout)
+	}
+	result.Passed = result.ExitOK && result.PatternMatched
+
+	var problems []string
+	if !result.ExitOK {
+		problems = append(problems, fmt.Sprintf("退出碼 %d（預期 %d）", result.ExitCode, c.config.VerifyExpectExit))
+	}
+	if !result.PatternMatched {
+		problems = append(problems, fmt.Sprintf("輸出不符合 %q", c.config.VerifyExpectPattern))
+	}
+	result.Reason = strings.Join(problems, "，")
+	return result
+}
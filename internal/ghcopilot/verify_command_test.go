@@ -0,0 +1,97 @@
+package ghcopilot
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestVerifyCommandCombinations 測試退出碼與輸出比對的四種組合
+func TestVerifyCommandCombinations(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("驗證命令使用 sh 語法")
+	}
+
+	tests := []struct {
+		name        string
+		command     string
+		wantPassed  bool
+		wantExitOK  bool
+		wantMatched bool
+	}{
+		{"退出碼正確且輸出符合", "echo 'All tests passed'; exit 0", true, true, true},
+		{"退出碼正確但輸出不符", "echo '2 tests FAILED'; exit 0", false, true, false},
+		{"退出碼錯誤但輸出符合", "echo 'All tests passed'; exit 1", false, false, true},
+		{"退出碼錯誤且輸出不符", "echo '2 tests FAILED'; exit 1", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultClientConfig()
+			config.VerifyCommand = tt.command
+			config.VerifyExpectPattern = "All tests passed"
+			client := newScriptedClient(config, "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\nREASON: 全部完成\n---END_RALPH_STATUS---")
+
+			result, err := client.ExecuteLoop(context.Background(), "修正測試")
+			if err != nil {
+				t.Fatalf("不應回傳錯誤: %v", err)
+			}
+
+			v := result.Verify
+			if v == nil {
+				t.Fatal("判定完成後應執行驗證命令")
+			}
+			if v.Passed != tt.wantPassed || v.ExitOK != tt.wantExitOK || v.PatternMatched != tt.wantMatched {
+				t.Errorf("驗證結果不符: %+v", v)
+			}
+			if result.ShouldContinue == tt.wantPassed {
+				t.Errorf("驗證通過=%v 時繼續應為 %v，但為 %v", tt.wantPassed, !tt.wantPassed, result.ShouldContinue)
+			}
+			if !tt.wantPassed {
+				if !strings.Contains(result.ExitReason, "驗證命令未通過") || !result.Decision.VerifyFailed {
+					t.Errorf("未通過時原因應說明驗證失敗: %s", result.ExitReason)
+				}
+			} else if result.ExitReason != "全部完成" {
+				t.Errorf("通過時應保留原本的原因，但為 %s", result.ExitReason)
+			}
+			if !strings.Contains(v.Output, "tests") {
+				t.Errorf("應保存驗證命令的 stdout: %q", v.Output)
+			}
+		})
+	}
+}
+
+// TestVerifyCommandNotRunWhenContinuing 測試尚未判定完成時不執行驗證命令
+func TestVerifyCommandNotRunWhenContinuing(t *testing.T) {
+	config := DefaultClientConfig()
+	config.VerifyCommand = "exit 0"
+	client := newScriptedClient(config, "仍在處理\n---RALPH_STATUS---\nEXIT_SIGNAL: false\n---END_RALPH_STATUS---")
+
+	result, err := client.ExecuteLoop(context.Background(), "修正測試")
+	if err != nil {
+		t.Fatalf("不應回傳錯誤: %v", err)
+	}
+	if result.Verify != nil {
+		t.Errorf("繼續迴圈時不應執行驗證命令: %+v", result.Verify)
+	}
+}
+
+// TestVerifyCommandInvalidPattern 測試無效的正規表示式視為未通過
+func TestVerifyCommandInvalidPattern(t *testing.T) {
+	config := DefaultClientConfig()
+	config.VerifyCommand = "exit 0"
+	config.VerifyExpectPattern = "(未關閉"
+	client := newScriptedClient(config, "完成\n---RALPH_STATUS---\nEXIT_SIGNAL: true\n---END_RALPH_STATUS---")
+
+	result, err := client.ExecuteLoop(context.Background(), "修正測試")
+	if err != nil {
+		t.Fatalf("不應回傳錯誤: %v", err)
+	}
+	if result.Verify == nil || result.Verify.Passed || !result.ShouldContinue {
+		t.Errorf("無效的 pattern 應視為未通過: %+v", result.Verify)
+	}
+	if !strings.Contains(result.Verify.Reason, "VerifyExpectPattern") {
+		t.Errorf("原因應指出 pattern 無效: %s", result.Verify.Reason)
+	}
+}
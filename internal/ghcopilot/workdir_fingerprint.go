@@ -0,0 +1,145 @@
+package ghcopilot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fingerprintTimeout 計算工作目錄指紋的最長時間
+const fingerprintTimeout = 10 * time.Second
+
+// fingerprintSkipDirs 計算 mtime 指紋時略過的目錄（Ralph Loop 自己的狀態檔不算變更）
+var fingerprintSkipDirs = map[string]bool{
+	".git":        true,
+	".ralph-loop": true,
+}
+
+// fingerprintSkipFiles 計算 mtime 指紋時略過的檔案
+var fingerprintSkipFiles = map[string]bool{
+	".circuit_breaker_state": true,
+}
+
+// ralphStatePaths Ralph Loop 自己在工作目錄中產生的狀態檔（git 命令一律排除）
+var ralphStatePaths = []string{".ralph-loop", ".circuit_breaker_state"}
+
+// ralphStatePathspec 傳回限定於 dir 並排除 Ralph Loop 狀態檔的 git pathspec（以 "--" 開頭）
+//
+// saveDir 位於 dir 內時一併排除；相對路徑以目前目錄解析，與 PersistenceManager 相同。
+func ralphStatePathspec(dir, saveDir string) []string {
+	spec := []string{"--", "."}
+	for _, p := range ralphStatePaths {
+		spec = append(spec, ":(exclude)"+p)
+	}
+	if saveDir == "" {
+		return spec
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return spec
+	}
+	absSave, err := filepath.Abs(saveDir)
+	if err != nil {
+		return spec
+	}
+	rel, err := filepath.Rel(absDir, absSave)
+	if err != nil || !filepath.IsLocal(rel) {
+		return spec
+	}
+	rel = filepath.ToSlash(rel)
+	for _, p := range ralphStatePaths {
+		if rel == p || strings.HasPrefix(rel, p+"/") {
+			return spec
+		}
+	}
+	return append(spec, ":(exclude)"+rel)
+}
+
+// workdirFingerprint 計算工作目錄的狀態指紋
+//
+// git 儲存庫使用 `git status`、`git diff` 與未追蹤檔案內容的雜湊；
+// 其他目錄退回使用所有檔案的路徑、大小與修改時間。
+// 兩者皆無法取得時回傳錯誤，呼叫端應略過無變更偵測。
+func workdirFingerprint(ctx context.Context, dir, saveDir string) (string, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fingerprintTimeout)
+	defer cancel()
+
+	if fp, err := gitFingerprint(ctx, dir, saveDir); err == nil {
+		return fp, nil
+	}
+	return mtimeFingerprint(dir)
+}
+
+// gitFingerprint 以 git 工作樹狀態計算指紋
+//
+// `git status` 與 `git diff HEAD` 看不到未追蹤檔案的內容變更，另外雜湊每個未追蹤檔案的內容。
+func gitFingerprint(ctx context.Context, dir, saveDir string) (string, error) {
+	h := sha256.New()
+	for _, args := range [][]string{
+		{"status", "--porcelain", "--untracked-files=all"},
+		{"diff", "HEAD", "--binary"},
+	} {
+		// #nosec G204 -- 參數為固定的 git 子命令
+		cmdArgs := append([]string{"-C", dir}, args...)
+		cmdArgs = append(cmdArgs, ralphStatePathspec(dir, saveDir)...)
+		cmd := exec.CommandContext(ctx, "git", cmdArgs...)
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("git %s 失敗: %w", args[0], err)
+		}
+		h.Write(out)
+	}
+
+	// #nosec G204 -- 參數為固定的 git 子命令
+	cmdArgs := append([]string{"-C", dir, "ls-files", "--others", "--exclude-standard"}, ralphStatePathspec(dir, saveDir)...)
+	out, err := exec.CommandContext(ctx, "git", cmdArgs...).Output()
+	if err != nil {
+		return "", fmt.Errorf("git ls-files 失敗: %w", err)
+	}
+	for _, name := range strings.Split(string(out), "\n") {
+		if name = strings.TrimSpace(name); name != "" {
+			fmt.Fprintf(h, "%s\x00%s\n", name, focusFileHash(filepath.Join(dir, name)))
+		}
+	}
+	return "git:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// mtimeFingerprint 以檔案路徑、大小與修改時間計算指紋
+func mtimeFingerprint(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && fingerprintSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fingerprintSkipFiles[d.Name()] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", rel, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("無法計算工作目錄指紋: %w", err)
+	}
+	return "mtime:" + hex.EncodeToString(h.Sum(nil)), nil
+}
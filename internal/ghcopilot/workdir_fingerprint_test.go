@@ -0,0 +1,151 @@
+package ghcopilot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMtimeFingerprint 測試檔案變更會改變指紋
+func TestMtimeFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fp1, err := mtimeFingerprint(dir)
+	if err != nil {
+		t.Fatalf("計算指紋失敗: %v", err)
+	}
+	fp2, _ := mtimeFingerprint(dir)
+	if fp1 != fp2 {
+		t.Error("未變更的目錄指紋應相同")
+	}
+
+	// Ralph Loop 自己的狀態目錄不算變更
+	if err := os.MkdirAll(filepath.Join(dir, ".ralph-loop"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".ralph-loop", "state.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if fp, _ := mtimeFingerprint(dir); fp != fp1 {
+		t.Error(".ralph-loop 內的變更不應影響指紋")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if fp, _ := mtimeFingerprint(dir); fp == fp1 {
+		t.Error("新增檔案後指紋應改變")
+	}
+}
+
+// TestMtimeFingerprintMissingDir 測試目錄不存在時回傳錯誤
+func TestMtimeFingerprintMissingDir(t *testing.T) {
+	if _, err := mtimeFingerprint(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("目錄不存在應回傳錯誤")
+	}
+}
+
+// TestExecuteLoopNoChangeDetection 測試模型從未修改檔案時提前結束
+func TestExecuteLoopNoChangeDetection(t *testing.T) {
+	config := DefaultClientConfig()
+	config.WorkDir = t.TempDir()
+	config.NoChangeLoopThreshold = 2
+	client, _ := newStepClient(config, func(call int, prompt string) (string, error) {
+		return pendingOutput(fmt.Sprintf("正在分析第 %d 次", call)), nil
+	})
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "重構程式碼", 10)
+	if err != nil {
+		t.Fatalf("無變更結束不應回傳錯誤: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("應在 2 個迴圈後結束，但執行了 %d 個", len(results))
+	}
+	last := results[len(results)-1]
+	if last.ShouldContinue {
+		t.Error("最後一個迴圈不應繼續")
+	}
+	if !strings.Contains(last.ExitReason, "無變更") {
+		t.Errorf("結束原因應說明無變更，但為 %q", last.ExitReason)
+	}
+}
+
+// TestExecuteLoopNoChangeDetectionWithChanges 測試有檔案變更時不會提前結束
+func TestExecuteLoopNoChangeDetectionWithChanges(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultClientConfig()
+	config.WorkDir = dir
+	config.NoChangeLoopThreshold = 2
+	client, _ := newStepClient(config, func(call int, prompt string) (string, error) {
+		_ = os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.txt", call)), []byte("x"), 0600)
+		return pendingOutput(fmt.Sprintf("正在分析第 %d 次", call)), nil
+	})
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "重構程式碼", 4)
+	if err == nil {
+		t.Error("未完成應回傳達到最大迴圈數的錯誤")
+	}
+	if len(results) != 4 {
+		t.Errorf("應執行 4 個迴圈，但為 %d", len(results))
+	}
+}
+
+// TestRalphStatePathspec 測試 pathspec 依 SaveDir 排除狀態目錄
+func TestRalphStatePathspec(t *testing.T) {
+	dir := t.TempDir()
+	base := []string{"--", ".", ":(exclude).ralph-loop", ":(exclude).circuit_breaker_state"}
+
+	tests := []struct {
+		name    string
+		saveDir string
+		want    []string
+	}{
+		{"未設定", "", base},
+		{"預設目錄", filepath.Join(dir, ".ralph-loop", "saves"), base},
+		{"自訂目錄", filepath.Join(dir, "state", "saves"), append(append([]string{}, base...), ":(exclude)state/saves")},
+		{"工作目錄外", filepath.Join(t.TempDir(), "saves"), base},
+	}
+	for _, tt := range tests {
+		got := ralphStatePathspec(dir, tt.saveDir)
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: 得到 %v，預期 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestExecuteLoopNoChangeDetectionUntrackedEdits 測試在 git 儲存庫中反覆修改同一個未追蹤檔案不算無變更
+func TestExecuteLoopNoChangeDetectionUntrackedEdits(t *testing.T) {
+	dir := initGitRepo(t)
+	config := DefaultClientConfig()
+	config.WorkDir = dir
+	config.NoChangeLoopThreshold = 2
+	client, _ := newStepClient(config, func(call int, prompt string) (string, error) {
+		_ = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(fmt.Sprintf("第 %d 版", call)), 0600)
+		return pendingOutput("正在修改 notes.txt"), nil
+	})
+
+	results, err := client.ExecuteUntilCompletion(context.Background(), "撰寫筆記", 4)
+	if err == nil {
+		t.Error("未完成應回傳達到最大迴圈數的錯誤")
+	}
+	if len(results) != 4 {
+		t.Errorf("每個迴圈都修改了未追蹤檔案，應執行 4 個迴圈，但為 %d", len(results))
+	}
+
+	fp1, err := gitFingerprint(context.Background(), dir, "")
+	if err != nil {
+		t.Fatalf("計算指紋失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("最終版"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if fp, _ := gitFingerprint(context.Background(), dir, ""); fp == fp1 {
+		t.Error("修改未追蹤檔案後指紋應改變")
+	}
+}